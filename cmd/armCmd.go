@@ -23,7 +23,6 @@
 package main
 
 import (
-	"context"
 	"fmt"
 	"os"
 
@@ -33,7 +32,6 @@ import (
 	"github.com/Azure/mpf/pkg/infrastructure/mpfSharedUtils"
 	resourceGroupManager "github.com/Azure/mpf/pkg/infrastructure/resourceGroupManager"
 	sproleassignmentmanager "github.com/Azure/mpf/pkg/infrastructure/spRoleAssignmentManager"
-	"github.com/Azure/mpf/pkg/presentation"
 	"github.com/Azure/mpf/pkg/usecase"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -58,7 +56,7 @@ func NewARMCommand() *cobra.Command {
 	Cobra is a CLI library for Go that empowers applications.
 	This application is a tool to generate the needed files
 	to quickly create a Cobra application.`,
-		Run: getMPFARM,
+		RunE: getMPFARM,
 	}
 
 	armCmd.Flags().StringVarP(&flgResourceGroupNamePfx, "resourceGroupNamePfx", "", "testdeployrg", "Resource Group Name Prefix")
@@ -81,11 +79,17 @@ func NewARMCommand() *cobra.Command {
 	// Note: fullDeployment flag removed - Full deployment mode is now the only supported mode for ARM templates
 	// Note: subscriptionScoped flag removed - Only resource group scoped deployments supported with Full deployment mode
 
+	addCheckpointFlags(armCmd)
+	addJournalFlags(armCmd)
+	addRoleAssignmentBackupFlags(armCmd)
+	addRunLockFlags(armCmd)
+
 	return armCmd
 }
 
-func getMPFARM(cmd *cobra.Command, args []string) {
+func getMPFARM(cmd *cobra.Command, args []string) error {
 	setLogLevel()
+	runMetadata := newRunMetadata("arm", flgTemplateFilePath, flgParametersFilePath)
 
 	log.Info("Executing MPF for ARM")
 
@@ -97,7 +101,7 @@ func getMPFARM(cmd *cobra.Command, args []string) {
 
 	// validate if template and parameters files exists
 	if _, err := os.Stat(flgTemplateFilePath); os.IsNotExist(err) {
-		log.Fatal("Template File does not exist")
+		return fmt.Errorf("template file does not exist: %s", flgTemplateFilePath)
 	}
 
 	flgTemplateFilePath, err := getAbsolutePath(flgTemplateFilePath)
@@ -106,7 +110,7 @@ func getMPFARM(cmd *cobra.Command, args []string) {
 	}
 
 	if _, err := os.Stat(flgParametersFilePath); os.IsNotExist(err) {
-		log.Fatal("Parameters File does not exist")
+		return fmt.Errorf("parameters file does not exist: %s", flgParametersFilePath)
 	}
 
 	flgParametersFilePath, err := getAbsolutePath(flgParametersFilePath)
@@ -114,73 +118,113 @@ func getMPFARM(cmd *cobra.Command, args []string) {
 		log.Errorf("Error getting absolute path for ARM template parameters file: %v\n", err)
 	}
 
-	ctx := context.Background()
+	ctx := cmd.Context()
 
-	mpfConfig := getRootMPFConfig()
+	mpfConfig := getRootMPFConfig(runMetadata)
+	if err := setupCloud(&mpfConfig); err != nil {
+		return err
+	}
 	mpfRG := domain.ResourceGroup{}
 	mpfRG.ResourceGroupName = fmt.Sprintf("%s-%s", flgResourceGroupNamePfx, mpfSharedUtils.GenerateRandomString(7))
 	mpfRG.ResourceGroupResourceID = fmt.Sprintf("/subscriptions/%s/resourceGroups/%s", flgSubscriptionID, mpfRG.ResourceGroupName)
 	mpfRG.Location = flgLocation
 	mpfConfig.ResourceGroup = mpfRG
 	deploymentName := fmt.Sprintf("%s-%s", flgDeploymentNamePfx, mpfSharedUtils.GenerateRandomString(7))
+
+	resumeCheckpoint, err := loadResumeCheckpoint("arm", &mpfConfig)
+	if err != nil {
+		return err
+	}
+	if resumeCheckpoint != nil && resumeCheckpoint.DeploymentName != "" {
+		deploymentName = resumeCheckpoint.DeploymentName
+	}
 	armConfig := &ARMTemplateShared.ArmTemplateAdditionalConfig{
 		TemplateFilePath:   flgTemplateFilePath,
 		ParametersFilePath: flgParametersFilePath,
 		DeploymentName:     deploymentName,
+		Tags:               runMetadata.Tags(),
 	}
 
 	var rgManager usecase.ResourceGroupManager
 	var spRoleAssignmentManager usecase.ServicePrincipalRolemAssignmentManager
-	rgManager = resourceGroupManager.NewResourceGroupManager(flgSubscriptionID)
-	spRoleAssignmentManager = sproleassignmentmanager.NewSPRoleAssignmentManager(flgSubscriptionID)
+	defaultRGManager, err := resourceGroupManager.NewResourceGroupManager(flgSubscriptionID)
+	if err != nil {
+		return err
+	}
+	defaultRGManager.SetTags(runMetadata.Tags())
+	rgManager = defaultRGManager
+	spRoleAssignmentManager, err = sproleassignmentmanager.NewSPRoleAssignmentManager(flgSubscriptionID)
+	if err != nil {
+		return err
+	}
 
 	var deploymentAuthorizationCheckerCleaner usecase.DeploymentAuthorizationCheckerCleaner
 	var mpfService *usecase.MPFService
-	var initialPermissionsToAdd []string
-	var permissionsToAddToResult []string
 
 	// Always use Full Deployment mode - whatif mode has been disabled
-	deploymentAuthorizationCheckerCleaner = ARMTemplateDeployment.NewARMTemplateDeploymentAuthorizationChecker(flgSubscriptionID, *armConfig)
+	deploymentAuthorizationCheckerCleaner, err = ARMTemplateDeployment.NewARMTemplateDeploymentAuthorizationChecker(flgSubscriptionID, *armConfig)
+	if err != nil {
+		return err
+	}
+
+	modeSettings := usecase.ARMModeSettings()
 
-	initialPermissionsToAdd = []string{"Microsoft.Resources/deployments/*", "Microsoft.Resources/subscriptions/operationresults/read"}
-	permissionsToAddToResult = []string{"Microsoft.Resources/deployments/read", "Microsoft.Resources/deployments/write"}
+	operationsCatalog, err := getOperationsCatalog()
+	if err != nil {
+		return err
+	}
 
 	// Add initial permissions from flag if provided (supports comma-separated string or @file.json)
-	initialPermissionsToAdd, permissionsToAddToResult = appendUserInitialPermissions(initialPermissionsToAdd, permissionsToAddToResult)
+	modeSettings.InitialPermissions, modeSettings.PermissionsToAddToResult, err = appendUserInitialPermissions(operationsCatalog, modeSettings.InitialPermissions, modeSettings.PermissionsToAddToResult)
+	if err != nil {
+		return err
+	}
+
+	mpfService = usecase.NewMPFServiceForMode(ctx, rgManager, spRoleAssignmentManager, deploymentAuthorizationCheckerCleaner, mpfConfig, modeSettings)
+	mpfService.SetOperationsCatalog(operationsCatalog)
 
-	mpfService = usecase.NewMPFService(ctx, rgManager, spRoleAssignmentManager, deploymentAuthorizationCheckerCleaner, mpfConfig, initialPermissionsToAdd, permissionsToAddToResult, true, false, true)
+	propagationWaiter, err := getPropagationWaiter(spRoleAssignmentManager)
+	if err != nil {
+		return err
+	}
+	mpfService.SetPropagationWaiter(propagationWaiter)
+
+	checkpointStore, err := setupCheckpoints(mpfService, domain.MPFCheckpoint{Mode: "arm", DeploymentName: deploymentName}, resumeCheckpoint)
+	if err != nil {
+		return err
+	}
+
+	if err := setupRunLock(mpfService, mpfConfig); err != nil {
+		return err
+	}
+
+	roleAssignmentSnapshotStore, err := setupRoleAssignmentBackup(mpfService, spRoleAssignmentManager)
+	if err != nil {
+		return err
+	}
+	defer finishRoleAssignmentBackup(roleAssignmentSnapshotStore)
+
+	iterationJournal, err := setupJournal(mpfService, resumeCheckpoint != nil)
+	if err != nil {
+		return err
+	}
+	defer closeJournal(iterationJournal)
 
 	log.Infof("Show Detailed Output: %t\n", flgShowDetailedOutput)
-	log.Infof("JSON Output: %t\n", flgJSONOutput)
+	log.Infof("Output: %s\n", getOutputFormat())
 	log.Infof("Subscription Resource ID: %s\n", mpfConfig.SubscriptionID)
 
-	displayOptions := getDislayOptions(flgShowDetailedOutput, flgJSONOutput, mpfConfig.SubscriptionID)
+	displayOptions := getDislayOptions(mpfConfig.SubscriptionID, runMetadata)
 
 	mpfResult, err := mpfService.GetMinimumPermissionsRequired()
+	finishCheckpoints(checkpointStore, err)
 	if err != nil {
 		if len(mpfResult.RequiredPermissions) > 0 {
 			fmt.Println("Error occurred while getting minimum permissions required. However, some permissions were identified prior to the error.")
-			displayResult(mpfResult, displayOptions)
+			_ = displayResult(cmd.OutOrStdout(), mpfResult, displayOptions)
 		}
-		log.Fatal(err)
+		return err
 	}
 
-	displayResult(mpfResult, displayOptions)
-}
-
-func getDislayOptions(flgShowDetailedOutput bool, flgJSONOutput bool, subscriptionID string) presentation.DisplayOptions {
-	return presentation.DisplayOptions{
-		ShowDetailedOutput: flgShowDetailedOutput,
-		JSONOutput:         flgJSONOutput,
-		SubscriptionID:     subscriptionID,
-	}
-}
-
-func displayResult(mpfResult domain.MPFResult, displayOptions presentation.DisplayOptions) {
-	resultDisplayer := presentation.NewMPFResultDisplayer(mpfResult, displayOptions)
-	err := resultDisplayer.DisplayResult(os.Stdout)
-
-	if err != nil {
-		log.Fatal(err)
-	}
+	return displayResult(cmd.OutOrStdout(), mpfResult, displayOptions)
 }
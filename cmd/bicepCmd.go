@@ -23,7 +23,6 @@
 package main
 
 import (
-	"context"
 	"fmt"
 	"os"
 	"os/exec"
@@ -58,7 +57,7 @@ func NewBicepCommand() *cobra.Command {
 	Cobra is a CLI library for Go that empowers applications.
 	This application is a tool to generate the needed files
 	to quickly create a Cobra application.`,
-		Run: getMPFBicep,
+		RunE: getMPFBicep,
 	}
 
 	bicepCmd.Flags().StringVarP(&flgResourceGroupNamePfx, "resourceGroupNamePfx", "", "testdeployrg", "Resource Group Name Prefix")
@@ -87,11 +86,17 @@ func NewBicepCommand() *cobra.Command {
 	// Note: fullDeployment flag removed - Full deployment mode is now the only supported mode for Bicep
 	// Note: subscriptionScoped flag removed - Only resource group scoped deployments supported with Full deployment mode
 
+	addCheckpointFlags(bicepCmd)
+	addJournalFlags(bicepCmd)
+	addRoleAssignmentBackupFlags(bicepCmd)
+	addRunLockFlags(bicepCmd)
+
 	return bicepCmd
 }
 
-func getMPFBicep(cmd *cobra.Command, args []string) {
+func getMPFBicep(cmd *cobra.Command, args []string) error {
 	setLogLevel()
+	runMetadata := newRunMetadata("bicep", flgBicepFilePath, flgParametersFilePath)
 
 	log.Info("Executing MPF for Bicep")
 
@@ -104,15 +109,15 @@ func getMPFBicep(cmd *cobra.Command, args []string) {
 
 	// validate if template and parameters files exists
 	if _, err := os.Stat(flgBicepFilePath); os.IsNotExist(err) {
-		log.Fatal("Bicep File does not exist")
+		return fmt.Errorf("bicep file does not exist: %s", flgBicepFilePath)
 	}
 
 	if _, err := os.Stat(flgBicepExecPath); os.IsNotExist(err) {
-		log.Fatal("Bicep Executable does not exist")
+		return fmt.Errorf("bicep executable does not exist: %s", flgBicepExecPath)
 	}
 
 	if _, err := os.Stat(flgParametersFilePath); os.IsNotExist(err) {
-		log.Fatal("Parameters File does not exist")
+		return fmt.Errorf("parameters file does not exist: %s", flgParametersFilePath)
 	}
 
 	flgBicepExecPath, err := getAbsolutePath(flgBicepExecPath)
@@ -139,7 +144,7 @@ func getMPFBicep(cmd *cobra.Command, args []string) {
 
 		compiledParamsPath, err := bicepUtils.CompileBicepParamsToTempFile(flgBicepExecPath, flgParametersFilePath)
 		if err != nil {
-			log.Fatalf("error compiling .bicepparam file: %v", err)
+			return fmt.Errorf("error compiling .bicepparam file: %w", err)
 		}
 
 		defer func(path string) {
@@ -162,71 +167,126 @@ func getMPFBicep(cmd *cobra.Command, args []string) {
 	}
 	log.Infoln("Bicep build successful, ARM Template created at:", armTemplatePath)
 
-	ctx := context.Background()
+	defer func() {
+		log.Infoln("Deleting Generated ARM Template file...")
+		// delete generated ARM template file
+		err := os.Remove(armTemplatePath)
+		if err != nil {
+			log.Errorf("Error deleting Generated ARM template file: %v\n", err)
+		}
+	}()
+
+	ctx := cmd.Context()
 
-	mpfConfig := getRootMPFConfig()
+	mpfConfig := getRootMPFConfig(runMetadata)
+	if err := setupCloud(&mpfConfig); err != nil {
+		return err
+	}
 	mpfRG := domain.ResourceGroup{}
 	mpfRG.ResourceGroupName = fmt.Sprintf("%s-%s", flgResourceGroupNamePfx, mpfSharedUtils.GenerateRandomString(7))
 	mpfRG.ResourceGroupResourceID = fmt.Sprintf("/subscriptions/%s/resourceGroups/%s", flgSubscriptionID, mpfRG.ResourceGroupName)
 	mpfRG.Location = flgLocation
 	mpfConfig.ResourceGroup = mpfRG
 	deploymentName := fmt.Sprintf("%s-%s", flgDeploymentNamePfx, mpfSharedUtils.GenerateRandomString(7))
+
+	resumeCheckpoint, err := loadResumeCheckpoint("bicep", &mpfConfig)
+	if err != nil {
+		return err
+	}
+	if resumeCheckpoint != nil && resumeCheckpoint.DeploymentName != "" {
+		deploymentName = resumeCheckpoint.DeploymentName
+	}
 	armConfig := &ARMTemplateShared.ArmTemplateAdditionalConfig{
 		TemplateFilePath:   armTemplatePath,
 		ParametersFilePath: flgParametersFilePath,
 		DeploymentName:     deploymentName,
+		Tags:               runMetadata.Tags(),
 	}
 
 	var rgManager usecase.ResourceGroupManager
 	var spRoleAssignmentManager usecase.ServicePrincipalRolemAssignmentManager
-	rgManager = resourceGroupManager.NewResourceGroupManager(flgSubscriptionID)
-	spRoleAssignmentManager = sproleassignmentmanager.NewSPRoleAssignmentManager(flgSubscriptionID)
+	defaultRGManager, err := resourceGroupManager.NewResourceGroupManager(flgSubscriptionID)
+	if err != nil {
+		return err
+	}
+	defaultRGManager.SetTags(runMetadata.Tags())
+	rgManager = defaultRGManager
+	spRoleAssignmentManager, err = sproleassignmentmanager.NewSPRoleAssignmentManager(flgSubscriptionID)
+	if err != nil {
+		return err
+	}
 
 	var deploymentAuthorizationCheckerCleaner usecase.DeploymentAuthorizationCheckerCleaner
 	var mpfService *usecase.MPFService
-	var initialPermissionsToAdd []string
-	var permissionsToAddToResult []string
 
 	// Always use Full Deployment mode - whatif mode has been disabled
-	deploymentAuthorizationCheckerCleaner = ARMTemplateDeployment.NewARMTemplateDeploymentAuthorizationChecker(flgSubscriptionID, *armConfig)
+	deploymentAuthorizationCheckerCleaner, err = ARMTemplateDeployment.NewARMTemplateDeploymentAuthorizationChecker(flgSubscriptionID, *armConfig)
+	if err != nil {
+		return err
+	}
 
-	initialPermissionsToAdd = []string{"Microsoft.Resources/deployments/*", "Microsoft.Resources/subscriptions/operationresults/read"}
-	permissionsToAddToResult = []string{"Microsoft.Resources/deployments/read", "Microsoft.Resources/deployments/write"}
+	// Bicep files are deployed as ARM templates
+	modeSettings := usecase.ARMModeSettings()
+
+	operationsCatalog, err := getOperationsCatalog()
+	if err != nil {
+		return err
+	}
 
 	// Add initial permissions from flag if provided (supports comma-separated string or @file.json)
-	initialPermissionsToAdd, permissionsToAddToResult = appendUserInitialPermissions(initialPermissionsToAdd, permissionsToAddToResult)
+	modeSettings.InitialPermissions, modeSettings.PermissionsToAddToResult, err = appendUserInitialPermissions(operationsCatalog, modeSettings.InitialPermissions, modeSettings.PermissionsToAddToResult)
+	if err != nil {
+		return err
+	}
 
-	// Always auto-create resource group since only resource group scoped deployments are supported
-	var autoCreateResourceGroup = true
-	mpfService = usecase.NewMPFService(ctx, rgManager, spRoleAssignmentManager, deploymentAuthorizationCheckerCleaner, mpfConfig, initialPermissionsToAdd, permissionsToAddToResult, true, false, autoCreateResourceGroup)
+	mpfService = usecase.NewMPFServiceForMode(ctx, rgManager, spRoleAssignmentManager, deploymentAuthorizationCheckerCleaner, mpfConfig, modeSettings)
+	mpfService.SetOperationsCatalog(operationsCatalog)
 
-	mpfResult, err := mpfService.GetMinimumPermissionsRequired()
+	propagationWaiter, err := getPropagationWaiter(spRoleAssignmentManager)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
+	mpfService.SetPropagationWaiter(propagationWaiter)
 
-	log.Infoln("Deleting Generated ARM Template file...")
-	// delete generated ARM template file
-	err = os.Remove(armTemplatePath)
+	checkpointStore, err := setupCheckpoints(mpfService, domain.MPFCheckpoint{Mode: "bicep", DeploymentName: deploymentName}, resumeCheckpoint)
 	if err != nil {
-		log.Errorf("Error deleting Generated ARM template file: %v\n", err)
+		return err
+	}
+
+	if err := setupRunLock(mpfService, mpfConfig); err != nil {
+		return err
 	}
 
+	roleAssignmentSnapshotStore, err := setupRoleAssignmentBackup(mpfService, spRoleAssignmentManager)
+	if err != nil {
+		return err
+	}
+	defer finishRoleAssignmentBackup(roleAssignmentSnapshotStore)
+
+	iterationJournal, err := setupJournal(mpfService, resumeCheckpoint != nil)
+	if err != nil {
+		return err
+	}
+	defer closeJournal(iterationJournal)
+
+	mpfResult, err := mpfService.GetMinimumPermissionsRequired()
+	finishCheckpoints(checkpointStore, err)
+
 	// log.Infof("Displaying MPF Result: %v\n", mpfResult)
 	log.Infof("Show Detailed Output: %t\n", flgShowDetailedOutput)
-	log.Infof("JSON Output: %t\n", flgJSONOutput)
+	log.Infof("Output: %s\n", getOutputFormat())
 	log.Infof("Subscription ID: %s\n", mpfConfig.SubscriptionID)
 
-	displayOptions := getDislayOptions(flgShowDetailedOutput, flgJSONOutput, mpfConfig.SubscriptionID)
+	displayOptions := getDislayOptions(mpfConfig.SubscriptionID, runMetadata)
 
 	if err != nil {
 		if len(mpfResult.RequiredPermissions) > 0 {
 			fmt.Println("Error occurred while getting minimum permissions required. However, some permissions were identified prior to the error.")
-			displayResult(mpfResult, displayOptions)
+			_ = displayResult(cmd.OutOrStdout(), mpfResult, displayOptions)
 		}
-		log.Fatal(err)
+		return err
 	}
 
-	displayResult(mpfResult, displayOptions)
+	return displayResult(cmd.OutOrStdout(), mpfResult, displayOptions)
 
 }
@@ -0,0 +1,134 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package main
+
+import (
+	"io"
+
+	"github.com/Azure/mpf/pkg/domain"
+	"github.com/Azure/mpf/pkg/infrastructure/azureAPI"
+	builtinroles "github.com/Azure/mpf/pkg/infrastructure/builtInRoles"
+	roledefinitions "github.com/Azure/mpf/pkg/infrastructure/roleDefinitions"
+	"github.com/Azure/mpf/pkg/presentation"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	flgBuiltInRolesFile string
+	flgMaxSingleRoles   int
+)
+
+// NewBuiltInRolesCommand returns the builtInRoles command, which compares required permissions with the built-in roles
+func NewBuiltInRolesCommand() *cobra.Command {
+
+	builtInRolesCmd := &cobra.Command{
+		Use:   "builtInRoles",
+		Short: "Compare required permissions with the built-in Azure roles",
+	}
+
+	compareCmd := &cobra.Command{
+		Use:   "compare [resultFilePath]",
+		Short: "Find the built-in roles granting the permissions of an MPF result",
+		Long: `Find the built-in roles granting the permissions of an MPF result written with --output json or --output jsonV1.
+
+The built-in roles granting all required permissions are listed with the least excess first, followed by the
+smallest combination of narrow built-in roles and the permissions no built-in role grants.
+Wildcards and notActions are matched as Azure does, and wildcard actions of a role are always reported as excess.
+
+The result is read from the given file, or from stdin if no file (or "-") is given.
+A snapshot of common built-in roles is bundled, use azmpf builtInRoles refresh to get all built-in roles of a cloud.
+No credentials, service principal or Azure calls are needed.`,
+		Example: `azmpf builtInRoles compare ./mpf-result.json
+		azmpf builtInRoles compare ./mpf-result.json --builtInRolesFile ./builtInRoles.json --output json`,
+		Args: cobra.MaximumNArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			disableRequiredRootFlags(cmd)
+			return nil
+		},
+		RunE: compareWithBuiltInRoles,
+	}
+	compareCmd.Flags().StringVarP(&flgBuiltInRolesFile, "builtInRolesFile", "", "", "Built-in role definitions written with azmpf builtInRoles refresh. Default is the bundled snapshot")
+	compareCmd.Flags().IntVarP(&flgMaxSingleRoles, "maxSingleRoles", "", 5, "Maximum number of built-in roles granting all required permissions to list, 0 lists all")
+
+	refreshCmd := &cobra.Command{
+		Use:   "refresh",
+		Short: "Write the built-in role definitions of the cloud to a snapshot file",
+		Long: `Write the built-in role definitions of the cloud to a snapshot file for azmpf builtInRoles compare --builtInRolesFile.
+The role definitions are fetched with the Azure CLI or default Azure credentials, and written to stdout or --outputFile.`,
+		Example: `azmpf builtInRoles refresh --outputFile ./builtInRoles.json
+		azmpf builtInRoles refresh --cloud AzureUSGovernment --outputFile ./builtInRoles.json`,
+		Args: cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			disableRequiredRootFlags(cmd)
+			return nil
+		},
+		RunE: refreshBuiltInRoles,
+	}
+
+	builtInRolesCmd.AddCommand(compareCmd)
+	builtInRolesCmd.AddCommand(refreshCmd)
+	return builtInRolesCmd
+}
+
+func compareWithBuiltInRoles(cmd *cobra.Command, args []string) error {
+	setLogLevel()
+
+	mpfResult, aggregateKey, err := readMPFResult(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	roles, err := builtinroles.LoadBuiltInRoles(flgBuiltInRolesFile)
+	if err != nil {
+		return err
+	}
+	log.Infof("Comparing with %d built-in roles", len(roles))
+
+	comparison := domain.CompareWithBuiltInRoles(mpfResult.RequiredPermissions[aggregateKey], mpfResult.RequiredDataActions[aggregateKey], roles)
+	return writeOutput(cmd.OutOrStdout(), func(w io.Writer) error {
+		return presentation.DisplayBuiltInRoleComparison(w, comparison, getOutputFormat(), flgMaxSingleRoles)
+	})
+}
+
+func refreshBuiltInRoles(cmd *cobra.Command, args []string) error {
+	setLogLevel()
+
+	mpfConfig := domain.MPFConfig{SubscriptionID: flgSubscriptionID}
+	if err := setupCloud(&mpfConfig); err != nil {
+		return err
+	}
+
+	azAPIClients, err := azureAPI.NewAzureAPIClients(mpfConfig.SubscriptionID)
+	if err != nil {
+		return err
+	}
+	data, err := roledefinitions.FetchBuiltInRoleDefinitions(cmd.Context(), azAPIClients)
+	if err != nil {
+		return err
+	}
+	return writeOutput(cmd.OutOrStdout(), func(w io.Writer) error {
+		_, err := w.Write(append(data, '\n'))
+		return err
+	})
+}
@@ -0,0 +1,59 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuiltInRolesCompareCommandRunsWithoutCredentials(t *testing.T) {
+	resultFilePath := filepath.Join(t.TempDir(), "result.json")
+	err := os.WriteFile(resultFilePath, []byte(`{"sub":["Microsoft.Storage/storageAccounts/write","Microsoft.Resources/deployments/write"]}`), 0600)
+	require.NoError(t, err)
+
+	rootCmd := NewRootCommand()
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	rootCmd.SetArgs([]string{"builtInRoles", "compare", resultFilePath, "--output", "json"})
+
+	err = rootCmd.Execute()
+	require.NoError(t, err)
+
+	var comparison struct {
+		SingleRoles []struct {
+			RoleName string `json:"roleName"`
+		} `json:"singleRoles"`
+		UncoveredActions []string `json:"uncoveredActions"`
+	}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &comparison))
+	require.NotEmpty(t, comparison.SingleRoles)
+	assert.Equal(t, "Storage Account Contributor", comparison.SingleRoles[0].RoleName)
+	assert.Empty(t, comparison.UncoveredActions)
+}
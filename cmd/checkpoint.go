@@ -0,0 +1,124 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Azure/mpf/pkg/domain"
+	"github.com/Azure/mpf/pkg/infrastructure/checkpoint"
+	"github.com/Azure/mpf/pkg/usecase"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const defaultCheckpointFilename = ".azmpf-checkpoint.json"
+
+var (
+	flgCheckpointFile string
+	flgResume         string
+)
+
+func addCheckpointFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&flgCheckpointFile, "checkpointFile", "", defaultCheckpointFilename, "File the run state is saved to after every iteration, so that an interrupted run can be resumed. It is deleted when the run succeeds. Set to an empty string to disable checkpoints")
+	cmd.Flags().StringVarP(&flgResume, "resume", "", "", "Resume an interrupted run from its checkpoint file")
+}
+
+// loadResumeCheckpoint loads the --resume checkpoint, if set, and makes mpfConfig reuse the custom role
+// and resource group of the interrupted run, so that resources it left behind are updated and cleaned up
+func loadResumeCheckpoint(mode string, mpfConfig *domain.MPFConfig) (*domain.MPFCheckpoint, error) {
+	if flgResume == "" {
+		return nil, nil
+	}
+
+	resumeFilePath, err := getAbsolutePath(flgResume)
+	if err != nil {
+		return nil, fmt.Errorf("error getting absolute path for checkpoint file: %w", err)
+	}
+
+	cp, err := checkpoint.LoadCheckpointFromFile(resumeFilePath)
+	if err != nil {
+		return nil, err
+	}
+	if err := cp.ValidateForResume(mode, mpfConfig.SubscriptionID); err != nil {
+		return nil, err
+	}
+
+	log.Infof("Resuming run from checkpoint %s, saved at %s", resumeFilePath, cp.UpdatedAt)
+
+	if cp.RoleDefinitionID != "" {
+		mpfConfig.Role.RoleDefinitionID = cp.RoleDefinitionID
+		mpfConfig.Role.RoleDefinitionName = cp.RoleDefinitionName
+		mpfConfig.Role.RoleDefinitionResourceID = fmt.Sprintf("/subscriptions/%s/providers/Microsoft.Authorization/roleDefinitions/%s", mpfConfig.SubscriptionID, cp.RoleDefinitionID)
+	}
+
+	if cp.ResourceGroupName != "" {
+		mpfConfig.ResourceGroup.ResourceGroupName = cp.ResourceGroupName
+		mpfConfig.ResourceGroup.ResourceGroupResourceID = fmt.Sprintf("/subscriptions/%s/resourceGroups/%s", mpfConfig.SubscriptionID, cp.ResourceGroupName)
+		if cp.Location != "" {
+			mpfConfig.ResourceGroup.Location = cp.Location
+		}
+	}
+
+	return &cp, nil
+}
+
+// setupCheckpoints makes mpfService save checkpoints to --checkpointFile and seeds it from the resumed checkpoint.
+// The returned store is nil if checkpoints are disabled.
+func setupCheckpoints(mpfService *usecase.MPFService, base domain.MPFCheckpoint, resumeCheckpoint *domain.MPFCheckpoint) (*checkpoint.FileCheckpointStore, error) {
+	if resumeCheckpoint != nil {
+		mpfService.ResumeFromCheckpoint(*resumeCheckpoint)
+	}
+
+	if flgCheckpointFile == "" {
+		return nil, nil
+	}
+
+	checkpointFilePath, err := getAbsolutePath(flgCheckpointFile)
+	if err != nil {
+		return nil, fmt.Errorf("error getting absolute path for checkpoint file: %w", err)
+	}
+
+	store := checkpoint.NewFileCheckpointStore(checkpointFilePath)
+	mpfService.EnableCheckpoints(store, base)
+	return store, nil
+}
+
+// finishCheckpoints deletes the checkpoint of a successful run, or tells how to resume a failed run
+func finishCheckpoints(store *checkpoint.FileCheckpointStore, runErr error) {
+	if store == nil {
+		return
+	}
+
+	if runErr != nil {
+		if _, err := os.Stat(store.FilePath()); err == nil {
+			fmt.Fprintf(os.Stderr, "The run state was saved to %s. Resume the run with --resume %s\n", store.FilePath(), store.FilePath())
+		}
+		return
+	}
+
+	if err := store.Delete(); err != nil {
+		log.Warnf("Could not delete checkpoint file: %v", err)
+	}
+}
@@ -0,0 +1,184 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/Azure/mpf/pkg/domain"
+	resourceGroupManager "github.com/Azure/mpf/pkg/infrastructure/resourceGroupManager"
+	sproleassignmentmanager "github.com/Azure/mpf/pkg/infrastructure/spRoleAssignmentManager"
+	"github.com/Azure/mpf/pkg/usecase"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var (
+	flgCleanupRoleNamePfx           string
+	flgCleanupResourceGroupNamePfxs []string
+	flgCleanupOlderThan             time.Duration
+	flgCleanupDryRun                bool
+	flgCleanupYes                   bool
+)
+
+// NewCleanupCommand returns the cleanup command, which deletes the custom roles, role assignments and resource groups
+// left behind by MPF runs which crashed or were killed
+func NewCleanupCommand() *cobra.Command {
+
+	cleanupCmd := &cobra.Command{
+		Use:   "cleanup",
+		Short: "Delete the custom roles, role assignments and resource groups left behind by crashed MPF runs",
+		Long: `Delete the custom roles, role assignments and resource groups left behind by MPF runs which crashed or were killed.
+
+Custom roles are found by the tmp-rol-<random suffix> names MPF gives them, and deleted with their role assignments.
+Resource groups are found by the azmpf-created-by tag MPF sets on them, or by the <prefix>-<random suffix> names MPF gives them.
+Resources created less than --olderThan ago are skipped, as they may belong to runs in progress.
+The resources found are listed and deleted after confirmation. Use --dryRun to only list them.`,
+		Example: `azmpf cleanup --subscriptionID <subscription ID> --dryRun
+azmpf cleanup --subscriptionID <subscription ID> --olderThan 72h --yes`,
+		Args: cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			disableRequiredRootFlags(cmd)
+			return nil
+		},
+		RunE: cleanup,
+	}
+
+	cleanupCmd.Flags().StringVarP(&flgCleanupRoleNamePfx, "roleNamePfx", "", domain.MPFRoleNamePrefix, "Prefix of the names of the custom roles created by MPF runs")
+	cleanupCmd.Flags().StringSliceVarP(&flgCleanupResourceGroupNamePfxs, "resourceGroupNamePfx", "", []string{"testdeployrg"}, "Comma-separated prefixes of the names of the resource groups created by MPF runs, as set with the resourceGroupNamePfx flag of the arm and bicep commands")
+	cleanupCmd.Flags().DurationVarP(&flgCleanupOlderThan, "olderThan", "", 24*time.Hour, "Only delete resources created at least this long ago. Resource groups created before MPF tagged them have no creation time, and are only deleted with 0")
+	cleanupCmd.Flags().BoolVarP(&flgCleanupDryRun, "dryRun", "", false, "Only list the resources which would be deleted")
+	cleanupCmd.Flags().BoolVarP(&flgCleanupYes, "yes", "y", false, "Delete the resources without confirmation")
+
+	// --dry-run is accepted as well
+	cleanupCmd.Flags().SetNormalizeFunc(func(f *pflag.FlagSet, name string) pflag.NormalizedName {
+		if name == "dry-run" {
+			name = "dryRun"
+		}
+		return pflag.NormalizedName(name)
+	})
+
+	return cleanupCmd
+}
+
+func cleanup(cmd *cobra.Command, args []string) error {
+	setLogLevel()
+
+	if flgSubscriptionID == "" {
+		return fmt.Errorf("required flag \"subscriptionID\" not set")
+	}
+	if flgCleanupOlderThan < 0 {
+		return fmt.Errorf("invalid age %s, expected a duration of at least 0", flgCleanupOlderThan)
+	}
+
+	mpfConfig := domain.MPFConfig{SubscriptionID: flgSubscriptionID}
+	if err := setupCloud(&mpfConfig); err != nil {
+		return err
+	}
+
+	spRoleAssignmentManager, err := sproleassignmentmanager.NewSPRoleAssignmentManager(mpfConfig.SubscriptionID)
+	if err != nil {
+		return err
+	}
+	rgManager, err := resourceGroupManager.NewResourceGroupManager(mpfConfig.SubscriptionID)
+	if err != nil {
+		return err
+	}
+	cleaner := usecase.NewOrphanedResourceCleaner(spRoleAssignmentManager, rgManager, mpfConfig.SubscriptionID)
+
+	orphans, err := cleaner.FindOrphanedResources(cmd.Context(), domain.OrphanedResourceFilter{
+		RoleNamePrefix:            flgCleanupRoleNamePfx,
+		ResourceGroupNamePrefixes: flgCleanupResourceGroupNamePfxs,
+		OlderThan:                 flgCleanupOlderThan,
+	})
+	if err != nil {
+		return err
+	}
+
+	w := cmd.OutOrStdout()
+	if orphans.IsEmpty() {
+		fmt.Fprintln(w, "No resources left behind by MPF runs found")
+		return nil
+	}
+	printOrphanedResources(w, orphans)
+
+	if flgCleanupDryRun {
+		fmt.Fprintln(w, "Dry run, nothing was deleted")
+		return nil
+	}
+
+	if !flgCleanupYes {
+		confirmed, err := confirm(cmd.InOrStdin(), w, "Delete these resources?")
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			fmt.Fprintln(w, "Nothing was deleted")
+			return nil
+		}
+	}
+
+	log.Infof("Deleting %d custom roles, %d role assignments and %d resource groups", len(orphans.Roles), orphans.RoleAssignmentCount(), len(orphans.ResourceGroups))
+	if err := cleaner.CleanUpOrphanedResources(cmd.Context(), orphans); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Deleted %d custom roles and %d role assignments, deletion of %d resource groups initiated\n", len(orphans.Roles), orphans.RoleAssignmentCount(), len(orphans.ResourceGroups))
+	return nil
+}
+
+func printOrphanedResources(w io.Writer, orphans domain.OrphanedResources) {
+	fmt.Fprintf(w, "Found %d custom roles, %d role assignments and %d resource groups left behind by MPF runs:\n", len(orphans.Roles), orphans.RoleAssignmentCount(), len(orphans.ResourceGroups))
+	for _, orphanedRole := range orphans.Roles {
+		fmt.Fprintf(w, "  Custom role %s (%s)%s\n", orphanedRole.Role.RoleDefinitionName, orphanedRole.Role.RoleDefinitionID, getCreatedText(orphanedRole.Role.CreatedOn))
+		for _, assignment := range orphanedRole.RoleAssignments {
+			fmt.Fprintf(w, "    Role assignment %s of principal %s at scope %s\n", assignment.Name, assignment.PrincipalID, assignment.Scope)
+		}
+	}
+	for _, group := range orphans.ResourceGroups {
+		fmt.Fprintf(w, "  Resource group %s%s\n", group.ResourceGroupName, getCreatedText(group.CreatedAt()))
+	}
+}
+
+func getCreatedText(createdAt time.Time) string {
+	if createdAt.IsZero() {
+		return ""
+	}
+	return ", created " + createdAt.UTC().Format(time.RFC3339)
+}
+
+// confirm asks the question and returns true if it is answered with yes
+func confirm(r io.Reader, w io.Writer, question string) (bool, error) {
+	fmt.Fprintf(w, "%s [y/N]: ", question)
+	answer, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
@@ -0,0 +1,133 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/Azure/mpf/pkg/domain"
+	"github.com/Azure/mpf/pkg/infrastructure/azureAPI"
+	fakearm "github.com/Azure/mpf/pkg/infrastructure/fakeARM"
+	resourceGroupManager "github.com/Azure/mpf/pkg/infrastructure/resourceGroupManager"
+	sproleassignmentmanager "github.com/Azure/mpf/pkg/infrastructure/spRoleAssignmentManager"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCleanupTestServer(t *testing.T, subscriptionID string) *fakearm.Server {
+	server := fakearm.NewServer(fakearm.Config{})
+	t.Cleanup(server.Close)
+	azureAPI.SetDefaultAzureAPIClientsOptions(server.AzureAPIClientsOptions())
+	t.Cleanup(func() { azureAPI.SetDefaultAzureAPIClientsOptions(azureAPI.AzureAPIClientsOptions{}) })
+
+	spRoleAssignmentManager, err := sproleassignmentmanager.NewSPRoleAssignmentManager(subscriptionID)
+	require.NoError(t, err)
+	for _, role := range []domain.Role{
+		{RoleDefinitionID: "11111111-1111-1111-1111-111111111111", RoleDefinitionName: "tmp-rol-aB3dE5g"},
+		{RoleDefinitionID: "22222222-2222-2222-2222-222222222222", RoleDefinitionName: "shared-deployer"},
+	} {
+		role.RoleDefinitionResourceID = "/subscriptions/" + subscriptionID + "/providers/Microsoft.Authorization/roleDefinitions/" + role.RoleDefinitionID
+		err, _ := spRoleAssignmentManager.CreateUpdateCustomRole(t.Context(), subscriptionID, role, []string{"Microsoft.Storage/storageAccounts/write"}, nil)
+		require.NoError(t, err)
+		require.NoError(t, spRoleAssignmentManager.AssignRoleToSP(t.Context(), subscriptionID, "OOOOOOOO-OOOO-OOOO-OOOO-OOOOOOOOOOOO", role))
+	}
+
+	rgManager, err := resourceGroupManager.NewResourceGroupManager(subscriptionID)
+	require.NoError(t, err)
+	require.NoError(t, rgManager.CreateResourceGroup(t.Context(), "e2e-deploy-rg", "eastus2"))
+	// resource groups created before MPF tagged them
+	azAPIClients, err := azureAPI.NewAzureAPIClients(subscriptionID)
+	require.NoError(t, err)
+	for _, name := range []string{"testdeployrg-Xy12345", "production"} {
+		_, err := azAPIClients.ResourceGroupsClient.CreateOrUpdate(t.Context(), name, armresources.ResourceGroup{Location: new("eastus2")}, nil)
+		require.NoError(t, err)
+	}
+	return server
+}
+
+func executeCleanupCommand(t *testing.T, stdin string, args ...string) (string, error) {
+	rootCmd := NewRootCommand()
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	rootCmd.SetIn(strings.NewReader(stdin))
+	rootCmd.SetArgs(append([]string{"cleanup"}, args...))
+	err := rootCmd.Execute()
+	return out.String(), err
+}
+
+func TestCleanupCommandDryRun(t *testing.T) {
+	const subscriptionID = "SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS"
+	server := newCleanupTestServer(t, subscriptionID)
+
+	out, err := executeCleanupCommand(t, "", "--subscriptionID", subscriptionID, "--olderThan", "0", "--dry-run")
+	require.NoError(t, err)
+
+	assert.Contains(t, out, "Found 1 custom roles, 1 role assignments and 2 resource groups left behind by MPF runs")
+	assert.Contains(t, out, "Custom role tmp-rol-aB3dE5g (11111111-1111-1111-1111-111111111111)")
+	assert.Contains(t, out, "Resource group e2e-deploy-rg, created ")
+	assert.Contains(t, out, "Resource group testdeployrg-Xy12345\n")
+	assert.NotContains(t, out, "production")
+	assert.Contains(t, out, "Dry run, nothing was deleted")
+	assert.Equal(t, 2, server.RoleDefinitionCount())
+	assert.Equal(t, 2, server.RoleAssignmentCount())
+}
+
+func TestCleanupCommandSkipsRecentResources(t *testing.T) {
+	const subscriptionID = "SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS"
+	newCleanupTestServer(t, subscriptionID)
+
+	out, err := executeCleanupCommand(t, "", "--subscriptionID", subscriptionID, "--dryRun")
+	require.NoError(t, err)
+
+	assert.Contains(t, out, "No resources left behind by MPF runs found")
+}
+
+func TestCleanupCommandAsksForConfirmation(t *testing.T) {
+	const subscriptionID = "SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS"
+	server := newCleanupTestServer(t, subscriptionID)
+
+	out, err := executeCleanupCommand(t, "n\n", "--subscriptionID", subscriptionID, "--olderThan", "0")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Delete these resources? [y/N]: Nothing was deleted")
+	assert.Equal(t, 2, server.RoleDefinitionCount())
+
+	out, err = executeCleanupCommand(t, "y\n", "--subscriptionID", subscriptionID, "--olderThan", "0")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Deleted 1 custom roles and 1 role assignments, deletion of 2 resource groups initiated")
+	assert.Equal(t, 1, server.RoleDefinitionCount())
+	assert.Equal(t, 1, server.RoleAssignmentCount())
+	assert.False(t, server.ResourceGroupExists("e2e-deploy-rg"))
+	assert.False(t, server.ResourceGroupExists("testdeployrg-Xy12345"))
+	assert.True(t, server.ResourceGroupExists("production"))
+}
+
+func TestCleanupCommandRequiresSubscription(t *testing.T) {
+	t.Setenv("MPF_SUBSCRIPTIONID", "")
+
+	_, err := executeCleanupCommand(t, "", "--yes")
+
+	assert.ErrorContains(t, err, `required flag "subscriptionID" not set`)
+}
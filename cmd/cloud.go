@@ -0,0 +1,71 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Azure/mpf/pkg/domain"
+	"github.com/Azure/mpf/pkg/infrastructure/azureAPI"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	flgCloud                        string
+	flgResourceManagerEndpoint      string
+	flgResourceManagerAudience      string
+	flgActiveDirectoryAuthorityHost string
+	flgTerraformEnvironment         string
+)
+
+func addCloudFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVarP(&flgCloud, "cloud", "", domain.AzurePublicCloudName, fmt.Sprintf("Azure cloud to run against, one of: %s", strings.Join(domain.CloudNames, ", ")))
+	cmd.PersistentFlags().StringVarP(&flgResourceManagerEndpoint, "resourceManagerEndpoint", "", "", "Azure Resource Manager endpoint, overrides the endpoint of the cloud. Required for the custom cloud")
+	cmd.PersistentFlags().StringVarP(&flgResourceManagerAudience, "resourceManagerAudience", "", "", "Audience of Azure Resource Manager access tokens, overrides the audience of the cloud. Defaults to the Resource Manager endpoint when the endpoint is overridden")
+	cmd.PersistentFlags().StringVarP(&flgActiveDirectoryAuthorityHost, "activeDirectoryAuthorityHost", "", "", "Microsoft Entra ID authority host, overrides the authority host of the cloud. Required for the custom cloud")
+	cmd.PersistentFlags().StringVarP(&flgTerraformEnvironment, "terraformEnvironment", "", "", "ARM_ENVIRONMENT of the Terraform azurerm provider, overrides the environment of the cloud")
+}
+
+// setupCloud resolves the cloud flags into mpfConfig and makes the Azure API clients use the endpoints of the cloud
+func setupCloud(mpfConfig *domain.MPFConfig) error {
+	cloud, err := domain.GetCloud(flgCloud, domain.Cloud{
+		ResourceManagerEndpoint:      flgResourceManagerEndpoint,
+		ResourceManagerAudience:      flgResourceManagerAudience,
+		ActiveDirectoryAuthorityHost: flgActiveDirectoryAuthorityHost,
+		TerraformEnvironment:         flgTerraformEnvironment,
+	})
+	if err != nil {
+		return err
+	}
+	mpfConfig.Cloud = cloud
+
+	if cloud.IsAzurePublicCloud() {
+		return nil
+	}
+
+	log.Infof("Cloud: %s, Resource Manager endpoint: %s", cloud.Name, cloud.ResourceManagerEndpoint)
+	azureAPI.SetDefaultAzureAPIClientsOptions(azureAPI.GetCloudAzureAPIClientsOptions(cloud))
+	return nil
+}
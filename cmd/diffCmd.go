@@ -0,0 +1,144 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Azure/mpf/pkg/domain"
+	"github.com/Azure/mpf/pkg/infrastructure/azureAPI"
+	roledefinitions "github.com/Azure/mpf/pkg/infrastructure/roleDefinitions"
+	"github.com/Azure/mpf/pkg/presentation"
+	"github.com/spf13/cobra"
+)
+
+var (
+	flgDiffRoleFile string
+	flgDiffRole     string
+)
+
+// NewDiffCommand returns the diff command, which compares an existing role definition with the permissions of an MPF result
+func NewDiffCommand() *cobra.Command {
+
+	diffCmd := &cobra.Command{
+		Use:   "diff [resultFilePath]",
+		Short: "Compare a role definition with the permissions of an MPF result",
+		Long: `Compare a role definition with the permissions of an MPF result written with --output json or --output jsonV1.
+The required permissions the role does not grant, the permissions of the role granting no required permission,
+and the wildcards of the role granting required permissions are listed.
+The command fails if the role does not grant all required permissions.
+
+The result is read from the given file, or from stdin if no file (or "-") is given, so that the output of an MPF run can be piped in.
+The role definition is read from --roleFile, in the format of az role definition list or create, or fetched by name or ID with --role,
+which requires --subscriptionID and the Azure CLI or default Azure credentials.`,
+		Example: `azmpf diff ./mpf-result.json --roleFile ./role.json
+		azmpf diff ./mpf-result.json --role "Website Contributor" --subscriptionID <subscriptionID>
+		azmpf arm --templateFilePath ./template.json --parametersFilePath ./parameters.json --output json | azmpf diff --roleFile ./role.json`,
+		Args: cobra.MaximumNArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			disableRequiredRootFlags(cmd)
+			return nil
+		},
+		RunE: diffRoleDefinition,
+	}
+
+	diffCmd.Flags().StringVarP(&flgDiffRoleFile, "roleFile", "", "", "Role definition file, in the format of az role definition list or az role definition create")
+	diffCmd.Flags().StringVarP(&flgDiffRole, "role", "", "", "Name, ID or resource ID of the role definition to fetch. Selects the role by name or ID if the role file holds several roles")
+
+	return diffCmd
+}
+
+func diffRoleDefinition(cmd *cobra.Command, args []string) error {
+	setLogLevel()
+
+	if flgDiffRoleFile == "" && flgDiffRole == "" {
+		return fmt.Errorf("one of --roleFile or --role is required")
+	}
+
+	mpfResult, aggregateKey, err := readMPFResult(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	role, err := getDiffRoleDefinition(cmd)
+	if err != nil {
+		return err
+	}
+
+	diff := domain.DiffRoleDefinition(mpfResult.RequiredPermissions[aggregateKey], mpfResult.RequiredDataActions[aggregateKey], role)
+	err = writeOutput(cmd.OutOrStdout(), func(w io.Writer) error {
+		return presentation.DisplayRoleDiff(w, role, diff, getOutputFormat())
+	})
+	if err != nil {
+		return err
+	}
+
+	if diff.HasMissingPermissions() {
+		return fmt.Errorf("role %s does not grant %d required permissions", role.Name, len(diff.MissingActions)+len(diff.MissingDataActions))
+	}
+	return nil
+}
+
+// getDiffRoleDefinition returns the role definition of the role file, or fetches it by name or ID
+func getDiffRoleDefinition(cmd *cobra.Command) (domain.RoleDefinition, error) {
+	if flgDiffRoleFile == "" {
+		if flgSubscriptionID == "" {
+			return domain.RoleDefinition{}, fmt.Errorf("--subscriptionID is required to fetch the role definition")
+		}
+
+		mpfConfig := domain.MPFConfig{SubscriptionID: flgSubscriptionID}
+		if err := setupCloud(&mpfConfig); err != nil {
+			return domain.RoleDefinition{}, err
+		}
+		azAPIClients, err := azureAPI.NewAzureAPIClients(mpfConfig.SubscriptionID)
+		if err != nil {
+			return domain.RoleDefinition{}, err
+		}
+		return roledefinitions.FetchRoleDefinition(cmd.Context(), azAPIClients, mpfConfig.SubscriptionID, flgDiffRole)
+	}
+
+	data, err := os.ReadFile(flgDiffRoleFile)
+	if err != nil {
+		return domain.RoleDefinition{}, fmt.Errorf("error reading role file: %w", err)
+	}
+	roles, err := roledefinitions.ParseRoleDefinitions(data)
+	if err != nil {
+		return domain.RoleDefinition{}, err
+	}
+
+	if flgDiffRole != "" {
+		for _, role := range roles {
+			if strings.EqualFold(role.Name, flgDiffRole) || strings.EqualFold(role.ID, flgDiffRole) {
+				return role, nil
+			}
+		}
+		return domain.RoleDefinition{}, fmt.Errorf("%w: %s in %s", roledefinitions.ErrRoleDefinitionNotFound, flgDiffRole, flgDiffRoleFile)
+	}
+	if len(roles) != 1 {
+		return domain.RoleDefinition{}, fmt.Errorf("the role file holds %d role definitions, select one with --role", len(roles))
+	}
+	return roles[0], nil
+}
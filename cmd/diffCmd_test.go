@@ -0,0 +1,96 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDiffResult = `{"RequiredPermissions":{"sub":["Microsoft.Storage/storageAccounts/write","Microsoft.Resources/deployments/write"]}}`
+
+func writeTestRoleFile(t *testing.T, content string) string {
+	roleFilePath := filepath.Join(t.TempDir(), "role.json")
+	require.NoError(t, os.WriteFile(roleFilePath, []byte(content), 0600))
+	return roleFilePath
+}
+
+func TestDiffCommandFailsForMissingPermissions(t *testing.T) {
+	roleFilePath := writeTestRoleFile(t, `{"Name":"Deployer","Actions":["Microsoft.Storage/*","Microsoft.Compute/virtualMachines/write"],"NotActions":[]}`)
+
+	rootCmd := NewRootCommand()
+	var out bytes.Buffer
+	rootCmd.SetIn(strings.NewReader(testDiffResult))
+	rootCmd.SetOut(&out)
+	rootCmd.SetErr(&bytes.Buffer{})
+	rootCmd.SetArgs([]string{"diff", "--roleFile", roleFilePath, "--output", "json"})
+
+	err := rootCmd.Execute()
+	assert.ErrorContains(t, err, "does not grant 1 required permissions")
+
+	var diff struct {
+		MissingActions  []string `json:"missingActions"`
+		ExcessActions   []string `json:"excessActions"`
+		WildcardActions []struct {
+			Pattern            string   `json:"pattern"`
+			CoveredPermissions []string `json:"coveredPermissions"`
+		} `json:"wildcardActions"`
+	}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &diff))
+	assert.Equal(t, []string{"Microsoft.Resources/deployments/write"}, diff.MissingActions)
+	assert.Equal(t, []string{"Microsoft.Compute/virtualMachines/write"}, diff.ExcessActions)
+	require.Len(t, diff.WildcardActions, 1)
+	assert.Equal(t, "Microsoft.Storage/*", diff.WildcardActions[0].Pattern)
+}
+
+func TestDiffCommandSucceedsWhenAllPermissionsAreGranted(t *testing.T) {
+	roleFilePath := writeTestRoleFile(t, `[{"name":"1","roleName":"Other","permissions":[]},{"name":"2","roleName":"Deployer","permissions":[{"actions":["Microsoft.Storage/storageAccounts/write","Microsoft.Resources/deployments/*"]}]}]`)
+
+	rootCmd := NewRootCommand()
+	var out bytes.Buffer
+	rootCmd.SetIn(strings.NewReader(testDiffResult))
+	rootCmd.SetOut(&out)
+	rootCmd.SetArgs([]string{"diff", "-", "--roleFile", roleFilePath, "--role", "Deployer"})
+
+	err := rootCmd.Execute()
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "The role grants all required permissions")
+}
+
+func TestDiffCommandRequiresRole(t *testing.T) {
+	rootCmd := NewRootCommand()
+	rootCmd.SetIn(strings.NewReader(testDiffResult))
+	rootCmd.SetOut(&bytes.Buffer{})
+	rootCmd.SetErr(&bytes.Buffer{})
+	rootCmd.SetArgs([]string{"diff"})
+
+	err := rootCmd.Execute()
+	assert.ErrorContains(t, err, "--roleFile or --role")
+}
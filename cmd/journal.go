@@ -0,0 +1,69 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/Azure/mpf/pkg/infrastructure/journal"
+	"github.com/Azure/mpf/pkg/usecase"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var flgJournalFile string
+
+func addJournalFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&flgJournalFile, "journalFile", "", "", "File to write a journal of the run to in JSON Lines format, with one entry per iteration recording the authorization error, the parsed findings, the permissions added to the role, the invalid actions removed and the propagation wait")
+}
+
+// setupJournal makes mpfService write its journal to --journalFile.
+// A resumed run appends to the journal of the interrupted run. The returned journal is nil if no journal file is set.
+func setupJournal(mpfService *usecase.MPFService, resume bool) (*journal.FileJournal, error) {
+	if flgJournalFile == "" {
+		return nil, nil
+	}
+
+	journalFilePath, err := getAbsolutePath(flgJournalFile)
+	if err != nil {
+		return nil, fmt.Errorf("error getting absolute path for journal file: %w", err)
+	}
+
+	fileJournal, err := journal.NewFileJournal(journalFilePath, resume)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("Writing journal to %s", journalFilePath)
+
+	mpfService.EnableJournal(fileJournal)
+	return fileJournal, nil
+}
+
+func closeJournal(fileJournal *journal.FileJournal) {
+	if fileJournal == nil {
+		return
+	}
+	if err := fileJournal.Close(); err != nil {
+		log.Warnf("Could not close journal file: %v", err)
+	}
+}
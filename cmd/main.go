@@ -23,8 +23,11 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -43,10 +46,37 @@ func main() {
 	}
 	log.SetLevel(logLevel)
 
+	ctx, cancel := cancelOnSignal(context.Background())
+	defer cancel()
+
 	rootCmd := NewRootCommand()
 	rootCmd.Version = fmt.Sprintf(": %s, commit: %s, date: %s", version, commit, date)
-	if err := rootCmd.Execute(); err != nil {
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
+		cancel()
 		os.Exit(1)
 	}
 
 }
+
+// cancelOnSignal returns a context which is cancelled on SIGINT or SIGTERM, so that MPF stops and cleans up
+// the resources it created. A second signal terminates MPF immediately, skipping the clean up.
+func cancelOnSignal(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case sig := <-signals:
+			fmt.Fprintf(os.Stderr, "Received %s, cleaning up resources created by MPF. Repeat to exit immediately without clean up.\n", sig)
+			// restore the default behaviour for the second signal
+			signal.Stop(signals)
+			cancel()
+		case <-ctx.Done():
+			signal.Stop(signals)
+		}
+	}()
+
+	return ctx, cancel
+}
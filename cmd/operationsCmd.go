@@ -0,0 +1,123 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Azure/mpf/pkg/domain"
+	"github.com/Azure/mpf/pkg/infrastructure/azureAPI"
+	operationscatalog "github.com/Azure/mpf/pkg/infrastructure/operationsCatalog"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// NewOperationsCommand returns the operations command, which manages the resource provider operations catalog
+func NewOperationsCommand() *cobra.Command {
+
+	operationsCmd := &cobra.Command{
+		Use:   "operations",
+		Short: "Manage the resource provider operations catalog used to validate actions",
+	}
+
+	refreshCmd := &cobra.Command{
+		Use:   "refresh",
+		Short: "Write the resource provider operations of the cloud to a snapshot file",
+		Long: `Write the resource provider operations of the cloud to a snapshot file for --operationsCatalogFile.
+The operations are fetched with the Azure CLI or default Azure credentials, and written to stdout or --outputFile.`,
+		Example: `azmpf operations refresh --outputFile ./providerOperations.json
+		azmpf operations refresh --cloud AzureUSGovernment --outputFile ./providerOperations.json`,
+		Args: cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			disableRequiredRootFlags(cmd)
+			return nil
+		},
+		RunE: refreshOperations,
+	}
+
+	operationsCmd.AddCommand(refreshCmd)
+	return operationsCmd
+}
+
+func refreshOperations(cmd *cobra.Command, args []string) error {
+	setLogLevel()
+
+	mpfConfig := domain.MPFConfig{SubscriptionID: flgSubscriptionID}
+	if err := setupCloud(&mpfConfig); err != nil {
+		return err
+	}
+
+	azAPIClients, err := azureAPI.NewAzureAPIClients(mpfConfig.SubscriptionID)
+	if err != nil {
+		return err
+	}
+	data, err := operationscatalog.FetchProviderOperations(cmd.Context(), azAPIClients)
+	if err != nil {
+		return err
+	}
+	return writeOutput(cmd.OutOrStdout(), func(w io.Writer) error {
+		_, err := w.Write(append(data, '\n'))
+		return err
+	})
+}
+
+// getOperationsCatalog loads the catalog of --operationsCatalogFile, or the bundled snapshot.
+// nil is returned if --skipActionValidation is set.
+func getOperationsCatalog() (*domain.OperationsCatalog, error) {
+	if flgSkipActionValidation {
+		log.Infoln("Skipping action validation")
+		return nil, nil
+	}
+
+	operationsCatalog, err := operationscatalog.LoadOperationsCatalog(flgOperationsCatalogFile)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("Validating actions with %d resource provider operations", operationsCatalog.Len())
+	return operationsCatalog, nil
+}
+
+// validatePermissions returns the permissions normalized with the catalog,
+// or an error listing the permissions the resource providers do not have
+func validatePermissions(operationsCatalog *domain.OperationsCatalog, permissions []string) ([]string, error) {
+	if operationsCatalog == nil {
+		return permissions, nil
+	}
+
+	normalized, invalid := operationsCatalog.ValidateActions(permissions)
+	if len(invalid) == 0 {
+		return normalized, nil
+	}
+
+	var lines []string
+	for _, action := range invalid {
+		if suggestion := operationsCatalog.SuggestAction(action); suggestion != "" {
+			lines = append(lines, fmt.Sprintf("%s (did you mean %s?)", action, suggestion))
+			continue
+		}
+		lines = append(lines, action)
+	}
+	return nil, fmt.Errorf("invalid permissions, the resource providers have no such operations:\n  %s\nUse --skipActionValidation if the operations catalog is outdated", strings.Join(lines, "\n  "))
+}
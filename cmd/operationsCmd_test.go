@@ -0,0 +1,71 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package main
+
+import (
+	"testing"
+
+	"github.com/Azure/mpf/pkg/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppendUserInitialPermissionsValidatesPermissions(t *testing.T) {
+	flgOperationsCatalogFile, flgSkipActionValidation = "", false
+	operationsCatalog, err := getOperationsCatalog()
+	require.NoError(t, err)
+
+	flgInitialPermissions = "microsoft.storage/storageaccounts/write,Microsoft.Web/sites/write"
+	t.Cleanup(func() { flgInitialPermissions = "" })
+	initialPermissions, resultPermissions, err := appendUserInitialPermissions(operationsCatalog, []string{"Microsoft.Resources/deployments/*"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Microsoft.Resources/deployments/*", "Microsoft.Storage/storageAccounts/write", "Microsoft.Web/sites/write"}, initialPermissions)
+	assert.Equal(t, []string{"Microsoft.Storage/storageAccounts/write", "Microsoft.Web/sites/write"}, resultPermissions)
+
+	flgInitialPermissions = "Microsoft.Storage/storageAccounts/wirte,Microsoft.KeyVault/vaults/accessPolicies/read"
+	_, _, err = appendUserInitialPermissions(operationsCatalog, nil, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Microsoft.Storage/storageAccounts/wirte (did you mean Microsoft.Storage/storageAccounts/write?)")
+	assert.Contains(t, err.Error(), "Microsoft.KeyVault/vaults/accessPolicies/read")
+}
+
+func TestGetOperationsCatalogSkipsValidation(t *testing.T) {
+	flgSkipActionValidation = true
+	t.Cleanup(func() { flgSkipActionValidation = false })
+
+	operationsCatalog, err := getOperationsCatalog()
+	require.NoError(t, err)
+	assert.Nil(t, operationsCatalog)
+
+	permissions, err := validatePermissions(operationsCatalog, []string{"Microsoft.Storage/storageAccounts/wirte"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Microsoft.Storage/storageAccounts/wirte"}, permissions)
+}
+
+func TestValidatePermissionsWithoutSuggestion(t *testing.T) {
+	operationsCatalog := domain.NewOperationsCatalog([]string{"Microsoft.Storage/storageAccounts/write"})
+
+	_, err := validatePermissions(operationsCatalog, []string{"Microsoft.Storage/storageAccounts/regenerateKey/action"})
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "did you mean")
+}
@@ -0,0 +1,146 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"time"
+
+	"github.com/Azure/mpf/pkg/domain"
+	operationscatalog "github.com/Azure/mpf/pkg/infrastructure/operationsCatalog"
+	"github.com/Azure/mpf/pkg/presentation"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// getOutputFormat returns the name of the formatter selected by --output, or by the deprecated --jsonOutput
+func getOutputFormat() string {
+	if flgJSONOutput {
+		return presentation.JSONOutput
+	}
+	return flgOutput
+}
+
+// newRunMetadata returns the metadata of a run of the command starting now, identified by a new run ID
+func newRunMetadata(deploymentType string, templatePath string, parametersPath string) domain.MPFRunMetadata {
+	return domain.MPFRunMetadata{
+		RunID:          uuid.NewString(),
+		User:           getCurrentUserName(),
+		Version:        version,
+		DeploymentType: deploymentType,
+		TemplatePath:   templatePath,
+		ParametersPath: parametersPath,
+		StartTime:      time.Now().UTC(),
+	}
+}
+
+// getCurrentUserName returns the name of the user running the command, or "unknown" if it cannot be determined
+func getCurrentUserName() string {
+	currentUser, err := user.Current()
+	if err != nil {
+		return "unknown"
+	}
+	return currentUser.Username
+}
+
+func getDislayOptions(subscriptionID string, runMetadata domain.MPFRunMetadata) presentation.DisplayOptions {
+	return presentation.DisplayOptions{
+		ShowDetailedOutput: flgShowDetailedOutput,
+		Output:             getOutputFormat(),
+		SubscriptionID:     subscriptionID,
+		RoleDefinition: presentation.RoleDefinitionOptions{
+			Name:             flgRoleDefinitionName,
+			Description:      flgRoleDefinitionDescription,
+			AssignableScopes: flgRoleDefinitionAssignableScopes,
+		},
+		Metadata: runMetadata,
+	}
+}
+
+// displayResult writes the result to --outputFile, or to w if no output file is set.
+// The run is considered ended when its result is displayed. With --compactWildcards, the result is compacted first
+// with the operations catalog of --operationsCatalogFile.
+func displayResult(w io.Writer, mpfResult domain.MPFResult, displayOptions presentation.DisplayOptions) error {
+	if displayOptions.Metadata.EndTime.IsZero() {
+		displayOptions.Metadata.EndTime = time.Now().UTC()
+	}
+	if flgCompactWildcards {
+		operationsCatalog, err := operationscatalog.LoadOperationsCatalog(flgOperationsCatalogFile)
+		if err != nil {
+			return fmt.Errorf("error loading operations catalog to compact wildcards: %w", err)
+		}
+		policy := domain.WildcardCompactionPolicy{Catalog: operationsCatalog}
+		mpfResult = domain.CompactMPFResultWildcards(mpfResult, displayOptions.SubscriptionID, policy)
+	}
+	resultDisplayer := presentation.NewMPFResultDisplayer(mpfResult, displayOptions)
+	return writeOutput(w, resultDisplayer.DisplayResult)
+}
+
+// writeOutput writes the output to the --outputFile file if set, or to w otherwise
+func writeOutput(w io.Writer, write func(io.Writer) error) error {
+	if flgOutputFile == "" {
+		return write(w)
+	}
+
+	outputFilePath, err := getAbsolutePath(flgOutputFile)
+	if err != nil {
+		return fmt.Errorf("error getting absolute path for output file: %w", err)
+	}
+	outputFile, err := os.Create(outputFilePath)
+	if err != nil {
+		return fmt.Errorf("error creating output file: %w", err)
+	}
+	if err := write(outputFile); err != nil {
+		_ = outputFile.Close()
+		return err
+	}
+	if err := outputFile.Close(); err != nil {
+		return fmt.Errorf("error writing output file: %w", err)
+	}
+	log.Infof("Wrote output to %s", outputFilePath)
+	return nil
+}
+
+// readMPFResult reads a result written with --output json or --output jsonV1 from the file given as argument,
+// or from stdin if no file (or "-") is given
+func readMPFResult(cmd *cobra.Command, args []string) (domain.MPFResult, string, error) {
+	var r io.Reader = cmd.InOrStdin()
+	if len(args) == 1 && args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return domain.MPFResult{}, "", fmt.Errorf("error opening result file: %w", err)
+		}
+		defer f.Close() //nolint:errcheck
+		r = f
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return domain.MPFResult{}, "", fmt.Errorf("error reading result: %w", err)
+	}
+
+	return presentation.ParseJSONResult(data)
+}
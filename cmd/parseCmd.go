@@ -0,0 +1,106 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Azure/mpf/pkg/domain"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// NewParseCommand returns the parse command, which converts raw deployment error text
+// into the permissions MPF would have added to the custom role. It runs fully offline.
+func NewParseCommand() *cobra.Command {
+
+	parseCmd := &cobra.Command{
+		Use:   "parse [errorFilePath]",
+		Short: "Parse raw Azure deployment error text into the required permissions",
+		Long: `Parse raw Azure deployment error text (AuthorizationFailed, LinkedAuthorizationFailed, LinkedAccessCheckFailed,
+AuthorizationPermissionMismatch and LackOfPermissions errors) into the permissions required by the deployment.
+
+The error text is read from the given file, or from stdin if no file (or "-") is given.
+No credentials, service principal or Azure calls are needed.`,
+		Example: `azmpf parse ./deployment-error.txt
+		cat ./deployment-error.txt | azmpf parse --output json`,
+		Args: cobra.MaximumNArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			disableRequiredRootFlags(cmd)
+			return nil
+		},
+		RunE: parseAuthErrors,
+	}
+
+	return parseCmd
+}
+
+func parseAuthErrors(cmd *cobra.Command, args []string) error {
+	setLogLevel()
+	runMetadata := newRunMetadata("parse", "-", "")
+
+	var r io.Reader = cmd.InOrStdin()
+	if len(args) == 1 && args[0] != "-" {
+		log.Infof("Reading deployment error from file: %s\n", args[0])
+		f, err := os.Open(args[0])
+		if err != nil {
+			return fmt.Errorf("error opening deployment error file: %w", err)
+		}
+		defer f.Close() //nolint:errcheck
+		r = f
+		runMetadata.TemplatePath = args[0]
+	}
+
+	mpfResult, err := getMPFResultFromAuthErrorText(r, flgSubscriptionID)
+	if err != nil {
+		return err
+	}
+
+	displayOptions := getDislayOptions(flgSubscriptionID, runMetadata)
+	return displayResult(cmd.OutOrStdout(), mpfResult, displayOptions)
+}
+
+// getMPFResultFromAuthErrorText parses the authorization error text read from r.
+// Permissions are reported per scope, and all permissions are also aggregated under
+// aggregateKey, which is the key the displayers use for the overall permission list.
+func getMPFResultFromAuthErrorText(r io.Reader, aggregateKey string) (domain.MPFResult, error) {
+	authErrText, err := io.ReadAll(r)
+	if err != nil {
+		return domain.MPFResult{}, fmt.Errorf("error reading deployment error: %w", err)
+	}
+
+	scpMp, err := domain.GetScopePermissionsFromAuthError(string(authErrText))
+	if err != nil {
+		return domain.MPFResult{}, err
+	}
+
+	requiredPermissions := make(map[string][]string)
+	for scope, permissions := range scpMp {
+		requiredPermissions[scope] = append(requiredPermissions[scope], permissions...)
+		requiredPermissions[aggregateKey] = append(requiredPermissions[aggregateKey], permissions...)
+	}
+
+	return domain.GetMPFResult(requiredPermissions), nil
+}
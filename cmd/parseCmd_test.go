@@ -0,0 +1,146 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testAuthorizationFailedError = `{"error":{"code":"AuthorizationFailed","message":"The client 'XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX' with object id 'XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX' does not have authorization to perform action 'Microsoft.Storage/storageAccounts/write' over scope '/subscriptions/SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS/resourcegroups/testdeployrg/providers/Microsoft.Storage/storageAccounts/sa1' or the scope is invalid. If access was recently granted, please refresh your credentials."}}`
+
+func TestGetMPFResultFromAuthErrorText(t *testing.T) {
+	mpfResult, err := getMPFResultFromAuthErrorText(strings.NewReader(testAuthorizationFailedError), "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Microsoft.Storage/storageAccounts/write"}, mpfResult.RequiredPermissions[""])
+	assert.Equal(t, []string{"Microsoft.Storage/storageAccounts/write"}, mpfResult.RequiredPermissions["/subscriptions/SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS/resourcegroups/testdeployrg/providers/Microsoft.Storage/storageAccounts/sa1"])
+}
+
+func TestGetMPFResultFromNonAuthErrorText(t *testing.T) {
+	_, err := getMPFResultFromAuthErrorText(strings.NewReader("InvalidTemplateDeployment: sku not allowed"), "")
+	assert.Error(t, err)
+}
+
+func TestParseCommandRunsWithoutCredentials(t *testing.T) {
+	rootCmd := NewRootCommand()
+	var out bytes.Buffer
+	rootCmd.SetIn(strings.NewReader(testAuthorizationFailedError))
+	rootCmd.SetOut(&out)
+	rootCmd.SetArgs([]string{"parse", "--jsonOutput"})
+
+	err := rootCmd.Execute()
+	assert.NoError(t, err)
+	assert.Contains(t, out.String(), "Microsoft.Storage/storageAccounts/write")
+}
+
+func TestParseCommandWritesOutputFile(t *testing.T) {
+	outputFilePath := filepath.Join(t.TempDir(), "permissions.csv")
+	rootCmd := NewRootCommand()
+	var out bytes.Buffer
+	rootCmd.SetIn(strings.NewReader(testAuthorizationFailedError))
+	rootCmd.SetOut(&out)
+	rootCmd.SetArgs([]string{"parse", "--output", "csv", "--outputFile", outputFilePath, "--subscriptionID", "SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS"})
+
+	err := rootCmd.Execute()
+	require.NoError(t, err)
+	assert.Empty(t, out.String())
+
+	output, err := os.ReadFile(outputFilePath)
+	require.NoError(t, err)
+	assert.Equal(t, "Scope,Type,Permission\n/subscriptions/SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS,Action,Microsoft.Storage/storageAccounts/write\n", string(output))
+}
+
+func TestParseCommandRejectsInvalidOutput(t *testing.T) {
+	rootCmd := NewRootCommand()
+	rootCmd.SetIn(strings.NewReader(testAuthorizationFailedError))
+	rootCmd.SetOut(&bytes.Buffer{})
+	rootCmd.SetErr(&bytes.Buffer{})
+	rootCmd.SetArgs([]string{"parse", "--output", "xml"})
+
+	err := rootCmd.Execute()
+	assert.ErrorContains(t, err, "invalid output format")
+}
+
+func TestParseCommandOutputsVersionedJSON(t *testing.T) {
+	rootCmd := NewRootCommand()
+	var out bytes.Buffer
+	rootCmd.SetIn(strings.NewReader(testAuthorizationFailedError))
+	rootCmd.SetOut(&out)
+	rootCmd.SetArgs([]string{"parse", "--output", "jsonV1"})
+
+	err := rootCmd.Execute()
+	require.NoError(t, err)
+
+	var result struct {
+		SchemaVersion string `json:"schemaVersion"`
+		Metadata      struct {
+			DeploymentType string `json:"deploymentType"`
+			TemplatePath   string `json:"templatePath"`
+		} `json:"metadata"`
+		Permissions struct {
+			Actions []string `json:"actions"`
+		} `json:"permissions"`
+	}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &result))
+	assert.Equal(t, "1.0", result.SchemaVersion)
+	assert.Equal(t, "parse", result.Metadata.DeploymentType)
+	assert.Equal(t, "-", result.Metadata.TemplatePath)
+	assert.Equal(t, []string{"Microsoft.Storage/storageAccounts/write"}, result.Permissions.Actions)
+}
+
+func TestParseCommandCompactsWildcards(t *testing.T) {
+	// the operations catalog lists these operations on subnets
+	var authErrors []string
+	for _, operation := range []string{"read", "write", "delete", "join/action", "joinViaServiceEndpoint/action", "prepareNetworkPolicies/action", "unprepareNetworkPolicies/action"} {
+		authErrors = append(authErrors, strings.ReplaceAll(testAuthorizationFailedError, "Microsoft.Storage/storageAccounts/write", "Microsoft.Network/virtualNetworks/subnets/"+operation))
+	}
+
+	rootCmd := NewRootCommand()
+	var out bytes.Buffer
+	rootCmd.SetIn(strings.NewReader(strings.Join(authErrors, "\n")))
+	rootCmd.SetOut(&out)
+	rootCmd.SetArgs([]string{"parse", "--compactWildcards", "--output", "jsonV1", "--subscriptionID", "SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS"})
+
+	err := rootCmd.Execute()
+	require.NoError(t, err)
+
+	var result struct {
+		Permissions struct {
+			Actions []string `json:"actions"`
+		} `json:"permissions"`
+		Scopes []struct {
+			Actions []string `json:"actions"`
+		} `json:"scopes"`
+	}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &result))
+	assert.Equal(t, []string{"Microsoft.Network/virtualNetworks/subnets/*"}, result.Permissions.Actions)
+	require.Len(t, result.Scopes, 1)
+	assert.Len(t, result.Scopes[0].Actions, 7)
+}
@@ -0,0 +1,155 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Azure/mpf/pkg/domain"
+	"github.com/Azure/mpf/pkg/infrastructure/journal"
+	propagationwaiter "github.com/Azure/mpf/pkg/infrastructure/propagationWaiter"
+	"github.com/Azure/mpf/pkg/infrastructure/replay"
+	"github.com/Azure/mpf/pkg/usecase"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// replaySubscriptionID is used when no subscription ID is given, as the replay makes no Azure calls
+const replaySubscriptionID = "00000000-0000-0000-0000-000000000000"
+
+var flgReplayMode string
+
+// NewReplayCommand returns the replay command, which reruns the MPF loop offline with the deployment results recorded in a journal
+func NewReplayCommand() *cobra.Command {
+
+	replayCmd := &cobra.Command{
+		Use:   "replay <journalFilePath>",
+		Short: "Rerun MPF offline with the deployment errors recorded in a journal",
+		Long: `Rerun the MPF loop offline with the deployment authorization errors recorded in a journal written with --journalFile.
+The errors are parsed with the current parsers and special case rules, and the custom role and resource group are kept in memory.
+
+No credentials, service principal or Azure calls are needed.`,
+		Example: `azmpf replay ./mpf-journal.jsonl
+		azmpf replay ./mpf-journal.jsonl --mode terraform --output json`,
+		Args: cobra.ExactArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			disableRequiredRootFlags(cmd)
+			return nil
+		},
+		RunE: replayJournal,
+	}
+
+	replayCmd.Flags().StringVarP(&flgReplayMode, "mode", "", usecase.ARMMode, "Command the journal was recorded with: arm, bicep or terraform. It selects the permissions MPF adds for each write permission and to the result")
+	addJournalFlags(replayCmd)
+
+	return replayCmd
+}
+
+func replayJournal(cmd *cobra.Command, args []string) error {
+	setLogLevel()
+	runMetadata := newRunMetadata("replay", args[0], "")
+
+	// the initial permissions are recorded in the journal, the other settings are those of the command the journal was recorded with
+	modeSettings, err := usecase.GetModeSettings(flgReplayMode)
+	if err != nil {
+		return err
+	}
+
+	entries, err := journal.LoadJournalFromFile(args[0])
+	if err != nil {
+		return err
+	}
+	log.Infof("Replaying %d journal entries from %s", len(entries), args[0])
+
+	subscriptionID := flgSubscriptionID
+	if subscriptionID == "" {
+		subscriptionID = replaySubscriptionID
+	}
+
+	mpfConfig := domain.MPFConfig{
+		SubscriptionID: subscriptionID,
+		SP: domain.ServicePrincipal{
+			SPObjectID: flgSPObjectID,
+		},
+		Role: domain.Role{
+			RoleDefinitionID:   "replay",
+			RoleDefinitionName: "replay",
+		},
+	}
+
+	var invalidActions []string
+	modeSettings.InitialPermissions, invalidActions = getReplayInitialPermissions(entries)
+	modeSettings.AutoCreateResourceGroup = false
+	checker := replay.NewJournalReplayChecker(entries)
+
+	mpfService := usecase.NewMPFServiceForMode(cmd.Context(), replay.NewMemoryResourceGroupManager(), replay.NewMemorySPRoleAssignmentManager(invalidActions...), checker, mpfConfig, modeSettings)
+	mpfService.SetPropagationWaiter(propagationwaiter.NewFixedPropagationWaiter(0, 0))
+
+	iterationJournal, err := setupJournal(mpfService, false)
+	if err != nil {
+		return err
+	}
+	defer closeJournal(iterationJournal)
+
+	displayOptions := getDislayOptions(mpfConfig.SubscriptionID, runMetadata)
+
+	mpfResult, err := mpfService.GetMinimumPermissionsRequired()
+	if errors.Is(err, replay.ErrJournalExhausted) {
+		// the journal of an interrupted run ends without a successful deployment
+		log.Warnln("The journal ended before the deployment succeeded, showing the permissions found so far")
+		err = nil
+	}
+	if err != nil {
+		if len(mpfResult.RequiredPermissions) > 0 {
+			fmt.Println("Error occurred while replaying the journal. However, some permissions were identified prior to the error.")
+			_ = displayResult(cmd.OutOrStdout(), mpfResult, displayOptions)
+		}
+		return err
+	}
+
+	if remaining := checker.Remaining(); remaining > 0 {
+		log.Warnf("The replay finished with %d recorded deployments not replayed", remaining)
+	}
+
+	return displayResult(cmd.OutOrStdout(), mpfResult, displayOptions)
+}
+
+// getReplayInitialPermissions returns the permissions the custom role was initialized with in the recorded run,
+// and all actions which were removed from the role as invalid
+func getReplayInitialPermissions(entries []domain.MPFJournalEntry) ([]string, []string) {
+	var initialPermissions []string
+	var invalidActions []string
+	initialized := false
+	for _, entry := range entries {
+		// a resumed run initializes the role again, with the permissions already replayed
+		if entry.Outcome == domain.JournalRoleInitialized && !initialized {
+			initialized = true
+			for _, permissions := range entry.PermissionsAdded {
+				initialPermissions = append(initialPermissions, permissions...)
+			}
+		}
+		invalidActions = append(invalidActions, entry.InvalidActions...)
+	}
+	return initialPermissions, invalidActions
+}
@@ -0,0 +1,112 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/mpf/pkg/domain"
+	"github.com/Azure/mpf/pkg/infrastructure/journal"
+	propagationwaiter "github.com/Azure/mpf/pkg/infrastructure/propagationWaiter"
+	"github.com/Azure/mpf/pkg/infrastructure/replay"
+	"github.com/Azure/mpf/pkg/usecase"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplayCommandRunsWithoutCredentials(t *testing.T) {
+	journalFilePath := filepath.Join(t.TempDir(), "journal.jsonl")
+	fileJournal, err := journal.NewFileJournal(journalFilePath, false)
+	assert.NoError(t, err)
+	for _, entry := range []domain.MPFJournalEntry{
+		{
+			Outcome:          domain.JournalRoleInitialized,
+			PermissionsAdded: map[string][]string{"sub": {"Microsoft.Resources/deployments/*"}},
+		},
+		{
+			Outcome:            domain.JournalAuthorizationErrors,
+			AuthorizationError: testAuthorizationFailedError,
+		},
+		{
+			Iteration: 1,
+			Outcome:   domain.JournalSucceeded,
+		},
+	} {
+		assert.NoError(t, fileJournal.WriteJournalEntry(entry))
+	}
+	assert.NoError(t, fileJournal.Close())
+
+	rootCmd := NewRootCommand()
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	rootCmd.SetArgs([]string{"replay", journalFilePath, "--jsonOutput"})
+
+	err = rootCmd.Execute()
+	assert.NoError(t, err)
+	assert.Contains(t, out.String(), "Microsoft.Storage/storageAccounts/write")
+	// read permissions are added for each write permission, like the arm command does
+	assert.Contains(t, out.String(), "Microsoft.Storage/storageAccounts/read")
+}
+
+func TestReplayMatchesRecordedTerraformResult(t *testing.T) {
+	defer func(mode string) { flgReplayMode = mode }(flgReplayMode)
+
+	// record the journal of a terraform run, with a checker replaying the deployment results
+	journalFilePath := filepath.Join(t.TempDir(), "journal.jsonl")
+	fileJournal, err := journal.NewFileJournal(journalFilePath, false)
+	require.NoError(t, err)
+	checker := replay.NewJournalReplayChecker([]domain.MPFJournalEntry{
+		{Outcome: domain.JournalAuthorizationErrors, AuthorizationError: testAuthorizationFailedError},
+		{Iteration: 1, Outcome: domain.JournalSucceeded},
+	})
+	mpfConfig := domain.MPFConfig{SubscriptionID: replaySubscriptionID, Role: domain.Role{RoleDefinitionID: "recorded"}}
+	mpfService := usecase.NewMPFServiceForMode(t.Context(), replay.NewMemoryResourceGroupManager(), replay.NewMemorySPRoleAssignmentManager(), checker, mpfConfig, usecase.TerraformModeSettings())
+	mpfService.SetPropagationWaiter(propagationwaiter.NewFixedPropagationWaiter(0, 0))
+	mpfService.EnableJournal(fileJournal)
+	recordedResult, err := mpfService.GetMinimumPermissionsRequired()
+	require.NoError(t, err)
+	require.NoError(t, fileJournal.Close())
+
+	rootCmd := NewRootCommand()
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	rootCmd.SetArgs([]string{"replay", journalFilePath, "--mode", usecase.TerraformMode, "--output", "json"})
+	require.NoError(t, rootCmd.Execute())
+
+	var replayedPermissions map[string][]string
+	require.NoError(t, json.Unmarshal(out.Bytes(), &replayedPermissions))
+	assert.ElementsMatch(t, recordedResult.RequiredPermissions[replaySubscriptionID], replayedPermissions[replaySubscriptionID])
+	assert.Contains(t, replayedPermissions[replaySubscriptionID], "Microsoft.Resources/deployments/write")
+	assert.Contains(t, replayedPermissions[replaySubscriptionID], "Microsoft.Storage/storageAccounts/delete")
+}
+
+func TestReplayRejectsInvalidMode(t *testing.T) {
+	defer func(mode string) { flgReplayMode = mode }(flgReplayMode)
+
+	rootCmd := NewRootCommand()
+	rootCmd.SetArgs([]string{"replay", filepath.Join(t.TempDir(), "journal.jsonl"), "--mode", "pulumi"})
+	assert.ErrorContains(t, rootCmd.Execute(), `invalid mode "pulumi"`)
+}
@@ -0,0 +1,91 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/Azure/mpf/pkg/domain"
+	roleassignmentsnapshot "github.com/Azure/mpf/pkg/infrastructure/roleAssignmentSnapshot"
+	sproleassignmentmanager "github.com/Azure/mpf/pkg/infrastructure/spRoleAssignmentManager"
+	"github.com/Azure/mpf/pkg/usecase"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// NewRestoreAssignmentsCommand returns the restoreAssignments command, which restores the role assignments
+// of a service principal saved by an MPF run
+func NewRestoreAssignmentsCommand() *cobra.Command {
+
+	restoreAssignmentsCmd := &cobra.Command{
+		Use:     "restoreAssignments <snapshotFilePath>",
+		Aliases: []string{"restore-assignments"},
+		Short:   "Restore the role assignments of the service principal saved by an interrupted run",
+		Long: `Restore the role assignments of the service principal saved to --roleAssignmentsBackupFile by an MPF run.
+
+The arm, bicep and terraform commands remove all role assignments of the service principal before the run,
+and restore them when the run ends. If the run is killed, or restoring fails, the snapshot file is kept,
+and the role assignments can be restored with this command. Role assignments which still exist are skipped.
+The role assignments are created with the Azure CLI or default Azure credentials. The file is deleted when all are restored.`,
+		Example: `azmpf restoreAssignments ./.azmpf-role-assignments.json`,
+		Args:    cobra.ExactArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			disableRequiredRootFlags(cmd)
+			return nil
+		},
+		RunE: restoreAssignments,
+	}
+
+	return restoreAssignmentsCmd
+}
+
+func restoreAssignments(cmd *cobra.Command, args []string) error {
+	setLogLevel()
+
+	snapshotFilePath, err := getAbsolutePath(args[0])
+	if err != nil {
+		return fmt.Errorf("error getting absolute path for role assignment snapshot file: %w", err)
+	}
+
+	snapshot, err := roleassignmentsnapshot.LoadRoleAssignmentSnapshotFromFile(snapshotFilePath)
+	if err != nil {
+		return err
+	}
+	log.Infof("Restoring %d role assignments of service principal %s, saved at %s", len(snapshot.RoleAssignments), snapshot.SPObjectID, snapshot.CreatedAt)
+
+	mpfConfig := domain.MPFConfig{SubscriptionID: snapshot.SubscriptionID}
+	if err := setupCloud(&mpfConfig); err != nil {
+		return err
+	}
+
+	spRoleAssignmentManager, err := sproleassignmentmanager.NewSPRoleAssignmentManager(mpfConfig.SubscriptionID)
+	if err != nil {
+		return err
+	}
+	if err := usecase.RestoreRoleAssignments(cmd.Context(), spRoleAssignmentManager, snapshot); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Restored %d role assignments of service principal %s\n", len(snapshot.RoleAssignments), snapshot.SPObjectID)
+	return roleassignmentsnapshot.NewFileRoleAssignmentSnapshotStore(snapshotFilePath).DeleteRoleAssignmentSnapshot()
+}
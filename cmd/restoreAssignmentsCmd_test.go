@@ -0,0 +1,85 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Azure/mpf/pkg/domain"
+	"github.com/Azure/mpf/pkg/infrastructure/azureAPI"
+	fakearm "github.com/Azure/mpf/pkg/infrastructure/fakeARM"
+	roleassignmentsnapshot "github.com/Azure/mpf/pkg/infrastructure/roleAssignmentSnapshot"
+	sproleassignmentmanager "github.com/Azure/mpf/pkg/infrastructure/spRoleAssignmentManager"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestoreAssignmentsCommand(t *testing.T) {
+	const subscriptionID = "SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS"
+	server := fakearm.NewServer(fakearm.Config{})
+	defer server.Close()
+	azureAPI.SetDefaultAzureAPIClientsOptions(server.AzureAPIClientsOptions())
+	defer azureAPI.SetDefaultAzureAPIClientsOptions(azureAPI.AzureAPIClientsOptions{})
+
+	role := domain.Role{
+		RoleDefinitionID:         "RRRRRRRR-RRRR-RRRR-RRRR-RRRRRRRRRRRR",
+		RoleDefinitionName:       "shared-deployer",
+		RoleDefinitionResourceID: "/subscriptions/" + subscriptionID + "/providers/Microsoft.Authorization/roleDefinitions/RRRRRRRR-RRRR-RRRR-RRRR-RRRRRRRRRRRR",
+	}
+	spRoleAssignmentManager, err := sproleassignmentmanager.NewSPRoleAssignmentManager(subscriptionID)
+	require.NoError(t, err)
+	err, _ = spRoleAssignmentManager.CreateUpdateCustomRole(t.Context(), subscriptionID, role, []string{"Microsoft.Storage/storageAccounts/write"}, nil)
+	require.NoError(t, err)
+
+	snapshotFilePath := filepath.Join(t.TempDir(), ".azmpf-role-assignments.json")
+	require.NoError(t, roleassignmentsnapshot.NewFileRoleAssignmentSnapshotStore(snapshotFilePath).SaveRoleAssignmentSnapshot(domain.RoleAssignmentSnapshot{
+		Version:        domain.RoleAssignmentSnapshotVersion,
+		SubscriptionID: subscriptionID,
+		SPObjectID:     "OOOOOOOO-OOOO-OOOO-OOOO-OOOOOOOOOOOO",
+		RoleAssignments: []domain.RoleAssignment{{
+			Name:             "11111111-1111-1111-1111-111111111111",
+			Scope:            "/subscriptions/" + subscriptionID,
+			RoleDefinitionID: role.RoleDefinitionResourceID,
+			PrincipalID:      "OOOOOOOO-OOOO-OOOO-OOOO-OOOOOOOOOOOO",
+			PrincipalType:    "ServicePrincipal",
+		}},
+		CreatedAt: time.Now().UTC(),
+	}))
+
+	rootCmd := NewRootCommand()
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	rootCmd.SetArgs([]string{"restore-assignments", snapshotFilePath})
+
+	require.NoError(t, rootCmd.Execute())
+	assert.Contains(t, out.String(), "Restored 1 role assignments")
+	assert.Equal(t, 1, server.RoleAssignmentCount())
+
+	// the snapshot is deleted once restored
+	_, err = os.Stat(snapshotFilePath)
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,84 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	roleassignmentsnapshot "github.com/Azure/mpf/pkg/infrastructure/roleAssignmentSnapshot"
+	"github.com/Azure/mpf/pkg/usecase"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const defaultRoleAssignmentsBackupFilename = ".azmpf-role-assignments.json"
+
+var (
+	flgRoleAssignmentsBackupFile   string
+	flgKeepExistingRoleAssignments bool
+)
+
+func addRoleAssignmentBackupFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&flgRoleAssignmentsBackupFile, "roleAssignmentsBackupFile", "", defaultRoleAssignmentsBackupFilename, "File the existing role assignments of the service principal are saved to before they are removed. They are restored and the file is deleted when the run ends. Set to an empty string to remove them without a backup")
+	cmd.Flags().BoolVarP(&flgKeepExistingRoleAssignments, "keepExistingRoleAssignments", "", false, "Do not remove the existing role assignments of the service principal. The permissions they grant are missing from the result")
+}
+
+// setupRoleAssignmentBackup makes mpfService keep the existing role assignments of the service principal,
+// or back them up to --roleAssignmentsBackupFile. The returned store is nil if there is no backup.
+func setupRoleAssignmentBackup(mpfService *usecase.MPFService, spRoleAssignmentManager usecase.ServicePrincipalRolemAssignmentManager) (*roleassignmentsnapshot.FileRoleAssignmentSnapshotStore, error) {
+	if flgKeepExistingRoleAssignments {
+		mpfService.KeepExistingRoleAssignments()
+		return nil, nil
+	}
+
+	if flgRoleAssignmentsBackupFile == "" {
+		log.Warnln("The existing role assignments of the service principal are removed without a backup")
+		return nil, nil
+	}
+
+	snapshotter, ok := spRoleAssignmentManager.(usecase.RoleAssignmentSnapshotter)
+	if !ok {
+		return nil, fmt.Errorf("the role assignment manager cannot back up role assignments")
+	}
+
+	backupFilePath, err := getAbsolutePath(flgRoleAssignmentsBackupFile)
+	if err != nil {
+		return nil, fmt.Errorf("error getting absolute path for role assignments backup file: %w", err)
+	}
+
+	store := roleassignmentsnapshot.NewFileRoleAssignmentSnapshotStore(backupFilePath)
+	mpfService.EnableRoleAssignmentBackup(snapshotter, store)
+	return store, nil
+}
+
+// finishRoleAssignmentBackup tells how to restore the role assignments if they could not be restored by the run
+func finishRoleAssignmentBackup(store *roleassignmentsnapshot.FileRoleAssignmentSnapshotStore) {
+	if store == nil {
+		return
+	}
+
+	if _, err := os.Stat(store.FilePath()); err == nil {
+		fmt.Fprintf(os.Stderr, "The role assignments of the service principal were saved to %s. Restore them with azmpf restoreAssignments %s\n", store.FilePath(), store.FilePath())
+	}
+}
@@ -28,9 +28,13 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/Azure/mpf/pkg/domain"
 	"github.com/Azure/mpf/pkg/infrastructure/mpfSharedUtils"
+	propagationwaiter "github.com/Azure/mpf/pkg/infrastructure/propagationWaiter"
+	"github.com/Azure/mpf/pkg/presentation"
+	"github.com/Azure/mpf/pkg/usecase"
 	"github.com/google/uuid"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -51,9 +55,25 @@ var (
 	flgSPClientSecret     string
 	flgShowDetailedOutput bool
 	flgJSONOutput         bool
+	flgOutput             string
+	flgOutputFile         string
 	flgVerbose            bool
 	flgDebug              bool
 	flgInitialPermissions string
+
+	flgPropagationWaitStrategy string
+	flgPropagationInitialWait  time.Duration
+	flgPropagationWait         time.Duration
+	flgPropagationMaxWait      time.Duration
+
+	flgRoleDefinitionName             string
+	flgRoleDefinitionDescription      string
+	flgRoleDefinitionAssignableScopes []string
+
+	flgCompactWildcards bool
+
+	flgOperationsCatalogFile string
+	flgSkipActionValidation  bool
 	// RootCmd            *cobra.Command
 )
 
@@ -66,11 +86,18 @@ func NewRootCommand() *cobra.Command {
 
 		This CLI allows you to find the minimum permissions required for Azure deployments including ARM and Terraform based deployments.
 		A Service Principal is required to run this CLI. All permissions associated with the Service principal are initially wiped by this command:`,
+		// errors are returned by the commands at run time, after the flags were validated
+		SilenceUsage: true,
 		Example: `azmpf arm --subscriptionID <subscriptionID> --tenantID <tenantID> --spClientID <spClientID> --spObjectID <spObjectID> --spClientSecret <spClientSecret>
 		azmpf terraform --subscriptionID <subscriptionID> --tenantID <tenantID> --spClientID <spClientID> --spObjectID <spObjectID> --spClientSecret <spClientSecret> --tfPath <executablePath> --workingDir <workingDir> --varFilePath <varFilePath>
 		`,
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-			return initializeConfig(cmd)
+			if err := initializeConfig(cmd); err != nil {
+				return err
+			}
+			// fail before a run rather than when displaying its result
+			_, err := presentation.GetFormatter(getOutputFormat())
+			return err
 		},
 		Run: func(cmd *cobra.Command, args []string) {
 
@@ -84,11 +111,29 @@ func NewRootCommand() *cobra.Command {
 	rootCmd.PersistentFlags().StringVarP(&flgSPObjectID, "spObjectID", "", "", "Service Principal Object ID")
 	rootCmd.PersistentFlags().StringVarP(&flgSPClientSecret, "spClientSecret", "", "", "Service Principal Client Secret")
 	rootCmd.PersistentFlags().BoolVarP(&flgShowDetailedOutput, "showDetailedOutput", "", false, "Show detailed output")
-	rootCmd.PersistentFlags().BoolVarP(&flgJSONOutput, "jsonOutput", "", false, "Output in JSON format")
+	rootCmd.PersistentFlags().BoolVarP(&flgJSONOutput, "jsonOutput", "", false, "Output in JSON format. Deprecated, use --output json")
+	rootCmd.PersistentFlags().StringVarP(&flgOutput, "output", "o", presentation.TextOutput, fmt.Sprintf("Output format, one of: %s", strings.Join(presentation.FormatterNames(), ", ")))
+	rootCmd.PersistentFlags().StringVarP(&flgOutputFile, "outputFile", "", "", "File to write the output to instead of stdout")
 	rootCmd.PersistentFlags().BoolVarP(&flgVerbose, "verbose", "v", false, "verbose output")
 	rootCmd.PersistentFlags().BoolVarP(&flgDebug, "debug", "d", false, "debug output")
 	rootCmd.PersistentFlags().StringVarP(&flgInitialPermissions, "initialPermissions", "", "", "Initial permissions to add to the custom role before starting MPF analysis. Can be a comma-separated list (e.g., 'perm1,perm2') or @path/to/file.json to load from a JSON file with format: {\"RequiredPermissions\":{\"\":[\"perm1\",\"perm2\"]}}.")
 
+	rootCmd.PersistentFlags().StringVarP(&flgPropagationWaitStrategy, "propagationWaitStrategy", "", propagationwaiter.FixedStrategy, fmt.Sprintf("Strategy used to wait for RBAC changes to propagate, one of: %s", strings.Join(propagationwaiter.Strategies, ", ")))
+	rootCmd.PersistentFlags().DurationVarP(&flgPropagationInitialWait, "propagationInitialWait", "", usecase.DefaultRoleAssignmentsRemovedWait, "Wait after removing the existing role assignments of the service principal (fixed and exponential strategies)")
+	rootCmd.PersistentFlags().DurationVarP(&flgPropagationWait, "propagationWait", "", usecase.DefaultRoleChangeWait, "Wait after assigning or updating the custom role. Base wait of the exponential strategy and initial poll interval of the verify strategy")
+	rootCmd.PersistentFlags().DurationVarP(&flgPropagationMaxWait, "propagationMaxWait", "", 2*time.Minute, "Maximum single wait of the exponential strategy and maximum polling time of the verify strategy")
+
+	rootCmd.PersistentFlags().StringVarP(&flgRoleDefinitionName, "roleDefinitionName", "", presentation.DefaultRoleDefinitionName, "Name of the custom role definition output with the roleDefinition output formats")
+	rootCmd.PersistentFlags().StringVarP(&flgRoleDefinitionDescription, "roleDefinitionDescription", "", presentation.DefaultRoleDefinitionDescription, "Description of the custom role definition output with the roleDefinition output formats")
+	rootCmd.PersistentFlags().StringSliceVarP(&flgRoleDefinitionAssignableScopes, "roleDefinitionAssignableScopes", "", nil, "Comma-separated assignable scopes of the custom role definition output with the roleDefinition output formats. Default is the subscription")
+
+	rootCmd.PersistentFlags().BoolVarP(&flgCompactWildcards, "compactWildcards", "", false, "Fold the required actions on a resource type into a wildcard, e.g. Microsoft.Storage/storageAccounts/*, when every operation the operations catalog lists for the resource type and its child types is required. The detailed output keeps the actions of each scope")
+
+	rootCmd.PersistentFlags().StringVarP(&flgOperationsCatalogFile, "operationsCatalogFile", "", "", "Resource provider operations written with azmpf operations refresh, used to validate actions. Default is the bundled snapshot")
+	rootCmd.PersistentFlags().BoolVarP(&flgSkipActionValidation, "skipActionValidation", "", false, "Do not validate the initial permissions and the permissions added for each write permission with the operations catalog")
+
+	addCloudFlags(rootCmd)
+
 	err := rootCmd.MarkPersistentFlagRequired("subscriptionID")
 	if err != nil {
 		log.Errorf("Error marking flag required for subscription ID: %v\n", err)
@@ -115,11 +160,23 @@ func NewRootCommand() *cobra.Command {
 	}
 
 	rootCmd.MarkFlagsMutuallyExclusive("showDetailedOutput", "jsonOutput")
+	rootCmd.MarkFlagsMutuallyExclusive("output", "jsonOutput")
+	err = rootCmd.PersistentFlags().MarkDeprecated("jsonOutput", "use --output json instead")
+	if err != nil {
+		log.Errorf("Error marking flag deprecated for JSON output: %v\n", err)
+	}
 
 	// Add subcommands
 	rootCmd.AddCommand(NewARMCommand())
 	rootCmd.AddCommand(NewBicepCommand())
 	rootCmd.AddCommand(NewTerraformCommand())
+	rootCmd.AddCommand(NewParseCommand())
+	rootCmd.AddCommand(NewReplayCommand())
+	rootCmd.AddCommand(NewBuiltInRolesCommand())
+	rootCmd.AddCommand(NewDiffCommand())
+	rootCmd.AddCommand(NewOperationsCommand())
+	rootCmd.AddCommand(NewRestoreAssignmentsCommand())
+	rootCmd.AddCommand(NewCleanupCommand())
 
 	return rootCmd
 }
@@ -169,6 +226,15 @@ func bindFlags(cmd *cobra.Command, v *viper.Viper) {
 	})
 }
 
+// disableRequiredRootFlags removes the required annotation from the persistent root flags
+// inherited by cmd. It is used by commands which run offline and need no service principal.
+// Cobra validates required flags after PreRunE, so it must be called from PreRunE at the latest.
+func disableRequiredRootFlags(cmd *cobra.Command) {
+	cmd.InheritedFlags().VisitAll(func(f *pflag.Flag) {
+		delete(f.Annotations, cobra.BashCompOneRequiredFlag)
+	})
+}
+
 func setLogLevel() {
 	if flgVerbose {
 		log.SetLevel(log.InfoLevel)
@@ -178,12 +244,14 @@ func setLogLevel() {
 	}
 }
 
-func getRootMPFConfig() domain.MPFConfig {
+// getRootMPFConfig returns the configuration of a run with a new custom role, described with the run metadata
+func getRootMPFConfig(runMetadata domain.MPFRunMetadata) domain.MPFConfig {
 	mpfRole := domain.Role{}
 
 	roleDefUUID, _ := uuid.NewRandom()
 	mpfRole.RoleDefinitionID = roleDefUUID.String()
-	mpfRole.RoleDefinitionName = fmt.Sprintf("tmp-rol-%s", mpfSharedUtils.GenerateRandomString(7))
+	mpfRole.RoleDefinitionName = fmt.Sprintf("%s-%s", domain.MPFRoleNamePrefix, mpfSharedUtils.GenerateRandomString(7))
+	mpfRole.RoleDefinitionDescription = runMetadata.RoleDescription()
 	mpfRole.RoleDefinitionResourceID = fmt.Sprintf("/subscriptions/%s/providers/Microsoft.Authorization/roleDefinitions/%s", flgSubscriptionID, mpfRole.RoleDefinitionID)
 	log.Infoln("roleDefinitionResourceID:", mpfRole.RoleDefinitionResourceID)
 
@@ -199,6 +267,31 @@ func getRootMPFConfig() domain.MPFConfig {
 	}
 }
 
+// getPropagationWaiter returns the propagation waiter configured by the propagation wait flags.
+// The RBAC state reader is needed by the verify strategy.
+func getPropagationWaiter(spRoleAssignmentManager usecase.ServicePrincipalRolemAssignmentManager) (usecase.PropagationWaiter, error) {
+	if flgPropagationInitialWait < 0 || flgPropagationWait < 0 || flgPropagationMaxWait < 0 {
+		return nil, fmt.Errorf("propagation waits cannot be negative")
+	}
+	if flgPropagationMaxWait < flgPropagationWait {
+		return nil, fmt.Errorf("propagationMaxWait (%s) cannot be less than propagationWait (%s)", flgPropagationMaxWait, flgPropagationWait)
+	}
+
+	switch strings.ToLower(flgPropagationWaitStrategy) {
+	case propagationwaiter.FixedStrategy:
+		return propagationwaiter.NewFixedPropagationWaiter(flgPropagationInitialWait, flgPropagationWait), nil
+	case propagationwaiter.ExponentialStrategy:
+		return propagationwaiter.NewExponentialPropagationWaiter(flgPropagationInitialWait, flgPropagationWait, flgPropagationMaxWait), nil
+	case propagationwaiter.VerifyStrategy:
+		reader, ok := spRoleAssignmentManager.(propagationwaiter.RBACStateReader)
+		if !ok {
+			return nil, fmt.Errorf("propagation wait strategy %q is not supported by the role assignment manager", flgPropagationWaitStrategy)
+		}
+		return propagationwaiter.NewVerifyingPropagationWaiter(reader, flgPropagationWait, flgPropagationMaxWait), nil
+	}
+	return nil, fmt.Errorf("unknown propagation wait strategy %q, supported strategies are: %s", flgPropagationWaitStrategy, strings.Join(propagationwaiter.Strategies, ", "))
+}
+
 func getAbsolutePath(path string) (string, error) {
 	absPath := path
 	if !filepath.IsAbs(path) {
@@ -216,6 +309,7 @@ func getAbsolutePath(path string) (string, error) {
 // or from a JSON file (if the value starts with @).
 // The JSON file should have the same format as .permissionsFromFailedRun.json:
 // {"RequiredPermissions":{"":["perm1","perm2"]}}
+// Data actions in the file's RequiredDataActions are returned along with the permissions.
 func parseInitialPermissions(value string) ([]string, error) {
 	if value == "" {
 		return nil, nil
@@ -241,7 +335,7 @@ func parseInitialPermissions(value string) ([]string, error) {
 			return nil, fmt.Errorf("error parsing permissions file %s: %w", absPath, err)
 		}
 
-		permissions := result.RequiredPermissions[""]
+		permissions := append(result.RequiredPermissions[""], result.RequiredDataActions[""]...)
 		if len(permissions) == 0 {
 			log.Warnf("No permissions found in file %s under the empty string key", absPath)
 		}
@@ -256,17 +350,22 @@ func parseInitialPermissions(value string) ([]string, error) {
 	return permissions, nil
 }
 
-// appendUserInitialPermissions parses the --initialPermissions flag and appends
-// the permissions to both slices. This is a helper to reduce code duplication
-// across arm, bicep, and terraform commands.
-func appendUserInitialPermissions(initialPermissionsToAdd, permissionsToAddToResult []string) ([]string, []string) {
+// appendUserInitialPermissions parses the --initialPermissions flag, validates the permissions
+// with the operations catalog if not nil, and appends them to both slices. This is a helper
+// to reduce code duplication across arm, bicep, and terraform commands.
+func appendUserInitialPermissions(operationsCatalog *domain.OperationsCatalog, initialPermissionsToAdd, permissionsToAddToResult []string) ([]string, []string, error) {
 	if flgInitialPermissions == "" {
-		return initialPermissionsToAdd, permissionsToAddToResult
+		return initialPermissionsToAdd, permissionsToAddToResult, nil
 	}
 
 	userPermissions, err := parseInitialPermissions(flgInitialPermissions)
 	if err != nil {
-		log.Fatalf("Error parsing initial permissions: %v\n", err)
+		return nil, nil, fmt.Errorf("error parsing initial permissions: %w", err)
+	}
+
+	userPermissions, err = validatePermissions(operationsCatalog, userPermissions)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	if len(userPermissions) > 0 {
@@ -275,5 +374,5 @@ func appendUserInitialPermissions(initialPermissionsToAdd, permissionsToAddToRes
 		permissionsToAddToResult = append(permissionsToAddToResult, userPermissions...)
 	}
 
-	return initialPermissionsToAdd, permissionsToAddToResult
+	return initialPermissionsToAdd, permissionsToAddToResult, nil
 }
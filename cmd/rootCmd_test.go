@@ -87,6 +87,28 @@ func TestParseInitialPermissions(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name:      "file reference with data actions",
+			input:     "@testdataactions.json",
+			setupFile: true,
+			fileContent: `{
+				"RequiredPermissions": {
+					"": [
+						"Microsoft.Storage/storageAccounts/read"
+					]
+				},
+				"RequiredDataActions": {
+					"": [
+						"Microsoft.Storage/storageAccounts/blobServices/containers/blobs/read"
+					]
+				}
+			}`,
+			want: []string{
+				"Microsoft.Storage/storageAccounts/read",
+				"Microsoft.Storage/storageAccounts/blobServices/containers/blobs/read",
+			},
+			wantErr: false,
+		},
 		{
 			name:    "file reference to non-existent file",
 			input:   "@nonexistent.json",
@@ -0,0 +1,90 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Azure/mpf/pkg/domain"
+	runlock "github.com/Azure/mpf/pkg/infrastructure/runLock"
+	"github.com/Azure/mpf/pkg/usecase"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const (
+	runLockResourceGroup = "resourceGroup"
+	runLockFile          = "file"
+	runLockNone          = "none"
+)
+
+var (
+	flgRunLock         string
+	flgRunLockTTL      time.Duration
+	flgRunLockLocation string
+)
+
+func addRunLockFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&flgRunLock, "runLock", "", runLockResourceGroup, "How the service principal is locked, so that concurrent runs do not remove each other's role assignments: resourceGroup (tags of the azmpf-lock-<SP object ID> resource group, which is created and kept, for runs on any machine), file (lock file in the temporary directory, only for tests and runs on a single machine) or none")
+	cmd.Flags().DurationVarP(&flgRunLockTTL, "runLockTTL", "", time.Hour, "Time after which the lock of a run which stopped renewing it, for example because it crashed, expires")
+	cmd.Flags().StringVarP(&flgRunLockLocation, "runLockLocation", "", "eastus2", "Location of the lock resource group, if it has to be created")
+}
+
+// setupRunLock makes mpfService lock the service principal for the run with the --runLock locker
+func setupRunLock(mpfService *usecase.MPFService, mpfConfig domain.MPFConfig) error {
+	var runLocker usecase.RunLocker
+	switch flgRunLock {
+	case runLockResourceGroup:
+		resourceGroupRunLocker, err := runlock.NewResourceGroupRunLocker(mpfConfig.SubscriptionID, flgRunLockLocation)
+		if err != nil {
+			return err
+		}
+		runLocker = resourceGroupRunLocker
+	case runLockFile:
+		runLocker = runlock.NewFileRunLocker(os.TempDir())
+	case runLockNone:
+		log.Warnln("The service principal is not locked, concurrent runs on the same service principal remove each other's role assignments")
+		return nil
+	default:
+		return fmt.Errorf("invalid run lock %q, expected %s, %s or %s", flgRunLock, runLockResourceGroup, runLockFile, runLockNone)
+	}
+
+	if flgRunLockTTL <= 0 {
+		return fmt.Errorf("invalid run lock TTL %s, expected a positive duration", flgRunLockTTL)
+	}
+
+	mpfService.EnableRunLock(runLocker, getRunLockHolder(mpfConfig), flgRunLockTTL)
+	return nil
+}
+
+// getRunLockHolder identifies the run by its user, machine and custom role.
+// A run resumed from a checkpoint reuses the custom role, so that it takes over the lock of the interrupted run.
+func getRunLockHolder(mpfConfig domain.MPFConfig) string {
+	hostName, err := os.Hostname()
+	if err != nil {
+		hostName = "unknown"
+	}
+	return fmt.Sprintf("%s@%s role %s", getCurrentUserName(), hostName, mpfConfig.Role.RoleDefinitionName)
+}
@@ -0,0 +1,69 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Azure/mpf/pkg/domain"
+	"github.com/Azure/mpf/pkg/usecase"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetupRunLock(t *testing.T) {
+	defer func(runLock string, ttl time.Duration) {
+		flgRunLock, flgRunLockTTL = runLock, ttl
+	}(flgRunLock, flgRunLockTTL)
+	mpfConfig := domain.MPFConfig{SubscriptionID: "sub", Role: domain.Role{RoleDefinitionName: "tmp-rol-abc"}}
+	mpfService := usecase.NewMPFService(t.Context(), nil, nil, nil, mpfConfig, nil, nil, false, false, false)
+
+	flgRunLockTTL = time.Hour
+	for _, runLock := range []string{runLockFile, runLockNone} {
+		flgRunLock = runLock
+		assert.NoError(t, setupRunLock(mpfService, mpfConfig), runLock)
+	}
+
+	flgRunLock = "blob"
+	assert.ErrorContains(t, setupRunLock(mpfService, mpfConfig), `invalid run lock "blob"`)
+
+	flgRunLock = runLockFile
+	flgRunLockTTL = 0
+	assert.ErrorContains(t, setupRunLock(mpfService, mpfConfig), "invalid run lock TTL")
+}
+
+func TestGetRunLockHolder(t *testing.T) {
+	holder := getRunLockHolder(domain.MPFConfig{Role: domain.Role{RoleDefinitionName: "tmp-rol-abc"}})
+
+	assert.Contains(t, holder, "@")
+	assert.Contains(t, holder, "role tmp-rol-abc")
+}
+
+func TestRunLockDefaultsToResourceGroup(t *testing.T) {
+	cmd := &cobra.Command{}
+	addRunLockFlags(cmd)
+
+	// the file lock does not prevent concurrent runs on different machines, so it has to be chosen explicitly
+	assert.Equal(t, runLockResourceGroup, cmd.Flags().Lookup("runLock").DefValue)
+}
@@ -23,10 +23,10 @@
 package main
 
 import (
-	"context"
 	"fmt"
 	"os"
 
+	"github.com/Azure/mpf/pkg/domain"
 	"github.com/Azure/mpf/pkg/infrastructure/authorizationCheckers/terraform"
 	resourceGroupManager "github.com/Azure/mpf/pkg/infrastructure/resourceGroupManager"
 	sproleassignmentmanager "github.com/Azure/mpf/pkg/infrastructure/spRoleAssignmentManager"
@@ -59,7 +59,7 @@ func NewTerraformCommand() *cobra.Command {
 	Cobra is a CLI library for Go that empowers applications.
 	This application is a tool to generate the needed files
 	to quickly create a Cobra application.`,
-		Run: getMPFTerraform,
+		RunE: getMPFTerraform,
 	}
 
 	terraformCmd.Flags().StringVarP(&flgTFPath, "tfPath", "", "", "Path to Terraform Executable")
@@ -79,11 +79,18 @@ func NewTerraformCommand() *cobra.Command {
 
 	terraformCmd.Flags().StringVarP(&flgTargetModule, "targetModule", "", "", "The Terraform module to Target Module to run MPF on")
 
+	addCheckpointFlags(terraformCmd)
+	addJournalFlags(terraformCmd)
+	addRoleAssignmentBackupFlags(terraformCmd)
+	addRunLockFlags(terraformCmd)
+
 	return terraformCmd
 }
 
-func getMPFTerraform(cmd *cobra.Command, args []string) {
+func getMPFTerraform(cmd *cobra.Command, args []string) error {
 	setLogLevel()
+	runMetadata := newRunMetadata("terraform", flgWorkingDir, flgVarFilePath)
+	runMetadata.TargetModule = flgTargetModule
 
 	log.Info("Executin MPF for Terraform")
 	log.Infof("TFPath: %s\n", flgTFPath)
@@ -93,7 +100,7 @@ func getMPFTerraform(cmd *cobra.Command, args []string) {
 
 	// validate if working directory exists
 	if _, err := os.Stat(flgWorkingDir); os.IsNotExist(err) {
-		log.Fatalf("Working Directory does not exist: %s\n", flgWorkingDir)
+		return fmt.Errorf("working directory does not exist: %s", flgWorkingDir)
 	}
 
 	flgWorkingDir, err := getAbsolutePath(flgWorkingDir)
@@ -103,7 +110,7 @@ func getMPFTerraform(cmd *cobra.Command, args []string) {
 
 	// validate if tfPath exists
 	if _, err := os.Stat(flgTFPath); os.IsNotExist(err) {
-		log.Fatalf("Terraform Executable does not exist: %s\n", flgTFPath)
+		return fmt.Errorf("terraform executable does not exist: %s", flgTFPath)
 	}
 
 	flgTFPath, err := getAbsolutePath(flgTFPath)
@@ -114,7 +121,7 @@ func getMPFTerraform(cmd *cobra.Command, args []string) {
 	if flgVarFilePath != "" {
 
 		if _, err := os.Stat(flgVarFilePath); os.IsNotExist(err) {
-			log.Fatalf("Terraform Variable File does not exist: %s\n", flgVarFilePath)
+			return fmt.Errorf("terraform variable file does not exist: %s", flgVarFilePath)
 		}
 
 		flgVarFilePath, err = getAbsolutePath(flgVarFilePath)
@@ -124,23 +131,46 @@ func getMPFTerraform(cmd *cobra.Command, args []string) {
 
 	}
 
-	ctx := context.Background()
+	ctx := cmd.Context()
 
-	mpfConfig := getRootMPFConfig()
+	mpfConfig := getRootMPFConfig(runMetadata)
+	if err := setupCloud(&mpfConfig); err != nil {
+		return err
+	}
+
+	resumeCheckpoint, err := loadResumeCheckpoint("terraform", &mpfConfig)
+	if err != nil {
+		return err
+	}
 
 	var rgManager usecase.ResourceGroupManager
 	var spRoleAssignmentManager usecase.ServicePrincipalRolemAssignmentManager
-	rgManager = resourceGroupManager.NewResourceGroupManager(flgSubscriptionID)
-	spRoleAssignmentManager = sproleassignmentmanager.NewSPRoleAssignmentManager(flgSubscriptionID)
+	defaultRGManager, err := resourceGroupManager.NewResourceGroupManager(flgSubscriptionID)
+	if err != nil {
+		return err
+	}
+	defaultRGManager.SetTags(runMetadata.Tags())
+	rgManager = defaultRGManager
+	spRoleAssignmentManager, err = sproleassignmentmanager.NewSPRoleAssignmentManager(flgSubscriptionID)
+	if err != nil {
+		return err
+	}
 
 	var deploymentAuthorizationCheckerCleaner usecase.DeploymentAuthorizationCheckerCleaner
 	var mpfService *usecase.MPFService
 
-	initialPermissionsToAdd := []string{"Microsoft.Resources/deployments/read", "Microsoft.Resources/deployments/write"}
-	permissionsToAddToResult := []string{"Microsoft.Resources/deployments/read", "Microsoft.Resources/deployments/write"}
+	modeSettings := usecase.TerraformModeSettings()
+
+	operationsCatalog, err := getOperationsCatalog()
+	if err != nil {
+		return err
+	}
 
 	// Add initial permissions from flag if provided (supports comma-separated string or @file.json)
-	initialPermissionsToAdd, permissionsToAddToResult = appendUserInitialPermissions(initialPermissionsToAdd, permissionsToAddToResult)
+	modeSettings.InitialPermissions, modeSettings.PermissionsToAddToResult, err = appendUserInitialPermissions(operationsCatalog, modeSettings.InitialPermissions, modeSettings.PermissionsToAddToResult)
+	if err != nil {
+		return err
+	}
 
 	// Check if permissions file from previous failed run exists
 	if terraform.DoesTFFileExist(flgWorkingDir, FoundPermissionsFromFailedRunFilename) {
@@ -148,34 +178,63 @@ func getMPFTerraform(cmd *cobra.Command, args []string) {
 		if err != nil {
 			log.Warnf("Error loading permissions from previous failed run: %v\n, continuing....", err)
 		}
-		prevRunFoundPermissions := prevResult.RequiredPermissions[""]
+		prevRunFoundPermissions := append(prevResult.RequiredPermissions[""], prevResult.RequiredDataActions[""]...)
 		if len(prevRunFoundPermissions) > 0 {
 			log.Warnf("Found permissions from previous failed run: %v\n Adding the Permissions....", prevRunFoundPermissions)
-			initialPermissionsToAdd = append(initialPermissionsToAdd, prevRunFoundPermissions...)
-			permissionsToAddToResult = append(permissionsToAddToResult, prevRunFoundPermissions...)
+			modeSettings.InitialPermissions = append(modeSettings.InitialPermissions, prevRunFoundPermissions...)
+			modeSettings.PermissionsToAddToResult = append(modeSettings.PermissionsToAddToResult, prevRunFoundPermissions...)
 		}
 	}
 
 	deploymentAuthorizationCheckerCleaner = terraform.NewTerraformAuthorizationChecker(flgWorkingDir, flgTFPath, flgVarFilePath, flgImportExistingResourcesToState, flgTargetModule)
-	mpfService = usecase.NewMPFService(ctx, rgManager, spRoleAssignmentManager, deploymentAuthorizationCheckerCleaner, mpfConfig, initialPermissionsToAdd, permissionsToAddToResult, false, true, false)
+	mpfService = usecase.NewMPFServiceForMode(ctx, rgManager, spRoleAssignmentManager, deploymentAuthorizationCheckerCleaner, mpfConfig, modeSettings)
+	mpfService.SetOperationsCatalog(operationsCatalog)
+
+	propagationWaiter, err := getPropagationWaiter(spRoleAssignmentManager)
+	if err != nil {
+		return err
+	}
+	mpfService.SetPropagationWaiter(propagationWaiter)
+
+	checkpointStore, err := setupCheckpoints(mpfService, domain.MPFCheckpoint{Mode: "terraform"}, resumeCheckpoint)
+	if err != nil {
+		return err
+	}
+
+	if err := setupRunLock(mpfService, mpfConfig); err != nil {
+		return err
+	}
+
+	roleAssignmentSnapshotStore, err := setupRoleAssignmentBackup(mpfService, spRoleAssignmentManager)
+	if err != nil {
+		return err
+	}
+	defer finishRoleAssignmentBackup(roleAssignmentSnapshotStore)
+
+	iterationJournal, err := setupJournal(mpfService, resumeCheckpoint != nil)
+	if err != nil {
+		return err
+	}
+	defer closeJournal(iterationJournal)
 
-	displayOptions := getDislayOptions(flgShowDetailedOutput, flgJSONOutput, mpfConfig.SubscriptionID)
+	displayOptions := getDislayOptions(mpfConfig.SubscriptionID, runMetadata)
 
 	mpfResult, err := mpfService.GetMinimumPermissionsRequired()
+	finishCheckpoints(checkpointStore, err)
 	if err != nil {
 		if len(mpfResult.RequiredPermissions) > 0 {
 			fmt.Println("Error occurred while getting minimum permissions required. However, some permissions were identified prior to the error.")
 			_ = terraform.SaveMPFResultsToFile(flgWorkingDir, FoundPermissionsFromFailedRunFilename, mpfResult)
 
-			displayResult(mpfResult, displayOptions)
+			_ = displayResult(cmd.OutOrStdout(), mpfResult, displayOptions)
 		}
-		log.Fatal(err)
+		return err
 	}
 
 	if terraform.DoesTFFileExist(flgWorkingDir, FoundPermissionsFromFailedRunFilename) {
 		_ = terraform.DeleteTFFile(flgWorkingDir, FoundPermissionsFromFailedRunFilename)
 	}
 
-	displayResult(mpfResult, displayOptions)
+	return displayResult(cmd.OutOrStdout(), mpfResult, displayOptions)
 
 }
@@ -34,6 +34,7 @@ import (
 	sproleassignmentmanager "github.com/Azure/mpf/pkg/infrastructure/spRoleAssignmentManager"
 	"github.com/Azure/mpf/pkg/usecase"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // func TestARMTemplatWhatIfInvalidParams(t *testing.T) {
@@ -145,13 +146,16 @@ func TestARMTemplatDeploymentInvalidParams(t *testing.T) {
 
 	var rgManager usecase.ResourceGroupManager
 	var spRoleAssignmentManager usecase.ServicePrincipalRolemAssignmentManager
-	rgManager = resourceGroupManager.NewResourceGroupManager(mpfArgs.SubscriptionID)
-	spRoleAssignmentManager = sproleassignmentmanager.NewSPRoleAssignmentManager(mpfArgs.SubscriptionID)
+	rgManager, err = resourceGroupManager.NewResourceGroupManager(mpfArgs.SubscriptionID)
+	require.NoError(t, err)
+	spRoleAssignmentManager, err = sproleassignmentmanager.NewSPRoleAssignmentManager(mpfArgs.SubscriptionID)
+	require.NoError(t, err)
 
 	var deploymentAuthorizationCheckerCleaner usecase.DeploymentAuthorizationCheckerCleaner
 	var mpfService *usecase.MPFService
 
-	deploymentAuthorizationCheckerCleaner = ARMTemplateDeployment.NewARMTemplateDeploymentAuthorizationChecker(mpfArgs.SubscriptionID, *armConfig)
+	deploymentAuthorizationCheckerCleaner, err = ARMTemplateDeployment.NewARMTemplateDeploymentAuthorizationChecker(mpfArgs.SubscriptionID, *armConfig)
+	require.NoError(t, err)
 	initialPermissionsToAdd := []string{"Microsoft.Resources/deployments/*", "Microsoft.Resources/subscriptions/operationresults/read"}
 	permissionsToAddToResult := []string{"Microsoft.Resources/deployments/read", "Microsoft.Resources/deployments/write"}
 	mpfService = usecase.NewMPFService(ctx, rgManager, spRoleAssignmentManager, deploymentAuthorizationCheckerCleaner, mpfConfig, initialPermissionsToAdd, permissionsToAddToResult, true, false, true)
@@ -186,13 +190,16 @@ func TestARMTemplatDeploymentInvalidTemplate(t *testing.T) {
 
 	var rgManager usecase.ResourceGroupManager
 	var spRoleAssignmentManager usecase.ServicePrincipalRolemAssignmentManager
-	rgManager = resourceGroupManager.NewResourceGroupManager(mpfArgs.SubscriptionID)
-	spRoleAssignmentManager = sproleassignmentmanager.NewSPRoleAssignmentManager(mpfArgs.SubscriptionID)
+	rgManager, err = resourceGroupManager.NewResourceGroupManager(mpfArgs.SubscriptionID)
+	require.NoError(t, err)
+	spRoleAssignmentManager, err = sproleassignmentmanager.NewSPRoleAssignmentManager(mpfArgs.SubscriptionID)
+	require.NoError(t, err)
 
 	var deploymentAuthorizationCheckerCleaner usecase.DeploymentAuthorizationCheckerCleaner
 	var mpfService *usecase.MPFService
 
-	deploymentAuthorizationCheckerCleaner = ARMTemplateDeployment.NewARMTemplateDeploymentAuthorizationChecker(mpfArgs.SubscriptionID, *armConfig)
+	deploymentAuthorizationCheckerCleaner, err = ARMTemplateDeployment.NewARMTemplateDeploymentAuthorizationChecker(mpfArgs.SubscriptionID, *armConfig)
+	require.NoError(t, err)
 	initialPermissionsToAdd := []string{"Microsoft.Resources/deployments/*", "Microsoft.Resources/subscriptions/operationresults/read"}
 	permissionsToAddToResult := []string{"Microsoft.Resources/deployments/read", "Microsoft.Resources/deployments/write"}
 	mpfService = usecase.NewMPFService(ctx, rgManager, spRoleAssignmentManager, deploymentAuthorizationCheckerCleaner, mpfConfig, initialPermissionsToAdd, permissionsToAddToResult, true, false, true)
@@ -227,13 +234,16 @@ func TestARMTemplatDeploymentBlankTemplateAndParams(t *testing.T) {
 
 	var rgManager usecase.ResourceGroupManager
 	var spRoleAssignmentManager usecase.ServicePrincipalRolemAssignmentManager
-	rgManager = resourceGroupManager.NewResourceGroupManager(mpfArgs.SubscriptionID)
-	spRoleAssignmentManager = sproleassignmentmanager.NewSPRoleAssignmentManager(mpfArgs.SubscriptionID)
+	rgManager, err = resourceGroupManager.NewResourceGroupManager(mpfArgs.SubscriptionID)
+	require.NoError(t, err)
+	spRoleAssignmentManager, err = sproleassignmentmanager.NewSPRoleAssignmentManager(mpfArgs.SubscriptionID)
+	require.NoError(t, err)
 
 	var deploymentAuthorizationCheckerCleaner usecase.DeploymentAuthorizationCheckerCleaner
 	var mpfService *usecase.MPFService
 
-	deploymentAuthorizationCheckerCleaner = ARMTemplateDeployment.NewARMTemplateDeploymentAuthorizationChecker(mpfArgs.SubscriptionID, *armConfig)
+	deploymentAuthorizationCheckerCleaner, err = ARMTemplateDeployment.NewARMTemplateDeploymentAuthorizationChecker(mpfArgs.SubscriptionID, *armConfig)
+	require.NoError(t, err)
 	initialPermissionsToAdd := []string{"Microsoft.Resources/deployments/*", "Microsoft.Resources/subscriptions/operationresults/read"}
 	permissionsToAddToResult := []string{"Microsoft.Resources/deployments/read", "Microsoft.Resources/deployments/write"}
 	mpfService = usecase.NewMPFService(ctx, rgManager, spRoleAssignmentManager, deploymentAuthorizationCheckerCleaner, mpfConfig, initialPermissionsToAdd, permissionsToAddToResult, true, false, true)
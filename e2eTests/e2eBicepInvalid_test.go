@@ -40,6 +40,7 @@ import (
 	"github.com/Azure/mpf/pkg/usecase"
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // func TestBicepInvalidParams(t *testing.T) {
@@ -178,13 +179,16 @@ func TestBicepInvalidParamsFullDeployment(t *testing.T) {
 
 	var rgManager usecase.ResourceGroupManager
 	var spRoleAssignmentManager usecase.ServicePrincipalRolemAssignmentManager
-	rgManager = rgm.NewResourceGroupManager(mpfArgs.SubscriptionID)
-	spRoleAssignmentManager = spram.NewSPRoleAssignmentManager(mpfArgs.SubscriptionID)
+	rgManager, err = rgm.NewResourceGroupManager(mpfArgs.SubscriptionID)
+	require.NoError(t, err)
+	spRoleAssignmentManager, err = spram.NewSPRoleAssignmentManager(mpfArgs.SubscriptionID)
+	require.NoError(t, err)
 
 	var deploymentAuthorizationCheckerCleaner usecase.DeploymentAuthorizationCheckerCleaner
 	var mpfService *usecase.MPFService
 
-	deploymentAuthorizationCheckerCleaner = ARMTemplateDeployment.NewARMTemplateDeploymentAuthorizationChecker(mpfArgs.SubscriptionID, *armConfig)
+	deploymentAuthorizationCheckerCleaner, err = ARMTemplateDeployment.NewARMTemplateDeploymentAuthorizationChecker(mpfArgs.SubscriptionID, *armConfig)
+	require.NoError(t, err)
 	initialPermissionsToAdd := []string{"Microsoft.Resources/deployments/*", "Microsoft.Resources/subscriptions/operationresults/read"}
 	permissionsToAddToResult := []string{"Microsoft.Resources/deployments/read", "Microsoft.Resources/deployments/write"}
 	mpfService = usecase.NewMPFService(ctx, rgManager, spRoleAssignmentManager, deploymentAuthorizationCheckerCleaner, mpfConfig, initialPermissionsToAdd, permissionsToAddToResult, true, false, true)
@@ -0,0 +1,240 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package e2etests
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Azure/mpf/pkg/domain"
+	"github.com/Azure/mpf/pkg/infrastructure/ARMTemplateShared"
+	"github.com/Azure/mpf/pkg/infrastructure/authorizationCheckers/ARMTemplateDeployment"
+	"github.com/Azure/mpf/pkg/infrastructure/azureAPI"
+	fakearm "github.com/Azure/mpf/pkg/infrastructure/fakeARM"
+	propagationwaiter "github.com/Azure/mpf/pkg/infrastructure/propagationWaiter"
+	resourceGroupManager "github.com/Azure/mpf/pkg/infrastructure/resourceGroupManager"
+	roleassignmentsnapshot "github.com/Azure/mpf/pkg/infrastructure/roleAssignmentSnapshot"
+	sproleassignmentmanager "github.com/Azure/mpf/pkg/infrastructure/spRoleAssignmentManager"
+	"github.com/Azure/mpf/pkg/usecase"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const fakeARMTemplate = `{
+	"$schema": "https://schema.management.azure.com/schemas/2019-04-01/deploymentTemplate.json#",
+	"contentVersion": "1.0.0.0",
+	"parameters": {
+		"storageAccountName": { "type": "string" }
+	},
+	"resources": [
+		{
+			"type": "Microsoft.Storage/storageAccounts",
+			"apiVersion": "2023-01-01",
+			"name": "[parameters('storageAccountName')]",
+			"location": "eastus2",
+			"sku": { "name": "Standard_LRS" },
+			"kind": "StorageV2"
+		},
+		{
+			"type": "Microsoft.Network/virtualNetworks",
+			"apiVersion": "2023-04-01",
+			"name": "vnet",
+			"location": "eastus2"
+		},
+		{
+			"type": "Microsoft.Network/virtualNetworks/subnets",
+			"apiVersion": "2023-04-01",
+			"name": "vnet/default"
+		}
+	]
+}`
+
+const fakeARMParameters = `{
+	"$schema": "https://schema.management.azure.com/schemas/2019-04-01/deploymentParameters.json#",
+	"contentVersion": "1.0.0.0",
+	"parameters": {
+		"storageAccountName": { "value": "fakearmsa" }
+	}
+}`
+
+// TestFakeARMTemplateDeployment runs the ARM flow against a local fake of Azure Resource Manager,
+// so it needs no Azure subscription or credentials
+func TestFakeARMTemplateDeployment(t *testing.T) {
+	server := fakearm.NewServer(fakearm.Config{
+		SPClientID: "CCCCCCCC-CCCC-CCCC-CCCC-CCCCCCCCCCCC",
+		SPObjectID: "OOOOOOOO-OOOO-OOOO-OOOO-OOOOOOOOOOOO",
+		RequiredActions: map[string][]string{
+			"Microsoft.Storage/storageAccounts":         {"Microsoft.Storage/storageAccounts/write"},
+			"Microsoft.Network/virtualNetworks":         {"Microsoft.Network/virtualNetworks/write"},
+			"Microsoft.Network/virtualNetworks/subnets": {"Microsoft.Network/virtualNetworks/subnets/write", "Microsoft.Network/virtualNetworks/subnets/join/action"},
+		},
+		// the read permission added for the subnet write is removed from the role as invalid
+		InvalidActions: []string{"Microsoft.Network/virtualNetworks/subnets/read"},
+	})
+	defer server.Close()
+
+	azureAPI.SetDefaultAzureAPIClientsOptions(server.AzureAPIClientsOptions())
+	defer azureAPI.SetDefaultAzureAPIClientsOptions(azureAPI.AzureAPIClientsOptions{})
+
+	dir := t.TempDir()
+	templateFilePath := filepath.Join(dir, "template.json")
+	parametersFilePath := filepath.Join(dir, "parameters.json")
+	assert.NoError(t, os.WriteFile(templateFilePath, []byte(fakeARMTemplate), 0600))
+	assert.NoError(t, os.WriteFile(parametersFilePath, []byte(fakeARMParameters), 0600))
+
+	mpfConfig := getMPFConfig(MpfCLIArgs{
+		SubscriptionID:       "SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS",
+		TenantID:             "TTTTTTTT-TTTT-TTTT-TTTT-TTTTTTTTTTTT",
+		SPClientID:           "CCCCCCCC-CCCC-CCCC-CCCC-CCCCCCCCCCCC",
+		SPObjectID:           "OOOOOOOO-OOOO-OOOO-OOOO-OOOOOOOOOOOO",
+		SPClientSecret:       "fake",
+		ResourceGroupNamePfx: "e2eFakeARM",
+		Location:             "eastus2",
+	})
+	runMetadata := domain.MPFRunMetadata{
+		RunID:          "8f14e45f-ceea-467a-9af0-fd5bd2b2d1a4",
+		DeploymentType: "arm",
+		TemplatePath:   templateFilePath,
+		ParametersPath: parametersFilePath,
+		StartTime:      time.Now().UTC(),
+	}
+	armConfig := ARMTemplateShared.ArmTemplateAdditionalConfig{
+		TemplateFilePath:   templateFilePath,
+		ParametersFilePath: parametersFilePath,
+		DeploymentName:     "e2eFakeARM",
+		Tags:               runMetadata.Tags(),
+	}
+
+	rgManager, err := resourceGroupManager.NewResourceGroupManager(mpfConfig.SubscriptionID)
+	require.NoError(t, err)
+	rgManager.SetTags(runMetadata.Tags())
+	spRoleAssignmentManager, err := sproleassignmentmanager.NewSPRoleAssignmentManager(mpfConfig.SubscriptionID)
+	require.NoError(t, err)
+	deploymentAuthorizationCheckerCleaner, err := ARMTemplateDeployment.NewARMTemplateDeploymentAuthorizationChecker(mpfConfig.SubscriptionID, armConfig)
+	require.NoError(t, err)
+
+	initialPermissionsToAdd := []string{"Microsoft.Resources/deployments/*", "Microsoft.Resources/subscriptions/operationresults/read"}
+	permissionsToAddToResult := []string{"Microsoft.Resources/deployments/read", "Microsoft.Resources/deployments/write"}
+	mpfService := usecase.NewMPFService(t.Context(), rgManager, spRoleAssignmentManager, deploymentAuthorizationCheckerCleaner, mpfConfig, initialPermissionsToAdd, permissionsToAddToResult, true, false, true)
+	// the verify strategy exercises the role definition and role assignment reads of the fake
+	mpfService.SetPropagationWaiter(propagationwaiter.NewVerifyingPropagationWaiter(spRoleAssignmentManager, 0, 0))
+
+	mpfResult, err := mpfService.GetMinimumPermissionsRequired()
+	assert.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{
+		"Microsoft.Network/virtualNetworks/read",
+		"Microsoft.Network/virtualNetworks/subnets/join/action",
+		"Microsoft.Network/virtualNetworks/subnets/read",
+		"Microsoft.Network/virtualNetworks/subnets/write",
+		"Microsoft.Network/virtualNetworks/write",
+		"Microsoft.Resources/deployments/read",
+		"Microsoft.Resources/deployments/write",
+		"Microsoft.Storage/storageAccounts/read",
+		"Microsoft.Storage/storageAccounts/write",
+	}, mpfResult.RequiredPermissions[mpfConfig.SubscriptionID])
+	assert.Contains(t, mpfResult.RequiredPermissions, fmt.Sprintf("%s/providers/Microsoft.Network/virtualNetworks/vnet/subnets/default", mpfConfig.ResourceGroup.ResourceGroupResourceID))
+
+	// the deployment is tagged with the run metadata
+	deploymentTags := server.DeploymentTags(mpfConfig.ResourceGroup.ResourceGroupName, "e2eFakeARM")
+	assert.Equal(t, "8f14e45f-ceea-467a-9af0-fd5bd2b2d1a4", deploymentTags[domain.MPFRunIDTagName])
+	assert.Equal(t, "template.json, parameters.json", deploymentTags[domain.MPFTemplateTagName])
+
+	// all resources created by MPF are cleaned up
+	assert.False(t, server.ResourceGroupExists(mpfConfig.ResourceGroup.ResourceGroupName))
+	assert.Equal(t, 0, server.RoleDefinitionCount())
+	assert.Equal(t, 0, server.RoleAssignmentCount())
+}
+
+// TestFakeARMRestoresRoleAssignments checks that the role assignments the service principal had before the run
+// are removed during the run, so that the permissions they grant are found, and restored afterwards
+func TestFakeARMRestoresRoleAssignments(t *testing.T) {
+	server := fakearm.NewServer(fakearm.Config{
+		SPClientID: "CCCCCCCC-CCCC-CCCC-CCCC-CCCCCCCCCCCC",
+		SPObjectID: "OOOOOOOO-OOOO-OOOO-OOOO-OOOOOOOOOOOO",
+		RequiredActions: map[string][]string{
+			"Microsoft.Storage/storageAccounts": {"Microsoft.Storage/storageAccounts/write"},
+		},
+	})
+	defer server.Close()
+
+	azureAPI.SetDefaultAzureAPIClientsOptions(server.AzureAPIClientsOptions())
+	defer azureAPI.SetDefaultAzureAPIClientsOptions(azureAPI.AzureAPIClientsOptions{})
+
+	dir := t.TempDir()
+	templateFilePath := filepath.Join(dir, "template.json")
+	parametersFilePath := filepath.Join(dir, "parameters.json")
+	require.NoError(t, os.WriteFile(templateFilePath, []byte(fakeARMTemplate), 0600))
+	require.NoError(t, os.WriteFile(parametersFilePath, []byte(fakeARMParameters), 0600))
+
+	mpfConfig := getMPFConfig(MpfCLIArgs{
+		SubscriptionID:       "SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS",
+		TenantID:             "TTTTTTTT-TTTT-TTTT-TTTT-TTTTTTTTTTTT",
+		SPClientID:           "CCCCCCCC-CCCC-CCCC-CCCC-CCCCCCCCCCCC",
+		SPObjectID:           "OOOOOOOO-OOOO-OOOO-OOOO-OOOOOOOOOOOO",
+		SPClientSecret:       "fake",
+		ResourceGroupNamePfx: "e2eFakeARM",
+		Location:             "eastus2",
+	})
+
+	// the service principal is shared, and already has a role granting the storage account write
+	spRoleAssignmentManager, err := sproleassignmentmanager.NewSPRoleAssignmentManager(mpfConfig.SubscriptionID)
+	require.NoError(t, err)
+	sharedRole := domain.Role{
+		RoleDefinitionID:         "DDDDDDDD-DDDD-DDDD-DDDD-DDDDDDDDDDDD",
+		RoleDefinitionName:       "shared-deployer",
+		RoleDefinitionResourceID: fmt.Sprintf("/subscriptions/%s/providers/Microsoft.Authorization/roleDefinitions/DDDDDDDD-DDDD-DDDD-DDDD-DDDDDDDDDDDD", mpfConfig.SubscriptionID),
+	}
+	err, _ = spRoleAssignmentManager.CreateUpdateCustomRole(t.Context(), mpfConfig.SubscriptionID, sharedRole, []string{"Microsoft.Storage/storageAccounts/write"}, nil)
+	require.NoError(t, err)
+	require.NoError(t, spRoleAssignmentManager.AssignRoleToSP(t.Context(), mpfConfig.SubscriptionID, mpfConfig.SP.SPObjectID, sharedRole))
+
+	armConfig := ARMTemplateShared.ArmTemplateAdditionalConfig{
+		TemplateFilePath:   templateFilePath,
+		ParametersFilePath: parametersFilePath,
+		DeploymentName:     "e2eFakeARM",
+	}
+	rgManager, err := resourceGroupManager.NewResourceGroupManager(mpfConfig.SubscriptionID)
+	require.NoError(t, err)
+	deploymentAuthorizationCheckerCleaner, err := ARMTemplateDeployment.NewARMTemplateDeploymentAuthorizationChecker(mpfConfig.SubscriptionID, armConfig)
+	require.NoError(t, err)
+
+	initialPermissionsToAdd := []string{"Microsoft.Resources/deployments/*", "Microsoft.Resources/subscriptions/operationresults/read"}
+	mpfService := usecase.NewMPFService(t.Context(), rgManager, spRoleAssignmentManager, deploymentAuthorizationCheckerCleaner, mpfConfig, initialPermissionsToAdd, nil, false, false, true)
+	mpfService.SetPropagationWaiter(propagationwaiter.NewVerifyingPropagationWaiter(spRoleAssignmentManager, 0, 0))
+	snapshotFilePath := filepath.Join(dir, ".azmpf-role-assignments.json")
+	mpfService.EnableRoleAssignmentBackup(spRoleAssignmentManager, roleassignmentsnapshot.NewFileRoleAssignmentSnapshotStore(snapshotFilePath))
+
+	mpfResult, err := mpfService.GetMinimumPermissionsRequired()
+	require.NoError(t, err)
+	assert.Contains(t, mpfResult.RequiredPermissions[mpfConfig.SubscriptionID], "Microsoft.Storage/storageAccounts/write")
+
+	// the shared role assignment is restored, and the snapshot deleted
+	assert.Equal(t, 1, server.RoleAssignmentCount())
+	assert.Equal(t, 1, server.RoleDefinitionCount())
+	_, err = os.Stat(snapshotFilePath)
+	assert.True(t, os.IsNotExist(err))
+}
@@ -34,6 +34,7 @@ import (
 	"github.com/Azure/mpf/pkg/usecase"
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 //authorizationFailedErrMsg
@@ -69,8 +70,10 @@ func TestTerraformAuthorizationPermissionMismatch(t *testing.T) {
 
 	var rgManager usecase.ResourceGroupManager
 	var spRoleAssignmentManager usecase.ServicePrincipalRolemAssignmentManager
-	rgManager = rgm.NewResourceGroupManager(mpfArgs.SubscriptionID)
-	spRoleAssignmentManager = spram.NewSPRoleAssignmentManager(mpfArgs.SubscriptionID)
+	rgManager, err = rgm.NewResourceGroupManager(mpfArgs.SubscriptionID)
+	require.NoError(t, err)
+	spRoleAssignmentManager, err = spram.NewSPRoleAssignmentManager(mpfArgs.SubscriptionID)
+	require.NoError(t, err)
 
 	var deploymentAuthorizationCheckerCleaner usecase.DeploymentAuthorizationCheckerCleaner
 	var mpfService *usecase.MPFService
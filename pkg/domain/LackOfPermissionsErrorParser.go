@@ -29,7 +29,18 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+// lackOfPermissionsScope is reported as scope for LackOfPermissions errors, as the error does not include the scope
+const lackOfPermissionsScope = "ScopeCannotBeParsedFromLackOfPermissionsError"
+
 func parseLackOfPermissionsError(authorizationFailedErrMsg string) (map[string][]string, error) {
+	findings, err := parseLackOfPermissionsErrorFindings(authorizationFailedErrMsg)
+	if err != nil {
+		return nil, err
+	}
+	return getScopePermissionsMap(findings), nil
+}
+
+func parseLackOfPermissionsErrorFindings(authorizationFailedErrMsg string) ([]AuthorizationFinding, error) {
 
 	log.Debugf("Attempting to Parse LackOfPermissions Error: %s", authorizationFailedErrMsg)
 
@@ -43,27 +54,29 @@ func parseLackOfPermissionsError(authorizationFailedErrMsg string) (map[string][
 		return nil, errors.New("no matches found in 'LackOfPermissions' error message")
 	}
 
-	scopePermissionsMap := make(map[string][]string)
+	var findings []AuthorizationFinding
 
-	// Iterate through the matches and populate the map
+	// Iterate through the matches and collect the findings
 	for _, match := range matches {
 		if len(match) == 3 {
-			// resourceType := match[1]
 			action := match[1]
-			scope := "ScopeCannotBeParsedFromLackOfPermissionsError"
 
-			if _, ok := scopePermissionsMap[scope]; !ok {
-				scopePermissionsMap[scope] = make([]string, 0)
-			}
-			scopePermissionsMap[scope] = append(scopePermissionsMap[scope], action)
+			findings = append(findings, AuthorizationFinding{
+				ErrorCode:    "LackOfPermissions",
+				Action:       action,
+				Scope:        lackOfPermissionsScope,
+				ResourceType: GetResourceTypeFromAction(action),
+				SourceParser: LackOfPermissionsParserName,
+				RawSnippet:   match[0],
+			})
 		}
 	}
 
-	// if map is empty, return error
-	if len(scopePermissionsMap) == 0 {
+	// if no findings, return error
+	if len(findings) == 0 {
 		return nil, errors.New("no scope/permissions found in LackOfPermissions message")
 	}
 
-	return scopePermissionsMap, nil
+	return findings, nil
 
 }
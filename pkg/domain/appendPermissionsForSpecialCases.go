@@ -19,14 +19,22 @@ var toAppendSpecialCasePermissions = map[string][]string{
 	},
 }
 
-func appendPermissionsForSpecialCases(scpPerms map[string][]string) map[string][]string {
-	for scp, perms := range scpPerms {
-		for _, perm := range perms {
-			if toAppend, ok := toAppendSpecialCasePermissions[perm]; ok {
-				scpPerms[scp] = append(scpPerms[scp], toAppend...)
-				log.Infof("Appended special case permissions for scope %s: %v", scp, toAppend)
-			}
+// appendFindingsForSpecialCases appends a finding for every special case permission required
+// by the actions of the findings. The appended findings keep the details of the finding they were derived from.
+func appendFindingsForSpecialCases(findings []AuthorizationFinding) []AuthorizationFinding {
+	for _, finding := range findings {
+		toAppend, ok := toAppendSpecialCasePermissions[finding.Action]
+		if !ok {
+			continue
 		}
+		for _, action := range toAppend {
+			specialCaseFinding := finding
+			specialCaseFinding.Action = action
+			specialCaseFinding.ResourceType = getResourceType(finding.Scope, action)
+			specialCaseFinding.SourceParser = SpecialCasePermissionsParserName
+			findings = append(findings, specialCaseFinding)
+		}
+		log.Infof("Appended special case permissions for scope %s: %v", finding.Scope, toAppend)
 	}
-	return scpPerms
+	return findings
 }
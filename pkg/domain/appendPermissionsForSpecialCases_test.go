@@ -1,11 +1,13 @@
 package domain
 
 import (
+	"maps"
 	"reflect"
+	"slices"
 	"testing"
 )
 
-func TestAppendPermissiosnForSpecialCases(t *testing.T) {
+func TestAppendFindingsForSpecialCases(t *testing.T) {
 	tests := []struct {
 		name     string
 		input    map[string][]string
@@ -108,7 +110,17 @@ func TestAppendPermissiosnForSpecialCases(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := appendPermissionsForSpecialCases(tt.input)
+			var findings []AuthorizationFinding
+			for _, scope := range slices.Sorted(maps.Keys(tt.input)) {
+				for _, action := range tt.input[scope] {
+					findings = append(findings, AuthorizationFinding{Scope: scope, Action: action, SourceParser: AuthorizationFailedParserName})
+				}
+			}
+
+			result := make(map[string][]string)
+			for _, finding := range appendFindingsForSpecialCases(findings) {
+				result[finding.Scope] = append(result[finding.Scope], finding.Action)
+			}
 			if !reflect.DeepEqual(result, tt.expected) {
 				t.Errorf("expected %v, got %v", tt.expected, result)
 			}
@@ -0,0 +1,206 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// AuthErrorParser parses one format of Azure authorization error into findings.
+// Parsers for additional error formats can be added with RegisterAuthErrorParser.
+type AuthErrorParser interface {
+	// Name identifies the parser. It is used as SourceParser of findings which do not set one.
+	Name() string
+	// Matches reports whether the error message contains errors in the format handled by the parser
+	Matches(authErrMesg string) bool
+	// Parse returns the findings for the errors handled by the parser.
+	// It is only called if Matches returned true, and must return an error if nothing could be parsed.
+	Parse(authErrMesg string) ([]AuthorizationFinding, error)
+}
+
+// AuthErrorCoder can be implemented by an AuthErrorParser to name the Azure error code of the errors it parses.
+// An error message containing the error code is reported as an authorization error which could not be parsed,
+// rather than as a non-authorization error, even if the parser does not match it.
+type AuthErrorCoder interface {
+	ErrorCode() string
+}
+
+type funcAuthErrorParser struct {
+	name      string
+	errorCode string
+	matches   func(authErrMesg string) bool
+	parse     func(authErrMesg string) ([]AuthorizationFinding, error)
+}
+
+// NewAuthErrorParser returns an AuthErrorParser using the given match and parse functions
+func NewAuthErrorParser(name string, matches func(authErrMesg string) bool, parse func(authErrMesg string) ([]AuthorizationFinding, error)) AuthErrorParser {
+	return &funcAuthErrorParser{
+		name:    name,
+		matches: matches,
+		parse:   parse,
+	}
+}
+
+// newAuthErrorCodeParser returns an AuthErrorParser for the errors with the Azure error code
+func newAuthErrorCodeParser(name string, errorCode string, matches func(authErrMesg string) bool, parse func(authErrMesg string) ([]AuthorizationFinding, error)) AuthErrorParser {
+	return &funcAuthErrorParser{
+		name:      name,
+		errorCode: errorCode,
+		matches:   matches,
+		parse:     parse,
+	}
+}
+
+func (p *funcAuthErrorParser) Name() string {
+	return p.name
+}
+
+func (p *funcAuthErrorParser) ErrorCode() string {
+	return p.errorCode
+}
+
+func (p *funcAuthErrorParser) Matches(authErrMesg string) bool {
+	return p.matches(authErrMesg)
+}
+
+func (p *funcAuthErrorParser) Parse(authErrMesg string) ([]AuthorizationFinding, error) {
+	return p.parse(authErrMesg)
+}
+
+// AuthErrorParserRegistry holds the parsers used to parse deployment authorization errors.
+// Every parser which matches an error message contributes its findings, in registration order.
+type AuthErrorParserRegistry struct {
+	mu      sync.RWMutex
+	parsers []AuthErrorParser
+}
+
+func NewAuthErrorParserRegistry(parsers ...AuthErrorParser) *AuthErrorParserRegistry {
+	return &AuthErrorParserRegistry{
+		parsers: parsers,
+	}
+}
+
+// Register adds the parser to the registry. It is safe for concurrent use.
+func (r *AuthErrorParserRegistry) Register(parser AuthErrorParser) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.parsers = append(r.parsers, parser)
+}
+
+// Parsers returns the registered parsers in registration order
+func (r *AuthErrorParserRegistry) Parsers() []AuthErrorParser {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]AuthErrorParser(nil), r.parsers...)
+}
+
+// GetAuthorizationFindings parses the deployment authorization error with every matching parser,
+// and returns the findings including the permissions appended for special cases.
+func (r *AuthErrorParserRegistry) GetAuthorizationFindings(authErrMesg string) ([]AuthorizationFinding, error) {
+	log.Debugf("Attempting to Parse Authorization Error: %s", authErrMesg)
+
+	hasAuthorizationRequestDenied := strings.Contains(authErrMesg, "Authorization_RequestDenied")
+
+	var matchingParsers []AuthErrorParser
+	hasAuthorizationErrorCode := false
+	for _, parser := range r.Parsers() {
+		if parser.Matches(authErrMesg) {
+			matchingParsers = append(matchingParsers, parser)
+		}
+		if coder, ok := parser.(AuthErrorCoder); ok && coder.ErrorCode() != "" && strings.Contains(authErrMesg, coder.ErrorCode()) {
+			hasAuthorizationErrorCode = true
+		}
+	}
+
+	// an error with the code of an authorization error no parser could match is reported as unparsable below
+	if authErrMesg != "" && !hasAuthorizationRequestDenied && !hasAuthorizationErrorCode && len(matchingParsers) == 0 {
+		log.Warnln("Non Authorization Error when creating deployment:", authErrMesg)
+		return nil, errors.New("could not parse deployment error, potentially due to a non-authorization error")
+	}
+
+	var findings []AuthorizationFinding
+	for _, parser := range matchingParsers {
+		log.Debugf("Parsing %s Error", parser.Name())
+		parsedFindings, err := parser.Parse(authErrMesg)
+		if err != nil {
+			return nil, err
+		}
+		for i := range parsedFindings {
+			if parsedFindings[i].SourceParser == "" {
+				parsedFindings[i].SourceParser = parser.Name()
+			}
+		}
+		findings = append(findings, parsedFindings...)
+	}
+
+	// If nothing was found, return error
+	if len(findings) == 0 {
+		// If the original error contained Authorization_RequestDenied and nothing
+		// else parseable was found, surface the dedicated guidance message so the
+		// user knows this requires admin consent / Global Administrator privileges.
+		if hasAuthorizationRequestDenied {
+			log.Warnln("Authorization_RequestDenied error detected. This error originates from Microsoft Graph / Azure AD and cannot be resolved by MPF.")
+			return nil, ErrAuthorizationRequestDenied
+		}
+		return nil, fmt.Errorf("could not parse deployment error for scope/permissions: %s", authErrMesg)
+	}
+
+	return appendFindingsForSpecialCases(findings), nil
+}
+
+// DefaultAuthErrorParsers returns the parsers for the authorization error formats supported by MPF
+func DefaultAuthErrorParsers() []AuthErrorParser {
+	return []AuthErrorParser{
+		newAuthErrorCodeParser(LinkedAuthorizationFailedParserName, "LinkedAuthorizationFailed", func(authErrMesg string) bool {
+			return strings.Contains(authErrMesg, "LinkedAuthorizationFailed")
+		}, parseLinkedAuthorizationFailedErrorFindings),
+		newAuthErrorCodeParser(LackOfPermissionsParserName, "LackOfPermissions", func(authErrMesg string) bool {
+			return strings.Contains(authErrMesg, "LackOfPermissions")
+		}, parseLackOfPermissionsErrorFindings),
+		newAuthErrorCodeParser(AuthorizationFailedParserName, "AuthorizationFailed", func(authErrMesg string) bool {
+			return strings.Contains(authErrMesg, "\"AuthorizationFailed") || strings.Contains(authErrMesg, " AuthorizationFailed:")
+		}, parseMultiAuthorizationFailedErrorFindings),
+		newAuthErrorCodeParser(TemplateResourceAuthorizationFailedParserName, "Authorization failed", func(authErrMesg string) bool {
+			return strings.Contains(authErrMesg, "Authorization failed")
+		}, parseMultiAuthorizationErrorFindings),
+		newAuthErrorCodeParser(AuthorizationPermissionMismatchParserName, "AuthorizationPermissionMismatch", func(authErrMesg string) bool {
+			return strings.Contains(authErrMesg, "AuthorizationPermissionMismatch")
+		}, parseAuthorizationPermissionMismatchErrorFindings),
+		newAuthErrorCodeParser(LinkedAccessCheckFailedParserName, "LinkedAccessCheckFailed", func(authErrMesg string) bool {
+			return strings.Contains(authErrMesg, "LinkedAccessCheckFailed")
+		}, parseLinkedAccessCheckFailedErrorFindings),
+	}
+}
+
+var defaultAuthErrorParserRegistry = NewAuthErrorParserRegistry(DefaultAuthErrorParsers()...)
+
+// RegisterAuthErrorParser adds a parser to the registry used by GetAuthorizationFindingsFromAuthError
+// and GetScopePermissionsFromAuthError, so additional error formats are parsed during MPF runs
+func RegisterAuthErrorParser(parser AuthErrorParser) {
+	defaultAuthErrorParserRegistry.Register(parser)
+}
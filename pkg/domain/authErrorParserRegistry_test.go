@@ -0,0 +1,107 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package domain
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// sample provider specific error format used to test custom parsers
+const testCustomProviderErrMesg = `Error: creating workspace: CustomProviderAuthorizationError: principal lacks 'Microsoft.Databricks/workspaces/write' on '/subscriptions/SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS/resourceGroups/rg/providers/Microsoft.Databricks/workspaces/ws'`
+
+func newTestCustomProviderParser() AuthErrorParser {
+	re := regexp.MustCompile(`principal lacks '([^']+)' on '([^']+)'`)
+	return NewAuthErrorParser("CustomProvider", func(authErrMesg string) bool {
+		return strings.Contains(authErrMesg, "CustomProviderAuthorizationError")
+	}, func(authErrMesg string) ([]AuthorizationFinding, error) {
+		var findings []AuthorizationFinding
+		for _, match := range re.FindAllStringSubmatch(authErrMesg, -1) {
+			findings = append(findings, AuthorizationFinding{
+				ErrorCode: "CustomProviderAuthorizationError",
+				Action:    match[1],
+				Scope:     match[2],
+			})
+		}
+		if len(findings) == 0 {
+			return nil, errors.New("no matches found in 'CustomProviderAuthorizationError' error message")
+		}
+		return findings, nil
+	})
+}
+
+func TestAuthErrorParserRegistryWithCustomParser(t *testing.T) {
+	registry := NewAuthErrorParserRegistry(DefaultAuthErrorParsers()...)
+
+	// not parseable before the custom parser is registered
+	_, err := registry.GetAuthorizationFindings(testCustomProviderErrMesg)
+	assert.Error(t, err)
+
+	registry.Register(newTestCustomProviderParser())
+
+	findings, err := registry.GetAuthorizationFindings(testCustomProviderErrMesg)
+	assert.NoError(t, err)
+	assert.Len(t, findings, 1)
+	assert.Equal(t, "Microsoft.Databricks/workspaces/write", findings[0].Action)
+	assert.Equal(t, "/subscriptions/SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS/resourceGroups/rg/providers/Microsoft.Databricks/workspaces/ws", findings[0].Scope)
+	assert.Equal(t, "CustomProvider", findings[0].SourceParser)
+}
+
+func TestAuthErrorParserRegistryParserError(t *testing.T) {
+	registry := NewAuthErrorParserRegistry(newTestCustomProviderParser())
+
+	_, err := registry.GetAuthorizationFindings("CustomProviderAuthorizationError: something unexpected")
+	assert.Error(t, err)
+}
+
+func TestAuthErrorParserRegistryNonAuthorizationError(t *testing.T) {
+	registry := NewAuthErrorParserRegistry(newTestCustomProviderParser())
+
+	_, err := registry.GetAuthorizationFindings("InvalidTemplateDeployment: sku not allowed")
+	assert.ErrorContains(t, err, "non-authorization error")
+}
+
+func TestAuthErrorParserRegistryUnparsableAuthorizationError(t *testing.T) {
+	registry := NewAuthErrorParserRegistry(DefaultAuthErrorParsers()...)
+
+	// the error code of an authorization error is reported as a parse error, even if no parser matches the message
+	_, err := registry.GetAuthorizationFindings("unexpected status 403 AuthorizationFailed for the client")
+	assert.ErrorContains(t, err, "could not parse deployment error for scope/permissions")
+	assert.NotContains(t, err.Error(), "non-authorization error")
+}
+
+func TestRegisterAuthErrorParser(t *testing.T) {
+	errMesg := strings.ReplaceAll(testCustomProviderErrMesg, "CustomProviderAuthorizationError", "RegisteredCustomProviderAuthorizationError")
+	parser := newTestCustomProviderParser()
+	RegisterAuthErrorParser(NewAuthErrorParser("RegisteredCustomProvider", func(authErrMesg string) bool {
+		return strings.Contains(authErrMesg, "RegisteredCustomProviderAuthorizationError")
+	}, parser.Parse))
+
+	spm, err := GetScopePermissionsFromAuthError(errMesg)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Microsoft.Databricks/workspaces/write"}, spm["/subscriptions/SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS/resourceGroups/rg/providers/Microsoft.Databricks/workspaces/ws"])
+}
@@ -24,12 +24,7 @@ package domain
 
 import (
 	"errors"
-	"fmt"
 	"regexp"
-	"slices"
-	"strings"
-
-	log "github.com/sirupsen/logrus"
 )
 
 // ErrAuthorizationRequestDenied is returned when an Authorization_RequestDenied error
@@ -42,118 +37,30 @@ var ErrAuthorizationRequestDenied = errors.New("Authorization_RequestDenied: ins
 	"and https://registry.terraform.io/providers/hashicorp/azuread/latest/docs/guides/service_principal_configuration for Terraform AzureAD provider setup")
 
 func GetScopePermissionsFromAuthError(authErrMesg string) (map[string][]string, error) {
-	log.Debugf("Attempting to Parse Authorization Error: %s", authErrMesg)
-
-	hasAuthorizationRequestDenied := strings.Contains(authErrMesg, "Authorization_RequestDenied")
-
-	if authErrMesg != "" && !hasAuthorizationRequestDenied && !strings.Contains(authErrMesg, "AuthorizationFailed") && !strings.Contains(authErrMesg, "Authorization failed") && !strings.Contains(authErrMesg, "AuthorizationPermissionMismatch") && !strings.Contains(authErrMesg, "LinkedAccessCheckFailed") && !strings.Contains(authErrMesg, "LackOfPermissions") {
-		log.Warnln("Non Authorization Error when creating deployment:", authErrMesg)
-		return nil, errors.New("could not parse deployment error, potentially due to a non-authorization error")
-	}
-
-	resMap := make(map[string][]string)
-	var tempMaps []map[string][]string
-
-	// Process LinkedAuthorizationFailed errors
-	if strings.Count(authErrMesg, "LinkedAuthorizationFailed") >= 1 {
-		log.Debug("Parsing LinkedAuthorizationFailed Error")
-		tempMap, err := parseLinkedAuthorizationFailedErrors(authErrMesg)
-		if err != nil {
-			return nil, err
-		}
-		tempMaps = append(tempMaps, tempMap)
-	}
-
-	// Process LackOfPermissions errors
-	if strings.Count(authErrMesg, "LackOfPermissions") >= 1 {
-		log.Debug("Parsing LackOfPermissions Error")
-		tempMap, err := parseLackOfPermissionsError(authErrMesg)
-		if err != nil {
-			return nil, err
-		}
-		tempMaps = append(tempMaps, tempMap)
-	}
-
-	// Process AuthorizationFailed errors
-	if strings.Count(authErrMesg, "\"AuthorizationFailed") >= 1 || strings.Count(authErrMesg, " AuthorizationFailed:") >= 1 {
-		log.Debug("Parsing AuthorizationFailed Error")
-		tempMap, err := parseMultiAuthorizationFailedErrors(authErrMesg)
-		if err != nil {
-			return nil, err
-		}
-		tempMaps = append(tempMaps, tempMap)
-	}
-
-	// Process Authorization failed errors
-	if strings.Count(authErrMesg, "Authorization failed") >= 1 {
-		log.Debug("Parsing Authorization failed Error")
-		tempMap, err := parseMultiAuthorizationErrors(authErrMesg)
-		if err != nil {
-			return nil, err
-		}
-		tempMaps = append(tempMaps, tempMap)
-	}
-
-	// Process AuthorizationPermissionMismatch errors
-	if strings.Count(authErrMesg, "AuthorizationPermissionMismatch") >= 1 {
-		log.Debug("Parsing AuthorizationPermissionMismatch Error")
-		tempMap, err := parseAuthorizationPermissionMismatchError(authErrMesg)
-		if err != nil {
-			return nil, err
-		}
-		tempMaps = append(tempMaps, tempMap)
+	findings, err := GetAuthorizationFindingsFromAuthError(authErrMesg)
+	if err != nil {
+		return nil, err
 	}
-
-	// Process LinkedAccessCheckFailed errors
-	if strings.Count(authErrMesg, "LinkedAccessCheckFailed") >= 1 {
-		log.Debug("Parsing LinkedAccessCheckFailed Error")
-		tempMap, err := parseLinkedAccessCheckFailedError(authErrMesg)
-		if err != nil {
-			return nil, err
-		}
-		tempMaps = append(tempMaps, tempMap)
-	}
-
-	// Merge all temp maps into the result map
-	for _, tempMap := range tempMaps {
-		mergePermissionMaps(resMap, tempMap)
-	}
-
-	// If map is empty, return error
-	if len(resMap) == 0 {
-		// If the original error contained Authorization_RequestDenied and nothing
-		// else parseable was found, surface the dedicated guidance message so the
-		// user knows this requires admin consent / Global Administrator privileges.
-		if hasAuthorizationRequestDenied {
-			log.Warnln("Authorization_RequestDenied error detected. This error originates from Microsoft Graph / Azure AD and cannot be resolved by MPF.")
-			return nil, ErrAuthorizationRequestDenied
-		}
-		return nil, fmt.Errorf("could not parse deployment error for scope/permissions: %s", authErrMesg)
-	}
-
-	return appendPermissionsForSpecialCases(resMap), nil
+	return GetScopePermissionsFromFindings(findings), nil
 }
 
-// mergePermissionMaps merges the source map into the destination map,
-// ensuring unique permissions for each scope
-func mergePermissionMaps(dest, src map[string][]string) {
-	for scope, permissions := range src {
-		if _, exists := dest[scope]; !exists {
-			dest[scope] = make([]string, 0)
-		}
-
-		// Add permissions, avoiding duplicates
-		for _, permission := range permissions {
-			found := slices.Contains(dest[scope], permission)
-			if !found {
-				dest[scope] = append(dest[scope], permission)
-			}
-		}
-	}
+// GetAuthorizationFindingsFromAuthError parses the deployment authorization error into findings,
+// one per missing action and scope, using the parsers registered with RegisterAuthErrorParser
+// in addition to the default parsers.
+func GetAuthorizationFindingsFromAuthError(authErrMesg string) ([]AuthorizationFinding, error) {
+	return defaultAuthErrorParserRegistry.GetAuthorizationFindings(authErrMesg)
 }
 
 // For 'AuthorizationFailed' errors
 func parseMultiAuthorizationFailedErrors(authorizationFailedErrMsg string) (map[string][]string, error) {
+	findings, err := parseMultiAuthorizationFailedErrorFindings(authorizationFailedErrMsg)
+	if err != nil {
+		return nil, err
+	}
+	return getScopePermissionsMap(findings), nil
+}
+
+func parseMultiAuthorizationFailedErrorFindings(authorizationFailedErrMsg string) ([]AuthorizationFinding, error) {
 
 	re := regexp.MustCompile(`The client '([^']+)' with object id '([^']+)' does not have authorization to perform action '([^']+)'.* over scope '([^']+)' or the scope is invalid\.`)
 
@@ -163,33 +70,46 @@ func parseMultiAuthorizationFailedErrors(authorizationFailedErrMsg string) (map[
 		return nil, errors.New("no matches found in 'AuthorizationFailed' error message")
 	}
 
-	scopePermissionsMap := make(map[string][]string)
+	var findings []AuthorizationFinding
 
-	// Iterate through the matches and populate the map
+	// Iterate through the matches and collect the findings
 	for _, match := range matches {
 		if len(match) == 5 {
-			// resourceType := match[1]
 			action := match[3]
 			scope := match[4]
 
-			if _, ok := scopePermissionsMap[scope]; !ok {
-				scopePermissionsMap[scope] = make([]string, 0)
-			}
-			scopePermissionsMap[scope] = append(scopePermissionsMap[scope], action)
+			findings = append(findings, AuthorizationFinding{
+				ErrorCode:    "AuthorizationFailed",
+				Action:       action,
+				Scope:        scope,
+				ResourceType: getResourceType(scope, action),
+				ClientID:     match[1],
+				ObjectID:     match[2],
+				SourceParser: AuthorizationFailedParserName,
+				RawSnippet:   match[0],
+			})
 		}
 	}
 
-	// if map is empty, return error
-	if len(scopePermissionsMap) == 0 {
+	// if no findings, return error
+	if len(findings) == 0 {
 		return nil, errors.New("no scope/permissions found in Multi error message")
 	}
 
-	return scopePermissionsMap, nil
+	return findings, nil
 
 }
 
 // For 'Authorization failed' errors
 func parseMultiAuthorizationErrors(authorizationFailedErrMsg string) (map[string][]string, error) {
+	findings, err := parseMultiAuthorizationErrorFindings(authorizationFailedErrMsg)
+	if err != nil {
+		return nil, err
+	}
+	return getScopePermissionsMap(findings), nil
+}
+
+func parseMultiAuthorizationErrorFindings(authorizationFailedErrMsg string) ([]AuthorizationFinding, error) {
 
 	// Regular expression to extract resource information
 	re := regexp.MustCompile(`Authorization failed for template resource '([^']+)' of type '([^']+)'\. The client '([^']+)' with object id '([^']+)' does not have permission to perform action '([^']+)' at scope '([^']+)'\.`)
@@ -202,28 +122,30 @@ func parseMultiAuthorizationErrors(authorizationFailedErrMsg string) (map[string
 		return nil, errors.New("no matches found in 'Authorization failed' error message")
 	}
 
-	// Create a map to store scope/permissions
-	scopePermissionsMap := make(map[string][]string)
+	var findings []AuthorizationFinding
 
-	// Iterate through the matches and populate the map
+	// Iterate through the matches and collect the findings
 	for _, match := range matches {
 		if len(match) == 7 {
-			// resourceType := match[1]
-			action := match[5]
-			scope := match[6]
-
-			if _, ok := scopePermissionsMap[scope]; !ok {
-				scopePermissionsMap[scope] = make([]string, 0)
-			}
-			scopePermissionsMap[scope] = append(scopePermissionsMap[scope], action)
+			findings = append(findings, AuthorizationFinding{
+				ErrorCode:            "AuthorizationFailed",
+				Action:               match[5],
+				Scope:                match[6],
+				ResourceType:         match[2],
+				TemplateResourceName: match[1],
+				ClientID:             match[3],
+				ObjectID:             match[4],
+				SourceParser:         TemplateResourceAuthorizationFailedParserName,
+				RawSnippet:           match[0],
+			})
 		}
 	}
 
-	// if map is empty, return error
-	if len(scopePermissionsMap) == 0 {
+	// if no findings, return error
+	if len(findings) == 0 {
 		return nil, errors.New("no scope/permissions found in Multi error message")
 	}
 
-	return scopePermissionsMap, nil
+	return findings, nil
 
 }
@@ -0,0 +1,134 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package domain
+
+import (
+	"slices"
+	"strings"
+)
+
+// Source parser names reported in AuthorizationFinding.SourceParser
+const (
+	AuthorizationFailedParserName                 = "AuthorizationFailed"
+	TemplateResourceAuthorizationFailedParserName = "TemplateResourceAuthorizationFailed"
+	LinkedAuthorizationFailedParserName           = "LinkedAuthorizationFailed"
+	LackOfPermissionsParserName                   = "LackOfPermissions"
+	AuthorizationPermissionMismatchParserName     = "AuthorizationPermissionMismatch"
+	LinkedAccessCheckFailedParserName             = "LinkedAccessCheckFailed"
+	SpecialCasePermissionsParserName              = "SpecialCasePermissions"
+)
+
+// AuthorizationFinding is a single missing permission extracted from a deployment authorization error,
+// together with the details the error gave about why the permission was required.
+type AuthorizationFinding struct {
+	// ErrorCode is the Azure error code the finding was reported with, e.g. AuthorizationFailed
+	ErrorCode string
+	// Action is the missing permission
+	Action string
+	// Scope is the scope at which the action is missing
+	Scope string
+	// ResourceType is the type of the resource the action was required for, e.g. Microsoft.Storage/storageAccounts
+	ResourceType string
+	// TemplateResourceName is the name of the template resource, if the error reported it
+	TemplateResourceName string
+	// ClientID and ObjectID identify the principal the error was reported for, if the error reported them
+	ClientID string
+	ObjectID string
+	// SourceParser is the name of the parser which produced the finding
+	SourceParser string
+	// RawSnippet is the part of the error message the finding was parsed from
+	RawSnippet string
+}
+
+// GetScopePermissionsFromFindings returns the scope to permissions map for the findings.
+// Permissions are unique per scope and keep the order in which they were found.
+func GetScopePermissionsFromFindings(findings []AuthorizationFinding) map[string][]string {
+	scopePermissionsMap := make(map[string][]string)
+	for _, finding := range findings {
+		if slices.Contains(scopePermissionsMap[finding.Scope], finding.Action) {
+			continue
+		}
+		scopePermissionsMap[finding.Scope] = append(scopePermissionsMap[finding.Scope], finding.Action)
+	}
+	return scopePermissionsMap
+}
+
+// getScopePermissionsMap returns the scope to permissions map for the findings, keeping duplicates
+func getScopePermissionsMap(findings []AuthorizationFinding) map[string][]string {
+	scopePermissionsMap := make(map[string][]string)
+	for _, finding := range findings {
+		scopePermissionsMap[finding.Scope] = append(scopePermissionsMap[finding.Scope], finding.Action)
+	}
+	return scopePermissionsMap
+}
+
+// getResourceTypeFromScope returns the resource type of the resource identified by scope,
+// e.g. Microsoft.Network/virtualNetworks/subnets for a subnet resource ID.
+// An empty string is returned if the scope is not a resource ID.
+func getResourceTypeFromScope(scope string) string {
+	if resourceGroupScopeRe.MatchString(scope) {
+		return "Microsoft.Resources/resourceGroups"
+	}
+	if subscriptionScopeRe.MatchString(scope) {
+		return "Microsoft.Resources/subscriptions"
+	}
+
+	idx := strings.LastIndex(strings.ToLower(scope), "/providers/")
+	if idx < 0 {
+		return ""
+	}
+
+	// after the last providers segment the resource ID is namespace/type/name[/type/name...]
+	segments := strings.Split(strings.Trim(scope[idx+len("/providers/"):], "/"), "/")
+	if len(segments) < 2 {
+		return ""
+	}
+	resourceType := segments[0]
+	for i := 1; i < len(segments); i += 2 {
+		resourceType += "/" + segments[i]
+	}
+	return resourceType
+}
+
+// GetResourceTypeFromAction returns the resource type an action applies to,
+// e.g. Microsoft.Network/virtualNetworks/subnets for Microsoft.Network/virtualNetworks/subnets/join/action
+func GetResourceTypeFromAction(action string) string {
+	segments := strings.Split(action, "/")
+	if len(segments) < 3 {
+		return ""
+	}
+	segments = segments[:len(segments)-1]
+	if strings.EqualFold(action[strings.LastIndex(action, "/")+1:], "action") && len(segments) > 2 {
+		segments = segments[:len(segments)-1]
+	}
+	return strings.Join(segments, "/")
+}
+
+// getResourceType returns the resource type for the finding's scope, falling back to the action
+// when the scope is not a resource ID
+func getResourceType(scope, action string) string {
+	if resourceType := getResourceTypeFromScope(scope); resourceType != "" {
+		return resourceType
+	}
+	return GetResourceTypeFromAction(action)
+}
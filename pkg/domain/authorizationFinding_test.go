@@ -0,0 +1,139 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAuthorizationFindingsFromTemplateResourceError(t *testing.T) {
+	authErr := "{\"error\":{\"code\":\"InvalidTemplateDeployment\",\"message\":\"The template deployment failed with error: 'Authorization failed for template resource 'appgw-zcrtnp4zt4k44WafPolicy' of type 'Microsoft.Network/ApplicationGatewayWebApplicationFirewallPolicies'. The client 'CCCCCCCC-CCCC-CCCC-CCCC-CCCCCCCCCCCC' with object id 'OOOOOOOO-OOOO-OOOO-OOOO-OOOOOOOOOOOO' does not have permission to perform action 'Microsoft.Network/ApplicationGatewayWebApplicationFirewallPolicies/write' at scope '/subscriptions/SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS/resourceGroups/testdeployrg/providers/Microsoft.Network/ApplicationGatewayWebApplicationFirewallPolicies/appgw-zcrtnp4zt4k44WafPolicy'.'.\"}}"
+
+	findings, err := GetAuthorizationFindingsFromAuthError(authErr)
+	assert.NoError(t, err)
+	assert.Len(t, findings, 1)
+
+	finding := findings[0]
+	assert.Equal(t, "AuthorizationFailed", finding.ErrorCode)
+	assert.Equal(t, "Microsoft.Network/ApplicationGatewayWebApplicationFirewallPolicies/write", finding.Action)
+	assert.Equal(t, "/subscriptions/SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS/resourceGroups/testdeployrg/providers/Microsoft.Network/ApplicationGatewayWebApplicationFirewallPolicies/appgw-zcrtnp4zt4k44WafPolicy", finding.Scope)
+	assert.Equal(t, "Microsoft.Network/ApplicationGatewayWebApplicationFirewallPolicies", finding.ResourceType)
+	assert.Equal(t, "appgw-zcrtnp4zt4k44WafPolicy", finding.TemplateResourceName)
+	assert.Equal(t, "CCCCCCCC-CCCC-CCCC-CCCC-CCCCCCCCCCCC", finding.ClientID)
+	assert.Equal(t, "OOOOOOOO-OOOO-OOOO-OOOO-OOOOOOOOOOOO", finding.ObjectID)
+	assert.Equal(t, TemplateResourceAuthorizationFailedParserName, finding.SourceParser)
+	assert.Contains(t, finding.RawSnippet, "Authorization failed for template resource 'appgw-zcrtnp4zt4k44WafPolicy'")
+}
+
+func TestGetAuthorizationFindingsFromLinkedAuthorizationFailedError(t *testing.T) {
+	authErr := "error: LinkedAuthorizationFailed: The client 'a31fc7f1-1349-4b3c-af16-60422be430cc' with object id 'b31fc7f1-1349-4b3c-af16-60422be430cc' has permission to perform action 'Microsoft.ContainerService/managedClusters/write' on scope '/subscriptions/SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS/resourceGroups/az-mpf-tf-test-rg/providers/Microsoft.ContainerService/managedClusters/aks-32a70ccbb3247e2b'; however, it does not have permission to perform action(s) 'Microsoft.Network/virtualNetworks/subnets/join/action' on the linked scope(s) '/subscriptions/SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS/resourceGroups/az-mpf-tf-test-rg/providers/Microsoft.Network/virtualNetworks/vnet-32a70ccbb3247e2b/subnets/subnet-32a70ccbb3247e2b' (respectively) or the linked scope(s) are invalid."
+
+	findings, err := GetAuthorizationFindingsFromAuthError(authErr)
+	assert.NoError(t, err)
+	assert.Len(t, findings, 1)
+	assert.Equal(t, "LinkedAuthorizationFailed", findings[0].ErrorCode)
+	assert.Equal(t, "Microsoft.Network/virtualNetworks/subnets/join/action", findings[0].Action)
+	assert.Equal(t, "Microsoft.Network/virtualNetworks/subnets", findings[0].ResourceType)
+	assert.Equal(t, "a31fc7f1-1349-4b3c-af16-60422be430cc", findings[0].ClientID)
+	assert.Equal(t, "b31fc7f1-1349-4b3c-af16-60422be430cc", findings[0].ObjectID)
+}
+
+func TestGetAuthorizationFindingsIncludesSpecialCases(t *testing.T) {
+	authErr := "{\"error\":{\"code\":\"AuthorizationFailed\",\"message\":\"The client 'XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX' with object id 'XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX' does not have authorization to perform action 'Microsoft.Insights/components/write' over scope '/subscriptions/SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS/resourcegroups/testdeployrg/providers/Microsoft.Insights/components/appi' or the scope is invalid. If access was recently granted, please refresh your credentials.\"}}"
+
+	findings, err := GetAuthorizationFindingsFromAuthError(authErr)
+	assert.NoError(t, err)
+	assert.Len(t, findings, 3)
+	assert.Equal(t, AuthorizationFailedParserName, findings[0].SourceParser)
+	assert.Equal(t, "Microsoft.Insights/components", findings[0].ResourceType)
+	assert.Equal(t, "Microsoft.Insights/components/currentbillingfeatures/write", findings[1].Action)
+	assert.Equal(t, SpecialCasePermissionsParserName, findings[1].SourceParser)
+	assert.Equal(t, findings[0].Scope, findings[1].Scope)
+
+	spm, err := GetScopePermissionsFromAuthError(authErr)
+	assert.NoError(t, err)
+	assert.Equal(t, GetScopePermissionsFromFindings(findings), spm)
+}
+
+func TestGetScopePermissionsFromFindings(t *testing.T) {
+	findings := []AuthorizationFinding{
+		{Action: "Microsoft.Storage/storageAccounts/write", Scope: "scope1"},
+		{Action: "Microsoft.Storage/storageAccounts/write", Scope: "scope1"},
+		{Action: "Microsoft.Storage/storageAccounts/read", Scope: "scope1"},
+		{Action: "Microsoft.Storage/storageAccounts/write", Scope: "scope2"},
+	}
+
+	want := map[string][]string{
+		"scope1": {"Microsoft.Storage/storageAccounts/write", "Microsoft.Storage/storageAccounts/read"},
+		"scope2": {"Microsoft.Storage/storageAccounts/write"},
+	}
+	assert.Equal(t, want, GetScopePermissionsFromFindings(findings))
+}
+
+func TestGetResourceType(t *testing.T) {
+	tests := []struct {
+		name   string
+		scope  string
+		action string
+		want   string
+	}{
+		{
+			name:   "nested resource",
+			scope:  "/subscriptions/SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet/subnets/subnet",
+			action: "Microsoft.Network/virtualNetworks/subnets/join/action",
+			want:   "Microsoft.Network/virtualNetworks/subnets",
+		},
+		{
+			name:   "extension resource",
+			scope:  "/subscriptions/SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS/resourceGroups/rg/providers/Microsoft.KeyVault/vaults/kv/providers/Microsoft.Insights/diagnosticSettings/default",
+			action: "Microsoft.Insights/diagnosticSettings/write",
+			want:   "Microsoft.Insights/diagnosticSettings",
+		},
+		{
+			name:   "resource group scope",
+			scope:  "/subscriptions/SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS/resourceGroups/rg",
+			action: "Microsoft.Resources/subscriptions/resourceGroups/read",
+			want:   "Microsoft.Resources/resourceGroups",
+		},
+		{
+			name:   "scope is not a resource ID",
+			scope:  lackOfPermissionsScope,
+			action: "Microsoft.MachineLearningServices/workspaces/hubs/write",
+			want:   "Microsoft.MachineLearningServices/workspaces/hubs",
+		},
+		{
+			name:   "scope is not a resource ID with action verb",
+			scope:  "",
+			action: "Microsoft.Storage/storageAccounts/listKeys/action",
+			want:   "Microsoft.Storage/storageAccounts",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, getResourceType(tt.scope, tt.action))
+		})
+	}
+}
@@ -35,6 +35,14 @@ type PermissionErrorDetails struct {
 }
 
 func parseAuthorizationPermissionMismatchError(authorizationFailedErrMsg string) (map[string][]string, error) {
+	findings, err := parseAuthorizationPermissionMismatchErrorFindings(authorizationFailedErrMsg)
+	if err != nil {
+		return nil, err
+	}
+	return getScopePermissionsMap(findings), nil
+}
+
+func parseAuthorizationPermissionMismatchErrorFindings(authorizationFailedErrMsg string) ([]AuthorizationFinding, error) {
 
 	log.Printf("Attempting to Parse AuthorizationPermissionMismatch Error: @@%s@@", authorizationFailedErrMsg)
 	re := regexp.MustCompile(`retrieving (queue|file|blob) properties for Storage Account \(Subscription: \"([^"]+)\"\nResource Group Name: \"([^"]+)\"\nStorage Account Name: \"([^"]+)\"\): executing request: unexpected status 403 \(403 This request is not authorized to perform this operation using this permission.\) with AuthorizationPermissionMismatch: This request is not authorized to perform this operation using this permission.`)
@@ -45,35 +53,46 @@ func parseAuthorizationPermissionMismatchError(authorizationFailedErrMsg string)
 		return nil, errors.New("no matches found in 'AuthorizationPermissionMismatch' error message")
 	}
 
-	scopePermissionsMap := make(map[string][]string)
+	var findings []AuthorizationFinding
 
-	// Iterate through the matches and populate the map
+	// Iterate through the matches and collect the findings
 	for _, match := range matches {
 		if len(match) == 5 {
-			var action string
+			// the storage service properties are read over the data plane,
+			// which needs the data action in addition to the service read action
+			var action, dataAction string
 			switch match[1] {
 			case "queue":
 				action = "Microsoft.Storage/storageAccounts/queueServices/read"
+				dataAction = "Microsoft.Storage/storageAccounts/queueServices/queues/messages/read"
 			case "file":
 				action = "Microsoft.Storage/storageAccounts/fileServices/read"
+				dataAction = "Microsoft.Storage/storageAccounts/fileServices/fileshares/files/read"
 			case "blob":
 				action = "Microsoft.Storage/storageAccounts/blobServices/read"
+				dataAction = "Microsoft.Storage/storageAccounts/blobServices/containers/blobs/read"
 			}
 
 			scope := fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Storage/storageAccounts/%s", match[2], match[3], match[4])
 
-			if _, ok := scopePermissionsMap[scope]; !ok {
-				scopePermissionsMap[scope] = make([]string, 0)
+			for _, a := range []string{action, dataAction} {
+				findings = append(findings, AuthorizationFinding{
+					ErrorCode:    "AuthorizationPermissionMismatch",
+					Action:       a,
+					Scope:        scope,
+					ResourceType: "Microsoft.Storage/storageAccounts",
+					SourceParser: AuthorizationPermissionMismatchParserName,
+					RawSnippet:   match[0],
+				})
 			}
-			scopePermissionsMap[scope] = append(scopePermissionsMap[scope], action)
 		}
 	}
 
-	// if map is empty, return error
-	if len(scopePermissionsMap) == 0 {
+	// if no findings, return error
+	if len(findings) == 0 {
 		return nil, errors.New("no scope/permissions found in AuthorizationPermissionMismatch error message")
 	}
 
-	return scopePermissionsMap, nil
+	return findings, nil
 
 }
@@ -40,7 +40,7 @@ func TestParseAuthorizationPermissionMismatchError(t *testing.T) {
 Resource Group Name: "rg-nygst"
 Storage Account Name: "sanygst"): executing request: unexpected status 403 (403 This request is not authorized to perform this operation using this permission.) with AuthorizationPermissionMismatch: This request is not authorized to perform this operation using this permission.`,
 			want: map[string][]string{
-				"/subscriptions/SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS/resourceGroups/rg-nygst/providers/Microsoft.Storage/storageAccounts/sanygst": {"Microsoft.Storage/storageAccounts/queueServices/read"},
+				"/subscriptions/SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS/resourceGroups/rg-nygst/providers/Microsoft.Storage/storageAccounts/sanygst": {"Microsoft.Storage/storageAccounts/queueServices/read", "Microsoft.Storage/storageAccounts/queueServices/queues/messages/read"},
 			},
 			wantErr: false,
 		},
@@ -50,7 +50,7 @@ Storage Account Name: "sanygst"): executing request: unexpected status 403 (403
 Resource Group Name: "rg-nygst"
 Storage Account Name: "sanygst"): executing request: unexpected status 403 (403 This request is not authorized to perform this operation using this permission.) with AuthorizationPermissionMismatch: This request is not authorized to perform this operation using this permission.`,
 			want: map[string][]string{
-				"/subscriptions/SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS/resourceGroups/rg-nygst/providers/Microsoft.Storage/storageAccounts/sanygst": {"Microsoft.Storage/storageAccounts/fileServices/read"},
+				"/subscriptions/SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS/resourceGroups/rg-nygst/providers/Microsoft.Storage/storageAccounts/sanygst": {"Microsoft.Storage/storageAccounts/fileServices/read", "Microsoft.Storage/storageAccounts/fileServices/fileshares/files/read"},
 			},
 			wantErr: false,
 		},
@@ -60,7 +60,7 @@ Storage Account Name: "sanygst"): executing request: unexpected status 403 (403
 Resource Group Name: "rg-nygst"
 Storage Account Name: "sanygst"): executing request: unexpected status 403 (403 This request is not authorized to perform this operation using this permission.) with AuthorizationPermissionMismatch: This request is not authorized to perform this operation using this permission.`,
 			want: map[string][]string{
-				"/subscriptions/SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS/resourceGroups/rg-nygst/providers/Microsoft.Storage/storageAccounts/sanygst": {"Microsoft.Storage/storageAccounts/blobServices/read"},
+				"/subscriptions/SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS/resourceGroups/rg-nygst/providers/Microsoft.Storage/storageAccounts/sanygst": {"Microsoft.Storage/storageAccounts/blobServices/read", "Microsoft.Storage/storageAccounts/blobServices/containers/blobs/read"},
 			},
 			wantErr: false,
 		},
@@ -0,0 +1,233 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package domain
+
+import (
+	"slices"
+	"sort"
+	"strings"
+)
+
+// BuiltInRoleMatch holds the required permissions a built-in role grants and what else it grants
+type BuiltInRoleMatch struct {
+	Role               RoleDefinition
+	CoveredActions     []string
+	CoveredDataActions []string
+	// ExcessActions are the actions and wildcards of the role beyond the required actions.
+	// Wildcards always grant more than the required actions and are always excess.
+	ExcessActions     []string
+	ExcessDataActions []string
+	// ExcessScore weighs the excess permissions by their breadth, e.g. * weighs more than Microsoft.Storage/*
+	ExcessScore int
+}
+
+// BuiltInRoleComparison compares the required permissions with the built-in roles
+type BuiltInRoleComparison struct {
+	// SingleRoles are the roles granting all required permissions on their own, with the least excess first
+	SingleRoles []BuiltInRoleMatch
+	// Cover is a set of narrow roles jointly granting all required permissions granted by any role
+	Cover []BuiltInRoleMatch
+	// UncoveredActions and UncoveredDataActions are granted by no built-in role
+	UncoveredActions     []string
+	UncoveredDataActions []string
+}
+
+// CompareWithBuiltInRoles compares the required actions and data actions with the built-in roles.
+// The cover is chosen greedily, taking the role with the least excess per newly covered permission first,
+// and roles made redundant by later roles are dropped.
+func CompareWithBuiltInRoles(actions []string, dataActions []string, roles []RoleDefinition) BuiltInRoleComparison {
+	actions = getUniqueSortedPermissions(actions)
+	dataActions = getUniqueSortedPermissions(dataActions)
+
+	matches := make([]BuiltInRoleMatch, 0, len(roles))
+	for _, role := range roles {
+		match := getBuiltInRoleMatch(role, actions, dataActions)
+		if len(match.CoveredActions) > 0 || len(match.CoveredDataActions) > 0 {
+			matches = append(matches, match)
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].ExcessScore != matches[j].ExcessScore {
+			return matches[i].ExcessScore < matches[j].ExcessScore
+		}
+		return matches[i].Role.Name < matches[j].Role.Name
+	})
+
+	comparison := BuiltInRoleComparison{}
+	for _, match := range matches {
+		if len(match.CoveredActions) == len(actions) && len(match.CoveredDataActions) == len(dataActions) {
+			comparison.SingleRoles = append(comparison.SingleRoles, match)
+		}
+	}
+
+	comparison.Cover = getGreedyRoleCover(matches)
+
+	covered := map[string]bool{}
+	for _, match := range matches {
+		for _, permission := range match.CoveredActions {
+			covered["a:"+permission] = true
+		}
+		for _, permission := range match.CoveredDataActions {
+			covered["d:"+permission] = true
+		}
+	}
+	for _, action := range actions {
+		if !covered["a:"+action] {
+			comparison.UncoveredActions = append(comparison.UncoveredActions, action)
+		}
+	}
+	for _, dataAction := range dataActions {
+		if !covered["d:"+dataAction] {
+			comparison.UncoveredDataActions = append(comparison.UncoveredDataActions, dataAction)
+		}
+	}
+	return comparison
+}
+
+func getBuiltInRoleMatch(role RoleDefinition, actions []string, dataActions []string) BuiltInRoleMatch {
+	match := BuiltInRoleMatch{Role: role}
+	for _, action := range actions {
+		if role.GrantsAction(action) {
+			match.CoveredActions = append(match.CoveredActions, action)
+		}
+	}
+	for _, dataAction := range dataActions {
+		if role.GrantsDataAction(dataAction) {
+			match.CoveredDataActions = append(match.CoveredDataActions, dataAction)
+		}
+	}
+
+	for _, p := range role.Permissions {
+		for _, pattern := range p.Actions {
+			if isExcessPattern(pattern, actions) {
+				match.ExcessActions = append(match.ExcessActions, pattern)
+				match.ExcessScore += getPatternBreadth(pattern)
+			}
+		}
+		for _, pattern := range p.DataActions {
+			if isExcessPattern(pattern, dataActions) {
+				match.ExcessDataActions = append(match.ExcessDataActions, pattern)
+				match.ExcessScore += getPatternBreadth(pattern)
+			}
+		}
+	}
+	return match
+}
+
+// isExcessPattern returns true if the pattern of a role is a wildcard or is not a required permission
+func isExcessPattern(pattern string, required []string) bool {
+	if strings.Contains(pattern, "*") {
+		return !slices.ContainsFunc(required, func(permission string) bool { return strings.EqualFold(permission, pattern) })
+	}
+	return !slices.ContainsFunc(required, func(permission string) bool { return actionMatchesPattern(pattern, permission) })
+}
+
+// getPatternBreadth weighs a pattern by the number of leading segments before its first wildcard:
+// 1000 for *, 100 for a provider wildcard like Microsoft.Storage/*, 10 for narrower wildcards and 1 for an action.
+// Wildcards granting only read actions, e.g. */read, weigh a tenth.
+func getPatternBreadth(pattern string) int {
+	idx := strings.Index(pattern, "*")
+	if idx < 0 {
+		return 1
+	}
+
+	breadth := 10
+	switch strings.Count(pattern[:idx], "/") {
+	case 0:
+		breadth = 1000
+	case 1:
+		breadth = 100
+	}
+	if strings.HasSuffix(strings.ToLower(pattern), "/read") {
+		breadth /= 10
+	}
+	return breadth
+}
+
+// getGreedyRoleCover returns roles jointly covering the permissions covered by the matches,
+// preferring roles with the least excess per newly covered permission
+func getGreedyRoleCover(matches []BuiltInRoleMatch) []BuiltInRoleMatch {
+	covered := map[string]bool{}
+	getPermissionKeys := func(match BuiltInRoleMatch) []string {
+		keys := make([]string, 0, len(match.CoveredActions)+len(match.CoveredDataActions))
+		for _, permission := range match.CoveredActions {
+			keys = append(keys, "a:"+permission)
+		}
+		for _, permission := range match.CoveredDataActions {
+			keys = append(keys, "d:"+permission)
+		}
+		return keys
+	}
+	countUncovered := func(match BuiltInRoleMatch) int {
+		count := 0
+		for _, key := range getPermissionKeys(match) {
+			if !covered[key] {
+				count++
+			}
+		}
+		return count
+	}
+
+	var cover []BuiltInRoleMatch
+	for {
+		best := -1
+		var bestCost float64
+		for i, match := range matches {
+			newlyCovered := countUncovered(match)
+			if newlyCovered == 0 {
+				continue
+			}
+			// matches are sorted by excess, so ties keep the narrower role
+			cost := float64(match.ExcessScore+1) / float64(newlyCovered)
+			if best < 0 || cost < bestCost {
+				best, bestCost = i, cost
+			}
+		}
+		if best < 0 {
+			break
+		}
+		for _, key := range getPermissionKeys(matches[best]) {
+			covered[key] = true
+		}
+		cover = append(cover, matches[best])
+	}
+
+	// drop roles whose permissions are covered by the other roles of the cover
+	for i := 0; i < len(cover); {
+		others := map[string]bool{}
+		for j, match := range cover {
+			if j == i {
+				continue
+			}
+			for _, key := range getPermissionKeys(match) {
+				others[key] = true
+			}
+		}
+		if !slices.ContainsFunc(getPermissionKeys(cover[i]), func(key string) bool { return !others[key] }) {
+			cover = slices.Delete(cover, i, i+1)
+			continue
+		}
+		i++
+	}
+	return cover
+}
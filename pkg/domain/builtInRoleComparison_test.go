@@ -0,0 +1,126 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testBuiltInRoles = []RoleDefinition{
+	{
+		Name:        "Owner",
+		Permissions: []RolePermission{{Actions: []string{"*"}}},
+	},
+	{
+		Name: "Contributor",
+		Permissions: []RolePermission{{
+			Actions:    []string{"*"},
+			NotActions: []string{"Microsoft.Authorization/*/Delete", "Microsoft.Authorization/*/Write", "Microsoft.Authorization/elevateAccess/Action"},
+		}},
+	},
+	{
+		Name:        "Reader",
+		Permissions: []RolePermission{{Actions: []string{"*/read"}}},
+	},
+	{
+		Name: "Storage Account Contributor",
+		Permissions: []RolePermission{{
+			Actions: []string{"Microsoft.Authorization/*/read", "Microsoft.Resources/deployments/*", "Microsoft.Storage/storageAccounts/*"},
+		}},
+	},
+	{
+		Name: "Key Vault Secrets User",
+		Permissions: []RolePermission{{
+			DataActions: []string{"Microsoft.KeyVault/vaults/secrets/getSecret/action", "Microsoft.KeyVault/vaults/secrets/readMetadata/action"},
+		}},
+	},
+}
+
+func TestBuiltInRoleGrantsAction(t *testing.T) {
+	contributor := testBuiltInRoles[1]
+	assert.True(t, contributor.GrantsAction("Microsoft.Storage/storageAccounts/write"))
+	assert.True(t, contributor.GrantsAction("Microsoft.Authorization/roleAssignments/read"))
+	assert.False(t, contributor.GrantsAction("microsoft.authorization/roleassignments/write"))
+	assert.False(t, contributor.GrantsAction("Microsoft.Authorization/elevateAccess/action"))
+	assert.False(t, contributor.GrantsDataAction("Microsoft.KeyVault/vaults/secrets/getSecret/action"))
+
+	// a wildcard is only granted if no not action overlaps it
+	assert.True(t, contributor.GrantsAction("Microsoft.Storage/*"))
+	assert.False(t, contributor.GrantsAction("Microsoft.Authorization/*"))
+
+	reader := testBuiltInRoles[2]
+	assert.True(t, reader.GrantsAction("Microsoft.Storage/storageAccounts/read"))
+	assert.False(t, reader.GrantsAction("Microsoft.Storage/storageAccounts/write"))
+}
+
+func TestCompareWithBuiltInRoles(t *testing.T) {
+	comparison := CompareWithBuiltInRoles(
+		[]string{"Microsoft.Storage/storageAccounts/write", "Microsoft.Storage/storageAccounts/read", "Microsoft.Resources/deployments/write"},
+		nil,
+		testBuiltInRoles,
+	)
+
+	var singleRoles []string
+	for _, match := range comparison.SingleRoles {
+		singleRoles = append(singleRoles, match.Role.Name)
+	}
+	assert.Equal(t, []string{"Storage Account Contributor", "Contributor", "Owner"}, singleRoles)
+	assert.Equal(t, []string{"Microsoft.Authorization/*/read", "Microsoft.Resources/deployments/*", "Microsoft.Storage/storageAccounts/*"}, comparison.SingleRoles[0].ExcessActions)
+
+	assert.Len(t, comparison.Cover, 1)
+	assert.Equal(t, "Storage Account Contributor", comparison.Cover[0].Role.Name)
+	assert.Empty(t, comparison.UncoveredActions)
+}
+
+func TestCompareWithBuiltInRolesCombinesRoles(t *testing.T) {
+	comparison := CompareWithBuiltInRoles(
+		[]string{"Microsoft.Storage/storageAccounts/write", "Microsoft.Network/virtualNetworks/read"},
+		[]string{"Microsoft.KeyVault/vaults/secrets/getSecret/action", "Microsoft.Storage/storageAccounts/blobServices/containers/blobs/read"},
+		testBuiltInRoles,
+	)
+
+	assert.Empty(t, comparison.SingleRoles)
+
+	var cover []string
+	for _, match := range comparison.Cover {
+		cover = append(cover, match.Role.Name)
+	}
+	assert.ElementsMatch(t, []string{"Key Vault Secrets User", "Reader", "Storage Account Contributor"}, cover)
+	assert.Empty(t, comparison.UncoveredActions)
+	assert.Equal(t, []string{"Microsoft.Storage/storageAccounts/blobServices/containers/blobs/read"}, comparison.UncoveredDataActions)
+}
+
+func TestCompareWithBuiltInRolesReportsUncoveredActions(t *testing.T) {
+	comparison := CompareWithBuiltInRoles(
+		[]string{"Microsoft.Authorization/roleAssignments/write", "Microsoft.Storage/storageAccounts/write"},
+		nil,
+		testBuiltInRoles[1:],
+	)
+
+	assert.Empty(t, comparison.SingleRoles)
+	assert.Equal(t, []string{"Microsoft.Authorization/roleAssignments/write"}, comparison.UncoveredActions)
+	assert.Len(t, comparison.Cover, 1)
+	assert.Equal(t, "Storage Account Contributor", comparison.Cover[0].Role.Name)
+}
@@ -0,0 +1,120 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Names of the Azure clouds supported by MPF
+const (
+	AzurePublicCloudName       = "AzurePublic"
+	AzureUSGovernmentCloudName = "AzureUSGovernment"
+	AzureChinaCloudName        = "AzureChina"
+	// CustomCloudName is a cloud with custom endpoints, e.g. Azure Stack Hub
+	CustomCloudName = "custom"
+)
+
+// CloudNames lists the values accepted by GetCloud
+var CloudNames = []string{AzurePublicCloudName, AzureUSGovernmentCloudName, AzureChinaCloudName, CustomCloudName}
+
+// Cloud holds the endpoints of the Azure cloud MPF runs against
+type Cloud struct {
+	Name string
+	// ResourceManagerEndpoint is the Azure Resource Manager endpoint, e.g. https://management.azure.com
+	ResourceManagerEndpoint string
+	// ResourceManagerAudience is the audience of Azure Resource Manager access tokens
+	ResourceManagerAudience string
+	// ActiveDirectoryAuthorityHost is the Microsoft Entra ID authority host, e.g. https://login.microsoftonline.com/
+	ActiveDirectoryAuthorityHost string
+	// TerraformEnvironment is the ARM_ENVIRONMENT of the Terraform azurerm provider.
+	// It is empty for custom clouds, whose endpoints the provider reads from the Resource Manager metadata endpoint.
+	TerraformEnvironment string
+}
+
+var (
+	AzurePublicCloud = Cloud{
+		Name:                         AzurePublicCloudName,
+		ResourceManagerEndpoint:      "https://management.azure.com",
+		ResourceManagerAudience:      "https://management.core.windows.net/",
+		ActiveDirectoryAuthorityHost: "https://login.microsoftonline.com/",
+		TerraformEnvironment:         "public",
+	}
+	AzureUSGovernmentCloud = Cloud{
+		Name:                         AzureUSGovernmentCloudName,
+		ResourceManagerEndpoint:      "https://management.usgovcloudapi.net",
+		ResourceManagerAudience:      "https://management.core.usgovcloudapi.net",
+		ActiveDirectoryAuthorityHost: "https://login.microsoftonline.us/",
+		TerraformEnvironment:         "usgovernment",
+	}
+	AzureChinaCloud = Cloud{
+		Name:                         AzureChinaCloudName,
+		ResourceManagerEndpoint:      "https://management.chinacloudapi.cn",
+		ResourceManagerAudience:      "https://management.core.chinacloudapi.cn",
+		ActiveDirectoryAuthorityHost: "https://login.chinacloudapi.cn/",
+		TerraformEnvironment:         "china",
+	}
+)
+
+// GetCloud returns the cloud with the given name, with the non-empty overrides applied.
+// The name is case insensitive. A custom cloud requires the Resource Manager endpoint and the authority host.
+func GetCloud(name string, overrides Cloud) (Cloud, error) {
+	var cloud Cloud
+	switch strings.ToLower(name) {
+	case "", strings.ToLower(AzurePublicCloudName):
+		cloud = AzurePublicCloud
+	case strings.ToLower(AzureUSGovernmentCloudName):
+		cloud = AzureUSGovernmentCloud
+	case strings.ToLower(AzureChinaCloudName):
+		cloud = AzureChinaCloud
+	case CustomCloudName:
+		if overrides.ResourceManagerEndpoint == "" || overrides.ActiveDirectoryAuthorityHost == "" {
+			return Cloud{}, fmt.Errorf("the %s cloud requires the Resource Manager endpoint and the Active Directory authority host", CustomCloudName)
+		}
+		cloud = Cloud{Name: CustomCloudName}
+	default:
+		return Cloud{}, fmt.Errorf("invalid cloud %q, must be one of: %s", name, strings.Join(CloudNames, ", "))
+	}
+
+	if overrides.ResourceManagerEndpoint != "" {
+		cloud.ResourceManagerEndpoint = strings.TrimSuffix(overrides.ResourceManagerEndpoint, "/")
+		// tokens for a custom endpoint are requested for the endpoint, unless the audience is overridden as well
+		cloud.ResourceManagerAudience = cloud.ResourceManagerEndpoint
+	}
+	if overrides.ResourceManagerAudience != "" {
+		cloud.ResourceManagerAudience = overrides.ResourceManagerAudience
+	}
+	if overrides.ActiveDirectoryAuthorityHost != "" {
+		cloud.ActiveDirectoryAuthorityHost = overrides.ActiveDirectoryAuthorityHost
+	}
+	if overrides.TerraformEnvironment != "" {
+		cloud.TerraformEnvironment = overrides.TerraformEnvironment
+	}
+	return cloud, nil
+}
+
+// IsAzurePublicCloud returns true if the cloud is the Azure public cloud without endpoint overrides
+func (c Cloud) IsAzurePublicCloud() bool {
+	return c == Cloud{} || c == AzurePublicCloud
+}
@@ -0,0 +1,70 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetCloud(t *testing.T) {
+	cloud, err := GetCloud("", Cloud{})
+	assert.NoError(t, err)
+	assert.Equal(t, AzurePublicCloud, cloud)
+	assert.True(t, cloud.IsAzurePublicCloud())
+
+	cloud, err = GetCloud("azureusgovernment", Cloud{})
+	assert.NoError(t, err)
+	assert.Equal(t, AzureUSGovernmentCloud, cloud)
+	assert.False(t, cloud.IsAzurePublicCloud())
+
+	cloud, err = GetCloud(AzureChinaCloudName, Cloud{})
+	assert.NoError(t, err)
+	assert.Equal(t, "china", cloud.TerraformEnvironment)
+
+	_, err = GetCloud("AzureGermany", Cloud{})
+	assert.ErrorContains(t, err, "invalid cloud")
+}
+
+func TestGetCloudOverrides(t *testing.T) {
+	cloud, err := GetCloud(AzurePublicCloudName, Cloud{ResourceManagerEndpoint: "https://localhost:8443/"})
+	assert.NoError(t, err)
+	assert.Equal(t, "https://localhost:8443", cloud.ResourceManagerEndpoint)
+	assert.Equal(t, "https://localhost:8443", cloud.ResourceManagerAudience)
+	assert.Equal(t, AzurePublicCloud.ActiveDirectoryAuthorityHost, cloud.ActiveDirectoryAuthorityHost)
+	assert.False(t, cloud.IsAzurePublicCloud())
+
+	_, err = GetCloud(CustomCloudName, Cloud{ResourceManagerEndpoint: "https://management.local.azurestack.external"})
+	assert.ErrorContains(t, err, "authority host")
+
+	cloud, err = GetCloud("Custom", Cloud{
+		ResourceManagerEndpoint:      "https://management.local.azurestack.external",
+		ResourceManagerAudience:      "https://management.adfs.azurestack.local/",
+		ActiveDirectoryAuthorityHost: "https://adfs.local.azurestack.external/",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, CustomCloudName, cloud.Name)
+	assert.Equal(t, "https://management.adfs.azurestack.local/", cloud.ResourceManagerAudience)
+	assert.Empty(t, cloud.TerraformEnvironment)
+}
@@ -0,0 +1,130 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package domain
+
+import "strings"
+
+// dataActionPatterns are the data plane operations MPF knows about. Data actions have to be added to the
+// dataActions of a role definition, adding them to the actions is rejected by Azure.
+// A '*' in a pattern matches any sequence of characters.
+var dataActionPatterns = []string{
+	// Storage
+	"Microsoft.Storage/storageAccounts/blobServices/containers/blobs/*",
+	"Microsoft.Storage/storageAccounts/queueServices/queues/messages/*",
+	"Microsoft.Storage/storageAccounts/fileServices/fileshares/files/*",
+	"Microsoft.Storage/storageAccounts/fileServices/readFileBackupSemantics/action",
+	"Microsoft.Storage/storageAccounts/fileServices/writeFileBackupSemantics/action",
+	"Microsoft.Storage/storageAccounts/tableServices/tables/entities/*",
+	// Key Vault
+	"Microsoft.KeyVault/vaults/secrets/*/action",
+	"Microsoft.KeyVault/vaults/secrets/delete",
+	"Microsoft.KeyVault/vaults/keys/*/action",
+	"Microsoft.KeyVault/vaults/keys/delete",
+	"Microsoft.KeyVault/vaults/certificates/*",
+	"Microsoft.KeyVault/vaults/certificatecas/*",
+	"Microsoft.KeyVault/vaults/certificatecontacts/*",
+	// App Configuration
+	"Microsoft.AppConfiguration/configurationStores/keyValues/*",
+	// Messaging
+	"Microsoft.ServiceBus/namespaces/messages/*",
+	"Microsoft.EventHub/namespaces/messages/*",
+	"Microsoft.EventGrid/topics/events/send/action",
+	"Microsoft.EventGrid/domains/events/send/action",
+	// Cosmos DB
+	"Microsoft.DocumentDB/databaseAccounts/readMetadata",
+	"Microsoft.DocumentDB/databaseAccounts/sqlDatabases/containers/items/*",
+	"Microsoft.DocumentDB/databaseAccounts/sqlDatabases/containers/executeQuery",
+	"Microsoft.DocumentDB/databaseAccounts/sqlDatabases/containers/readChangeFeed",
+	"Microsoft.DocumentDB/databaseAccounts/sqlDatabases/containers/executeStoredProcedure",
+	"Microsoft.DocumentDB/databaseAccounts/sqlDatabases/containers/manageConflicts",
+	// Container Registry
+	"Microsoft.ContainerRegistry/registries/repositories/*",
+	// Cognitive Services
+	"Microsoft.CognitiveServices/accounts/OpenAI/*",
+}
+
+// IsDataAction returns true if the action is a data plane operation, e.g.
+// Microsoft.Storage/storageAccounts/blobServices/containers/blobs/read
+func IsDataAction(action string) bool {
+	for _, pattern := range dataActionPatterns {
+		if actionMatchesPattern(action, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// SplitActionsAndDataActions splits the permissions into control plane actions and data actions,
+// keeping the order of the permissions
+func SplitActionsAndDataActions(permissions []string) (actions []string, dataActions []string) {
+	for _, permission := range permissions {
+		if IsDataAction(permission) {
+			dataActions = append(dataActions, permission)
+			continue
+		}
+		actions = append(actions, permission)
+	}
+	return actions, dataActions
+}
+
+// SplitScopePermissions splits a scope to permissions map into a map of control plane actions and a map of data actions.
+// Every scope is kept in the actions map, scopes without data actions are left out of the data actions map.
+func SplitScopePermissions(scopePermissions map[string][]string) (actions map[string][]string, dataActions map[string][]string) {
+	actions = make(map[string][]string)
+	dataActions = make(map[string][]string)
+	for scope, permissions := range scopePermissions {
+		scopeActions, scopeDataActions := SplitActionsAndDataActions(permissions)
+		actions[scope] = scopeActions
+		if len(scopeDataActions) > 0 {
+			dataActions[scope] = scopeDataActions
+		}
+	}
+	return actions, dataActions
+}
+
+// actionMatchesPattern returns true if the action matches the pattern, ignoring case.
+// A '*' in the pattern matches any sequence of characters, as in role definition actions.
+func actionMatchesPattern(action string, pattern string) bool {
+	action = strings.ToLower(action)
+	parts := strings.Split(strings.ToLower(pattern), "*")
+
+	if len(parts) == 1 {
+		return action == parts[0]
+	}
+
+	if !strings.HasPrefix(action, parts[0]) {
+		return false
+	}
+	action = action[len(parts[0]):]
+
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		idx := strings.Index(action, part)
+		if idx < 0 {
+			return false
+		}
+		action = action[idx+len(part):]
+	}
+
+	return len(action) >= len(last) && strings.HasSuffix(action, last)
+}
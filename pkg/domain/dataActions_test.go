@@ -0,0 +1,95 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsDataAction(t *testing.T) {
+	tests := []struct {
+		action string
+		want   bool
+	}{
+		{"Microsoft.Storage/storageAccounts/blobServices/containers/blobs/read", true},
+		{"microsoft.storage/storageaccounts/blobservices/containers/blobs/write", true},
+		{"Microsoft.Storage/storageAccounts/queueServices/queues/messages/add/action", true},
+		{"Microsoft.KeyVault/vaults/secrets/getSecret/action", true},
+		{"Microsoft.KeyVault/vaults/secrets/delete", true},
+		{"Microsoft.KeyVault/vaults/certificates/create/action", true},
+		{"Microsoft.Storage/storageAccounts/blobServices/read", false},
+		{"Microsoft.Storage/storageAccounts/blobServices/containers/write", false},
+		{"Microsoft.KeyVault/vaults/secrets/write", false},
+		{"Microsoft.KeyVault/vaults/secrets/read", false},
+		{"Microsoft.KeyVault/vaults/write", false},
+		{"Microsoft.Resources/deployments/write", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.action, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsDataAction(tt.action))
+		})
+	}
+}
+
+func TestActionMatchesPattern(t *testing.T) {
+	assert.True(t, actionMatchesPattern("Microsoft.Storage/storageAccounts/read", "Microsoft.Storage/storageAccounts/read"))
+	assert.True(t, actionMatchesPattern("Microsoft.Storage/storageAccounts/read", "microsoft.storage/*"))
+	assert.True(t, actionMatchesPattern("Microsoft.Storage/storageAccounts/read", "*"))
+	assert.True(t, actionMatchesPattern("Microsoft.Storage/storageAccounts/read", "*/read"))
+	assert.True(t, actionMatchesPattern("Microsoft.KeyVault/vaults/secrets/getSecret/action", "Microsoft.KeyVault/vaults/*/action"))
+	assert.False(t, actionMatchesPattern("Microsoft.Storage/storageAccounts/write", "*/read"))
+	assert.False(t, actionMatchesPattern("Microsoft.Storage/storageAccounts/read", "Microsoft.Network/*"))
+	assert.False(t, actionMatchesPattern("Microsoft.KeyVault/vaults/action", "Microsoft.KeyVault/vaults/*/action"))
+}
+
+func TestGetMPFResultSplitsDataActions(t *testing.T) {
+	mpfResult := GetMPFResult(map[string][]string{
+		"sub": {
+			"Microsoft.Storage/storageAccounts/write",
+			"Microsoft.Storage/storageAccounts/blobServices/containers/blobs/read",
+			"Microsoft.Storage/storageAccounts/blobServices/containers/blobs/read",
+		},
+		"/subscriptions/sub/resourceGroups/rg": {
+			"Microsoft.Storage/storageAccounts/write",
+		},
+	})
+
+	assert.Equal(t, map[string][]string{
+		"sub":                                  {"Microsoft.Storage/storageAccounts/write"},
+		"/subscriptions/sub/resourceGroups/rg": {"Microsoft.Storage/storageAccounts/write"},
+	}, mpfResult.RequiredPermissions)
+	assert.Equal(t, map[string][]string{
+		"sub": {"Microsoft.Storage/storageAccounts/blobServices/containers/blobs/read"},
+	}, mpfResult.RequiredDataActions)
+}
+
+func TestGetMPFResultWithoutDataActions(t *testing.T) {
+	mpfResult := GetMPFResult(map[string][]string{
+		"sub": {"Microsoft.Storage/storageAccounts/write"},
+	})
+
+	assert.Nil(t, mpfResult.RequiredDataActions)
+}
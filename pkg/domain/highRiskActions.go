@@ -0,0 +1,51 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package domain
+
+// highRiskAction is a pattern of actions that allow privilege escalation or access to secrets
+type highRiskAction struct {
+	pattern string
+	reason  string
+}
+
+var highRiskActions = []highRiskAction{
+	{"Microsoft.Authorization/roleAssignments/write", "Can assign any role, including to itself"},
+	{"Microsoft.Authorization/roleDefinitions/write", "Can change the permissions of custom roles"},
+	{"Microsoft.Authorization/elevateAccess/action", "Can elevate to User Access Administrator at root scope"},
+	{"Microsoft.Authorization/policyExemptions/write", "Can exempt resources from Azure Policy"},
+	{"Microsoft.KeyVault/*/write", "Can change Key Vault configuration, including access policies and network rules"},
+	{"*/listKeys/action", "Can read access keys"},
+	{"*/listCredentials/action", "Can read credentials"},
+	{"Microsoft.KeyVault/vaults/secrets/getSecret/action", "Can read Key Vault secrets"},
+}
+
+// GetHighRiskActionReason returns why the action is high risk, or an empty string if it is not.
+// High risk actions allow privilege escalation or access to secrets and deserve a review.
+func GetHighRiskActionReason(action string) string {
+	for _, highRisk := range highRiskActions {
+		if actionMatchesPattern(action, highRisk.pattern) {
+			return highRisk.reason
+		}
+	}
+	return ""
+}
@@ -29,6 +29,14 @@ import (
 )
 
 func parseLinkedAccessCheckFailedError(authorizationFailedErrMsg string) (map[string][]string, error) {
+	findings, err := parseLinkedAccessCheckFailedErrorFindings(authorizationFailedErrMsg)
+	if err != nil {
+		return nil, err
+	}
+	return getScopePermissionsMap(findings), nil
+}
+
+func parseLinkedAccessCheckFailedErrorFindings(authorizationFailedErrMsg string) ([]AuthorizationFinding, error) {
 
 	log.Printf("Attempting to Parse LinkedAccessCheckFailedError Error: %s", authorizationFailedErrMsg)
 
@@ -40,27 +48,31 @@ func parseLinkedAccessCheckFailedError(authorizationFailedErrMsg string) (map[st
 		return nil, errors.New("no matches found in 'LinkedAccessCheckFailedError' error message")
 	}
 
-	scopePermissionsMap := make(map[string][]string)
+	var findings []AuthorizationFinding
 
-	// Iterate through the matches and populate the map
+	// Iterate through the matches and collect the findings
 	for _, match := range matches {
 		if len(match) == 4 {
-			// resourceType := match[1]
 			action := match[2]
 			scope := match[3]
 
-			if _, ok := scopePermissionsMap[scope]; !ok {
-				scopePermissionsMap[scope] = make([]string, 0)
-			}
-			scopePermissionsMap[scope] = append(scopePermissionsMap[scope], action)
+			findings = append(findings, AuthorizationFinding{
+				ErrorCode:    "LinkedAccessCheckFailed",
+				Action:       action,
+				Scope:        scope,
+				ResourceType: getResourceType(scope, action),
+				ObjectID:     match[1],
+				SourceParser: LinkedAccessCheckFailedParserName,
+				RawSnippet:   match[0],
+			})
 		}
 	}
 
-	// if map is empty, return error
-	if len(scopePermissionsMap) == 0 {
+	// if no findings, return error
+	if len(findings) == 0 {
 		return nil, errors.New("no scope/permissions found in LinkedAccessCheckFailedError message")
 	}
 
-	return scopePermissionsMap, nil
+	return findings, nil
 
 }
@@ -29,12 +29,18 @@ import (
 
 // For 'LinkedAuthorizationFailed' errors
 func parseLinkedAuthorizationFailedErrors(authorizationFailedErrMsg string) (map[string][]string, error) {
+	findings, err := parseLinkedAuthorizationFailedErrorFindings(authorizationFailedErrMsg)
+	if err != nil {
+		return nil, err
+	}
+	return getScopePermissionsMap(findings), nil
+}
 
-	// Regular expression to extract resource information
-	// re := regexp.MustCompile(`Authorization failed for template resource '([^']+)' of type '([^']+)'\. The client '([^']+)' with object id '([^']+)' does not have permission to perform action '([^']+)' at scope '([^']+)'\.`)
+func parseLinkedAuthorizationFailedErrorFindings(authorizationFailedErrMsg string) ([]AuthorizationFinding, error) {
 
-	// Find regular expressions to pull action and scope from error message "does not have permission to perform action(s) 'Microsoft.Network/virtualNetworks/subnets/join/action' on the linked scope(s) '/subscriptions/SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS/resourceGroups/az-mpf-tf-test-rg/providers/Microsoft.Network/virtualNetworks/vnet-32a70ccbb3247e2b/subnets/subnet-32a70ccbb3247e2b' (respectively) or the linked scope(s) are invalid".
-	re := regexp.MustCompile(`does not have permission to perform action\(s\) '([^']+)' on the linked scope\(s\) '([^']+)' \(respectively\) or the linked scope\(s\) are invalid`)
+	// Find regular expressions to pull client, object id, action and scope from error message "The client 'XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX' with object id 'XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX' has permission to perform action 'Microsoft.ContainerService/managedClusters/write' on scope '/subscriptions/SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS/resourceGroups/az-mpf-tf-test-rg/providers/Microsoft.ContainerService/managedClusters/aks-32a70ccbb3247e2b'; however, it does not have permission to perform action(s) 'Microsoft.Network/virtualNetworks/subnets/join/action' on the linked scope(s) '/subscriptions/SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS/resourceGroups/az-mpf-tf-test-rg/providers/Microsoft.Network/virtualNetworks/vnet-32a70ccbb3247e2b/subnets/subnet-32a70ccbb3247e2b' (respectively) or the linked scope(s) are invalid".
+	// The client part is optional, as only the linked action and scope are needed.
+	re := regexp.MustCompile(`(?:The client '([^']+)' with object id '([^']+)' has permission to perform action '[^']+' on scope '[^']+'; however, it )?does not have permission to perform action\(s\) '([^']+)' on the linked scope\(s\) '([^']+)' \(respectively\) or the linked scope\(s\) are invalid`)
 
 	// Find all matches in the error message
 	matches := re.FindAllStringSubmatch(authorizationFailedErrMsg, -1)
@@ -44,15 +50,13 @@ func parseLinkedAuthorizationFailedErrors(authorizationFailedErrMsg string) (map
 		return nil, errors.New("no matches found in 'Authorization failed' error message")
 	}
 
-	// Create a map to store scope/permissions
-	scopePermissionsMap := make(map[string][]string)
+	var findings []AuthorizationFinding
 
-	// Iterate through the matches and populate the map
+	// Iterate through the matches and collect the findings
 	for _, match := range matches {
-		if len(match) == 3 {
-			// resourceType := match[1]
-			action := match[1]
-			scope := match[2]
+		if len(match) == 5 {
+			action := match[3]
+			scope := match[4]
 
 			// Complete partial actions using the helper function
 			completedAction, err := completePartialAction(action, scope)
@@ -60,18 +64,24 @@ func parseLinkedAuthorizationFailedErrors(authorizationFailedErrMsg string) (map
 				return nil, err
 			}
 
-			if _, ok := scopePermissionsMap[scope]; !ok {
-				scopePermissionsMap[scope] = make([]string, 0)
-			}
-			scopePermissionsMap[scope] = append(scopePermissionsMap[scope], completedAction)
+			findings = append(findings, AuthorizationFinding{
+				ErrorCode:    "LinkedAuthorizationFailed",
+				Action:       completedAction,
+				Scope:        scope,
+				ResourceType: getResourceType(scope, completedAction),
+				ClientID:     match[1],
+				ObjectID:     match[2],
+				SourceParser: LinkedAuthorizationFailedParserName,
+				RawSnippet:   match[0],
+			})
 		}
 	}
 
-	// if map is empty, return error
-	if len(scopePermissionsMap) == 0 {
+	// if no findings, return error
+	if len(findings) == 0 {
 		return nil, errors.New("no scope/permissions found in Multi error message")
 	}
 
-	return scopePermissionsMap, nil
+	return findings, nil
 
 }
@@ -0,0 +1,66 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// MPFCheckpointVersion is the version of the checkpoint format
+const MPFCheckpointVersion = 1
+
+// MPFCheckpoint is the state of an MPF run, saved after every iteration so that an interrupted run can be resumed
+type MPFCheckpoint struct {
+	Version int
+	// Mode is the deployment type of the run, e.g. arm, bicep or terraform
+	Mode           string
+	SubscriptionID string
+	// RoleDefinitionName and RoleDefinitionID identify the temporary custom role
+	RoleDefinitionName string
+	RoleDefinitionID   string
+	// ResourceGroupName is the temporary resource group, if one is created by the run
+	ResourceGroupName string
+	Location          string
+	// DeploymentName is the ARM deployment name, if the deployment type uses one
+	DeploymentName string
+	IterationCount int
+	// RequiredPermissions holds the permissions discovered so far, keyed by scope.
+	// The subscription ID key holds all permissions.
+	RequiredPermissions map[string][]string
+	UpdatedAt           time.Time
+}
+
+// ValidateForResume returns an error if the checkpoint cannot be used to resume a run of the mode in the subscription
+func (c MPFCheckpoint) ValidateForResume(mode string, subscriptionID string) error {
+	if c.Version != MPFCheckpointVersion {
+		return fmt.Errorf("unsupported checkpoint version %d, expected %d", c.Version, MPFCheckpointVersion)
+	}
+	if c.Mode != mode {
+		return fmt.Errorf("checkpoint was created by a %s run and cannot be resumed by a %s run", c.Mode, mode)
+	}
+	if c.SubscriptionID != subscriptionID {
+		return fmt.Errorf("checkpoint was created for subscription %s and cannot be resumed in subscription %s", c.SubscriptionID, subscriptionID)
+	}
+	return nil
+}
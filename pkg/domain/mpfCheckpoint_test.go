@@ -0,0 +1,44 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMPFCheckpointValidateForResume(t *testing.T) {
+	checkpoint := MPFCheckpoint{
+		Version:        MPFCheckpointVersion,
+		Mode:           "arm",
+		SubscriptionID: "SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS",
+	}
+
+	assert.NoError(t, checkpoint.ValidateForResume("arm", "SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS"))
+	assert.ErrorContains(t, checkpoint.ValidateForResume("terraform", "SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS"), "arm run")
+	assert.ErrorContains(t, checkpoint.ValidateForResume("arm", "TTTTTTTT-TTTT-TTTT-TTTT-TTTTTTTTTTTT"), "subscription")
+
+	checkpoint.Version = 0
+	assert.ErrorContains(t, checkpoint.ValidateForResume("arm", "SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS"), "version")
+}
@@ -47,25 +47,37 @@ type MPFConfig struct {
 	TenantID       string
 	SP             ServicePrincipal
 	Role           Role
+	// Cloud is the Azure cloud MPF runs against. The zero value is the Azure public cloud.
+	Cloud Cloud
 }
 
 type MPFResult struct {
 	// The map from which the minimum permissions will be calculated
 	RequiredPermissions map[string][]string
+	// RequiredDataActions holds the data plane permissions, keyed by scope like RequiredPermissions.
+	// These need to be added to the dataActions of a role definition.
+	RequiredDataActions map[string][]string `json:",omitempty"`
 	// IterationCount is the number of iterations MPF took to discover all permissions.
 	// A value of 0 means all required permissions were provided upfront via initialPermissions.
 	IterationCount int
+	// InvalidActions are the actions Azure rejected as invalid, which were removed from the custom role
+	InvalidActions []string `json:",omitempty"`
 }
 
 func GetMPFResult(requiredPermissions map[string][]string) MPFResult {
-	return MPFResult{
-		RequiredPermissions: getMapWithUniqueValues(requiredPermissions),
-	}
+	return GetMPFResultWithIterationCount(requiredPermissions, 0)
 }
 
+// GetMPFResultWithIterationCount returns the MPF result for the required permissions,
+// with the data actions moved from RequiredPermissions to RequiredDataActions
 func GetMPFResultWithIterationCount(requiredPermissions map[string][]string, iterationCount int) MPFResult {
-	return MPFResult{
-		RequiredPermissions: getMapWithUniqueValues(requiredPermissions),
+	actions, dataActions := SplitScopePermissions(requiredPermissions)
+	mpfResult := MPFResult{
+		RequiredPermissions: getMapWithUniqueValues(actions),
 		IterationCount:      iterationCount,
 	}
+	if len(dataActions) > 0 {
+		mpfResult.RequiredDataActions = getMapWithUniqueValues(dataActions)
+	}
+	return mpfResult
 }
@@ -0,0 +1,61 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package domain
+
+import "time"
+
+// JournalOutcome describes how an iteration of an MPF run ended
+type JournalOutcome string
+
+const (
+	// JournalRoleInitialized is recorded once the custom role is created with the initial permissions
+	JournalRoleInitialized JournalOutcome = "RoleInitialized"
+	// JournalAuthorizationErrors is recorded when the deployment failed with authorization errors and the missing permissions were added to the role
+	JournalAuthorizationErrors JournalOutcome = "AuthorizationErrors"
+	// JournalRetry is recorded when the deployment authorization checker asked for the deployment to be retried
+	JournalRetry JournalOutcome = "Retry"
+	// JournalSucceeded is recorded when the deployment succeeded without authorization errors
+	JournalSucceeded JournalOutcome = "Succeeded"
+	// JournalFailed is recorded when the iteration failed with an error which ended the run
+	JournalFailed JournalOutcome = "Failed"
+)
+
+// MPFJournalEntry is the record of a single iteration of an MPF run.
+// The journal gives the evidence for every permission in the final role.
+type MPFJournalEntry struct {
+	Iteration int
+	Timestamp time.Time
+	Outcome   JournalOutcome
+	// AuthorizationError is the authorization error message returned for the deployment
+	AuthorizationError string `json:",omitempty"`
+	// Findings are the missing permissions parsed from AuthorizationError
+	Findings []AuthorizationFinding `json:",omitempty"`
+	// PermissionsAdded are the permissions per scope which were added to the custom role in this iteration
+	PermissionsAdded map[string][]string `json:",omitempty"`
+	// InvalidActions are the actions removed from the custom role as Azure does not recognise them
+	InvalidActions []string `json:",omitempty"`
+	// WaitSeconds is the time spent waiting for the role update to propagate
+	WaitSeconds float64
+	// Error is the error which ended the run, if Outcome is JournalFailed
+	Error string `json:",omitempty"`
+}
@@ -0,0 +1,112 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package domain
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// MPFRunMetadata describes the run an MPF result was produced by
+type MPFRunMetadata struct {
+	// RunID identifies the invocation. It is stamped onto the resources created by the run.
+	RunID string
+	// User is the user who invoked the run
+	User string
+	// Version is the azmpf version
+	Version string
+	// DeploymentType is the command of the run, e.g. arm, bicep, terraform, parse or replay
+	DeploymentType string
+	// TemplatePath is the ARM template, the Bicep file, the Terraform working directory, or the input of parse and replay
+	TemplatePath string
+	// ParametersPath is the ARM or Bicep parameters file, or the Terraform variables file
+	ParametersPath string
+	// TargetModule is the targeted Terraform module
+	TargetModule string
+	StartTime    time.Time
+	EndTime      time.Time
+}
+
+// Duration returns the duration of the run, or 0 if it has not ended
+func (m MPFRunMetadata) Duration() time.Duration {
+	if m.StartTime.IsZero() || m.EndTime.IsZero() {
+		return 0
+	}
+	return m.EndTime.Sub(m.StartTime)
+}
+
+// TemplateIdentity identifies the template of the run by the names of its files, without their directories
+func (m MPFRunMetadata) TemplateIdentity() string {
+	if m.TemplatePath == "" {
+		return ""
+	}
+	identity := filepath.Base(m.TemplatePath)
+	if m.ParametersPath != "" {
+		identity += ", " + filepath.Base(m.ParametersPath)
+	}
+	if m.TargetModule != "" {
+		identity += ", " + m.TargetModule
+	}
+	return identity
+}
+
+// Tags returns the tags stamped onto the resource group and deployment created by the run,
+// so that resources left behind can be attributed to the run
+func (m MPFRunMetadata) Tags() map[string]string {
+	tags := GetMPFResourceGroupTags(m.StartTime)
+	addTag := func(name, value string) {
+		if value == "" {
+			return
+		}
+		if len(value) > maxTagValueLength {
+			value = value[:maxTagValueLength]
+		}
+		tags[name] = value
+	}
+	addTag(MPFRunIDTagName, m.RunID)
+	addTag(MPFVersionTagName, m.Version)
+	addTag(MPFUserTagName, m.User)
+	addTag(MPFTemplateTagName, m.TemplateIdentity())
+	return tags
+}
+
+// RoleDescription returns the description of the custom role created by the run
+func (m MPFRunMetadata) RoleDescription() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Temporary role of azmpf run %s, created %s", m.RunID, m.StartTime.UTC().Format(time.RFC3339))
+	if m.User != "" {
+		fmt.Fprintf(&sb, " by %s", m.User)
+	}
+	if m.Version != "" {
+		fmt.Fprintf(&sb, " with azmpf %s", m.Version)
+	}
+	if m.DeploymentType != "" {
+		fmt.Fprintf(&sb, " for %s", m.DeploymentType)
+	}
+	if templateIdentity := m.TemplateIdentity(); templateIdentity != "" {
+		fmt.Fprintf(&sb, " %s", templateIdentity)
+	}
+	return sb.String()
+}
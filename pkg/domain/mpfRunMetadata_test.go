@@ -0,0 +1,68 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package domain
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testRunMetadata = MPFRunMetadata{
+	RunID:          "8f14e45f-ceea-467a-9af0-fd5bd2b2d1a4",
+	User:           "pipeline",
+	Version:        "v1.2.3",
+	DeploymentType: "arm",
+	TemplatePath:   "/home/pipeline/templates/storage.json",
+	ParametersPath: "/home/pipeline/templates/storage.parameters.json",
+	StartTime:      time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+}
+
+func TestMPFRunMetadataTags(t *testing.T) {
+	assert.Equal(t, map[string]string{
+		MPFCreatedByTagName: MPFCreatedByTagValue,
+		MPFCreatedAtTagName: "2024-01-01T12:00:00Z",
+		MPFRunIDTagName:     "8f14e45f-ceea-467a-9af0-fd5bd2b2d1a4",
+		MPFVersionTagName:   "v1.2.3",
+		MPFUserTagName:      "pipeline",
+		MPFTemplateTagName:  "storage.json, storage.parameters.json",
+	}, testRunMetadata.Tags())
+
+	// unknown values are not tagged, and values are cut to the maximum length of tag values
+	metadata := MPFRunMetadata{RunID: strings.Repeat("a", 300), StartTime: testRunMetadata.StartTime}
+	tags := metadata.Tags()
+	assert.Len(t, tags[MPFRunIDTagName], 256)
+	assert.NotContains(t, tags, MPFUserTagName)
+	assert.NotContains(t, tags, MPFTemplateTagName)
+}
+
+func TestMPFRunMetadataTemplateIdentity(t *testing.T) {
+	assert.Equal(t, "infra, module.storage", MPFRunMetadata{TemplatePath: "/src/infra", TargetModule: "module.storage"}.TemplateIdentity())
+	assert.Empty(t, MPFRunMetadata{}.TemplateIdentity())
+}
+
+func TestMPFRunMetadataRoleDescription(t *testing.T) {
+	assert.Equal(t, "Temporary role of azmpf run 8f14e45f-ceea-467a-9af0-fd5bd2b2d1a4, created 2024-01-01T12:00:00Z by pipeline with azmpf v1.2.3 for arm storage.json, storage.parameters.json", testRunMetadata.RoleDescription())
+}
@@ -0,0 +1,169 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package domain
+
+import (
+	"sort"
+	"strings"
+)
+
+// OperationsCatalog holds the operations of the resource providers, used to validate and normalize actions without calling Azure.
+// The catalog may cover a subset of the resource types: actions on resource types it does not know are not validated.
+type OperationsCatalog struct {
+	// operations maps the lower case operation names to the names with the casing of the provider
+	operations map[string]string
+	// resourceTypes are the lower case resource types with operations in the catalog
+	resourceTypes map[string]bool
+	// providers are the lower case providers with operations in the catalog
+	providers map[string]bool
+}
+
+// NewOperationsCatalog returns a catalog of the operations, e.g. Microsoft.Storage/storageAccounts/write
+func NewOperationsCatalog(operations []string) *OperationsCatalog {
+	c := &OperationsCatalog{
+		operations:    make(map[string]string, len(operations)),
+		resourceTypes: map[string]bool{},
+		providers:     map[string]bool{},
+	}
+	for _, operation := range operations {
+		key := strings.ToLower(operation)
+		if _, ok := c.operations[key]; ok {
+			continue
+		}
+		c.operations[key] = operation
+		if resourceType := GetResourceTypeFromAction(key); resourceType != "" {
+			c.resourceTypes[resourceType] = true
+		}
+		c.providers[getProviderFromAction(key)] = true
+	}
+	return c
+}
+
+// Len returns the number of operations in the catalog
+func (c *OperationsCatalog) Len() int {
+	return len(c.operations)
+}
+
+// NormalizeAction returns the action with the casing of the provider operation.
+// Actions not in the catalog, including wildcards, are returned unchanged.
+func (c *OperationsCatalog) NormalizeAction(action string) string {
+	if operation, ok := c.operations[strings.ToLower(action)]; ok {
+		return operation
+	}
+	return action
+}
+
+// IsInvalidAction returns true if the catalog knows the resource type of the action, but not the action.
+// A wildcard is invalid if the catalog knows its provider, but the wildcard matches no operation.
+// The actions can be actions or data actions.
+func (c *OperationsCatalog) IsInvalidAction(action string) bool {
+	key := strings.ToLower(action)
+	if strings.Contains(key, "*") {
+		if !c.providers[getProviderFromAction(key)] {
+			return false
+		}
+		for operation := range c.operations {
+			if actionMatchesPattern(operation, key) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if _, ok := c.operations[key]; ok {
+		return false
+	}
+	return c.resourceTypes[GetResourceTypeFromAction(key)]
+}
+
+// SuggestAction returns the operation on the resource type of the invalid action closest to it, e.g. to point out a typo.
+// An empty string is returned if there is no similar operation.
+func (c *OperationsCatalog) SuggestAction(action string) string {
+	key := strings.ToLower(action)
+	resourceType := GetResourceTypeFromAction(key)
+
+	suggestion := ""
+	// only operations a few edits away are considered similar, allowing a few more for longer actions
+	bestDistance := len(key)/10 + 2
+	for operationKey, operation := range c.operations {
+		if GetResourceTypeFromAction(operationKey) != resourceType && getProviderFromAction(operationKey) != getProviderFromAction(key) {
+			continue
+		}
+		distance := getEditDistance(key, operationKey)
+		if distance < bestDistance || (distance == bestDistance && suggestion != "" && operation < suggestion) {
+			suggestion, bestDistance = operation, distance
+		}
+	}
+	return suggestion
+}
+
+// GetResourceTypeOperations returns the operations on the resource type and its child types, sorted
+func (c *OperationsCatalog) GetResourceTypeOperations(resourceType string) []string {
+	prefix := strings.ToLower(resourceType) + "/"
+	var operations []string
+	for key, operation := range c.operations {
+		if strings.HasPrefix(key, prefix) {
+			operations = append(operations, operation)
+		}
+	}
+	sort.Strings(operations)
+	return operations
+}
+
+// ValidateActions returns the actions normalized with the catalog, and the invalid actions
+func (c *OperationsCatalog) ValidateActions(actions []string) (normalized []string, invalid []string) {
+	for _, action := range actions {
+		if c.IsInvalidAction(action) {
+			invalid = append(invalid, action)
+			continue
+		}
+		normalized = append(normalized, c.NormalizeAction(action))
+	}
+	return normalized, invalid
+}
+
+func getProviderFromAction(action string) string {
+	provider, _, _ := strings.Cut(action, "/")
+	return provider
+}
+
+// getEditDistance returns the Levenshtein distance of the strings
+func getEditDistance(a string, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
@@ -0,0 +1,106 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func getTestOperationsCatalog() *OperationsCatalog {
+	return NewOperationsCatalog([]string{
+		"Microsoft.Storage/storageAccounts/read",
+		"Microsoft.Storage/storageAccounts/write",
+		"Microsoft.Storage/storageAccounts/delete",
+		"Microsoft.Storage/storageAccounts/listKeys/action",
+		"Microsoft.KeyVault/vaults/accessPolicies/write",
+	})
+}
+
+func TestOperationsCatalogNormalizeAction(t *testing.T) {
+	catalog := getTestOperationsCatalog()
+
+	assert.Equal(t, 5, catalog.Len())
+	assert.Equal(t, "Microsoft.Storage/storageAccounts/listKeys/action", catalog.NormalizeAction("microsoft.storage/storageaccounts/listkeys/action"))
+	assert.Equal(t, "Microsoft.Web/sites/write", catalog.NormalizeAction("Microsoft.Web/sites/write"))
+	assert.Equal(t, "microsoft.storage/*", catalog.NormalizeAction("microsoft.storage/*"))
+}
+
+func TestOperationsCatalogIsInvalidAction(t *testing.T) {
+	catalog := getTestOperationsCatalog()
+
+	tests := []struct {
+		action  string
+		invalid bool
+	}{
+		{"Microsoft.Storage/storageAccounts/write", false},
+		{"MICROSOFT.STORAGE/STORAGEACCOUNTS/WRITE", false},
+		{"Microsoft.Storage/storageAccounts/wirte", true},
+		{"Microsoft.KeyVault/vaults/accessPolicies/read", true},
+		// unknown resource types and providers may be missing from the snapshot
+		{"Microsoft.Storage/storageAccounts/blobServices/read", false},
+		{"Microsoft.Web/sites/write", false},
+		{"Microsoft.Storage/*", false},
+		{"Microsoft.Storage/storageAccounts/*/action", false},
+		{"Microsoft.Storage/disks/*", true},
+		{"Microsoft.Web/*", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.action, func(t *testing.T) {
+			assert.Equal(t, tt.invalid, catalog.IsInvalidAction(tt.action))
+		})
+	}
+}
+
+func TestOperationsCatalogSuggestAction(t *testing.T) {
+	catalog := getTestOperationsCatalog()
+
+	assert.Equal(t, "Microsoft.Storage/storageAccounts/write", catalog.SuggestAction("Microsoft.Storage/storageAccounts/wirte"))
+	assert.Equal(t, "Microsoft.Storage/storageAccounts/listKeys/action", catalog.SuggestAction("Microsoft.Storage/storageAccount/listKeys/action"))
+	assert.Empty(t, catalog.SuggestAction("Microsoft.KeyVault/vaults/secrets/getSecret/action"))
+}
+
+func TestOperationsCatalogValidateActions(t *testing.T) {
+	catalog := getTestOperationsCatalog()
+
+	normalized, invalid := catalog.ValidateActions([]string{
+		"microsoft.storage/storageaccounts/read",
+		"Microsoft.Storage/storageAccounts/wirte",
+		"Microsoft.Web/sites/write",
+	})
+	assert.Equal(t, []string{"Microsoft.Storage/storageAccounts/read", "Microsoft.Web/sites/write"}, normalized)
+	assert.Equal(t, []string{"Microsoft.Storage/storageAccounts/wirte"}, invalid)
+}
+
+func TestOperationsCatalogGetResourceTypeOperations(t *testing.T) {
+	catalog := getTestOperationsCatalog()
+
+	assert.Equal(t, []string{
+		"Microsoft.Storage/storageAccounts/delete",
+		"Microsoft.Storage/storageAccounts/listKeys/action",
+		"Microsoft.Storage/storageAccounts/read",
+		"Microsoft.Storage/storageAccounts/write",
+	}, catalog.GetResourceTypeOperations("microsoft.storage/storageaccounts"))
+	assert.Empty(t, catalog.GetResourceTypeOperations("Microsoft.Web/sites"))
+}
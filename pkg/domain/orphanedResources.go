@@ -0,0 +1,149 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// MPFRoleNamePrefix prefixes the names of the custom roles created by MPF runs
+const MPFRoleNamePrefix = "tmp-rol"
+
+// Tags of the resource groups and deployments created by MPF runs
+const (
+	MPFCreatedByTagName  = "azmpf-created-by"
+	MPFCreatedByTagValue = "azmpf"
+	MPFCreatedAtTagName  = "azmpf-created-at"
+	MPFRunIDTagName      = "azmpf-run-id"
+	MPFVersionTagName    = "azmpf-version"
+	MPFUserTagName       = "azmpf-user"
+	MPFTemplateTagName   = "azmpf-template"
+)
+
+// maxTagValueLength is the maximum length of the value of a resource group tag
+const maxTagValueLength = 256
+
+// mpfNameSuffixLength is the length of the random suffix of the names of the roles and resource groups created by MPF runs
+const mpfNameSuffixLength = 7
+
+// GetMPFResourceGroupTags returns the tags of a resource group created by an MPF run
+func GetMPFResourceGroupTags(createdAt time.Time) map[string]string {
+	return map[string]string{
+		MPFCreatedByTagName: MPFCreatedByTagValue,
+		MPFCreatedAtTagName: createdAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// CustomRole is a custom role definition of the subscription
+type CustomRole struct {
+	Role
+	// CreatedOn is zero if unknown
+	CreatedOn time.Time
+}
+
+// TaggedResourceGroup is a resource group of the subscription with its tags
+type TaggedResourceGroup struct {
+	ResourceGroup
+	Tags map[string]string
+}
+
+// CreatedAt returns when the resource group was created by an MPF run, or zero if it was not tagged by the run
+func (g TaggedResourceGroup) CreatedAt() time.Time {
+	createdAt, err := time.Parse(time.RFC3339, g.Tags[MPFCreatedAtTagName])
+	if err != nil {
+		return time.Time{}
+	}
+	return createdAt
+}
+
+// OrphanedRole is a custom role left behind by an MPF run, with its remaining role assignments
+type OrphanedRole struct {
+	Role            CustomRole
+	RoleAssignments []RoleAssignment
+}
+
+// OrphanedResources are the custom roles and resource groups left behind by MPF runs which crashed or were killed
+type OrphanedResources struct {
+	Roles          []OrphanedRole
+	ResourceGroups []TaggedResourceGroup
+}
+
+// IsEmpty returns true if no resources were left behind
+func (o OrphanedResources) IsEmpty() bool {
+	return len(o.Roles) == 0 && len(o.ResourceGroups) == 0
+}
+
+// RoleAssignmentCount returns the number of role assignments of the orphaned roles
+func (o OrphanedResources) RoleAssignmentCount() int {
+	count := 0
+	for _, role := range o.Roles {
+		count += len(role.RoleAssignments)
+	}
+	return count
+}
+
+// OrphanedResourceFilter selects the resources left behind by MPF runs
+type OrphanedResourceFilter struct {
+	// RoleNamePrefix and ResourceGroupNamePrefixes are the prefixes of the names MPF runs give to roles and resource groups,
+	// followed by a dash and a random suffix
+	RoleNamePrefix            string
+	ResourceGroupNamePrefixes []string
+	// OlderThan excludes the resources created less than this long ago, which may belong to runs in progress.
+	// Resources whose creation time is unknown are only selected if OlderThan is zero.
+	OlderThan time.Duration
+}
+
+// MatchesRole returns true if the custom role was created by an MPF run and is old enough
+func (f OrphanedResourceFilter) MatchesRole(role CustomRole, now time.Time) bool {
+	return isMPFName(role.RoleDefinitionName, f.RoleNamePrefix) && f.isOldEnough(role.CreatedOn, now)
+}
+
+// MatchesResourceGroup returns true if the resource group is tagged as created by an MPF run, or named like one, and is old enough
+func (f OrphanedResourceFilter) MatchesResourceGroup(group TaggedResourceGroup, now time.Time) bool {
+	isMPFResourceGroup := group.Tags[MPFCreatedByTagName] == MPFCreatedByTagValue
+	for _, prefix := range f.ResourceGroupNamePrefixes {
+		if isMPFName(group.ResourceGroupName, prefix) {
+			isMPFResourceGroup = true
+		}
+	}
+	return isMPFResourceGroup && f.isOldEnough(group.CreatedAt(), now)
+}
+
+func (f OrphanedResourceFilter) isOldEnough(createdAt time.Time, now time.Time) bool {
+	if f.OlderThan <= 0 {
+		return true
+	}
+	return !createdAt.IsZero() && now.Sub(createdAt) >= f.OlderThan
+}
+
+// isMPFName returns true if the name is the prefix followed by a dash and a random suffix
+func isMPFName(name string, prefix string) bool {
+	if prefix == "" {
+		return false
+	}
+	pattern := fmt.Sprintf("(?i)^%s-[a-z0-9]{%d}$", regexp.QuoteMeta(prefix), mpfNameSuffixLength)
+	matched, _ := regexp.MatchString(pattern, name)
+	return matched
+}
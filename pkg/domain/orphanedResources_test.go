@@ -0,0 +1,68 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrphanedResourceFilterMatchesRole(t *testing.T) {
+	now := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+	filter := OrphanedResourceFilter{RoleNamePrefix: MPFRoleNamePrefix}
+
+	assert.True(t, filter.MatchesRole(CustomRole{Role: Role{RoleDefinitionName: "tmp-rol-aB3dE5g"}}, now))
+	assert.False(t, filter.MatchesRole(CustomRole{Role: Role{RoleDefinitionName: "tmp-rol-keep"}}, now))
+	assert.False(t, filter.MatchesRole(CustomRole{Role: Role{RoleDefinitionName: "Custom Deployer"}}, now))
+
+	filter.OlderThan = 24 * time.Hour
+	assert.True(t, filter.MatchesRole(CustomRole{Role: Role{RoleDefinitionName: "tmp-rol-aB3dE5g"}, CreatedOn: now.Add(-25 * time.Hour)}, now))
+	assert.False(t, filter.MatchesRole(CustomRole{Role: Role{RoleDefinitionName: "tmp-rol-aB3dE5g"}, CreatedOn: now.Add(-time.Hour)}, now))
+	// the age of a role without creation time is unknown
+	assert.False(t, filter.MatchesRole(CustomRole{Role: Role{RoleDefinitionName: "tmp-rol-aB3dE5g"}}, now))
+}
+
+func TestOrphanedResourceFilterMatchesResourceGroup(t *testing.T) {
+	now := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+	filter := OrphanedResourceFilter{ResourceGroupNamePrefixes: []string{"testdeployrg"}}
+
+	assert.True(t, filter.MatchesResourceGroup(TaggedResourceGroup{ResourceGroup: ResourceGroup{ResourceGroupName: "testdeployrg-Ab3dE5g"}}, now))
+	assert.False(t, filter.MatchesResourceGroup(TaggedResourceGroup{ResourceGroup: ResourceGroup{ResourceGroupName: "testdeployrg-production"}}, now))
+	assert.True(t, filter.MatchesResourceGroup(TaggedResourceGroup{ResourceGroup: ResourceGroup{ResourceGroupName: "custom-rg"}, Tags: GetMPFResourceGroupTags(now)}, now))
+
+	filter.OlderThan = time.Hour
+	assert.True(t, filter.MatchesResourceGroup(TaggedResourceGroup{ResourceGroup: ResourceGroup{ResourceGroupName: "custom-rg"}, Tags: GetMPFResourceGroupTags(now.Add(-2 * time.Hour))}, now))
+	assert.False(t, filter.MatchesResourceGroup(TaggedResourceGroup{ResourceGroup: ResourceGroup{ResourceGroupName: "custom-rg"}, Tags: GetMPFResourceGroupTags(now)}, now))
+	// resource groups created before MPF tagged them have no creation time
+	assert.False(t, filter.MatchesResourceGroup(TaggedResourceGroup{ResourceGroup: ResourceGroup{ResourceGroupName: "testdeployrg-Ab3dE5g"}}, now))
+}
+
+func TestOrphanedResourcesCounts(t *testing.T) {
+	orphans := OrphanedResources{Roles: []OrphanedRole{{RoleAssignments: []RoleAssignment{{Name: "a"}, {Name: "b"}}}, {}}}
+
+	assert.False(t, orphans.IsEmpty())
+	assert.Equal(t, 2, orphans.RoleAssignmentCount())
+	assert.True(t, OrphanedResources{}.IsEmpty())
+}
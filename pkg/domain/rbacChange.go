@@ -0,0 +1,61 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package domain
+
+// RBACChangeKind identifies the RBAC change MPF waits to propagate
+type RBACChangeKind int
+
+const (
+	// RoleAssignmentsRemoved is the removal of the existing role assignments of the service principal
+	RoleAssignmentsRemoved RBACChangeKind = iota
+	// RoleAssigned is the assignment of the custom role to the service principal
+	RoleAssigned
+	// RoleUpdated is an update of the permissions of the custom role
+	RoleUpdated
+)
+
+func (k RBACChangeKind) String() string {
+	switch k {
+	case RoleAssignmentsRemoved:
+		return "RoleAssignmentsRemoved"
+	case RoleAssigned:
+		return "RoleAssigned"
+	case RoleUpdated:
+		return "RoleUpdated"
+	}
+	return "Unknown"
+}
+
+// RBACChange describes an RBAC change which has to propagate before the deployment is retried
+type RBACChange struct {
+	Kind           RBACChangeKind
+	SubscriptionID string
+	SPObjectID     string
+	Role           Role
+	// Actions and DataActions are the permissions of the custom role after a RoleUpdated change
+	Actions     []string
+	DataActions []string
+	// Attempt is the number of consecutive role updates after which the deployment
+	// reported no new missing permissions, which usually means the previous wait was too short
+	Attempt int
+}
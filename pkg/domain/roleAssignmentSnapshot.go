@@ -0,0 +1,89 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// RoleAssignmentSnapshotVersion is the version of the role assignment snapshot format
+const RoleAssignmentSnapshotVersion = 1
+
+// RoleAssignment is a role assignment of a service principal, with everything needed to recreate it
+type RoleAssignment struct {
+	// Name is the GUID of the role assignment
+	Name             string
+	Scope            string
+	RoleDefinitionID string
+	PrincipalID      string
+	PrincipalType    string `json:",omitempty"`
+	Description      string `json:",omitempty"`
+	Condition        string `json:",omitempty"`
+	ConditionVersion string `json:",omitempty"`
+}
+
+// RoleAssignmentSnapshot holds the role assignments a service principal had before MPF removed them,
+// so that they can be restored after the run
+type RoleAssignmentSnapshot struct {
+	Version         int
+	SubscriptionID  string
+	SPObjectID      string
+	RoleAssignments []RoleAssignment
+	CreatedAt       time.Time
+}
+
+// Validate returns an error if the snapshot cannot be restored
+func (s RoleAssignmentSnapshot) Validate() error {
+	if s.Version != RoleAssignmentSnapshotVersion {
+		return fmt.Errorf("unsupported role assignment snapshot version %d, expected %d", s.Version, RoleAssignmentSnapshotVersion)
+	}
+	for _, assignment := range s.RoleAssignments {
+		if assignment.Name == "" || assignment.Scope == "" || assignment.RoleDefinitionID == "" || assignment.PrincipalID == "" {
+			return fmt.Errorf("role assignment %q of the snapshot lacks its name, scope, role definition or principal", assignment.Name)
+		}
+	}
+	return nil
+}
+
+// IsSnapshotOf returns true if the snapshot holds the role assignments of the service principal in the subscription
+func (s RoleAssignmentSnapshot) IsSnapshotOf(subscriptionID string, spObjectID string) bool {
+	return strings.EqualFold(s.SubscriptionID, subscriptionID) && strings.EqualFold(s.SPObjectID, spObjectID)
+}
+
+// AddRoleAssignments adds the role assignments which are not in the snapshot yet
+func (s *RoleAssignmentSnapshot) AddRoleAssignments(assignments []RoleAssignment) {
+	for _, assignment := range assignments {
+		exists := false
+		for _, existing := range s.RoleAssignments {
+			if strings.EqualFold(existing.Name, assignment.Name) {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			s.RoleAssignments = append(s.RoleAssignments, assignment)
+		}
+	}
+}
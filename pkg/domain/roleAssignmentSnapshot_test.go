@@ -0,0 +1,55 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoleAssignmentSnapshotValidate(t *testing.T) {
+	assignment := RoleAssignment{Name: "a", Scope: "/subscriptions/sub", RoleDefinitionID: "/providers/Microsoft.Authorization/roleDefinitions/reader", PrincipalID: "sp"}
+
+	assert.NoError(t, RoleAssignmentSnapshot{Version: RoleAssignmentSnapshotVersion, RoleAssignments: []RoleAssignment{assignment}}.Validate())
+	assert.Error(t, RoleAssignmentSnapshot{Version: 2}.Validate())
+
+	assignment.Scope = ""
+	assert.Error(t, RoleAssignmentSnapshot{Version: RoleAssignmentSnapshotVersion, RoleAssignments: []RoleAssignment{assignment}}.Validate())
+}
+
+func TestRoleAssignmentSnapshotIsSnapshotOf(t *testing.T) {
+	snapshot := RoleAssignmentSnapshot{SubscriptionID: "SUB", SPObjectID: "SP"}
+
+	assert.True(t, snapshot.IsSnapshotOf("sub", "sp"))
+	assert.False(t, snapshot.IsSnapshotOf("sub", "other"))
+	assert.False(t, snapshot.IsSnapshotOf("other", "sp"))
+}
+
+func TestRoleAssignmentSnapshotAddRoleAssignments(t *testing.T) {
+	snapshot := RoleAssignmentSnapshot{RoleAssignments: []RoleAssignment{{Name: "a", Scope: "/subscriptions/sub"}}}
+
+	snapshot.AddRoleAssignments([]RoleAssignment{{Name: "A", Scope: "/subscriptions/other"}, {Name: "b", Scope: "/subscriptions/sub"}})
+
+	assert.Equal(t, []RoleAssignment{{Name: "a", Scope: "/subscriptions/sub"}, {Name: "b", Scope: "/subscriptions/sub"}}, snapshot.RoleAssignments)
+}
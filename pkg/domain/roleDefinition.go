@@ -0,0 +1,77 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package domain
+
+import (
+	"slices"
+	"strings"
+)
+
+// RolePermission is a permission block of a role definition.
+// An action is granted if it matches one of the actions and none of the not actions of the same block.
+type RolePermission struct {
+	Actions        []string
+	NotActions     []string
+	DataActions    []string
+	NotDataActions []string
+}
+
+// RoleDefinition is an Azure role definition, either built-in or custom
+type RoleDefinition struct {
+	ID          string
+	Name        string
+	Description string
+	Permissions []RolePermission
+}
+
+// GrantsAction returns true if the role grants the action
+func (r RoleDefinition) GrantsAction(action string) bool {
+	for _, p := range r.Permissions {
+		if isGrantedByPatterns(action, p.Actions, p.NotActions) {
+			return true
+		}
+	}
+	return false
+}
+
+// GrantsDataAction returns true if the role grants the data action
+func (r RoleDefinition) GrantsDataAction(dataAction string) bool {
+	for _, p := range r.Permissions {
+		if isGrantedByPatterns(dataAction, p.DataActions, p.NotDataActions) {
+			return true
+		}
+	}
+	return false
+}
+
+// isGrantedByPatterns returns true if the permission matches one of the patterns and is not excluded.
+// A permission containing a wildcard, e.g. Microsoft.Resources/deployments/*, is granted
+// if a pattern covers it entirely and no excluded pattern overlaps it.
+func isGrantedByPatterns(permission string, patterns []string, notPatterns []string) bool {
+	if !slices.ContainsFunc(patterns, func(pattern string) bool { return actionMatchesPattern(permission, pattern) }) {
+		return false
+	}
+	return !slices.ContainsFunc(notPatterns, func(notPattern string) bool {
+		return actionMatchesPattern(permission, notPattern) || (strings.Contains(permission, "*") && actionMatchesPattern(notPattern, permission))
+	})
+}
@@ -0,0 +1,104 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package domain
+
+import (
+	"strings"
+)
+
+// WildcardCoverage holds a wildcard of a role definition and the required permissions it grants
+type WildcardCoverage struct {
+	Pattern            string
+	CoveredPermissions []string
+}
+
+// RoleDiff compares a role definition with the required permissions
+type RoleDiff struct {
+	// MissingActions and MissingDataActions are required, but not granted by the role
+	MissingActions     []string
+	MissingDataActions []string
+	// ExcessActions and ExcessDataActions are the actions and wildcards of the role granting no required permission
+	ExcessActions     []string
+	ExcessDataActions []string
+	// WildcardActions and WildcardDataActions are the wildcards of the role granting required permissions.
+	// They grant more than required and may be replaced by the covered permissions.
+	WildcardActions     []WildcardCoverage
+	WildcardDataActions []WildcardCoverage
+}
+
+// HasMissingPermissions returns true if the role does not grant all required permissions
+func (d RoleDiff) HasMissingPermissions() bool {
+	return len(d.MissingActions) > 0 || len(d.MissingDataActions) > 0
+}
+
+// DiffRoleDefinition compares the role definition with the required actions and data actions
+func DiffRoleDefinition(actions []string, dataActions []string, role RoleDefinition) RoleDiff {
+	actions = getUniqueSortedPermissions(actions)
+	dataActions = getUniqueSortedPermissions(dataActions)
+
+	diff := RoleDiff{}
+	for _, action := range actions {
+		if !role.GrantsAction(action) {
+			diff.MissingActions = append(diff.MissingActions, action)
+		}
+	}
+	for _, dataAction := range dataActions {
+		if !role.GrantsDataAction(dataAction) {
+			diff.MissingDataActions = append(diff.MissingDataActions, dataAction)
+		}
+	}
+
+	for _, p := range role.Permissions {
+		excess, wildcards := getPatternCoverage(p.Actions, p.NotActions, actions)
+		diff.ExcessActions = append(diff.ExcessActions, excess...)
+		diff.WildcardActions = append(diff.WildcardActions, wildcards...)
+
+		excess, wildcards = getPatternCoverage(p.DataActions, p.NotDataActions, dataActions)
+		diff.ExcessDataActions = append(diff.ExcessDataActions, excess...)
+		diff.WildcardDataActions = append(diff.WildcardDataActions, wildcards...)
+	}
+	return diff
+}
+
+// getPatternCoverage returns the patterns of a permission block granting none of the required permissions,
+// and the wildcards granting required permissions
+func getPatternCoverage(patterns []string, notPatterns []string, required []string) ([]string, []WildcardCoverage) {
+	var excess []string
+	var wildcards []WildcardCoverage
+	for _, pattern := range patterns {
+		var covered []string
+		for _, permission := range required {
+			if isGrantedByPatterns(permission, []string{pattern}, notPatterns) {
+				covered = append(covered, permission)
+			}
+		}
+
+		switch {
+		case len(covered) == 0:
+			excess = append(excess, pattern)
+		case strings.Contains(pattern, "*") && (len(covered) > 1 || !strings.EqualFold(covered[0], pattern)):
+			wildcards = append(wildcards, WildcardCoverage{Pattern: pattern, CoveredPermissions: covered})
+		}
+	}
+	return excess, wildcards
+}
@@ -0,0 +1,67 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffRoleDefinition(t *testing.T) {
+	role := RoleDefinition{
+		Name: "Deployer",
+		Permissions: []RolePermission{{
+			Actions:     []string{"Microsoft.Storage/*", "Microsoft.Resources/deployments/write", "Microsoft.Compute/virtualMachines/write"},
+			NotActions:  []string{"Microsoft.Storage/storageAccounts/delete"},
+			DataActions: []string{"Microsoft.KeyVault/vaults/secrets/*"},
+		}},
+	}
+
+	diff := DiffRoleDefinition(
+		[]string{"Microsoft.Storage/storageAccounts/write", "Microsoft.Storage/storageAccounts/read", "Microsoft.Storage/storageAccounts/delete", "Microsoft.Resources/deployments/write", "Microsoft.Network/virtualNetworks/write"},
+		[]string{"Microsoft.KeyVault/vaults/secrets/getSecret/action", "Microsoft.Storage/storageAccounts/blobServices/containers/blobs/read"},
+		role,
+	)
+
+	assert.True(t, diff.HasMissingPermissions())
+	assert.Equal(t, []string{"Microsoft.Network/virtualNetworks/write", "Microsoft.Storage/storageAccounts/delete"}, diff.MissingActions)
+	assert.Equal(t, []string{"Microsoft.Storage/storageAccounts/blobServices/containers/blobs/read"}, diff.MissingDataActions)
+	assert.Equal(t, []string{"Microsoft.Compute/virtualMachines/write"}, diff.ExcessActions)
+	assert.Empty(t, diff.ExcessDataActions)
+	assert.Equal(t, []WildcardCoverage{{Pattern: "Microsoft.Storage/*", CoveredPermissions: []string{"Microsoft.Storage/storageAccounts/read", "Microsoft.Storage/storageAccounts/write"}}}, diff.WildcardActions)
+	assert.Equal(t, []WildcardCoverage{{Pattern: "Microsoft.KeyVault/vaults/secrets/*", CoveredPermissions: []string{"Microsoft.KeyVault/vaults/secrets/getSecret/action"}}}, diff.WildcardDataActions)
+}
+
+func TestDiffRoleDefinitionGrantingAllPermissions(t *testing.T) {
+	role := RoleDefinition{
+		Permissions: []RolePermission{{Actions: []string{"microsoft.storage/storageaccounts/write", "Microsoft.Resources/deployments/*"}}},
+	}
+
+	diff := DiffRoleDefinition([]string{"Microsoft.Storage/storageAccounts/write", "Microsoft.Resources/deployments/*"}, nil, role)
+
+	assert.False(t, diff.HasMissingPermissions())
+	assert.Empty(t, diff.ExcessActions)
+	// a wildcard which is itself required is not reported as granting more than required
+	assert.Empty(t, diff.WildcardActions)
+}
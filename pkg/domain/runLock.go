@@ -0,0 +1,69 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// RunLockNamePrefix prefixes the name of the lock of a service principal
+const RunLockNamePrefix = "azmpf-lock-"
+
+// RunLock is held by an MPF run on its service principal, as the run removes all role assignments of the service principal.
+// Concurrent runs on the same service principal would remove each other's role assignments.
+type RunLock struct {
+	SubscriptionID string
+	SPObjectID     string
+	// Holder identifies the run holding the lock
+	Holder     string
+	AcquiredAt time.Time
+	// ExpiresAt is when the lock becomes stale, so that the lock of a crashed run does not block later runs forever
+	ExpiresAt time.Time
+}
+
+// IsExpired returns true if the lock is stale at the given time
+func (l RunLock) IsExpired(now time.Time) bool {
+	return !now.Before(l.ExpiresAt)
+}
+
+// IsHeldBy returns true if the lock is held by the holder
+func (l RunLock) IsHeldBy(holder string) bool {
+	return l.Holder == holder
+}
+
+// GetRunLockName returns the name of the lock of the service principal
+func GetRunLockName(spObjectID string) string {
+	return RunLockNamePrefix + strings.ToLower(spObjectID)
+}
+
+// RunLockedError is returned when the lock of the service principal is held by another run
+type RunLockedError struct {
+	Lock RunLock
+}
+
+func (e *RunLockedError) Error() string {
+	return fmt.Sprintf("service principal %s is locked by another MPF run: %s, acquired at %s, expires at %s. Wait for the run to end or for the lock to expire",
+		e.Lock.SPObjectID, e.Lock.Holder, e.Lock.AcquiredAt.Format(time.RFC3339), e.Lock.ExpiresAt.Format(time.RFC3339))
+}
@@ -0,0 +1,55 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunLockIsExpired(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	lock := RunLock{ExpiresAt: now}
+
+	assert.True(t, lock.IsExpired(now))
+	assert.True(t, lock.IsExpired(now.Add(time.Second)))
+	assert.False(t, lock.IsExpired(now.Add(-time.Second)))
+}
+
+func TestGetRunLockName(t *testing.T) {
+	assert.Equal(t, "azmpf-lock-0a1b2c", GetRunLockName("0A1B2C"))
+}
+
+func TestRunLockedErrorNamesHolder(t *testing.T) {
+	err := &RunLockedError{Lock: RunLock{
+		SPObjectID: "sp",
+		Holder:     "pipeline-a",
+		AcquiredAt: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+		ExpiresAt:  time.Date(2024, 1, 1, 13, 0, 0, 0, time.UTC),
+	}}
+
+	assert.Contains(t, err.Error(), "service principal sp is locked by another MPF run: pipeline-a")
+	assert.Contains(t, err.Error(), "expires at 2024-01-01T13:00:00Z")
+}
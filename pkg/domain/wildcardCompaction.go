@@ -0,0 +1,132 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package domain
+
+import (
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// WildcardCompactionPolicy decides which actions on a resource type are folded into a wildcard.
+// Actions are only folded into a wildcard on a resource type, e.g. Microsoft.Network/virtualNetworks/subnets/*,
+// when every operation the catalog lists for the resource type and its child types is required.
+// Actions are never folded across providers.
+type WildcardCompactionPolicy struct {
+	// Catalog lists the operations of the resource types. Without a catalog, no actions are folded
+	Catalog *OperationsCatalog
+	// MinActions is the minimum number of required actions on a resource type to fold them
+	MinActions int
+}
+
+// CompactWildcards folds the permissions on a resource type into a wildcard if the policy allows it.
+// Permissions granted by a wildcard of the result are dropped, and the result is sorted.
+func CompactWildcards(permissions []string, policy WildcardCompactionPolicy) []string {
+	if policy.Catalog == nil {
+		return getUniqueSortedPermissions(permissions)
+	}
+
+	required := map[string]bool{}
+	resourceTypes := map[string]string{}
+	for _, permission := range getUniqueSlice(permissions) {
+		if strings.Contains(permission, "*") {
+			continue
+		}
+		required[strings.ToLower(permission)] = true
+		// the actions on a child type can be folded into a wildcard on any of its parent types
+		for resourceType := GetResourceTypeFromAction(permission); strings.Count(resourceType, "/") > 0; resourceType = resourceType[:strings.LastIndex(resourceType, "/")] {
+			key := strings.ToLower(resourceType)
+			if _, ok := resourceTypes[key]; !ok {
+				resourceTypes[key] = resourceType
+			}
+		}
+	}
+
+	var wildcards []string
+	for _, key := range slices.Sorted(maps.Keys(resourceTypes)) {
+		if isCompactableResourceType(key, required, policy) {
+			wildcards = append(wildcards, resourceTypes[key]+"/*")
+		}
+	}
+
+	compacted := make([]string, 0, len(permissions))
+	for _, permission := range append(wildcards, permissions...) {
+		isGranted := slices.ContainsFunc(wildcards, func(wildcard string) bool {
+			return !strings.EqualFold(permission, wildcard) && actionMatchesPattern(permission, wildcard)
+		})
+		if !isGranted {
+			compacted = append(compacted, permission)
+		}
+	}
+	return getUniqueSortedPermissions(compacted)
+}
+
+// isCompactableResourceType returns true if every operation the catalog lists for the resource type and its child types
+// is required. Actions and data actions are granted separately, so only the operations of the kind of the required
+// permissions are considered.
+func isCompactableResourceType(resourceType string, required map[string]bool, policy WildcardCompactionPolicy) bool {
+	var requiredOnResourceType []string
+	for permission := range required {
+		if strings.HasPrefix(permission, resourceType+"/") {
+			requiredOnResourceType = append(requiredOnResourceType, permission)
+		}
+	}
+	if len(requiredOnResourceType) == 0 || len(requiredOnResourceType) < policy.MinActions {
+		return false
+	}
+	isDataAction := IsDataAction(requiredOnResourceType[0])
+
+	operations := 0
+	for _, operation := range policy.Catalog.GetResourceTypeOperations(resourceType) {
+		if IsDataAction(operation) != isDataAction {
+			continue
+		}
+		if !required[strings.ToLower(operation)] {
+			return false
+		}
+		operations++
+	}
+	return operations > 0
+}
+
+// CompactMPFResultWildcards compacts the permissions of all scopes, held under aggregateKey, with the policy.
+// The permissions of the individual scopes are kept as they were discovered, so that the detailed output shows them.
+func CompactMPFResultWildcards(result MPFResult, aggregateKey string, policy WildcardCompactionPolicy) MPFResult {
+	compacted := result
+	if permissions, ok := result.RequiredPermissions[aggregateKey]; ok {
+		compacted.RequiredPermissions = maps.Clone(result.RequiredPermissions)
+		compacted.RequiredPermissions[aggregateKey] = CompactWildcards(permissions, policy)
+	}
+	if dataActions, ok := result.RequiredDataActions[aggregateKey]; ok {
+		compacted.RequiredDataActions = maps.Clone(result.RequiredDataActions)
+		compacted.RequiredDataActions[aggregateKey] = CompactWildcards(dataActions, policy)
+	}
+	return compacted
+}
+
+func getUniqueSortedPermissions(permissions []string) []string {
+	unique := getUniqueSlice(permissions)
+	sort.Strings(unique)
+	return unique
+}
@@ -0,0 +1,152 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testWildcardCompactionCatalog = NewOperationsCatalog([]string{
+	"Microsoft.Network/virtualNetworks/read",
+	"Microsoft.Network/virtualNetworks/write",
+	"Microsoft.Network/virtualNetworks/delete",
+	"Microsoft.Network/virtualNetworks/subnets/read",
+	"Microsoft.Network/virtualNetworks/subnets/write",
+	"Microsoft.Network/virtualNetworks/subnets/delete",
+	"Microsoft.Network/virtualNetworks/subnets/join/action",
+	"Microsoft.Storage/storageAccounts/read",
+	"Microsoft.Storage/storageAccounts/write",
+	"Microsoft.Storage/storageAccounts/delete",
+	"Microsoft.Storage/storageAccounts/listKeys/action",
+	"Microsoft.Storage/storageAccounts/blobServices/containers/blobs/read",
+	"Microsoft.Storage/storageAccounts/blobServices/containers/blobs/write",
+	"Microsoft.Resources/deployments/read",
+	"Microsoft.Resources/deployments/write",
+})
+
+func TestCompactWildcards(t *testing.T) {
+	permissions := []string{
+		"Microsoft.Network/virtualNetworks/subnets/read",
+		"Microsoft.Network/virtualNetworks/subnets/write",
+		"Microsoft.Network/virtualNetworks/subnets/delete",
+		"Microsoft.Network/virtualNetworks/subnets/join/action",
+		"Microsoft.Network/virtualNetworks/read",
+		"Microsoft.Network/virtualNetworks/write",
+		"Microsoft.Storage/storageAccounts/read",
+		"Microsoft.Storage/storageAccounts/write",
+		"Microsoft.Storage/storageAccounts/delete",
+		"Microsoft.Resources/deployments/read",
+	}
+
+	compacted := CompactWildcards(permissions, WildcardCompactionPolicy{Catalog: testWildcardCompactionCatalog})
+
+	// storage accounts are not folded, as Microsoft.Storage/storageAccounts/* would grant listKeys as well
+	assert.Equal(t, []string{
+		"Microsoft.Network/virtualNetworks/read",
+		"Microsoft.Network/virtualNetworks/subnets/*",
+		"Microsoft.Network/virtualNetworks/write",
+		"Microsoft.Resources/deployments/read",
+		"Microsoft.Storage/storageAccounts/delete",
+		"Microsoft.Storage/storageAccounts/read",
+		"Microsoft.Storage/storageAccounts/write",
+	}, compacted)
+}
+
+func TestCompactWildcardsFoldsNestedResourceTypes(t *testing.T) {
+	permissions := []string{
+		"Microsoft.Network/virtualNetworks/read",
+		"Microsoft.Network/virtualNetworks/write",
+		"Microsoft.Network/virtualNetworks/delete",
+		"Microsoft.Network/virtualNetworks/subnets/read",
+		"Microsoft.Network/virtualNetworks/subnets/write",
+		"Microsoft.Network/virtualNetworks/subnets/delete",
+	}
+	policy := WildcardCompactionPolicy{Catalog: testWildcardCompactionCatalog}
+
+	// Microsoft.Network/virtualNetworks/* would grant the subnet join action, which was not discovered
+	assert.Equal(t, []string{
+		"Microsoft.Network/virtualNetworks/delete",
+		"Microsoft.Network/virtualNetworks/read",
+		"Microsoft.Network/virtualNetworks/subnets/delete",
+		"Microsoft.Network/virtualNetworks/subnets/read",
+		"Microsoft.Network/virtualNetworks/subnets/write",
+		"Microsoft.Network/virtualNetworks/write",
+	}, CompactWildcards(permissions, policy))
+
+	permissions = append(permissions, "Microsoft.Network/virtualNetworks/subnets/join/action")
+	assert.Equal(t, []string{"Microsoft.Network/virtualNetworks/*"}, CompactWildcards(permissions, policy))
+}
+
+func TestCompactWildcardsKeepsActionsAndDataActionsApart(t *testing.T) {
+	actions := []string{
+		"Microsoft.Storage/storageAccounts/read",
+		"Microsoft.Storage/storageAccounts/write",
+		"Microsoft.Storage/storageAccounts/delete",
+		"Microsoft.Storage/storageAccounts/listKeys/action",
+	}
+	dataActions := []string{
+		"Microsoft.Storage/storageAccounts/blobServices/containers/blobs/read",
+		"Microsoft.Storage/storageAccounts/blobServices/containers/blobs/write",
+	}
+	policy := WildcardCompactionPolicy{Catalog: testWildcardCompactionCatalog}
+
+	assert.Equal(t, []string{"Microsoft.Storage/storageAccounts/*"}, CompactWildcards(actions, policy))
+	assert.Equal(t, []string{"Microsoft.Storage/storageAccounts/*"}, CompactWildcards(dataActions, policy))
+}
+
+func TestCompactWildcardsPolicy(t *testing.T) {
+	permissions := []string{"Microsoft.Resources/deployments/read", "Microsoft.Resources/deployments/write"}
+
+	assert.Equal(t, []string{"Microsoft.Resources/deployments/*"}, CompactWildcards(permissions, WildcardCompactionPolicy{Catalog: testWildcardCompactionCatalog}))
+	assert.Equal(t, permissions, CompactWildcards(permissions, WildcardCompactionPolicy{Catalog: testWildcardCompactionCatalog, MinActions: 3}))
+	// without a catalog, nothing is folded
+	assert.Equal(t, permissions, CompactWildcards(permissions, WildcardCompactionPolicy{}))
+	// resource types the catalog does not know are not folded
+	unknown := []string{"Microsoft.Web/sites/read", "Microsoft.Web/sites/write"}
+	assert.Equal(t, unknown, CompactWildcards(unknown, WildcardCompactionPolicy{Catalog: testWildcardCompactionCatalog}))
+}
+
+func TestCompactMPFResultWildcards(t *testing.T) {
+	scope := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Storage/storageAccounts/sa"
+	permissions := []string{
+		"Microsoft.Storage/storageAccounts/delete",
+		"Microsoft.Storage/storageAccounts/listKeys/action",
+		"Microsoft.Storage/storageAccounts/read",
+		"Microsoft.Storage/storageAccounts/write",
+	}
+	result := MPFResult{
+		RequiredPermissions: map[string][]string{"sub": permissions, scope: permissions},
+		RequiredDataActions: map[string][]string{"sub": {"Microsoft.Storage/storageAccounts/blobServices/containers/blobs/read"}},
+	}
+
+	compacted := CompactMPFResultWildcards(result, "sub", WildcardCompactionPolicy{Catalog: testWildcardCompactionCatalog})
+
+	assert.Equal(t, []string{"Microsoft.Storage/storageAccounts/*"}, compacted.RequiredPermissions["sub"])
+	assert.Equal(t, permissions, compacted.RequiredPermissions[scope])
+	// the blob write data action was not discovered
+	assert.Equal(t, []string{"Microsoft.Storage/storageAccounts/blobServices/containers/blobs/read"}, compacted.RequiredDataActions["sub"])
+	// the result passed in is unchanged
+	assert.Equal(t, permissions, result.RequiredPermissions["sub"])
+}
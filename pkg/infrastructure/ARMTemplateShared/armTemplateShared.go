@@ -30,6 +30,8 @@ type ArmTemplateAdditionalConfig struct {
 	TemplateFilePath   string
 	ParametersFilePath string
 	DeploymentName     string
+	// Tags are added to the deployments, to identify the run which created them
+	Tags map[string]string
 }
 
 // Get parameters in standard format that is without the schema, contentVersion and parameters fields
@@ -40,43 +40,48 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
-	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 )
 
 type armDeploymentConfig struct {
-	ctx         context.Context
 	armConfig   ARMTemplateShared.ArmTemplateAdditionalConfig
 	azAPIClient *azureAPI.AzureAPIClients
 }
 
-func NewARMTemplateDeploymentAuthorizationChecker(subscriptionID string, armConfig ARMTemplateShared.ArmTemplateAdditionalConfig) *armDeploymentConfig {
-	azAPIClient := azureAPI.NewAzureAPIClients(subscriptionID)
+func NewARMTemplateDeploymentAuthorizationChecker(subscriptionID string, armConfig ARMTemplateShared.ArmTemplateAdditionalConfig) (*armDeploymentConfig, error) {
+	azAPIClient, err := azureAPI.NewAzureAPIClients(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	return NewARMTemplateDeploymentAuthorizationCheckerWithClients(azAPIClient, armConfig), nil
+
+}
+
+// NewARMTemplateDeploymentAuthorizationCheckerWithClients returns the checker deploying with the given Azure API clients
+func NewARMTemplateDeploymentAuthorizationCheckerWithClients(azAPIClient *azureAPI.AzureAPIClients, armConfig ARMTemplateShared.ArmTemplateAdditionalConfig) *armDeploymentConfig {
 	return &armDeploymentConfig{
 		azAPIClient: azAPIClient,
 		armConfig:   armConfig,
-		ctx:         context.Background(),
 	}
-
 }
 
-func (a *armDeploymentConfig) GetDeploymentAuthorizationErrors(mpfConfig domain.MPFConfig) (string, error) {
+func (a *armDeploymentConfig) GetDeploymentAuthorizationErrors(ctx context.Context, mpfConfig domain.MPFConfig) (string, error) {
 	// return a.deployARMTemplate(a.armConfig.DeploymentName, mpfConfig)
-	return a.deployARMTemplatev2(a.armConfig.DeploymentName, mpfConfig)
+	return a.deployARMTemplatev2(ctx, a.armConfig.DeploymentName, mpfConfig)
 }
 
-func (a *armDeploymentConfig) CleanDeployment(mpfConfig domain.MPFConfig) error {
+func (a *armDeploymentConfig) CleanDeployment(ctx context.Context, mpfConfig domain.MPFConfig) error {
 	log.Infoln("Cleaning up resources...")
 	log.Infoln("*************************")
 
 	// Cancel deployment. Even if cancelling deployment fails attempt to delete other resources
-	_ = a.cancelDeployment(a.ctx, a.armConfig.DeploymentName, mpfConfig)
+	_ = a.cancelDeployment(ctx, a.armConfig.DeploymentName, mpfConfig)
 
 	return nil
 }
 
-func (a *armDeploymentConfig) deployARMTemplatev2(deploymentName string, mpfConfig domain.MPFConfig) (string, error) {
+func (a *armDeploymentConfig) deployARMTemplatev2(ctx context.Context, deploymentName string, mpfConfig domain.MPFConfig) (string, error) {
 
-	cred, err := azidentity.NewClientSecretCredential(mpfConfig.TenantID, mpfConfig.SP.SPClientID, mpfConfig.SP.SPClientSecret, nil)
+	cred, err := a.azAPIClient.NewSPCredential(mpfConfig.TenantID, mpfConfig.SP.SPClientID, mpfConfig.SP.SPClientSecret)
 	if err != nil {
 		return "", fmt.Errorf("error creating client secret credential: %w", err)
 	}
@@ -88,8 +93,7 @@ func (a *armDeploymentConfig) deployARMTemplatev2(deploymentName string, mpfConf
 		}
 	}()
 
-	ctx := context.Background()
-	clientFactory, err := armresources.NewClientFactory(mpfConfig.SubscriptionID, cred, nil)
+	clientFactory, err := armresources.NewClientFactory(mpfConfig.SubscriptionID, cred, a.azAPIClient.ClientOptions())
 	if err != nil {
 		return "", fmt.Errorf("error creating client factory: %w", err)
 	}
@@ -134,9 +138,15 @@ func (a *armDeploymentConfig) deployARMTemplatev2(deploymentName string, mpfConf
 			Parameters: parameters,
 			Template:   template,
 		},
+		Tags: getDeploymentTags(a.armConfig.Tags),
 	}, nil)
 
 	if err != nil {
+		// the run was cancelled, not a deployment error
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+
 		errMesg := err.Error()
 
 		// Check for different types of errors and handle accordingly
@@ -170,6 +180,10 @@ func (a *armDeploymentConfig) deployARMTemplatev2(deploymentName string, mpfConf
 
 	_, err = poller.PollUntilDone(ctx, nil)
 	if err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+
 		errMesg := err.Error()
 		log.Debugf("Error message: %s", errMesg)
 
@@ -282,10 +296,26 @@ func (a *armDeploymentConfig) cancelDeployment(ctx context.Context, deploymentNa
 
 		// cancel deployment
 		log.Warnf("Could not cancel deployment %s: %s, retrying in a bit", deploymentName, err)
-		time.Sleep(5 * time.Second)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(5 * time.Second):
+		}
 	}
 
 	log.Warnf("Could not cancel deployment %s after %d retries, giving up", deploymentName, maxRetries)
 	return errors.New("could not cancel deployment")
 
 }
+
+// getDeploymentTags converts the tags to the format of the deployment
+func getDeploymentTags(tags map[string]string) map[string]*string {
+	if len(tags) == 0 {
+		return nil
+	}
+	deploymentTags := make(map[string]*string, len(tags))
+	for name, value := range tags {
+		deploymentTags[name] = new(value)
+	}
+	return deploymentTags
+}
@@ -24,6 +24,8 @@ package terraform
 
 import (
 	"context"
+	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
@@ -34,7 +36,6 @@ import (
 )
 
 type terraformDeploymentConfig struct {
-	ctx                            context.Context
 	workingDir                     string
 	execPath                       string
 	varFilePath                    string
@@ -66,18 +67,17 @@ func NewTerraformAuthorizationChecker(workDir string, execPath string, varFilePa
 	return &terraformDeploymentConfig{
 		workingDir:                     workDir,
 		execPath:                       filepath.Clean(execPath),
-		ctx:                            context.Background(),
 		varFilePath:                    varFilePath,
 		importExistingResourcesToState: importExistingResources,
 		targetModule:                   targetModule,
 	}
 }
 
-func (a *terraformDeploymentConfig) GetDeploymentAuthorizationErrors(mpfConfig domain.MPFConfig) (string, error) {
-	return a.deployTerraform(mpfConfig)
+func (a *terraformDeploymentConfig) GetDeploymentAuthorizationErrors(ctx context.Context, mpfConfig domain.MPFConfig) (string, error) {
+	return a.deployTerraform(ctx, mpfConfig)
 }
 
-func (a *terraformDeploymentConfig) CleanDeployment(mpfConfig domain.MPFConfig) error {
+func (a *terraformDeploymentConfig) CleanDeployment(ctx context.Context, mpfConfig domain.MPFConfig) error {
 
 	err := deleteEnteredDestroyPhaseStateFile(a.workingDir, TFDestroyStateEnteredFileName)
 	if err != nil {
@@ -86,10 +86,10 @@ func (a *terraformDeploymentConfig) CleanDeployment(mpfConfig domain.MPFConfig)
 
 	tf, err := tfexec.NewTerraform(a.workingDir, a.execPath)
 	if err != nil {
-		log.Fatalf("error running NewTerraform: %s", err)
+		return fmt.Errorf("error running NewTerraform: %w", err)
 	}
 
-	err = tf.Init(context.Background())
+	err = tf.Init(ctx)
 	if err != nil {
 		log.Warnf("error running Init: %s", err)
 		return err
@@ -97,13 +97,13 @@ func (a *terraformDeploymentConfig) CleanDeployment(mpfConfig domain.MPFConfig)
 
 	switch {
 	case a.varFilePath == "" && a.targetModule == "":
-		err = tf.Destroy(a.ctx)
+		err = tf.Destroy(ctx)
 	case a.varFilePath != "" && a.targetModule == "":
-		err = tf.Destroy(a.ctx, tfexec.VarFile(a.varFilePath))
+		err = tf.Destroy(ctx, tfexec.VarFile(a.varFilePath))
 	case a.varFilePath == "" && a.targetModule != "":
-		err = tf.Destroy(a.ctx, tfexec.Target(a.targetModule))
+		err = tf.Destroy(ctx, tfexec.Target(a.targetModule))
 	case a.varFilePath != "" && a.targetModule != "":
-		err = tf.Destroy(a.ctx, tfexec.VarFile(a.varFilePath), tfexec.Target(a.targetModule))
+		err = tf.Destroy(ctx, tfexec.VarFile(a.varFilePath), tfexec.Target(a.targetModule))
 	}
 	if err != nil {
 		log.Warnf("error running terraform destroy: %s", err)
@@ -118,7 +118,7 @@ func (a *terraformDeploymentConfig) setTFConfig(mpfConfig domain.MPFConfig) (*tf
 
 	tf, err := tfexec.NewTerraform(a.workingDir, a.execPath)
 	if err != nil {
-		log.Fatalf("error running NewTerraform: %s", err)
+		return nil, fmt.Errorf("error running NewTerraform: %w", err)
 	}
 
 	pathEnvVal := os.Getenv("PATH")
@@ -176,6 +176,10 @@ func (a *terraformDeploymentConfig) setTFConfig(mpfConfig domain.MPFConfig) (*tf
 		envVars["TF_REATTACH_PROVIDERS"] = tfReattachProviders
 	}
 
+	for k, v := range getCloudEnvVars(mpfConfig.Cloud) {
+		envVars[k] = v
+	}
+
 	err = tf.SetEnv(envVars)
 	if err != nil {
 		log.Warnf("error setting Terraform env vars: %s", err)
@@ -183,28 +187,43 @@ func (a *terraformDeploymentConfig) setTFConfig(mpfConfig domain.MPFConfig) (*tf
 	return tf, nil
 }
 
-func (a *terraformDeploymentConfig) deployTerraform(mpfConfig domain.MPFConfig) (string, error) {
+// getCloudEnvVars returns the azurerm provider env vars selecting the cloud.
+// The provider reads the endpoints of a custom cloud from the metadata endpoint of its Resource Manager host.
+func getCloudEnvVars(cloud domain.Cloud) map[string]string {
+	envVars := map[string]string{}
+	if cloud.TerraformEnvironment != "" {
+		envVars["ARM_ENVIRONMENT"] = cloud.TerraformEnvironment
+	}
+	if cloud.Name == domain.CustomCloudName && cloud.ResourceManagerEndpoint != "" {
+		if u, err := url.Parse(cloud.ResourceManagerEndpoint); err == nil && u.Host != "" {
+			envVars["ARM_METADATA_HOSTNAME"] = u.Host
+		}
+	}
+	return envVars
+}
+
+func (a *terraformDeploymentConfig) deployTerraform(ctx context.Context, mpfConfig domain.MPFConfig) (string, error) {
 	tf, err := a.setTFConfig(mpfConfig)
 	if err != nil {
-		log.Fatalf("error setting Terraform start config: %s", err)
+		return "", fmt.Errorf("error setting Terraform start config: %w", err)
 	}
 
 	inDestroyPhase = doesEnteredDestroyPhaseStateFileExist(a.workingDir, TFDestroyStateEnteredFileName)
 	if !inDestroyPhase {
 		log.Infof("destroy phase file does not exist, in apply phase")
-		msg, err := a.terraformApply(mpfConfig, tf)
+		msg, err := a.terraformApply(ctx, mpfConfig, tf)
 		if err != nil || msg != "" {
 			return msg, err
 		}
 	}
 
-	return a.terraformDestroy(mpfConfig, tf)
+	return a.terraformDestroy(ctx, mpfConfig, tf)
 
 }
 
-func (a *terraformDeploymentConfig) terraformApply(mpfConfig domain.MPFConfig, tf *tfexec.Terraform) (string, error) {
+func (a *terraformDeploymentConfig) terraformApply(ctx context.Context, mpfConfig domain.MPFConfig, tf *tfexec.Terraform) (string, error) {
 
-	err := tf.Init(context.Background())
+	err := tf.Init(ctx)
 	if err != nil {
 		log.Warnf("error running Init: %s", err)
 		return "", err
@@ -214,19 +233,24 @@ func (a *terraformDeploymentConfig) terraformApply(mpfConfig domain.MPFConfig, t
 
 	switch {
 	case a.varFilePath == "" && a.targetModule == "":
-		err = tf.Apply(a.ctx)
+		err = tf.Apply(ctx)
 	case a.varFilePath != "" && a.targetModule == "":
-		err = tf.Apply(a.ctx, tfexec.VarFile(a.varFilePath))
+		err = tf.Apply(ctx, tfexec.VarFile(a.varFilePath))
 	case a.varFilePath == "" && a.targetModule != "":
-		err = tf.Apply(a.ctx, tfexec.Target(a.targetModule))
+		err = tf.Apply(ctx, tfexec.Target(a.targetModule))
 	case a.varFilePath != "" && a.targetModule != "":
-		err = tf.Apply(a.ctx, tfexec.VarFile(a.varFilePath), tfexec.Target(a.targetModule))
+		err = tf.Apply(ctx, tfexec.VarFile(a.varFilePath), tfexec.Target(a.targetModule))
 	}
 
 	if err == nil {
 		return "", nil
 	}
 
+	// the run was cancelled, not a deployment error
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+
 	errorMsg := err.Error()
 	log.Debugln("terraform apply error: ", errorMsg)
 
@@ -240,14 +264,14 @@ func (a *terraformDeploymentConfig) terraformApply(mpfConfig domain.MPFConfig, t
 	// as described in https://github.com/hashicorp/terraform-provider-azurerm/issues/27961#issuecomment-2467392936
 	if a.importExistingResourcesToState && strings.Contains(errorMsg, TFExistingResourceErrorMsg) {
 
-		msg, err := a.terraformImport(tf, errorMsg)
+		msg, err := a.terraformImport(ctx, tf, errorMsg)
 		if err != nil || msg != "" {
 			if strings.Contains(msg, "Authorization") {
 				return msg, nil
 			}
 			return msg, err
 		}
-		return a.terraformApply(mpfConfig, tf)
+		return a.terraformApply(ctx, mpfConfig, tf)
 	}
 
 	if strings.Contains(errorMsg, "Authorization") || strings.Contains(errorMsg, "LinkedAccessCheckFailed") {
@@ -263,7 +287,7 @@ func (a *terraformDeploymentConfig) terraformApply(mpfConfig domain.MPFConfig, t
 	return errorMsg, err
 }
 
-func (a *terraformDeploymentConfig) terraformImport(tf *tfexec.Terraform, existingResErrMesg string) (string, error) {
+func (a *terraformDeploymentConfig) terraformImport(ctx context.Context, tf *tfexec.Terraform, existingResErrMesg string) (string, error) {
 	log.Warnf("terraform apply: existing resource error occured:|| %s ||\n\n", existingResErrMesg)
 	log.Warn("importing existing resources to state")
 
@@ -276,9 +300,9 @@ func (a *terraformDeploymentConfig) terraformImport(tf *tfexec.Terraform, existi
 	for addr, resID := range exstResAddrAndResIDs {
 		log.Warnf("importing existing resource: %s, %s ||\n", addr, resID)
 		if a.varFilePath != "" {
-			err = tf.Import(a.ctx, addr, resID, tfexec.VarFile(a.varFilePath))
+			err = tf.Import(ctx, addr, resID, tfexec.VarFile(a.varFilePath))
 		} else {
-			err = tf.Import(a.ctx, addr, resID)
+			err = tf.Import(ctx, addr, resID)
 		}
 
 		if err != nil {
@@ -291,7 +315,7 @@ func (a *terraformDeploymentConfig) terraformImport(tf *tfexec.Terraform, existi
 	return "", nil
 }
 
-func (a *terraformDeploymentConfig) terraformDestroy(mpfConfig domain.MPFConfig, tf *tfexec.Terraform) (string, error) {
+func (a *terraformDeploymentConfig) terraformDestroy(ctx context.Context, mpfConfig domain.MPFConfig, tf *tfexec.Terraform) (string, error) {
 	var err error
 	log.Infoln("in destroy phase")
 	if !inDestroyPhase {
@@ -303,16 +327,19 @@ func (a *terraformDeploymentConfig) terraformDestroy(mpfConfig domain.MPFConfig,
 
 	switch {
 	case a.varFilePath == "" && a.targetModule == "":
-		err = tf.Destroy(a.ctx)
+		err = tf.Destroy(ctx)
 	case a.varFilePath != "" && a.targetModule == "":
-		err = tf.Destroy(a.ctx, tfexec.VarFile(a.varFilePath))
+		err = tf.Destroy(ctx, tfexec.VarFile(a.varFilePath))
 	case a.varFilePath == "" && a.targetModule != "":
-		err = tf.Destroy(a.ctx, tfexec.Target(a.targetModule))
+		err = tf.Destroy(ctx, tfexec.Target(a.targetModule))
 	case a.varFilePath != "" && a.targetModule != "":
-		err = tf.Destroy(a.ctx, tfexec.VarFile(a.varFilePath), tfexec.Target(a.targetModule))
+		err = tf.Destroy(ctx, tfexec.VarFile(a.varFilePath), tfexec.Target(a.targetModule))
 	}
 
 	if err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
 		errorMsg := err.Error()
 		log.Debugln(errorMsg)
 		if strings.Contains(errorMsg, "Authorization") {
@@ -0,0 +1,42 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package terraform
+
+import (
+	"testing"
+
+	"github.com/Azure/mpf/pkg/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetCloudEnvVars(t *testing.T) {
+	assert.Empty(t, getCloudEnvVars(domain.Cloud{}))
+	assert.Equal(t, map[string]string{"ARM_ENVIRONMENT": "usgovernment"}, getCloudEnvVars(domain.AzureUSGovernmentCloud))
+
+	cloud, err := domain.GetCloud(domain.CustomCloudName, domain.Cloud{
+		ResourceManagerEndpoint:      "https://management.local.azurestack.external",
+		ActiveDirectoryAuthorityHost: "https://adfs.local.azurestack.external/",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"ARM_METADATA_HOSTNAME": "management.local.azurestack.external"}, getCloudEnvVars(cloud))
+}
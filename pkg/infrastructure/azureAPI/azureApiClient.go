@@ -24,13 +24,20 @@ package azureAPI
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/authorization/armauthorization/v3"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/Azure/mpf/pkg/domain"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -38,9 +45,9 @@ import (
 type AzureAPIClients struct {
 	RoleAssignmentsClient         *armauthorization.RoleAssignmentsClient
 	RoleAssignmentsDeletionClient *armauthorization.RoleAssignmentsClient
-	// RoleDefinitionsClient authorization.RoleDefinitionsClient
-	DeploymentsClient    *armresources.DeploymentsClient
-	ResourceGroupsClient *armresources.ResourceGroupsClient
+	RoleDefinitionsClient         *armauthorization.RoleDefinitionsClient
+	DeploymentsClient             *armresources.DeploymentsClient
+	ResourceGroupsClient          *armresources.ResourceGroupsClient
 
 	// Default CLI Creds
 	CLICred                             *azidentity.AzureCLICredential
@@ -48,26 +55,166 @@ type AzureAPIClients struct {
 	defaultAPIBearerToken               string
 	defaultAPIBearerTokenLastCachedTime time.Time
 	// SPCred                *azidentity.ClientSecretCredential
+
+	options AzureAPIClientsOptions
+	// defaultTokenProvider is DefaultCred, or the credential set in the options
+	defaultTokenProvider TokenProvider
+}
+
+// AzureAPIClientsOptions overrides the Azure Resource Manager endpoint, the credentials and the HTTP transport
+// used by the Azure API clients, e.g. to run MPF against a local fake of Azure Resource Manager
+type AzureAPIClientsOptions struct {
+	// Endpoint is the Azure Resource Manager endpoint. Default is https://management.azure.com
+	Endpoint string
+	// Audience is the audience of Azure Resource Manager access tokens. Default is the endpoint
+	Audience string
+	// ActiveDirectoryAuthorityHost is the Microsoft Entra ID authority host used by the credentials.
+	// Default is the authority host of the Azure public cloud.
+	ActiveDirectoryAuthorityHost string
+	// Credential replaces the Azure CLI and default Azure credentials used to manage the custom role and resource group
+	Credential azcore.TokenCredential
+	// SPCredential returns the credential of the service principal used for the deployments.
+	// Default is a client secret credential.
+	SPCredential func(tenantID, clientID, clientSecret string) (azcore.TokenCredential, error)
+	// Transport sends the HTTP requests. Default is the Azure SDK default transport.
+	Transport policy.Transporter
 }
 
-const defaultTokenCacheDuration = 10 * time.Minute
+const (
+	defaultTokenCacheDuration = 10 * time.Minute
+	// DefaultEndpoint is the Azure Resource Manager endpoint of the Azure public cloud
+	DefaultEndpoint = "https://management.azure.com"
+)
 
-func NewAzureAPIClients(subscriptionID string) *AzureAPIClients {
-	a := &AzureAPIClients{}
+var (
+	defaultOptionsMu sync.RWMutex
+	defaultOptions   AzureAPIClientsOptions
+)
+
+// SetDefaultAzureAPIClientsOptions sets the options used by NewAzureAPIClients
+func SetDefaultAzureAPIClientsOptions(options AzureAPIClientsOptions) {
+	defaultOptionsMu.Lock()
+	defer defaultOptionsMu.Unlock()
+	defaultOptions = options
+}
+
+func getDefaultAzureAPIClientsOptions() AzureAPIClientsOptions {
+	defaultOptionsMu.RLock()
+	defer defaultOptionsMu.RUnlock()
+	return defaultOptions
+}
+
+// GetCloudAzureAPIClientsOptions returns the options making the Azure API clients use the endpoints of the cloud
+func GetCloudAzureAPIClientsOptions(cloud domain.Cloud) AzureAPIClientsOptions {
+	if cloud.IsAzurePublicCloud() {
+		return AzureAPIClientsOptions{}
+	}
+	return AzureAPIClientsOptions{
+		Endpoint:                     cloud.ResourceManagerEndpoint,
+		Audience:                     cloud.ResourceManagerAudience,
+		ActiveDirectoryAuthorityHost: cloud.ActiveDirectoryAuthorityHost,
+	}
+}
+
+// NewAzureAPIClients returns the Azure API clients configured with the default options
+func NewAzureAPIClients(subscriptionID string) (*AzureAPIClients, error) {
+	return NewAzureAPIClientsWithOptions(subscriptionID, getDefaultAzureAPIClientsOptions())
+}
+
+// NewAzureAPIClientsWithOptions returns the Azure API clients configured with options
+func NewAzureAPIClientsWithOptions(subscriptionID string, options AzureAPIClientsOptions) (*AzureAPIClients, error) {
+	a := &AzureAPIClients{
+		options: options,
+	}
 	err := a.SetApiClients(subscriptionID)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
+	}
+	return a, nil
+}
+
+// Endpoint returns the Azure Resource Manager endpoint, without a trailing slash
+func (a *AzureAPIClients) Endpoint() string {
+	if a.options.Endpoint == "" {
+		return DefaultEndpoint
+	}
+	return strings.TrimSuffix(a.options.Endpoint, "/")
+}
+
+// audience returns the audience of Azure Resource Manager access tokens, without a trailing slash
+func (a *AzureAPIClients) audience() string {
+	if a.options.Audience == "" {
+		return a.Endpoint()
 	}
-	return a
+	return strings.TrimSuffix(a.options.Audience, "/")
+}
+
+// cloudConfiguration returns the Azure SDK cloud configuration of the endpoint and authority host
+func (a *AzureAPIClients) cloudConfiguration() cloud.Configuration {
+	if a.options.Endpoint == "" && a.options.ActiveDirectoryAuthorityHost == "" {
+		return cloud.AzurePublic
+	}
+
+	authorityHost := a.options.ActiveDirectoryAuthorityHost
+	if authorityHost == "" {
+		authorityHost = cloud.AzurePublic.ActiveDirectoryAuthorityHost
+	}
+	return cloud.Configuration{
+		ActiveDirectoryAuthorityHost: authorityHost,
+		Services: map[cloud.ServiceName]cloud.ServiceConfiguration{
+			cloud.ResourceManager: {
+				Endpoint: a.Endpoint(),
+				Audience: a.audience(),
+			},
+		},
+	}
+}
+
+// ClientOptions returns the options for Azure SDK resource manager clients
+func (a *AzureAPIClients) ClientOptions() *arm.ClientOptions {
+	if a.options.Endpoint == "" && a.options.ActiveDirectoryAuthorityHost == "" && a.options.Transport == nil {
+		return nil
+	}
+
+	clientOptions := &arm.ClientOptions{}
+	clientOptions.Transport = a.options.Transport
+	clientOptions.Cloud = a.cloudConfiguration()
+	return clientOptions
+}
+
+// azcoreClientOptions returns the options for the Azure identity credentials
+func (a *AzureAPIClients) azcoreClientOptions() azcore.ClientOptions {
+	return azcore.ClientOptions{
+		Cloud:     a.cloudConfiguration(),
+		Transport: a.options.Transport,
+	}
+}
+
+// NewSPCredential returns the credential of the service principal
+func (a *AzureAPIClients) NewSPCredential(tenantID, clientID, clientSecret string) (azcore.TokenCredential, error) {
+	if a.options.SPCredential != nil {
+		return a.options.SPCredential(tenantID, clientID, clientSecret)
+	}
+	return azidentity.NewClientSecretCredential(tenantID, clientID, clientSecret, &azidentity.ClientSecretCredentialOptions{
+		ClientOptions: a.azcoreClientOptions(),
+	})
+}
+
+// Do sends an HTTP request to Azure Resource Manager with the configured transport
+func (a *AzureAPIClients) Do(req *http.Request) (*http.Response, error) {
+	if a.options.Transport != nil {
+		return a.options.Transport.Do(req)
+	}
+	return http.DefaultClient.Do(req)
 }
 
 type TokenProvider interface {
 	GetToken(ctx context.Context, opts policy.TokenRequestOptions) (azcore.AccessToken, error)
 }
 
-func (a *AzureAPIClients) getBearerToken(tp TokenProvider) (bearerToken string, err error) {
-	opts := policy.TokenRequestOptions{Scopes: []string{"https://management.azure.com/.default"}}
-	tok, err := tp.GetToken(context.Background(), opts)
+func (a *AzureAPIClients) getBearerToken(ctx context.Context, tp TokenProvider) (bearerToken string, err error) {
+	opts := policy.TokenRequestOptions{Scopes: []string{a.audience() + "/.default"}}
+	tok, err := tp.GetToken(ctx, opts)
 	if err != nil {
 		return "", err
 	}
@@ -78,59 +225,67 @@ func (a *AzureAPIClients) getBearerToken(tp TokenProvider) (bearerToken string,
 func (a *AzureAPIClients) SetApiClients(subscriptionId string) error {
 	var err error
 
-	a.CLICred, err = azidentity.NewAzureCLICredential(nil)
-	if err != nil {
-		// log.Fatal(err)
-		log.Fatal(err)
+	cliCred, defaultCred := a.options.Credential, a.options.Credential
+	if a.options.Credential == nil {
+		a.CLICred, err = azidentity.NewAzureCLICredential(nil)
+		if err != nil {
+			return fmt.Errorf("failed to create Azure CLI credential: %w", err)
+		}
+
+		// the Azure CLI credential uses the cloud the Azure CLI is logged in to
+		a.DefaultCred, err = azidentity.NewDefaultAzureCredential(&azidentity.DefaultAzureCredentialOptions{
+			ClientOptions: a.azcoreClientOptions(),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create default Azure credential: %w", err)
+		}
+		cliCred, defaultCred = a.CLICred, a.DefaultCred
 	}
+	a.defaultTokenProvider = defaultCred
+	clientOptions := a.ClientOptions()
 
-	a.RoleAssignmentsClient, err = armauthorization.NewRoleAssignmentsClient(subscriptionId, a.CLICred, nil)
+	a.RoleAssignmentsClient, err = armauthorization.NewRoleAssignmentsClient(subscriptionId, cliCred, clientOptions)
 	if err != nil {
-		log.Fatalf("failed to create role assignments client: %v", err)
+		return fmt.Errorf("failed to create role assignments client: %w", err)
 	}
 
-	a.DefaultCred, err = azidentity.NewDefaultAzureCredential(nil)
+	a.RoleAssignmentsDeletionClient, err = armauthorization.NewRoleAssignmentsClient(subscriptionId, defaultCred, clientOptions)
 	if err != nil {
-		// log.Fatal(err)
-		log.Fatal(err)
+		return fmt.Errorf("failed to create role assignments deletion client: %w", err)
 	}
 
-	a.RoleAssignmentsDeletionClient, err = armauthorization.NewRoleAssignmentsClient(subscriptionId, a.DefaultCred, nil)
+	a.RoleDefinitionsClient, err = armauthorization.NewRoleDefinitionsClient(defaultCred, clientOptions)
 	if err != nil {
-		log.Fatalf("failed to create role assignments deletion client: %v", err)
+		return fmt.Errorf("failed to create role definitions client: %w", err)
 	}
 
-	// Set RoleDefinitionsClient
-	// a.RoleDefinitionsClient = authorization.NewRoleDefinitionsClient(subscriptionId)
-	// a.RoleDefinitionsClient.Authorizer = authorizer
-
-	resourcesClientFactory, err := armresources.NewClientFactory(subscriptionId, a.DefaultCred, nil)
+	resourcesClientFactory, err := armresources.NewClientFactory(subscriptionId, defaultCred, clientOptions)
 	if err != nil {
-		log.Fatal(err)
+		return fmt.Errorf("failed to create resources client factory: %w", err)
 	}
 
 	// Set DeploymentsClient
 	a.DeploymentsClient = resourcesClientFactory.NewDeploymentsClient()
 
 	// Set ResourceGroupsClient
-	a.ResourceGroupsClient, err = armresources.NewResourceGroupsClient(subscriptionId, a.DefaultCred, nil)
+	a.ResourceGroupsClient, err = armresources.NewResourceGroupsClient(subscriptionId, defaultCred, clientOptions)
 	if err != nil {
-		log.Fatal(err)
+		return fmt.Errorf("failed to create resource groups client: %w", err)
 	}
 
 	return nil
 
 }
 
-func (a *AzureAPIClients) GetSPBearerToken(tenantID, spClientID, spClientSecret string) (string, error) {
+func (a *AzureAPIClients) GetSPBearerToken(ctx context.Context, tenantID, spClientID, spClientSecret string) (string, error) {
 	// Get the Service Principal creds
-	spCred, err := azidentity.NewClientSecretCredential(tenantID, spClientID, spClientSecret, nil)
+	spCred, err := a.NewSPCredential(tenantID, spClientID, spClientSecret)
 	if err != nil {
 		log.Error(err)
 		return "", err
 	}
 
-	bearerToken, err := a.getBearerToken(spCred)
+	bearerToken, err := a.getBearerToken(ctx, spCred)
 	if err != nil {
 		log.Error(err)
 		return "", err
@@ -140,10 +295,10 @@ func (a *AzureAPIClients) GetSPBearerToken(tenantID, spClientID, spClientSecret
 
 }
 
-func (a *AzureAPIClients) GetDefaultAPIBearerToken() (bearerToken string, err error) {
+func (a *AzureAPIClients) GetDefaultAPIBearerToken(ctx context.Context) (bearerToken string, err error) {
 
 	if a.defaultAPIBearerToken == "" || time.Since(a.defaultAPIBearerTokenLastCachedTime) > defaultTokenCacheDuration {
-		bearerToken, err = a.getBearerToken(a.DefaultCred)
+		bearerToken, err = a.getBearerToken(ctx, a.defaultTokenProvider)
 		if err != nil {
 			return "", err
 		}
@@ -0,0 +1,51 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package builtinroles
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+
+	"github.com/Azure/mpf/pkg/domain"
+	roledefinitions "github.com/Azure/mpf/pkg/infrastructure/roleDefinitions"
+)
+
+// snapshot is a curated subset of the built-in role definitions, in the format of the role definitions list API.
+// Run azmpf builtInRoles refresh to get all built-in roles of a cloud.
+//
+//go:embed builtInRoles.json
+var snapshot []byte
+
+// LoadBuiltInRoles returns the built-in roles of the snapshot file, or of the embedded snapshot if filePath is empty
+func LoadBuiltInRoles(filePath string) ([]domain.RoleDefinition, error) {
+	if filePath == "" {
+		return roledefinitions.ParseRoleDefinitions(snapshot)
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading built-in roles file: %w", err)
+	}
+	return roledefinitions.ParseRoleDefinitions(data)
+}
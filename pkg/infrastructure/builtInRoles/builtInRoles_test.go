@@ -0,0 +1,61 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package builtinroles
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadBuiltInRolesFromSnapshot(t *testing.T) {
+	roles, err := LoadBuiltInRoles("")
+	require.NoError(t, err)
+	require.NotEmpty(t, roles)
+
+	for _, role := range roles {
+		if role.Name != "Contributor" {
+			continue
+		}
+		assert.Equal(t, "b24988ac-6180-42a0-ab88-20f7382dd24c", role.ID)
+		assert.True(t, role.GrantsAction("Microsoft.Storage/storageAccounts/write"))
+		assert.False(t, role.GrantsAction("Microsoft.Authorization/roleAssignments/write"))
+		return
+	}
+	t.Fatal("Contributor not found in the built-in roles snapshot")
+}
+
+func TestLoadBuiltInRolesFromFile(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "builtInRoles.json")
+	err := os.WriteFile(filePath, []byte(`{"value":[{"id":"/providers/Microsoft.Authorization/roleDefinitions/1","name":"1","properties":{"roleName":"Test Role","type":"BuiltInRole","permissions":[{"actions":["Microsoft.Test/*"],"notActions":["Microsoft.Test/a/delete"],"dataActions":[],"notDataActions":[]}]}}]}`), 0600)
+	require.NoError(t, err)
+
+	roles, err := LoadBuiltInRoles(filePath)
+	require.NoError(t, err)
+	require.Len(t, roles, 1)
+	assert.Equal(t, "Test Role", roles[0].Name)
+	assert.Equal(t, []string{"Microsoft.Test/a/delete"}, roles[0].Permissions[0].NotActions)
+}
@@ -0,0 +1,103 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package checkpoint
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Azure/mpf/pkg/domain"
+	log "github.com/sirupsen/logrus"
+)
+
+// FileCheckpointStore saves MPF checkpoints as JSON to a file
+type FileCheckpointStore struct {
+	filePath string
+}
+
+func NewFileCheckpointStore(filePath string) *FileCheckpointStore {
+	return &FileCheckpointStore{
+		filePath: filePath,
+	}
+}
+
+// FilePath returns the path of the checkpoint file
+func (s *FileCheckpointStore) FilePath() string {
+	return s.filePath
+}
+
+// SaveCheckpoint replaces the checkpoint file. The checkpoint is written to a temporary file first,
+// so that the previous checkpoint is kept if MPF is terminated while writing.
+func (s *FileCheckpointStore) SaveCheckpoint(checkpoint domain.MPFCheckpoint) error {
+	data, err := json.MarshalIndent(checkpoint, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(s.filePath), filepath.Base(s.filePath)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("error creating checkpoint file: %w", err)
+	}
+	defer os.Remove(tmpFile.Name()) //nolint:errcheck
+
+	_, err = tmpFile.Write(data)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("error writing checkpoint file: %w", err)
+	}
+
+	if err := os.Rename(tmpFile.Name(), s.filePath); err != nil {
+		return fmt.Errorf("error writing checkpoint file: %w", err)
+	}
+	log.Debugf("Checkpoint saved to %s", s.filePath)
+	return nil
+}
+
+// Delete deletes the checkpoint file, if it exists
+func (s *FileCheckpointStore) Delete() error {
+	err := os.Remove(s.filePath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// LoadCheckpointFromFile reads a checkpoint saved by FileCheckpointStore
+func LoadCheckpointFromFile(filePath string) (domain.MPFCheckpoint, error) {
+	var checkpoint domain.MPFCheckpoint
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return checkpoint, fmt.Errorf("error reading checkpoint file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return checkpoint, fmt.Errorf("error parsing checkpoint file %s: %w", filePath, err)
+	}
+	return checkpoint, nil
+}
@@ -0,0 +1,86 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package checkpoint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Azure/mpf/pkg/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileCheckpointStoreSaveLoadDelete(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), ".azmpf-checkpoint.json")
+	store := NewFileCheckpointStore(filePath)
+
+	checkpoint := domain.MPFCheckpoint{
+		Version:            domain.MPFCheckpointVersion,
+		Mode:               "arm",
+		SubscriptionID:     "SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS",
+		RoleDefinitionName: "tmp-rol-abcdefg",
+		RoleDefinitionID:   "RRRRRRRR-RRRR-RRRR-RRRR-RRRRRRRRRRRR",
+		ResourceGroupName:  "testdeployrg-abcdefg",
+		DeploymentName:     "testDeploy-abcdefg",
+		IterationCount:     3,
+		RequiredPermissions: map[string][]string{
+			"SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS": {"Microsoft.Storage/storageAccounts/write"},
+		},
+		UpdatedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	assert.NoError(t, store.SaveCheckpoint(checkpoint))
+
+	// saving again replaces the checkpoint
+	checkpoint.IterationCount = 4
+	assert.NoError(t, store.SaveCheckpoint(checkpoint))
+
+	loaded, err := LoadCheckpointFromFile(filePath)
+	assert.NoError(t, err)
+	assert.Equal(t, checkpoint, loaded)
+
+	// no temporary files are left behind
+	entries, err := os.ReadDir(filepath.Dir(filePath))
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	assert.NoError(t, store.Delete())
+	_, err = os.Stat(filePath)
+	assert.True(t, os.IsNotExist(err))
+
+	// deleting a missing checkpoint is not an error
+	assert.NoError(t, store.Delete())
+}
+
+func TestLoadCheckpointFromInvalidFile(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "invalid.json")
+	assert.NoError(t, os.WriteFile(filePath, []byte("not json"), 0644))
+
+	_, err := LoadCheckpointFromFile(filePath)
+	assert.Error(t, err)
+
+	_, err = LoadCheckpointFromFile(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,655 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package fakearm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/mpf/pkg/infrastructure/azureAPI"
+	"github.com/google/uuid"
+)
+
+const (
+	adminToken    = "fake-arm-admin"
+	spTokenPrefix = "fake-arm-sp-"
+)
+
+// Config configures the fake Azure Resource Manager server
+type Config struct {
+	// SPClientID and SPObjectID identify the service principal whose permissions are enforced.
+	// All other callers may do everything.
+	SPClientID string
+	SPObjectID string
+	// RequiredActions are the actions the service principal needs to deploy a template resource of each resource type.
+	// Resources of other types are deployed without permission checks.
+	RequiredActions map[string][]string
+	// InvalidActions are rejected in role definitions with an InvalidActionOrNotAction error
+	InvalidActions []string
+}
+
+type resourceGroup struct {
+	id       string
+	name     string
+	location string
+	tags     map[string]string
+}
+
+type deployment struct {
+	provisioningState string
+	tags              map[string]string
+}
+
+type roleDefinition struct {
+	id          string
+	name        string
+	roleName    string
+	actions     []string
+	dataActions []string
+	createdOn   time.Time
+}
+
+type roleAssignment struct {
+	id               string
+	name             string
+	scope            string
+	principalID      string
+	roleDefinitionID string
+}
+
+// Server is a fake of the Azure Resource Manager API used by MPF, for end-to-end tests without an Azure subscription.
+// It implements resource groups, role definitions, role assignments and template deployments,
+// and rejects deployments of the service principal with the authorization errors Azure returns if its roles lack the required actions.
+type Server struct {
+	config Config
+	server *httptest.Server
+
+	mu              sync.Mutex
+	resourceGroups  map[string]resourceGroup
+	roleDefinitions map[string]roleDefinition
+	roleAssignments map[string]roleAssignment
+	deployments     map[string]deployment
+}
+
+// NewServer starts a fake Azure Resource Manager server. Close the server when done.
+func NewServer(config Config) *Server {
+	s := &Server{
+		config:          config,
+		resourceGroups:  make(map[string]resourceGroup),
+		roleDefinitions: make(map[string]roleDefinition),
+		roleAssignments: make(map[string]roleAssignment),
+		deployments:     make(map[string]deployment),
+	}
+	// the Azure SDK only sends bearer tokens over TLS
+	s.server = httptest.NewTLSServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Close shuts the server down
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// URL returns the Azure Resource Manager endpoint of the server
+func (s *Server) URL() string {
+	return s.server.URL
+}
+
+// AzureAPIClientsOptions returns the options which make the Azure API clients use the server
+func (s *Server) AzureAPIClientsOptions() azureAPI.AzureAPIClientsOptions {
+	return azureAPI.AzureAPIClientsOptions{
+		Endpoint:   s.server.URL,
+		Credential: staticTokenCredential(adminToken),
+		SPCredential: func(tenantID, clientID, clientSecret string) (azcore.TokenCredential, error) {
+			return staticTokenCredential(spTokenPrefix + clientID), nil
+		},
+		Transport: s.server.Client(),
+	}
+}
+
+// ResourceGroupExists returns true if the resource group exists
+func (s *Server) ResourceGroupExists(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.resourceGroups[strings.ToLower(name)]
+	return ok
+}
+
+// ResourceGroupTags returns the tags of the resource group, or nil if it does not exist
+func (s *Server) ResourceGroupTags(name string) map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return maps.Clone(s.resourceGroups[strings.ToLower(name)].tags)
+}
+
+// DeploymentTags returns the tags of the deployment in the resource group, or nil if it does not exist
+func (s *Server) DeploymentTags(resourceGroupName string, name string) map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	suffix := strings.ToLower(fmt.Sprintf("/resourcegroups/%s/providers/microsoft.resources/deployments/%s", resourceGroupName, name))
+	for id, d := range s.deployments {
+		if strings.HasSuffix(id, suffix) {
+			return maps.Clone(d.tags)
+		}
+	}
+	return nil
+}
+
+// RoleDefinitionCount returns the number of custom role definitions
+func (s *Server) RoleDefinitionCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.roleDefinitions)
+}
+
+// RoleAssignmentCount returns the number of role assignments
+func (s *Server) RoleAssignmentCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.roleAssignments)
+}
+
+// staticTokenCredential returns the same token for every request, which the server uses to identify the caller
+type staticTokenCredential string
+
+func (c staticTokenCredential) GetToken(ctx context.Context, opts policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	return azcore.AccessToken{Token: string(c), ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	isSP := token == spTokenPrefix+s.config.SPClientID
+	if token != adminToken && !isSP {
+		writeError(w, http.StatusUnauthorized, "InvalidAuthenticationToken", "The access token is invalid.")
+		return
+	}
+
+	path := strings.Trim(r.URL.Path, "/")
+	segments := strings.Split(path, "/")
+	lowerSegments := strings.Split(strings.ToLower(path), "/")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case matchSegments(lowerSegments, "subscriptions", "*", "resourcegroups"):
+		s.listResourceGroups(w, r)
+	case matchSegments(lowerSegments, "subscriptions", "*", "resourcegroups", "*"):
+		s.serveResourceGroup(w, r, segments)
+	case matchSegments(lowerSegments, "subscriptions", "*", "resourcegroups", "*", "providers", "microsoft.resources", "deployments", "*"):
+		s.serveDeployment(w, r, segments, isSP)
+	case matchSegments(lowerSegments, "subscriptions", "*", "resourcegroups", "*", "providers", "microsoft.resources", "deployments", "*", "cancel"):
+		w.WriteHeader(http.StatusNoContent)
+	case matchSegments(lowerSegments, "subscriptions", "*", "providers", "microsoft.authorization", "roledefinitions"):
+		s.listRoleDefinitions(w, r)
+	case matchSegments(lowerSegments, "subscriptions", "*", "providers", "microsoft.authorization", "roledefinitions", "*"):
+		s.serveRoleDefinition(w, r, segments)
+	case matchSegments(lowerSegments, "subscriptions", "*", "providers", "microsoft.authorization", "roleassignments"):
+		s.listRoleAssignments(w, r)
+	case matchSegments(lowerSegments, "subscriptions", "*", "providers", "microsoft.authorization", "roleassignments", "*"):
+		s.serveRoleAssignment(w, r, segments)
+	default:
+		writeError(w, http.StatusNotFound, "NotFound", fmt.Sprintf("The fake Azure Resource Manager does not implement %s %s", r.Method, r.URL.Path))
+	}
+}
+
+func (s *Server) listResourceGroups(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", r.Method)
+		return
+	}
+
+	value := []map[string]any{}
+	for _, group := range s.resourceGroups {
+		value = append(value, resourceGroupBody(group))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"value": value})
+}
+
+func (s *Server) serveResourceGroup(w http.ResponseWriter, r *http.Request, segments []string) {
+	name := segments[3]
+	id := "/" + strings.Join(segments, "/")
+
+	switch r.Method {
+	case http.MethodGet:
+		group, ok := s.resourceGroups[strings.ToLower(name)]
+		if !ok {
+			writeError(w, http.StatusNotFound, "ResourceGroupNotFound", fmt.Sprintf("Resource group '%s' could not be found.", name))
+			return
+		}
+		writeJSON(w, http.StatusOK, resourceGroupBody(group))
+	case http.MethodPut:
+		var body struct {
+			Location string            `json:"location"`
+			Tags     map[string]string `json:"tags"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, "InvalidRequestContent", err.Error())
+			return
+		}
+		group := resourceGroup{id: id, name: name, location: body.Location, tags: body.Tags}
+		s.resourceGroups[strings.ToLower(name)] = group
+		writeJSON(w, http.StatusCreated, resourceGroupBody(group))
+	case http.MethodDelete:
+		if _, ok := s.resourceGroups[strings.ToLower(name)]; !ok {
+			writeError(w, http.StatusNotFound, "ResourceGroupNotFound", fmt.Sprintf("Resource group '%s' could not be found.", name))
+			return
+		}
+		delete(s.resourceGroups, strings.ToLower(name))
+		w.WriteHeader(http.StatusOK)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", r.Method)
+	}
+}
+
+func resourceGroupBody(group resourceGroup) map[string]any {
+	return map[string]any{
+		"id":         group.id,
+		"name":       group.name,
+		"location":   group.location,
+		"tags":       group.tags,
+		"properties": map[string]any{"provisioningState": "Succeeded"},
+	}
+}
+
+func (s *Server) serveDeployment(w http.ResponseWriter, r *http.Request, segments []string, isSP bool) {
+	resourceGroupName := segments[3]
+	name := segments[7]
+	id := "/" + strings.Join(segments, "/")
+	// Azure reports scopes with the resourceGroups casing, whichever casing the request used
+	resourceGroupScope := fmt.Sprintf("/subscriptions/%s/resourceGroups/%s", segments[1], resourceGroupName)
+
+	switch r.Method {
+	case http.MethodGet:
+		existing, ok := s.deployments[strings.ToLower(id)]
+		if !ok {
+			writeError(w, http.StatusNotFound, "DeploymentNotFound", fmt.Sprintf("Deployment '%s' could not be found.", name))
+			return
+		}
+		writeJSON(w, http.StatusOK, deploymentBody(id, name, existing))
+	case http.MethodPut:
+		if _, ok := s.resourceGroups[strings.ToLower(resourceGroupName)]; !ok {
+			writeError(w, http.StatusNotFound, "ResourceGroupNotFound", fmt.Sprintf("Resource group '%s' could not be found.", resourceGroupName))
+			return
+		}
+
+		var body struct {
+			Properties struct {
+				Template   armTemplate    `json:"template"`
+				Parameters map[string]any `json:"parameters"`
+			} `json:"properties"`
+			Tags map[string]string `json:"tags"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, "InvalidRequestContent", err.Error())
+			return
+		}
+
+		if isSP {
+			actions := s.getSPActions()
+			if !isActionGranted(actions, "Microsoft.Resources/deployments/write") {
+				writeError(w, http.StatusForbidden, "AuthorizationFailed", fmt.Sprintf("The client '%s' with object id '%s' does not have authorization to perform action 'Microsoft.Resources/deployments/write' over scope '%s' or the scope is invalid. If access was recently granted, please refresh your credentials.", s.config.SPClientID, s.config.SPObjectID, id))
+				return
+			}
+
+			authorizationErrors := s.getTemplateAuthorizationErrors(actions, resourceGroupScope, body.Properties.Template, body.Properties.Parameters)
+			if len(authorizationErrors) == 1 {
+				writeError(w, http.StatusBadRequest, "InvalidTemplateDeployment", fmt.Sprintf("The template deployment failed with error: '%s'.", authorizationErrors[0]))
+				return
+			}
+			if len(authorizationErrors) > 1 {
+				writeError(w, http.StatusBadRequest, "InvalidTemplateDeployment", fmt.Sprintf("Deployment failed with multiple errors: '%s'", strings.Join(authorizationErrors, ":")))
+				return
+			}
+		}
+
+		created := deployment{provisioningState: "Succeeded", tags: body.Tags}
+		s.deployments[strings.ToLower(id)] = created
+		writeJSON(w, http.StatusOK, deploymentBody(id, name, created))
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", r.Method)
+	}
+}
+
+func deploymentBody(id, name string, d deployment) map[string]any {
+	return map[string]any{
+		"id":         id,
+		"name":       name,
+		"type":       "Microsoft.Resources/deployments",
+		"tags":       d.tags,
+		"properties": map[string]any{"provisioningState": d.provisioningState},
+	}
+}
+
+type armTemplate struct {
+	Parameters map[string]struct {
+		DefaultValue any `json:"defaultValue"`
+	} `json:"parameters"`
+	Resources []struct {
+		Type string `json:"type"`
+		Name string `json:"name"`
+	} `json:"resources"`
+}
+
+var parameterExpressionRe = regexp.MustCompile(`^\[parameters\('([^']+)'\)\]$`)
+
+// getTemplateAuthorizationErrors returns an "Authorization failed for template resource" error
+// for every required action of a template resource the service principal is not granted
+func (s *Server) getTemplateAuthorizationErrors(actions []string, resourceGroupScope string, template armTemplate, parameters map[string]any) []string {
+	var authorizationErrors []string
+	for _, resource := range template.Resources {
+		requiredActions := s.getRequiredActions(resource.Type)
+		if len(requiredActions) == 0 {
+			continue
+		}
+
+		// only literal names and parameter references are resolved
+		name := resource.Name
+		if match := parameterExpressionRe.FindStringSubmatch(name); match != nil {
+			name = match[1]
+			if parameter, ok := parameters[match[1]].(map[string]any); ok {
+				name = fmt.Sprint(parameter["value"])
+			} else if parameter, ok := template.Parameters[match[1]]; ok && parameter.DefaultValue != nil {
+				name = fmt.Sprint(parameter.DefaultValue)
+			}
+		}
+		scope := resourceGroupScope + "/providers/" + getResourceIDPath(resource.Type, name)
+
+		for _, action := range requiredActions {
+			if isActionGranted(actions, action) {
+				continue
+			}
+			authorizationErrors = append(authorizationErrors, fmt.Sprintf("Authorization failed for template resource '%s' of type '%s'. The client '%s' with object id '%s' does not have permission to perform action '%s' at scope '%s'.", name, resource.Type, s.config.SPClientID, s.config.SPObjectID, action, scope))
+		}
+	}
+	return authorizationErrors
+}
+
+func (s *Server) getRequiredActions(resourceType string) []string {
+	for configuredType, actions := range s.config.RequiredActions {
+		if strings.EqualFold(configuredType, resourceType) {
+			return actions
+		}
+	}
+	return nil
+}
+
+// getResourceIDPath returns the resource ID path of a resource, e.g. Microsoft.Compute/virtualMachines/vm/extensions/ext
+// for type Microsoft.Compute/virtualMachines/extensions and name vm/ext
+func getResourceIDPath(resourceType, name string) string {
+	typeSegments := strings.Split(resourceType, "/")
+	nameSegments := strings.Split(name, "/")
+	path := typeSegments[0]
+	for i, typeSegment := range typeSegments[1:] {
+		path += "/" + typeSegment
+		if i < len(nameSegments) {
+			path += "/" + nameSegments[i]
+		}
+	}
+	return path
+}
+
+// getSPActions returns the actions of all role definitions assigned to the service principal
+func (s *Server) getSPActions() []string {
+	var actions []string
+	for _, assignment := range s.roleAssignments {
+		if !strings.EqualFold(assignment.principalID, s.config.SPObjectID) {
+			continue
+		}
+		if definition, ok := s.roleDefinitions[strings.ToLower(assignment.roleDefinitionID)]; ok {
+			actions = append(actions, definition.actions...)
+		}
+	}
+	return actions
+}
+
+// isActionGranted returns true if an action matches action. Actions may contain * wildcards and are case insensitive.
+func isActionGranted(actions []string, action string) bool {
+	for _, grantedAction := range actions {
+		pattern := "(?i)^" + strings.ReplaceAll(regexp.QuoteMeta(grantedAction), `\*`, ".*") + "$"
+		if matched, _ := regexp.MatchString(pattern, action); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// listRoleDefinitions lists the custom role definitions, as the server has no built-in roles
+func (s *Server) listRoleDefinitions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", r.Method)
+		return
+	}
+
+	value := []map[string]any{}
+	for _, definition := range s.roleDefinitions {
+		value = append(value, roleDefinitionBody(definition))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"value": value})
+}
+
+func (s *Server) serveRoleDefinition(w http.ResponseWriter, r *http.Request, segments []string) {
+	name := segments[5]
+	id := "/" + strings.Join(segments, "/")
+
+	switch r.Method {
+	case http.MethodGet:
+		definition, ok := s.roleDefinitions[strings.ToLower(id)]
+		if !ok {
+			writeError(w, http.StatusNotFound, "RoleDefinitionDoesNotExist", "The specified role definition does not exist.")
+			return
+		}
+		writeJSON(w, http.StatusOK, roleDefinitionBody(definition))
+	case http.MethodPut:
+		var body struct {
+			Properties struct {
+				RoleName    string `json:"roleName"`
+				Permissions []struct {
+					Actions     []string `json:"actions"`
+					DataActions []string `json:"dataActions"`
+				} `json:"permissions"`
+			} `json:"properties"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, "InvalidRequestContent", err.Error())
+			return
+		}
+
+		definition := roleDefinition{id: id, name: name, roleName: body.Properties.RoleName, createdOn: time.Now().UTC()}
+		if existing, ok := s.roleDefinitions[strings.ToLower(id)]; ok {
+			definition.createdOn = existing.createdOn
+		}
+		for _, permission := range body.Properties.Permissions {
+			definition.actions = append(definition.actions, permission.Actions...)
+			definition.dataActions = append(definition.dataActions, permission.DataActions...)
+		}
+		// like Azure, only the first invalid action is reported
+		for _, action := range append(slices.Clone(definition.actions), definition.dataActions...) {
+			if slices.Contains(s.config.InvalidActions, action) {
+				writeError(w, http.StatusBadRequest, "InvalidActionOrNotAction", fmt.Sprintf("'%s' does not match any of the actions supported by the providers.", action))
+				return
+			}
+		}
+
+		s.roleDefinitions[strings.ToLower(id)] = definition
+		writeJSON(w, http.StatusCreated, roleDefinitionBody(definition))
+	case http.MethodDelete:
+		definition, ok := s.roleDefinitions[strings.ToLower(id)]
+		if !ok {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		delete(s.roleDefinitions, strings.ToLower(id))
+		writeJSON(w, http.StatusOK, roleDefinitionBody(definition))
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", r.Method)
+	}
+}
+
+func roleDefinitionBody(definition roleDefinition) map[string]any {
+	return map[string]any{
+		"id":   definition.id,
+		"name": definition.name,
+		"type": "Microsoft.Authorization/roleDefinitions",
+		"properties": map[string]any{
+			"roleName":  definition.roleName,
+			"roleType":  "CustomRole",
+			"createdOn": definition.createdOn.Format(time.RFC3339),
+			"permissions": []map[string]any{
+				{
+					"actions":     definition.actions,
+					"dataActions": definition.dataActions,
+				},
+			},
+		},
+	}
+}
+
+var assignedToFilterRe = regexp.MustCompile(`assignedTo\('([^']+)'\)`)
+
+func (s *Server) listRoleAssignments(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", r.Method)
+		return
+	}
+
+	principalID := ""
+	if match := assignedToFilterRe.FindStringSubmatch(r.URL.Query().Get("$filter")); match != nil {
+		principalID = match[1]
+	}
+
+	value := []map[string]any{}
+	for _, assignment := range s.roleAssignments {
+		if principalID != "" && !strings.EqualFold(assignment.principalID, principalID) {
+			continue
+		}
+		value = append(value, roleAssignmentBody(assignment))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"value": value})
+}
+
+func (s *Server) serveRoleAssignment(w http.ResponseWriter, r *http.Request, segments []string) {
+	id := "/" + strings.Join(segments, "/")
+
+	switch r.Method {
+	case http.MethodGet:
+		assignment, ok := s.roleAssignments[strings.ToLower(id)]
+		if !ok {
+			writeError(w, http.StatusNotFound, "RoleAssignmentNotFound", "The role assignment does not exist.")
+			return
+		}
+		writeJSON(w, http.StatusOK, roleAssignmentBody(assignment))
+	case http.MethodPut:
+		var body struct {
+			Properties struct {
+				PrincipalID      string `json:"principalId"`
+				RoleDefinitionID string `json:"roleDefinitionId"`
+			} `json:"properties"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, "InvalidRequestContent", err.Error())
+			return
+		}
+		if _, ok := s.roleDefinitions[strings.ToLower(body.Properties.RoleDefinitionID)]; !ok {
+			writeError(w, http.StatusBadRequest, "RoleDefinitionDoesNotExist", "The specified role definition does not exist.")
+			return
+		}
+
+		assignment := roleAssignment{
+			id:               id,
+			name:             segments[len(segments)-1],
+			scope:            "/" + strings.Join(segments[:2], "/"),
+			principalID:      body.Properties.PrincipalID,
+			roleDefinitionID: body.Properties.RoleDefinitionID,
+		}
+		if _, err := uuid.Parse(assignment.name); err != nil {
+			writeError(w, http.StatusBadRequest, "InvalidRoleAssignmentId", "The role assignment ID is not a valid GUID.")
+			return
+		}
+		s.roleAssignments[strings.ToLower(id)] = assignment
+		writeJSON(w, http.StatusCreated, roleAssignmentBody(assignment))
+	case http.MethodDelete:
+		assignment, ok := s.roleAssignments[strings.ToLower(id)]
+		if !ok {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		delete(s.roleAssignments, strings.ToLower(id))
+		writeJSON(w, http.StatusOK, roleAssignmentBody(assignment))
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", r.Method)
+	}
+}
+
+func roleAssignmentBody(assignment roleAssignment) map[string]any {
+	return map[string]any{
+		"id":   assignment.id,
+		"name": assignment.name,
+		"type": "Microsoft.Authorization/roleAssignments",
+		"properties": map[string]any{
+			"scope":            assignment.scope,
+			"principalId":      assignment.principalID,
+			"principalType":    "ServicePrincipal",
+			"roleDefinitionId": assignment.roleDefinitionID,
+		},
+	}
+}
+
+// matchSegments returns true if the path segments match the pattern, where * matches any segment
+func matchSegments(segments []string, pattern ...string) bool {
+	if len(segments) != len(pattern) {
+		return false
+	}
+	for i, segment := range pattern {
+		if segment != "*" && segment != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, statusCode int, code string, message string) {
+	writeJSON(w, statusCode, map[string]any{
+		"error": map[string]any{
+			"code":    code,
+			"message": message,
+		},
+	})
+}
@@ -0,0 +1,58 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package fakearm
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetResourceIDPath(t *testing.T) {
+	assert.Equal(t, "Microsoft.Storage/storageAccounts/sa", getResourceIDPath("Microsoft.Storage/storageAccounts", "sa"))
+	assert.Equal(t, "Microsoft.Compute/virtualMachines/vm/extensions/ext", getResourceIDPath("Microsoft.Compute/virtualMachines/extensions", "vm/ext"))
+}
+
+func TestIsActionGranted(t *testing.T) {
+	actions := []string{"Microsoft.Resources/deployments/*", "Microsoft.Storage/storageAccounts/write"}
+
+	assert.True(t, isActionGranted(actions, "Microsoft.Resources/deployments/write"))
+	assert.True(t, isActionGranted(actions, "microsoft.storage/storageaccounts/write"))
+	assert.False(t, isActionGranted(actions, "Microsoft.Storage/storageAccounts/read"))
+	assert.False(t, isActionGranted(actions, "Microsoft.Storage/storageAccounts/writeX"))
+}
+
+func TestServerRejectsUnknownToken(t *testing.T) {
+	server := NewServer(Config{})
+	defer server.Close()
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, server.URL()+"/subscriptions/sub/resourcegroups/rg", nil)
+	assert.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer unknown")
+
+	resp, err := server.AzureAPIClientsOptions().Transport.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close() //nolint:errcheck
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
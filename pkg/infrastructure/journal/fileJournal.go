@@ -0,0 +1,111 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/Azure/mpf/pkg/domain"
+)
+
+// FileJournal writes MPF journal entries to a file in JSON Lines format, one entry per line
+type FileJournal struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileJournal creates the journal file. If appendToFile is true, entries are appended to an existing file,
+// e.g. when resuming an interrupted run, otherwise an existing file is truncated.
+func NewFileJournal(filePath string, appendToFile bool) (*FileJournal, error) {
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if appendToFile {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+
+	file, err := os.OpenFile(filePath, flags, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("error creating journal file: %w", err)
+	}
+
+	return &FileJournal{
+		file: file,
+	}, nil
+}
+
+// WriteJournalEntry appends the entry to the journal file.
+// The file is synced after every entry, so that the journal is complete up to the last iteration if MPF is terminated.
+func (j *FileJournal) WriteJournalEntry(entry domain.MPFJournalEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if _, err := j.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("error writing journal file: %w", err)
+	}
+	return j.file.Sync()
+}
+
+// Close closes the journal file
+func (j *FileJournal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.file.Close()
+}
+
+// LoadJournalFromFile reads the entries of a journal written by FileJournal
+func LoadJournalFromFile(filePath string) ([]domain.MPFJournalEntry, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading journal file: %w", err)
+	}
+	defer file.Close() //nolint:errcheck
+
+	var entries []domain.MPFJournalEntry
+	scanner := bufio.NewScanner(file)
+	// authorization errors of large deployments can exceed the default token size
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry domain.MPFJournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("error parsing journal file %s line %d: %w", filePath, lineNumber, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading journal file: %w", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,100 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package journal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Azure/mpf/pkg/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileJournalWriteLoad(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "journal.jsonl")
+
+	entries := []domain.MPFJournalEntry{
+		{
+			Iteration: 0,
+			Timestamp: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+			Outcome:   domain.JournalAuthorizationErrors,
+			AuthorizationError: "Authorization failed for template resource 'sa' of type 'Microsoft.Storage/storageAccounts'. " +
+				"The client 'CCCCCCCC-CCCC-CCCC-CCCC-CCCCCCCCCCCC' with object id 'OOOOOOOO-OOOO-OOOO-OOOO-OOOOOOOOOOOO' does not have permission to perform action 'Microsoft.Storage/storageAccounts/write' at scope '/subscriptions/SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS/resourceGroups/rg/providers/Microsoft.Storage/storageAccounts/sa'.",
+			Findings: []domain.AuthorizationFinding{
+				{
+					Action:       "Microsoft.Storage/storageAccounts/write",
+					Scope:        "/subscriptions/SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS/resourceGroups/rg/providers/Microsoft.Storage/storageAccounts/sa",
+					SourceParser: domain.TemplateResourceAuthorizationFailedParserName,
+				},
+			},
+			PermissionsAdded: map[string][]string{
+				"/subscriptions/SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS/resourceGroups/rg/providers/Microsoft.Storage/storageAccounts/sa": {"Microsoft.Storage/storageAccounts/write"},
+			},
+			InvalidActions: []string{"Microsoft.Invalid/action"},
+			WaitSeconds:    5,
+		},
+		{
+			Iteration: 1,
+			Timestamp: time.Date(2026, 1, 2, 3, 4, 15, 0, time.UTC),
+			Outcome:   domain.JournalSucceeded,
+		},
+	}
+
+	journal, err := NewFileJournal(filePath, false)
+	assert.NoError(t, err)
+	assert.NoError(t, journal.WriteJournalEntry(entries[0]))
+	assert.NoError(t, journal.Close())
+
+	// appending keeps the existing entries
+	journal, err = NewFileJournal(filePath, true)
+	assert.NoError(t, err)
+	assert.NoError(t, journal.WriteJournalEntry(entries[1]))
+	assert.NoError(t, journal.Close())
+
+	loaded, err := LoadJournalFromFile(filePath)
+	assert.NoError(t, err)
+	assert.Equal(t, entries, loaded)
+
+	// a new journal replaces the existing file
+	journal, err = NewFileJournal(filePath, false)
+	assert.NoError(t, err)
+	assert.NoError(t, journal.WriteJournalEntry(entries[1]))
+	assert.NoError(t, journal.Close())
+
+	loaded, err = LoadJournalFromFile(filePath)
+	assert.NoError(t, err)
+	assert.Equal(t, entries[1:], loaded)
+}
+
+func TestLoadJournalFromInvalidFile(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "invalid.jsonl")
+	assert.NoError(t, os.WriteFile(filePath, []byte("{\"Iteration\":0}\nnot json\n"), 0644))
+
+	_, err := LoadJournalFromFile(filePath)
+	assert.ErrorContains(t, err, "line 2")
+
+	_, err = LoadJournalFromFile(filepath.Join(t.TempDir(), "missing.jsonl"))
+	assert.Error(t, err)
+}
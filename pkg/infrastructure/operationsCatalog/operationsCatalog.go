@@ -0,0 +1,165 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package operationscatalog
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/Azure/mpf/pkg/domain"
+	"github.com/Azure/mpf/pkg/infrastructure/azureAPI"
+	log "github.com/sirupsen/logrus"
+)
+
+// snapshot holds the operations of common resource types, in the format of the provider operations API.
+// Run azmpf operations refresh to get the operations of all resource providers of a cloud.
+//
+//go:embed providerOperations.json
+var snapshot []byte
+
+const providerOperationsAPIVersion = "2022-04-01"
+
+type providerOperationsList struct {
+	Value    []providerOperations `json:"value"`
+	NextLink string               `json:"nextLink,omitempty"`
+}
+
+type providerOperations struct {
+	Name          string                   `json:"name"`
+	Operations    []operation              `json:"operations"`
+	ResourceTypes []resourceTypeOperations `json:"resourceTypes"`
+}
+
+type resourceTypeOperations struct {
+	Name       string      `json:"name"`
+	Operations []operation `json:"operations"`
+}
+
+type operation struct {
+	Name         string `json:"name"`
+	IsDataAction bool   `json:"isDataAction"`
+}
+
+// LoadOperationsCatalog returns the catalog of the snapshot file, or of the embedded snapshot if filePath is empty
+func LoadOperationsCatalog(filePath string) (*domain.OperationsCatalog, error) {
+	if filePath == "" {
+		return ParseOperationsCatalog(snapshot)
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading operations catalog file: %w", err)
+	}
+	return ParseOperationsCatalog(data)
+}
+
+// ParseOperationsCatalog parses provider operations in the format of the provider operations API,
+// e.g. the output of az provider operation list
+func ParseOperationsCatalog(data []byte) (*domain.OperationsCatalog, error) {
+	var providers []providerOperations
+	var list providerOperationsList
+	if err := json.Unmarshal(data, &list); err == nil {
+		providers = list.Value
+	} else if err := json.Unmarshal(data, &providers); err != nil {
+		return nil, fmt.Errorf("error parsing operations catalog: %w", err)
+	}
+
+	var operations []string
+	for _, provider := range providers {
+		for _, op := range provider.Operations {
+			operations = append(operations, op.Name)
+		}
+		for _, resourceType := range provider.ResourceTypes {
+			for _, op := range resourceType.Operations {
+				operations = append(operations, op.Name)
+			}
+		}
+	}
+	return domain.NewOperationsCatalog(operations), nil
+}
+
+// FetchProviderOperations returns the operations of all resource providers of the cloud, in the format of the snapshot file.
+// Only the operation names are kept, as the descriptions make up most of the response.
+func FetchProviderOperations(ctx context.Context, azAPIClient *azureAPI.AzureAPIClients) ([]byte, error) {
+	query := url.Values{}
+	query.Set("$expand", "resourceTypes")
+	query.Set("api-version", providerOperationsAPIVersion)
+	nextLink := fmt.Sprintf("%s/providers/Microsoft.Authorization/providerOperations?%s", azAPIClient.Endpoint(), query.Encode())
+
+	all := providerOperationsList{Value: []providerOperations{}}
+	for nextLink != "" {
+		page, err := getProviderOperationsPage(ctx, azAPIClient, nextLink)
+		if err != nil {
+			return nil, err
+		}
+		all.Value = append(all.Value, page.Value...)
+		nextLink = page.NextLink
+	}
+	log.Infof("Fetched the operations of %d resource providers", len(all.Value))
+
+	return json.MarshalIndent(all, "", "  ")
+}
+
+func getProviderOperationsPage(ctx context.Context, azAPIClient *azureAPI.AzureAPIClients, pageURL string) (providerOperationsList, error) {
+	var page providerOperationsList
+
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
+	if err != nil {
+		return page, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "Go HTTP Client")
+
+	defaultApiBearerToken, err := azAPIClient.GetDefaultAPIBearerToken(ctx)
+	if err != nil {
+		return page, err
+	}
+	req.Header.Add("Authorization", "Bearer "+defaultApiBearerToken)
+
+	resp, err := azAPIClient.Do(req)
+	if err != nil {
+		return page, err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return page, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return page, fmt.Errorf("failed to list provider operations. Status: %s, Body: %s", resp.Status, string(body))
+	}
+
+	if err := json.Unmarshal(body, &page); err != nil {
+		return page, fmt.Errorf("error parsing provider operations: %w", err)
+	}
+	return page, nil
+}
@@ -0,0 +1,160 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package operationscatalog
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/mpf/pkg/infrastructure/azureAPI"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testProviderOperations = `{"name":"Microsoft.Web","displayName":"Azure App Service","operations":[{"name":"Microsoft.Web/register/action","display":{"description":"Registers the provider"},"isDataAction":false}],
+"resourceTypes":[{"name":"sites","operations":[{"name":"Microsoft.Web/sites/read","isDataAction":false},{"name":"Microsoft.Web/sites/write","isDataAction":false}]}]}`
+
+func TestLoadOperationsCatalogSnapshot(t *testing.T) {
+	catalog, err := LoadOperationsCatalog("")
+	require.NoError(t, err)
+	assert.Positive(t, catalog.Len())
+	assert.Equal(t, "Microsoft.Storage/storageAccounts/write", catalog.NormalizeAction("microsoft.storage/storageaccounts/write"))
+	assert.False(t, catalog.IsInvalidAction("Microsoft.KeyVault/vaults/accessPolicies/write"))
+	assert.True(t, catalog.IsInvalidAction("Microsoft.KeyVault/vaults/accessPolicies/read"))
+}
+
+func TestLoadOperationsCatalogFile(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"provider operations API", `{"value":[` + testProviderOperations + `]}`},
+		{"az provider operation list", `[` + testProviderOperations + `]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), "providerOperations.json")
+			require.NoError(t, os.WriteFile(filePath, []byte(tt.data), 0o600))
+
+			catalog, err := LoadOperationsCatalog(filePath)
+			require.NoError(t, err)
+			assert.Equal(t, 3, catalog.Len())
+			assert.Equal(t, "Microsoft.Web/register/action", catalog.NormalizeAction("microsoft.web/register/action"))
+			assert.True(t, catalog.IsInvalidAction("Microsoft.Web/sites/delete"))
+		})
+	}
+}
+
+func TestLoadOperationsCatalogErrors(t *testing.T) {
+	_, err := LoadOperationsCatalog(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+
+	_, err = ParseOperationsCatalog([]byte("not json"))
+	assert.Error(t, err)
+}
+
+type staticTokenCredential string
+
+func (c staticTokenCredential) GetToken(ctx context.Context, opts policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	return azcore.AccessToken{Token: string(c), ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+func newTestAzureAPIClients(t *testing.T, handler http.HandlerFunc) *azureAPI.AzureAPIClients {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	azAPIClients, err := azureAPI.NewAzureAPIClientsWithOptions("sub", azureAPI.AzureAPIClientsOptions{
+		Endpoint:   server.URL,
+		Credential: staticTokenCredential("token"),
+	})
+	require.NoError(t, err)
+	return azAPIClients
+}
+
+func TestFetchProviderOperations(t *testing.T) {
+	var expand string
+	azAPIClient := newTestAzureAPIClients(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "2" {
+			fmt.Fprintf(w, `{"value":[%s]}`, testProviderOperations)
+			return
+		}
+		expand = r.URL.Query().Get("$expand")
+		fmt.Fprintf(w, `{"value":[],"nextLink":"http://%s%s?page=2"}`, r.Host, r.URL.Path)
+	})
+
+	data, err := FetchProviderOperations(context.Background(), azAPIClient)
+	require.NoError(t, err)
+	assert.Equal(t, "resourceTypes", expand)
+	assert.NotContains(t, string(data), "Registers the provider")
+
+	catalog, err := ParseOperationsCatalog(data)
+	require.NoError(t, err)
+	assert.Equal(t, 3, catalog.Len())
+}
+
+func TestFetchProviderOperationsError(t *testing.T) {
+	azAPIClient := newTestAzureAPIClients(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"error":{"code":"AuthorizationFailed"}}`)
+	})
+
+	_, err := FetchProviderOperations(context.Background(), azAPIClient)
+	assert.Error(t, err)
+}
+
+type testContextKey struct{}
+
+// contextTokenCredential records the value of testContextKey of the context tokens are requested with
+type contextTokenCredential struct {
+	values []any
+}
+
+func (c *contextTokenCredential) GetToken(ctx context.Context, opts policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	c.values = append(c.values, ctx.Value(testContextKey{}))
+	return azcore.AccessToken{Token: "token", ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+func TestFetchProviderOperationsRequestsTokenWithContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"value":[]}`)
+	}))
+	t.Cleanup(server.Close)
+	credential := &contextTokenCredential{}
+	azAPIClient, err := azureAPI.NewAzureAPIClientsWithOptions("sub", azureAPI.AzureAPIClientsOptions{
+		Endpoint:   server.URL,
+		Credential: credential,
+	})
+	require.NoError(t, err)
+
+	ctx := context.WithValue(t.Context(), testContextKey{}, "caller")
+	_, err = FetchProviderOperations(ctx, azAPIClient)
+	require.NoError(t, err)
+	assert.Equal(t, []any{"caller"}, credential.values)
+}
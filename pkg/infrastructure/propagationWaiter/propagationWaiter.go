@@ -0,0 +1,205 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package propagationwaiter
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/Azure/mpf/pkg/domain"
+	log "github.com/sirupsen/logrus"
+)
+
+// Names of the propagation wait strategies
+const (
+	FixedStrategy       = "fixed"
+	ExponentialStrategy = "exponential"
+	VerifyStrategy      = "verify"
+)
+
+// Strategies lists the supported propagation wait strategies
+var Strategies = []string{FixedStrategy, ExponentialStrategy, VerifyStrategy}
+
+// RBACStateReader reads the RBAC state the verify strategy polls for
+type RBACStateReader interface {
+	// GetCustomRolePermissions returns the actions and data actions of the custom role
+	GetCustomRolePermissions(ctx context.Context, subscription string, role domain.Role) ([]string, []string, error)
+	// CountRoleAssignments returns the number of assignments of the role to the service principal,
+	// or the number of all its role assignments if the role has no resource ID
+	CountRoleAssignments(ctx context.Context, subscription string, SPObjectID string, role domain.Role) (int, error)
+}
+
+// FixedPropagationWaiter waits for a fixed duration after every change
+type FixedPropagationWaiter struct {
+	initialWait time.Duration
+	wait        time.Duration
+}
+
+func NewFixedPropagationWaiter(initialWait time.Duration, wait time.Duration) *FixedPropagationWaiter {
+	return &FixedPropagationWaiter{
+		initialWait: initialWait,
+		wait:        wait,
+	}
+}
+
+func (w *FixedPropagationWaiter) WaitForPropagation(ctx context.Context, change domain.RBACChange) error {
+	if change.Kind == domain.RoleAssignmentsRemoved {
+		return sleep(ctx, w.initialWait)
+	}
+	return sleep(ctx, w.wait)
+}
+
+// ExponentialPropagationWaiter doubles the wait after a role update for every consecutive
+// update which did not make the deployment progress, up to the max wait
+type ExponentialPropagationWaiter struct {
+	initialWait time.Duration
+	baseWait    time.Duration
+	maxWait     time.Duration
+}
+
+func NewExponentialPropagationWaiter(initialWait time.Duration, baseWait time.Duration, maxWait time.Duration) *ExponentialPropagationWaiter {
+	return &ExponentialPropagationWaiter{
+		initialWait: initialWait,
+		baseWait:    baseWait,
+		maxWait:     maxWait,
+	}
+}
+
+func (w *ExponentialPropagationWaiter) WaitForPropagation(ctx context.Context, change domain.RBACChange) error {
+	if change.Kind == domain.RoleAssignmentsRemoved {
+		return sleep(ctx, w.initialWait)
+	}
+	return sleep(ctx, backoff(w.baseWait, w.maxWait, change.Attempt))
+}
+
+// VerifyingPropagationWaiter polls until the change is visible, with the poll interval doubling up to the max wait.
+// If the previous role updates did not make the deployment progress, it additionally backs off exponentially,
+// as a role update can be visible before it is enforced by all authorization endpoints.
+type VerifyingPropagationWaiter struct {
+	reader       RBACStateReader
+	pollInterval time.Duration
+	maxWait      time.Duration
+}
+
+func NewVerifyingPropagationWaiter(reader RBACStateReader, pollInterval time.Duration, maxWait time.Duration) *VerifyingPropagationWaiter {
+	return &VerifyingPropagationWaiter{
+		reader:       reader,
+		pollInterval: pollInterval,
+		maxWait:      maxWait,
+	}
+}
+
+func (w *VerifyingPropagationWaiter) WaitForPropagation(ctx context.Context, change domain.RBACChange) error {
+	start := time.Now()
+	interval := w.pollInterval
+	for attempt := 0; ; attempt++ {
+		visible, err := w.isVisible(ctx, change)
+		if err != nil {
+			log.Warnf("Could not verify propagation of %s: %v", change.Kind, err)
+		}
+		if visible {
+			log.Debugf("%s visible after %s", change.Kind, time.Since(start).Round(time.Millisecond))
+			break
+		}
+
+		remaining := w.maxWait - time.Since(start)
+		if remaining <= 0 {
+			log.Warnf("%s not visible after %s, continuing", change.Kind, w.maxWait)
+			break
+		}
+		if err := sleep(ctx, min(backoff(interval, w.maxWait, attempt), remaining)); err != nil {
+			return err
+		}
+	}
+
+	if change.Kind == domain.RoleUpdated && change.Attempt > 0 {
+		return sleep(ctx, backoff(w.pollInterval, w.maxWait, change.Attempt))
+	}
+	return nil
+}
+
+func (w *VerifyingPropagationWaiter) isVisible(ctx context.Context, change domain.RBACChange) (bool, error) {
+	switch change.Kind {
+	case domain.RoleAssignmentsRemoved:
+		count, err := w.reader.CountRoleAssignments(ctx, change.SubscriptionID, change.SPObjectID, domain.Role{})
+		return err == nil && count == 0, err
+	case domain.RoleAssigned:
+		count, err := w.reader.CountRoleAssignments(ctx, change.SubscriptionID, change.SPObjectID, change.Role)
+		if err != nil || count == 0 {
+			return false, err
+		}
+		return w.hasPermissions(ctx, change)
+	case domain.RoleUpdated:
+		return w.hasPermissions(ctx, change)
+	}
+	return true, nil
+}
+
+// hasPermissions returns true if the custom role has all the actions and data actions of the change
+func (w *VerifyingPropagationWaiter) hasPermissions(ctx context.Context, change domain.RBACChange) (bool, error) {
+	actions, dataActions, err := w.reader.GetCustomRolePermissions(ctx, change.SubscriptionID, change.Role)
+	if err != nil {
+		return false, err
+	}
+	return containsAll(actions, change.Actions) && containsAll(dataActions, change.DataActions), nil
+}
+
+func containsAll(have []string, want []string) bool {
+	haveSet := make(map[string]bool, len(have))
+	for _, h := range have {
+		haveSet[strings.ToLower(h)] = true
+	}
+	for _, w := range want {
+		if !haveSet[strings.ToLower(w)] {
+			return false
+		}
+	}
+	return true
+}
+
+// backoff returns base * 2^attempt, capped at maxWait
+func backoff(base time.Duration, maxWait time.Duration, attempt int) time.Duration {
+	wait := base
+	for range attempt {
+		if wait >= maxWait/2 {
+			return maxWait
+		}
+		wait *= 2
+	}
+	return min(wait, maxWait)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
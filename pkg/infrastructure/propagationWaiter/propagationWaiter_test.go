@@ -0,0 +1,113 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package propagationwaiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Azure/mpf/pkg/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRBACStateReader makes the role permissions visible after a number of reads
+type fakeRBACStateReader struct {
+	actions          []string
+	dataActions      []string
+	readsUntilUpdate int
+	reads            int
+	assignments      int
+}
+
+func (f *fakeRBACStateReader) GetCustomRolePermissions(ctx context.Context, subscription string, role domain.Role) ([]string, []string, error) {
+	f.reads++
+	if f.reads <= f.readsUntilUpdate {
+		return nil, nil, nil
+	}
+	return f.actions, f.dataActions, nil
+}
+
+func (f *fakeRBACStateReader) CountRoleAssignments(ctx context.Context, subscription string, SPObjectID string, role domain.Role) (int, error) {
+	return f.assignments, nil
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, time.Second, backoff(time.Second, time.Minute, 0))
+	assert.Equal(t, 2*time.Second, backoff(time.Second, time.Minute, 1))
+	assert.Equal(t, 8*time.Second, backoff(time.Second, time.Minute, 3))
+	assert.Equal(t, time.Minute, backoff(time.Second, time.Minute, 10))
+	assert.Equal(t, time.Minute, backoff(time.Second, time.Minute, 1000))
+}
+
+func TestFixedPropagationWaiter(t *testing.T) {
+	w := NewFixedPropagationWaiter(20*time.Millisecond, time.Millisecond)
+
+	start := time.Now()
+	assert.NoError(t, w.WaitForPropagation(t.Context(), domain.RBACChange{Kind: domain.RoleAssignmentsRemoved}))
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+}
+
+func TestExponentialPropagationWaiter(t *testing.T) {
+	w := NewExponentialPropagationWaiter(0, 5*time.Millisecond, 40*time.Millisecond)
+
+	start := time.Now()
+	assert.NoError(t, w.WaitForPropagation(t.Context(), domain.RBACChange{Kind: domain.RoleUpdated, Attempt: 2}))
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+}
+
+func TestPropagationWaiterCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	w := NewFixedPropagationWaiter(time.Minute, time.Minute)
+	assert.ErrorIs(t, w.WaitForPropagation(ctx, domain.RBACChange{Kind: domain.RoleUpdated}), context.Canceled)
+}
+
+func TestVerifyingPropagationWaiterPollsUntilVisible(t *testing.T) {
+	reader := &fakeRBACStateReader{
+		actions:          []string{"Microsoft.Storage/storageAccounts/write"},
+		dataActions:      []string{"Microsoft.Storage/storageAccounts/blobServices/containers/blobs/read"},
+		readsUntilUpdate: 2,
+	}
+	w := NewVerifyingPropagationWaiter(reader, time.Millisecond, time.Second)
+
+	err := w.WaitForPropagation(t.Context(), domain.RBACChange{
+		Kind:        domain.RoleUpdated,
+		Actions:     []string{"microsoft.storage/storageAccounts/write"},
+		DataActions: []string{"Microsoft.Storage/storageAccounts/blobServices/containers/blobs/read"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, reader.reads)
+}
+
+func TestVerifyingPropagationWaiterGivesUpAfterMaxWait(t *testing.T) {
+	reader := &fakeRBACStateReader{assignments: 1}
+	w := NewVerifyingPropagationWaiter(reader, time.Millisecond, 20*time.Millisecond)
+
+	start := time.Now()
+	err := w.WaitForPropagation(t.Context(), domain.RBACChange{Kind: domain.RoleAssignmentsRemoved})
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+	assert.Less(t, time.Since(start), time.Second)
+}
@@ -0,0 +1,96 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package replay
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/Azure/mpf/pkg/domain"
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrJournalExhausted is returned when the deployment is checked more often than the journal recorded
+var ErrJournalExhausted = errors.New("no more deployments recorded in the journal")
+
+// JournalReplayChecker is a deployment authorization checker which replays the deployment results recorded in an MPF journal,
+// so that the MPF loop can be rerun offline
+type JournalReplayChecker struct {
+	mu      sync.Mutex
+	entries []domain.MPFJournalEntry
+}
+
+// NewJournalReplayChecker returns a checker replaying the deployments of the journal entries in order.
+// Entries which do not record a deployment, such as the role initialization, are skipped.
+func NewJournalReplayChecker(entries []domain.MPFJournalEntry) *JournalReplayChecker {
+	var deploymentEntries []domain.MPFJournalEntry
+	for _, entry := range entries {
+		if entry.Outcome == domain.JournalRoleInitialized {
+			continue
+		}
+		// a failure which was not caused by the deployment, e.g. a failed role update, cannot be replayed
+		if entry.Outcome == domain.JournalFailed && entry.AuthorizationError == "" && entry.Error == "" {
+			continue
+		}
+		deploymentEntries = append(deploymentEntries, entry)
+	}
+
+	return &JournalReplayChecker{
+		entries: deploymentEntries,
+	}
+}
+
+// Remaining returns the number of deployments which were not replayed yet
+func (c *JournalReplayChecker) Remaining() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+func (c *JournalReplayChecker) GetDeploymentAuthorizationErrors(ctx context.Context, mpfConfig domain.MPFConfig) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.entries) == 0 {
+		return "", ErrJournalExhausted
+	}
+	entry := c.entries[0]
+	c.entries = c.entries[1:]
+
+	log.Debugf("Replaying iteration %d recorded at %s with outcome %s", entry.Iteration, entry.Timestamp, entry.Outcome)
+
+	// the checker returned a non authorization error
+	if entry.Outcome == domain.JournalFailed && entry.AuthorizationError == "" {
+		return "", errors.New(entry.Error)
+	}
+	return entry.AuthorizationError, nil
+}
+
+func (c *JournalReplayChecker) CleanDeployment(ctx context.Context, mpfConfig domain.MPFConfig) error {
+	return nil
+}
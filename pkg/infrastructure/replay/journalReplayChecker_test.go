@@ -0,0 +1,111 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package replay
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Azure/mpf/pkg/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJournalReplayChecker(t *testing.T) {
+	checker := NewJournalReplayChecker([]domain.MPFJournalEntry{
+		{Outcome: domain.JournalRoleInitialized},
+		{Outcome: domain.JournalAuthorizationErrors, AuthorizationError: "AuthorizationFailed 1"},
+		{Outcome: domain.JournalRetry, AuthorizationError: "RetryGetDeploymentAuthorizationErrors"},
+		{Outcome: domain.JournalFailed, Error: "InvalidTemplate"},
+		{Outcome: domain.JournalSucceeded},
+	})
+	assert.Equal(t, 4, checker.Remaining())
+
+	authErrMesg, err := checker.GetDeploymentAuthorizationErrors(t.Context(), domain.MPFConfig{})
+	assert.NoError(t, err)
+	assert.Equal(t, "AuthorizationFailed 1", authErrMesg)
+
+	authErrMesg, err = checker.GetDeploymentAuthorizationErrors(t.Context(), domain.MPFConfig{})
+	assert.NoError(t, err)
+	assert.Equal(t, "RetryGetDeploymentAuthorizationErrors", authErrMesg)
+
+	authErrMesg, err = checker.GetDeploymentAuthorizationErrors(t.Context(), domain.MPFConfig{})
+	assert.EqualError(t, err, "InvalidTemplate")
+	assert.Empty(t, authErrMesg)
+
+	authErrMesg, err = checker.GetDeploymentAuthorizationErrors(t.Context(), domain.MPFConfig{})
+	assert.NoError(t, err)
+	assert.Empty(t, authErrMesg)
+
+	_, err = checker.GetDeploymentAuthorizationErrors(t.Context(), domain.MPFConfig{})
+	assert.ErrorIs(t, err, ErrJournalExhausted)
+}
+
+func TestJournalReplayCheckerCancelled(t *testing.T) {
+	checker := NewJournalReplayChecker([]domain.MPFJournalEntry{{Outcome: domain.JournalSucceeded}})
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	_, err := checker.GetDeploymentAuthorizationErrors(ctx, domain.MPFConfig{})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, checker.Remaining())
+}
+
+func TestMemorySPRoleAssignmentManager(t *testing.T) {
+	ctx := t.Context()
+	manager := NewMemorySPRoleAssignmentManager("Microsoft.Invalid/action")
+	role := domain.Role{RoleDefinitionID: "role-1"}
+
+	err, invalidActions := manager.CreateUpdateCustomRole(ctx, "sub", role, []string{"Microsoft.Storage/storageAccounts/write", "Microsoft.Invalid/action"}, []string{"Microsoft.Storage/storageAccounts/blobServices/containers/blobs/read"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Microsoft.Invalid/action"}, invalidActions)
+
+	actions, dataActions, err := manager.GetCustomRolePermissions(ctx, "sub", role)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Microsoft.Storage/storageAccounts/write"}, actions)
+	assert.Equal(t, []string{"Microsoft.Storage/storageAccounts/blobServices/containers/blobs/read"}, dataActions)
+
+	assert.NoError(t, manager.AssignRoleToSP(ctx, "sub", "sp", role))
+	assert.NoError(t, manager.AssignRoleToSP(ctx, "sub", "sp", domain.Role{RoleDefinitionID: "role-2"}))
+	count, err := manager.CountRoleAssignments(ctx, "sub", "sp", role)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	count, err = manager.CountRoleAssignments(ctx, "sub", "sp", domain.Role{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	assert.NoError(t, manager.DetachRolesFromSP(ctx, "sub", "sp", role))
+	count, err = manager.CountRoleAssignments(ctx, "sub", "sp", domain.Role{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	assert.NoError(t, manager.DetachRolesFromSP(ctx, "sub", "sp", domain.Role{}))
+	count, err = manager.CountRoleAssignments(ctx, "sub", "sp", domain.Role{})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	assert.NoError(t, manager.DeleteCustomRole(ctx, "sub", role))
+	actions, _, err = manager.GetCustomRolePermissions(ctx, "sub", role)
+	assert.NoError(t, err)
+	assert.Empty(t, actions)
+}
@@ -0,0 +1,66 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package replay
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// MemoryResourceGroupManager keeps resource groups in memory
+type MemoryResourceGroupManager struct {
+	mu             sync.Mutex
+	resourceGroups map[string]string
+}
+
+func NewMemoryResourceGroupManager() *MemoryResourceGroupManager {
+	return &MemoryResourceGroupManager{
+		resourceGroups: make(map[string]string),
+	}
+}
+
+func (m *MemoryResourceGroupManager) CreateResourceGroup(ctx context.Context, rgName, location string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.resourceGroups[rgName] = location
+	return nil
+}
+
+func (m *MemoryResourceGroupManager) DeleteResourceGroup(ctx context.Context, rgName string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.resourceGroups[rgName]; !ok {
+		return fmt.Errorf("resource group %s not found", rgName)
+	}
+	delete(m.resourceGroups, rgName)
+	return nil
+}
+
+// ResourceGroupExists returns true if the resource group was created and not deleted
+func (m *MemoryResourceGroupManager) ResourceGroupExists(rgName string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.resourceGroups[rgName]
+	return ok
+}
@@ -0,0 +1,134 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package replay
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/Azure/mpf/pkg/domain"
+)
+
+type memoryCustomRole struct {
+	actions     []string
+	dataActions []string
+}
+
+// MemorySPRoleAssignmentManager keeps custom roles and their assignments to service principals in memory
+type MemorySPRoleAssignmentManager struct {
+	mu sync.Mutex
+	// customRoles are keyed by role definition ID
+	customRoles map[string]memoryCustomRole
+	// roleAssignments are the role definition IDs assigned to each service principal object ID
+	roleAssignments map[string][]string
+	invalidActions  []string
+}
+
+// NewMemorySPRoleAssignmentManager returns a manager which removes the given invalid actions from custom roles,
+// like Azure removes actions it does not recognise
+func NewMemorySPRoleAssignmentManager(invalidActions ...string) *MemorySPRoleAssignmentManager {
+	return &MemorySPRoleAssignmentManager{
+		customRoles:     make(map[string]memoryCustomRole),
+		roleAssignments: make(map[string][]string),
+		invalidActions:  invalidActions,
+	}
+}
+
+// DetachRolesFromSP removes the assignment of the role to the service principal,
+// or all its role assignments if the role has no role definition ID
+func (m *MemorySPRoleAssignmentManager) DetachRolesFromSP(ctx context.Context, subscription string, SPOBjectID string, role domain.Role) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if role.RoleDefinitionID == "" {
+		delete(m.roleAssignments, SPOBjectID)
+		return nil
+	}
+	m.roleAssignments[SPOBjectID] = slices.DeleteFunc(m.roleAssignments[SPOBjectID], func(roleDefinitionID string) bool {
+		return roleDefinitionID == role.RoleDefinitionID
+	})
+	return nil
+}
+
+func (m *MemorySPRoleAssignmentManager) AssignRoleToSP(ctx context.Context, subscription string, SPOBjectID string, role domain.Role) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !slices.Contains(m.roleAssignments[SPOBjectID], role.RoleDefinitionID) {
+		m.roleAssignments[SPOBjectID] = append(m.roleAssignments[SPOBjectID], role.RoleDefinitionID)
+	}
+	return nil
+}
+
+func (m *MemorySPRoleAssignmentManager) CreateUpdateCustomRole(ctx context.Context, subscription string, role domain.Role, permissions []string, dataActions []string) (error, []string) { //nolint:staticcheck
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var removedActions []string
+	isInvalid := func(action string) bool {
+		if slices.Contains(m.invalidActions, action) {
+			if !slices.Contains(removedActions, action) {
+				removedActions = append(removedActions, action)
+			}
+			return true
+		}
+		return false
+	}
+
+	m.customRoles[role.RoleDefinitionID] = memoryCustomRole{
+		actions:     slices.DeleteFunc(slices.Clone(permissions), isInvalid),
+		dataActions: slices.DeleteFunc(slices.Clone(dataActions), isInvalid),
+	}
+	return nil, removedActions
+}
+
+func (m *MemorySPRoleAssignmentManager) DeleteCustomRole(ctx context.Context, subscription string, role domain.Role) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.customRoles, role.RoleDefinitionID)
+	return nil
+}
+
+// GetCustomRolePermissions returns the actions and data actions of the custom role
+func (m *MemorySPRoleAssignmentManager) GetCustomRolePermissions(ctx context.Context, subscription string, role domain.Role) ([]string, []string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	customRole := m.customRoles[role.RoleDefinitionID]
+	return slices.Clone(customRole.actions), slices.Clone(customRole.dataActions), nil
+}
+
+// CountRoleAssignments returns the number of assignments of the role to the service principal,
+// or the number of all its role assignments if the role has no role definition ID
+func (m *MemorySPRoleAssignmentManager) CountRoleAssignments(ctx context.Context, subscription string, SPObjectID string, role domain.Role) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if role.RoleDefinitionID == "" {
+		return len(m.roleAssignments[SPObjectID]), nil
+	}
+	if slices.Contains(m.roleAssignments[SPObjectID], role.RoleDefinitionID) {
+		return 1, nil
+	}
+	return 0, nil
+}
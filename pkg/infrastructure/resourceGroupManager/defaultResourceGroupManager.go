@@ -24,22 +24,39 @@ package resourcegroupmanager
 
 import (
 	"context"
+	"maps"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/Azure/mpf/pkg/domain"
 	azureAPI "github.com/Azure/mpf/pkg/infrastructure/azureAPI"
 )
 
 type RGManager struct {
 	rgAPIClient *armresources.ResourceGroupsClient
+	tags        map[string]string
 }
 
-func NewResourceGroupManager(subscriptionID string) *RGManager {
-	azAPIClient := azureAPI.NewAzureAPIClients(subscriptionID)
+func NewResourceGroupManager(subscriptionID string) (*RGManager, error) {
+	azAPIClient, err := azureAPI.NewAzureAPIClients(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	return NewResourceGroupManagerWithClients(azAPIClient), nil
+}
+
+// NewResourceGroupManagerWithClients returns the resource group manager using the given Azure API clients
+func NewResourceGroupManagerWithClients(azAPIClient *azureAPI.AzureAPIClients) *RGManager {
 	return &RGManager{
 		rgAPIClient: azAPIClient.ResourceGroupsClient,
 	}
 }
 
+// SetTags sets additional tags, such as the run metadata, to add to the resource groups created
+func (r *RGManager) SetTags(tags map[string]string) {
+	r.tags = tags
+}
+
 func (r *RGManager) DeleteResourceGroup(ctx context.Context, rgName string) error {
 
 	_, err := r.rgAPIClient.BeginDelete(ctx, rgName, nil)
@@ -50,12 +67,18 @@ func (r *RGManager) DeleteResourceGroup(ctx context.Context, rgName string) erro
 	return nil
 }
 
-// method to create resource group
+// method to create resource group. The resource group is tagged as created by MPF, so that it can be cleaned up if the run crashes.
 func (r *RGManager) CreateResourceGroup(ctx context.Context, rgName string, location string) error {
 
 	rgParams := armresources.ResourceGroup{
 		Location: &location,
 		Name:     &rgName,
+		Tags:     make(map[string]*string),
+	}
+	tags := domain.GetMPFResourceGroupTags(time.Now())
+	maps.Copy(tags, r.tags)
+	for name, value := range tags {
+		rgParams.Tags[name] = new(value)
 	}
 
 	// create resource group
@@ -66,3 +89,42 @@ func (r *RGManager) CreateResourceGroup(ctx context.Context, rgName string, loca
 
 	return nil
 }
+
+// ListResourceGroups returns the resource groups of the subscription with their tags
+func (r *RGManager) ListResourceGroups(ctx context.Context) ([]domain.TaggedResourceGroup, error) {
+	pager := r.rgAPIClient.NewListPager(nil)
+
+	var groups []domain.TaggedResourceGroup
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, group := range page.Value {
+			if group.Name == nil {
+				continue
+			}
+			taggedGroup := domain.TaggedResourceGroup{
+				ResourceGroup: domain.ResourceGroup{
+					ResourceGroupName: *group.Name,
+				},
+				Tags: make(map[string]string),
+			}
+			if group.ID != nil {
+				taggedGroup.ResourceGroupResourceID = *group.ID
+			}
+			if group.Location != nil {
+				taggedGroup.Location = *group.Location
+			}
+			for name, value := range group.Tags {
+				if value != nil {
+					taggedGroup.Tags[name] = *value
+				}
+			}
+			groups = append(groups, taggedGroup)
+		}
+	}
+
+	return groups, nil
+}
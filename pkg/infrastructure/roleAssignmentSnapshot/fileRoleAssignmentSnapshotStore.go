@@ -0,0 +1,119 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package roleassignmentsnapshot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Azure/mpf/pkg/domain"
+	log "github.com/sirupsen/logrus"
+)
+
+// FileRoleAssignmentSnapshotStore saves role assignment snapshots as JSON to a file
+type FileRoleAssignmentSnapshotStore struct {
+	filePath string
+}
+
+func NewFileRoleAssignmentSnapshotStore(filePath string) *FileRoleAssignmentSnapshotStore {
+	return &FileRoleAssignmentSnapshotStore{
+		filePath: filePath,
+	}
+}
+
+// FilePath returns the path of the snapshot file
+func (s *FileRoleAssignmentSnapshotStore) FilePath() string {
+	return s.filePath
+}
+
+// LoadRoleAssignmentSnapshot returns the snapshot of the file, or nil if the file does not exist
+func (s *FileRoleAssignmentSnapshotStore) LoadRoleAssignmentSnapshot() (*domain.RoleAssignmentSnapshot, error) {
+	if _, err := os.Stat(s.filePath); errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	snapshot, err := LoadRoleAssignmentSnapshotFromFile(s.filePath)
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// SaveRoleAssignmentSnapshot replaces the snapshot file. The snapshot is written to a temporary file first,
+// so that the previous snapshot is kept if MPF is terminated while writing.
+func (s *FileRoleAssignmentSnapshotStore) SaveRoleAssignmentSnapshot(snapshot domain.RoleAssignmentSnapshot) error {
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(s.filePath), filepath.Base(s.filePath)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("error creating role assignment snapshot file: %w", err)
+	}
+	defer os.Remove(tmpFile.Name()) //nolint:errcheck
+
+	_, err = tmpFile.Write(data)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("error writing role assignment snapshot file: %w", err)
+	}
+
+	if err := os.Rename(tmpFile.Name(), s.filePath); err != nil {
+		return fmt.Errorf("error writing role assignment snapshot file: %w", err)
+	}
+	log.Debugf("Role assignment snapshot saved to %s", s.filePath)
+	return nil
+}
+
+// DeleteRoleAssignmentSnapshot deletes the snapshot file, if it exists
+func (s *FileRoleAssignmentSnapshotStore) DeleteRoleAssignmentSnapshot() error {
+	err := os.Remove(s.filePath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// LoadRoleAssignmentSnapshotFromFile reads a snapshot saved by FileRoleAssignmentSnapshotStore
+func LoadRoleAssignmentSnapshotFromFile(filePath string) (domain.RoleAssignmentSnapshot, error) {
+	var snapshot domain.RoleAssignmentSnapshot
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return snapshot, fmt.Errorf("error reading role assignment snapshot file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return snapshot, fmt.Errorf("error parsing role assignment snapshot file %s: %w", filePath, err)
+	}
+	if err := snapshot.Validate(); err != nil {
+		return snapshot, fmt.Errorf("invalid role assignment snapshot file %s: %w", filePath, err)
+	}
+	return snapshot, nil
+}
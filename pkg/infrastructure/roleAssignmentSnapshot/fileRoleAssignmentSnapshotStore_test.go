@@ -0,0 +1,96 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package roleassignmentsnapshot
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Azure/mpf/pkg/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileRoleAssignmentSnapshotStoreSaveLoadDelete(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), ".azmpf-role-assignments.json")
+	store := NewFileRoleAssignmentSnapshotStore(filePath)
+
+	// loading a missing snapshot is not an error
+	loaded, err := store.LoadRoleAssignmentSnapshot()
+	require.NoError(t, err)
+	assert.Nil(t, loaded)
+
+	snapshot := domain.RoleAssignmentSnapshot{
+		Version:        domain.RoleAssignmentSnapshotVersion,
+		SubscriptionID: "SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS",
+		SPObjectID:     "OOOOOOOO-OOOO-OOOO-OOOO-OOOOOOOOOOOO",
+		RoleAssignments: []domain.RoleAssignment{
+			{
+				Name:             "AAAAAAAA-AAAA-AAAA-AAAA-AAAAAAAAAAAA",
+				Scope:            "/subscriptions/SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS/resourceGroups/shared",
+				RoleDefinitionID: "/subscriptions/SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS/providers/Microsoft.Authorization/roleDefinitions/ba92f5b4-2d11-453d-a403-e96b0029c9fe",
+				PrincipalID:      "OOOOOOOO-OOOO-OOOO-OOOO-OOOOOOOOOOOO",
+				PrincipalType:    "ServicePrincipal",
+				Condition:        "@Resource[Microsoft.Storage/storageAccounts/blobServices/containers:name] StringEquals 'state'",
+				ConditionVersion: "2.0",
+			},
+		},
+		CreatedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	require.NoError(t, store.SaveRoleAssignmentSnapshot(snapshot))
+
+	loaded, err = store.LoadRoleAssignmentSnapshot()
+	require.NoError(t, err)
+	assert.Equal(t, &snapshot, loaded)
+
+	// no temporary files are left behind
+	entries, err := os.ReadDir(filepath.Dir(filePath))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	require.NoError(t, store.DeleteRoleAssignmentSnapshot())
+	_, err = os.Stat(filePath)
+	assert.True(t, os.IsNotExist(err))
+
+	// deleting a missing snapshot is not an error
+	assert.NoError(t, store.DeleteRoleAssignmentSnapshot())
+}
+
+func TestLoadRoleAssignmentSnapshotFromInvalidFile(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := LoadRoleAssignmentSnapshotFromFile(filepath.Join(dir, "missing.json"))
+	assert.Error(t, err)
+
+	invalidFilePath := filepath.Join(dir, "invalid.json")
+	require.NoError(t, os.WriteFile(invalidFilePath, []byte("not json"), 0600))
+	_, err = LoadRoleAssignmentSnapshotFromFile(invalidFilePath)
+	assert.Error(t, err)
+
+	unsupportedFilePath := filepath.Join(dir, "unsupported.json")
+	require.NoError(t, os.WriteFile(unsupportedFilePath, []byte(`{"Version":2}`), 0600))
+	_, err = LoadRoleAssignmentSnapshotFromFile(unsupportedFilePath)
+	assert.ErrorContains(t, err, "unsupported role assignment snapshot version")
+}
@@ -0,0 +1,261 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package roledefinitions
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/Azure/mpf/pkg/domain"
+	"github.com/Azure/mpf/pkg/infrastructure/azureAPI"
+	log "github.com/sirupsen/logrus"
+)
+
+const roleDefinitionsAPIVersion = "2022-04-01"
+
+var roleDefinitionGUIDRegex = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// ErrRoleDefinitionNotFound is returned if no role definition has the requested name or ID
+var ErrRoleDefinitionNotFound = errors.New("role definition not found")
+
+// roleDefinitionDocument is a role definition in the format of the role definitions API, the format of
+// az role definition list, or the format of az role definition create.
+// JSON field names are matched case insensitively, so the same fields read all formats.
+type roleDefinitionDocument struct {
+	ID          string                    `json:"id"`
+	Name        string                    `json:"name"`
+	RoleName    string                    `json:"roleName"`
+	Description string                    `json:"description"`
+	Permissions []rolePermissionsDocument `json:"permissions"`
+	Properties  *struct {
+		RoleName    string                    `json:"roleName"`
+		Description string                    `json:"description"`
+		Permissions []rolePermissionsDocument `json:"permissions"`
+	} `json:"properties"`
+	rolePermissionsDocument
+}
+
+type rolePermissionsDocument struct {
+	Actions        []string `json:"actions"`
+	NotActions     []string `json:"notActions"`
+	DataActions    []string `json:"dataActions"`
+	NotDataActions []string `json:"notDataActions"`
+}
+
+type roleDefinitionListPage struct {
+	Value    []json.RawMessage `json:"value"`
+	NextLink string            `json:"nextLink,omitempty"`
+}
+
+func (p rolePermissionsDocument) toDomain() domain.RolePermission {
+	return domain.RolePermission{
+		Actions:        p.Actions,
+		NotActions:     p.NotActions,
+		DataActions:    p.DataActions,
+		NotDataActions: p.NotDataActions,
+	}
+}
+
+func (d roleDefinitionDocument) toDomain() domain.RoleDefinition {
+	role := domain.RoleDefinition{
+		ID:          d.Name,
+		Name:        d.RoleName,
+		Description: d.Description,
+	}
+	permissions := d.Permissions
+
+	switch {
+	case d.Properties != nil:
+		role.Name = d.Properties.RoleName
+		role.Description = d.Properties.Description
+		permissions = d.Properties.Permissions
+	case d.RoleName == "":
+		// az role definition create format, in which Name is the role name
+		role.ID = ""
+		role.Name = d.Name
+		permissions = []rolePermissionsDocument{d.rolePermissionsDocument}
+	}
+
+	for _, p := range permissions {
+		role.Permissions = append(role.Permissions, p.toDomain())
+	}
+	return role
+}
+
+// ParseRoleDefinitions parses role definitions in the format of the role definitions API, of az role definition list
+// or of az role definition create. The data is a single role definition, an array or a list with a value array.
+func ParseRoleDefinitions(data []byte) ([]domain.RoleDefinition, error) {
+	var documents []roleDefinitionDocument
+
+	data = bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(data, []byte("[")):
+		if err := json.Unmarshal(data, &documents); err != nil {
+			return nil, fmt.Errorf("error parsing role definitions: %w", err)
+		}
+	default:
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return nil, fmt.Errorf("error parsing role definitions: %w", err)
+		}
+		if value, ok := fields["value"]; ok {
+			if err := json.Unmarshal(value, &documents); err != nil {
+				return nil, fmt.Errorf("error parsing role definitions: %w", err)
+			}
+			break
+		}
+
+		var document roleDefinitionDocument
+		if err := json.Unmarshal(data, &document); err != nil {
+			return nil, fmt.Errorf("error parsing role definition: %w", err)
+		}
+		documents = append(documents, document)
+	}
+
+	roles := make([]domain.RoleDefinition, 0, len(documents))
+	for _, document := range documents {
+		roles = append(roles, document.toDomain())
+	}
+	return roles, nil
+}
+
+// FetchBuiltInRoleDefinitions returns all built-in role definitions of the cloud in the format of the role definitions list API
+func FetchBuiltInRoleDefinitions(ctx context.Context, azAPIClient *azureAPI.AzureAPIClients) ([]byte, error) {
+	query := url.Values{}
+	query.Set("$filter", "type eq 'BuiltInRole'")
+	query.Set("api-version", roleDefinitionsAPIVersion)
+
+	values, err := listRoleDefinitions(ctx, azAPIClient, fmt.Sprintf("%s/providers/Microsoft.Authorization/roleDefinitions?%s", azAPIClient.Endpoint(), query.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("Fetched %d built-in role definitions", len(values))
+
+	return json.MarshalIndent(roleDefinitionListPage{Value: values}, "", "  ")
+}
+
+// FetchRoleDefinition returns the role definition with the name or ID, which is a GUID or a role definition resource ID.
+// Role names are looked up among the built-in roles and the custom roles assignable in the subscription.
+func FetchRoleDefinition(ctx context.Context, azAPIClient *azureAPI.AzureAPIClients, subscriptionID string, nameOrID string) (domain.RoleDefinition, error) {
+	query := url.Values{}
+	query.Set("api-version", roleDefinitionsAPIVersion)
+
+	var requestURL string
+	switch {
+	case strings.Contains(strings.ToLower(nameOrID), "/providers/microsoft.authorization/roledefinitions/"):
+		requestURL = fmt.Sprintf("%s%s?%s", azAPIClient.Endpoint(), nameOrID, query.Encode())
+	case roleDefinitionGUIDRegex.MatchString(nameOrID):
+		requestURL = fmt.Sprintf("%s/subscriptions/%s/providers/Microsoft.Authorization/roleDefinitions/%s?%s", azAPIClient.Endpoint(), subscriptionID, nameOrID, query.Encode())
+	default:
+		query.Set("$filter", fmt.Sprintf("roleName eq '%s'", strings.ReplaceAll(nameOrID, "'", "''")))
+		values, err := listRoleDefinitions(ctx, azAPIClient, fmt.Sprintf("%s/subscriptions/%s/providers/Microsoft.Authorization/roleDefinitions?%s", azAPIClient.Endpoint(), subscriptionID, query.Encode()))
+		if err != nil {
+			return domain.RoleDefinition{}, err
+		}
+		if len(values) == 0 {
+			return domain.RoleDefinition{}, fmt.Errorf("%w: %s", ErrRoleDefinitionNotFound, nameOrID)
+		}
+		if len(values) > 1 {
+			return domain.RoleDefinition{}, fmt.Errorf("%d role definitions are named %s, use the role definition ID", len(values), nameOrID)
+		}
+		return parseRoleDefinition(values[0])
+	}
+
+	body, statusCode, err := getRoleDefinitionsAPI(ctx, azAPIClient, requestURL)
+	if err != nil {
+		return domain.RoleDefinition{}, err
+	}
+	if statusCode == http.StatusNotFound {
+		return domain.RoleDefinition{}, fmt.Errorf("%w: %s", ErrRoleDefinitionNotFound, nameOrID)
+	}
+	return parseRoleDefinition(body)
+}
+
+func parseRoleDefinition(data []byte) (domain.RoleDefinition, error) {
+	var document roleDefinitionDocument
+	if err := json.Unmarshal(data, &document); err != nil {
+		return domain.RoleDefinition{}, fmt.Errorf("error parsing role definition: %w", err)
+	}
+	return document.toDomain(), nil
+}
+
+// listRoleDefinitions returns the role definitions of all pages of the list request
+func listRoleDefinitions(ctx context.Context, azAPIClient *azureAPI.AzureAPIClients, requestURL string) ([]json.RawMessage, error) {
+	values := []json.RawMessage{}
+	for requestURL != "" {
+		body, _, err := getRoleDefinitionsAPI(ctx, azAPIClient, requestURL)
+		if err != nil {
+			return nil, err
+		}
+
+		var page roleDefinitionListPage
+		if err := json.Unmarshal(body, &page); err != nil {
+			return nil, fmt.Errorf("error parsing role definitions: %w", err)
+		}
+		values = append(values, page.Value...)
+		requestURL = page.NextLink
+	}
+	return values, nil
+}
+
+// getRoleDefinitionsAPI sends a GET request to the role definitions API.
+// Responses other than 200 and 404 are returned as errors.
+func getRoleDefinitionsAPI(ctx context.Context, azAPIClient *azureAPI.AzureAPIClients, requestURL string) ([]byte, int, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "Go HTTP Client")
+
+	defaultApiBearerToken, err := azAPIClient.GetDefaultAPIBearerToken(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Add("Authorization", "Bearer "+defaultApiBearerToken)
+
+	resp, err := azAPIClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return nil, resp.StatusCode, fmt.Errorf("failed to get role definitions. Status: %s, Body: %s", resp.Status, string(body))
+	}
+	return body, resp.StatusCode, nil
+}
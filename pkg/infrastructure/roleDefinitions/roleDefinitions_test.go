@@ -0,0 +1,145 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package roledefinitions
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/mpf/pkg/infrastructure/azureAPI"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testRoleDefinitionID = "11111111-1111-1111-1111-111111111111"
+
+const testRoleDefinition = `{"id":"/subscriptions/sub/providers/Microsoft.Authorization/roleDefinitions/` + testRoleDefinitionID + `","name":"` + testRoleDefinitionID + `","type":"Microsoft.Authorization/roleDefinitions",
+"properties":{"roleName":"Deployer","description":"Deploys storage","type":"CustomRole","permissions":[{"actions":["Microsoft.Storage/*"],"notActions":["Microsoft.Storage/storageAccounts/delete"],"dataActions":[],"notDataActions":[]}]}}`
+
+func TestParseRoleDefinitions(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"role definitions API", testRoleDefinition},
+		{"role definitions list API", `{"value":[` + testRoleDefinition + `]}`},
+		{"az role definition list", `[{"name":"` + testRoleDefinitionID + `","roleName":"Deployer","description":"Deploys storage","permissions":[{"actions":["Microsoft.Storage/*"],"notActions":["Microsoft.Storage/storageAccounts/delete"]}]}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			roles, err := ParseRoleDefinitions([]byte(tt.data))
+			require.NoError(t, err)
+			require.Len(t, roles, 1)
+			assert.Equal(t, testRoleDefinitionID, roles[0].ID)
+			assert.Equal(t, "Deployer", roles[0].Name)
+			assert.Equal(t, "Deploys storage", roles[0].Description)
+			require.Len(t, roles[0].Permissions, 1)
+			assert.Equal(t, []string{"Microsoft.Storage/*"}, roles[0].Permissions[0].Actions)
+			assert.Equal(t, []string{"Microsoft.Storage/storageAccounts/delete"}, roles[0].Permissions[0].NotActions)
+		})
+	}
+}
+
+func TestParseRoleDefinitionsAzureCLICreateFormat(t *testing.T) {
+	roles, err := ParseRoleDefinitions([]byte(`{"Name":"Deployer","IsCustom":true,"Actions":["Microsoft.Storage/storageAccounts/write"],"NotActions":[],"DataActions":["Microsoft.KeyVault/vaults/secrets/getSecret/action"],"AssignableScopes":["/subscriptions/sub"]}`))
+	require.NoError(t, err)
+	require.Len(t, roles, 1)
+	assert.Equal(t, "Deployer", roles[0].Name)
+	assert.Empty(t, roles[0].ID)
+	require.Len(t, roles[0].Permissions, 1)
+	assert.Equal(t, []string{"Microsoft.Storage/storageAccounts/write"}, roles[0].Permissions[0].Actions)
+	assert.Equal(t, []string{"Microsoft.KeyVault/vaults/secrets/getSecret/action"}, roles[0].Permissions[0].DataActions)
+}
+
+func TestParseRoleDefinitionsInvalidJSON(t *testing.T) {
+	_, err := ParseRoleDefinitions([]byte("not json"))
+	assert.Error(t, err)
+}
+
+type staticTokenCredential string
+
+func (c staticTokenCredential) GetToken(ctx context.Context, opts policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	return azcore.AccessToken{Token: string(c), ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+func newTestAzureAPIClients(t *testing.T, handler http.HandlerFunc) *azureAPI.AzureAPIClients {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	azAPIClients, err := azureAPI.NewAzureAPIClientsWithOptions("sub", azureAPI.AzureAPIClientsOptions{
+		Endpoint:   server.URL,
+		Credential: staticTokenCredential("token"),
+	})
+	require.NoError(t, err)
+	return azAPIClients
+}
+
+func TestFetchRoleDefinitionByName(t *testing.T) {
+	var filter string
+	azAPIClient := newTestAzureAPIClients(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "2" {
+			fmt.Fprintf(w, `{"value":[%s]}`, testRoleDefinition)
+			return
+		}
+		filter = r.URL.Query().Get("$filter")
+		fmt.Fprintf(w, `{"value":[],"nextLink":"http://%s%s?page=2"}`, r.Host, r.URL.Path)
+	})
+
+	role, err := FetchRoleDefinition(context.Background(), azAPIClient, "sub", "Deployer")
+	require.NoError(t, err)
+	assert.Equal(t, "roleName eq 'Deployer'", filter)
+	assert.Equal(t, "Deployer", role.Name)
+	assert.Equal(t, testRoleDefinitionID, role.ID)
+}
+
+func TestFetchRoleDefinitionByID(t *testing.T) {
+	var path string
+	azAPIClient := newTestAzureAPIClients(t, func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, testRoleDefinition)
+	})
+
+	role, err := FetchRoleDefinition(context.Background(), azAPIClient, "sub", testRoleDefinitionID)
+	require.NoError(t, err)
+	assert.Equal(t, "/subscriptions/sub/providers/Microsoft.Authorization/roleDefinitions/"+testRoleDefinitionID, path)
+	assert.Equal(t, "Deployer", role.Name)
+}
+
+func TestFetchRoleDefinitionNotFound(t *testing.T) {
+	azAPIClient := newTestAzureAPIClients(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error":{"code":"RoleDefinitionDoesNotExist"}}`)
+	})
+
+	_, err := FetchRoleDefinition(context.Background(), azAPIClient, "sub", "/providers/Microsoft.Authorization/roleDefinitions/"+testRoleDefinitionID)
+	assert.ErrorIs(t, err, ErrRoleDefinitionNotFound)
+}
@@ -0,0 +1,196 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package runlock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Azure/mpf/pkg/domain"
+	log "github.com/sirupsen/logrus"
+)
+
+// staleTakeoverFileAge is the age after which a takeover file is considered left behind by a run which ended
+// while taking over a lock. Taking over a lock only takes a few file operations.
+const staleTakeoverFileAge = time.Minute
+
+// FileRunLocker locks service principals with lock files in a directory,
+// for tests and for runs sharing a machine, such as the jobs of a single build agent
+type FileRunLocker struct {
+	dir string
+}
+
+func NewFileRunLocker(dir string) *FileRunLocker {
+	return &FileRunLocker{
+		dir: dir,
+	}
+}
+
+// FilePath returns the path of the lock file of the service principal
+func (l *FileRunLocker) FilePath(spObjectID string) string {
+	return filepath.Join(l.dir, domain.GetRunLockName(spObjectID)+".json")
+}
+
+// AcquireRunLock creates the lock file, or replaces it if the lock it holds is expired or held by the same holder.
+// Only one of the runs racing to take over an expired lock replaces it.
+// The lock is written to a temporary file first, so that other runs never read a partially written lock.
+func (l *FileRunLocker) AcquireRunLock(ctx context.Context, lock domain.RunLock) error {
+	filePath := l.FilePath(lock.SPObjectID)
+	data, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmpFilePath, err := l.writeTempLockFile(filePath, data)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFilePath) //nolint:errcheck
+
+	// linking fails if the lock file exists, so that only one run creates it
+	err = os.Link(tmpFilePath, filePath)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("error creating lock file: %w", err)
+	}
+
+	current, err := readRunLockFile(filePath)
+	if err != nil {
+		return err
+	}
+	if !current.IsHeldBy(lock.Holder) {
+		if !current.IsExpired(time.Now()) {
+			return &domain.RunLockedError{Lock: current}
+		}
+		return l.takeOverRunLock(filePath, tmpFilePath, lock, current)
+	}
+
+	// the holder renews its lock
+	if err := os.Rename(tmpFilePath, filePath); err != nil {
+		return fmt.Errorf("error writing lock file: %w", err)
+	}
+	return nil
+}
+
+// takeOverRunLock replaces the expired lock file with the temporary lock file.
+// Runs taking over a lock are serialized with a takeover file, which only one run can create,
+// and the lock file is read again once it is created, so that a lock another run took over in the meantime is kept.
+func (l *FileRunLocker) takeOverRunLock(filePath string, tmpFilePath string, lock domain.RunLock, expired domain.RunLock) error {
+	takeoverFilePath := filePath + ".takeover"
+	takeoverFile, err := os.OpenFile(takeoverFilePath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if errors.Is(err, os.ErrExist) {
+		if info, err := os.Stat(takeoverFilePath); err == nil && time.Since(info.ModTime()) > staleTakeoverFileAge {
+			return fmt.Errorf("the lock of service principal %s is being taken over since %s, delete %s if no MPF run is taking it over",
+				lock.SPObjectID, info.ModTime().Format(time.RFC3339), takeoverFilePath)
+		}
+		// another run is taking over the lock
+		return &domain.RunLockedError{Lock: expired}
+	}
+	if err != nil {
+		return fmt.Errorf("error creating lock takeover file: %w", err)
+	}
+	takeoverFile.Close()              //nolint:errcheck
+	defer os.Remove(takeoverFilePath) //nolint:errcheck
+
+	current, err := readRunLockFile(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		// the lock was released in the meantime
+		if err := os.Link(tmpFilePath, filePath); err != nil {
+			return fmt.Errorf("error creating lock file: %w", err)
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !current.IsHeldBy(lock.Holder) && !current.IsExpired(time.Now()) {
+		return &domain.RunLockedError{Lock: current}
+	}
+
+	log.Warnf("Taking over the lock of service principal %s from %s, which expired at %s", lock.SPObjectID, current.Holder, current.ExpiresAt.Format(time.RFC3339))
+	if err := os.Rename(tmpFilePath, filePath); err != nil {
+		return fmt.Errorf("error writing lock file: %w", err)
+	}
+	return nil
+}
+
+// writeTempLockFile writes the lock to a temporary file next to the lock file and returns its path
+func (l *FileRunLocker) writeTempLockFile(filePath string, data []byte) (string, error) {
+	tmpFile, err := os.CreateTemp(l.dir, filepath.Base(filePath)+".*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("error creating lock file: %w", err)
+	}
+
+	_, err = tmpFile.Write(data)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpFile.Name()) //nolint:errcheck
+		return "", fmt.Errorf("error writing lock file: %w", err)
+	}
+	return tmpFile.Name(), nil
+}
+
+// ReleaseRunLock deletes the lock file, if the lock is still held by the holder of the lock
+func (l *FileRunLocker) ReleaseRunLock(ctx context.Context, lock domain.RunLock) error {
+	filePath := l.FilePath(lock.SPObjectID)
+	if _, err := os.Stat(filePath); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	current, err := readRunLockFile(filePath)
+	if err != nil {
+		return err
+	}
+	if !current.IsHeldBy(lock.Holder) {
+		log.Debugf("The lock of service principal %s is not held by %s, it is not released", lock.SPObjectID, lock.Holder)
+		return nil
+	}
+
+	err = os.Remove(filePath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func readRunLockFile(filePath string) (domain.RunLock, error) {
+	var lock domain.RunLock
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return lock, fmt.Errorf("error reading lock file: %w", err)
+	}
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return lock, fmt.Errorf("error parsing lock file %s, delete it if no MPF run holds the lock: %w", filePath, err)
+	}
+	return lock, nil
+}
@@ -0,0 +1,164 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package runlock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/mpf/pkg/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileRunLocker(t *testing.T) {
+	locker := NewFileRunLocker(t.TempDir())
+
+	lockA := getTestRunLock("run-a", time.Hour)
+	require.NoError(t, locker.AcquireRunLock(t.Context(), lockA))
+	assert.FileExists(t, locker.FilePath(testSPObjectID))
+
+	var runLockedError *domain.RunLockedError
+	require.ErrorAs(t, locker.AcquireRunLock(t.Context(), getTestRunLock("run-b", time.Hour)), &runLockedError)
+	assert.Equal(t, "run-a", runLockedError.Lock.Holder)
+
+	// releasing a lock held by another run leaves it in place
+	require.NoError(t, locker.ReleaseRunLock(t.Context(), getTestRunLock("run-b", time.Hour)))
+	assert.FileExists(t, locker.FilePath(testSPObjectID))
+
+	// the holder renews its lock
+	require.NoError(t, locker.AcquireRunLock(t.Context(), lockA))
+
+	require.NoError(t, locker.ReleaseRunLock(t.Context(), lockA))
+	assert.NoFileExists(t, locker.FilePath(testSPObjectID))
+	require.NoError(t, locker.ReleaseRunLock(t.Context(), lockA))
+
+	require.NoError(t, locker.AcquireRunLock(t.Context(), getTestRunLock("run-b", time.Hour)))
+}
+
+func TestFileRunLockerConcurrentAcquire(t *testing.T) {
+	dir := t.TempDir()
+	locker := NewFileRunLocker(dir)
+
+	// runs racing for the lock never read a partially written lock file
+	errs := make([]error, 20)
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Go(func() {
+			errs[i] = locker.AcquireRunLock(t.Context(), getTestRunLock(fmt.Sprintf("run-%d", i), time.Hour))
+		})
+	}
+	wg.Wait()
+
+	acquired := 0
+	for _, err := range errs {
+		var runLockedError *domain.RunLockedError
+		if err == nil {
+			acquired++
+		} else if !errors.As(err, &runLockedError) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	assert.Equal(t, 1, acquired)
+
+	// only the lock file is left in the directory
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestFileRunLockerTakesOverExpiredLock(t *testing.T) {
+	locker := NewFileRunLocker(t.TempDir())
+
+	require.NoError(t, locker.AcquireRunLock(t.Context(), getTestRunLock("run-a", -time.Minute)))
+	require.NoError(t, locker.AcquireRunLock(t.Context(), getTestRunLock("run-b", time.Hour)))
+
+	lock, err := readRunLockFile(locker.FilePath(testSPObjectID))
+	require.NoError(t, err)
+	assert.Equal(t, "run-b", lock.Holder)
+}
+
+func TestFileRunLockerConcurrentTakeOver(t *testing.T) {
+	dir := t.TempDir()
+	locker := NewFileRunLocker(dir)
+	require.NoError(t, locker.AcquireRunLock(t.Context(), getTestRunLock("run-expired", -time.Minute)))
+
+	// only one of the runs racing for the expired lock takes it over
+	errs := make([]error, 20)
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Go(func() {
+			errs[i] = locker.AcquireRunLock(t.Context(), getTestRunLock(fmt.Sprintf("run-%d", i), time.Hour))
+		})
+	}
+	wg.Wait()
+
+	holder := ""
+	for i, err := range errs {
+		var runLockedError *domain.RunLockedError
+		if err == nil {
+			assert.Empty(t, holder, "the lock was taken over by more than one run")
+			holder = fmt.Sprintf("run-%d", i)
+		} else if !errors.As(err, &runLockedError) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	require.NotEmpty(t, holder)
+
+	lock, err := readRunLockFile(locker.FilePath(testSPObjectID))
+	require.NoError(t, err)
+	assert.Equal(t, holder, lock.Holder)
+
+	// only the lock file is left in the directory
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestFileRunLockerReportsStaleTakeoverFile(t *testing.T) {
+	locker := NewFileRunLocker(t.TempDir())
+	require.NoError(t, locker.AcquireRunLock(t.Context(), getTestRunLock("run-expired", -time.Minute)))
+
+	takeoverFilePath := locker.FilePath(testSPObjectID) + ".takeover"
+	require.NoError(t, os.WriteFile(takeoverFilePath, nil, 0o600))
+
+	// a run taking over the lock holds the takeover file
+	var runLockedError *domain.RunLockedError
+	require.ErrorAs(t, locker.AcquireRunLock(t.Context(), getTestRunLock("run-a", time.Hour)), &runLockedError)
+
+	// a takeover file left behind by a run which ended is reported
+	staleTime := time.Now().Add(-2 * staleTakeoverFileAge)
+	require.NoError(t, os.Chtimes(takeoverFilePath, staleTime, staleTime))
+	assert.ErrorContains(t, locker.AcquireRunLock(t.Context(), getTestRunLock("run-a", time.Hour)), takeoverFilePath)
+}
+
+func TestFileRunLockerRejectsInvalidLockFile(t *testing.T) {
+	locker := NewFileRunLocker(t.TempDir())
+	require.NoError(t, os.WriteFile(locker.FilePath(testSPObjectID), []byte("{"), 0o600))
+
+	assert.ErrorContains(t, locker.AcquireRunLock(t.Context(), getTestRunLock("run-a", time.Hour)), "error parsing lock file")
+}
@@ -0,0 +1,193 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package runlock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/Azure/mpf/pkg/domain"
+	azureAPI "github.com/Azure/mpf/pkg/infrastructure/azureAPI"
+	log "github.com/sirupsen/logrus"
+)
+
+// Tags of the lock resource group holding the lock
+const (
+	HolderTagName     = "azmpf-lock-holder"
+	AcquiredAtTagName = "azmpf-lock-acquired-at"
+	ExpiresAtTagName  = "azmpf-lock-expires-at"
+)
+
+// defaultSettleDelay is how long a written lock is left to settle before it is read again
+const defaultSettleDelay = 5 * time.Second
+
+// ResourceGroupRunLocker locks a service principal with the tags of an empty resource group named after the service principal.
+// Resource groups cannot be updated conditionally, so the lock is read again after it is written,
+// and only the run whose write was last holds the lock. The resource group is kept when the lock is released.
+type ResourceGroupRunLocker struct {
+	rgAPIClient *armresources.ResourceGroupsClient
+	// location is the location of the lock resource group, if it has to be created
+	location    string
+	settleDelay time.Duration
+}
+
+func NewResourceGroupRunLocker(subscriptionID string, location string) (*ResourceGroupRunLocker, error) {
+	azAPIClient, err := azureAPI.NewAzureAPIClients(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	return &ResourceGroupRunLocker{
+		rgAPIClient: azAPIClient.ResourceGroupsClient,
+		location:    location,
+		settleDelay: defaultSettleDelay,
+	}, nil
+}
+
+// AcquireRunLock writes the lock to the tags of the lock resource group, unless another run holds an unexpired lock
+func (l *ResourceGroupRunLocker) AcquireRunLock(ctx context.Context, lock domain.RunLock) error {
+	name := domain.GetRunLockName(lock.SPObjectID)
+
+	group, err := l.getResourceGroup(ctx, name)
+	if err != nil {
+		return fmt.Errorf("could not read lock resource group %s: %w", name, err)
+	}
+
+	location := l.location
+	tags := make(map[string]*string)
+	if group != nil {
+		current := getRunLockFromTags(lock, group.Tags)
+		if current != nil && !current.IsHeldBy(lock.Holder) && !current.IsExpired(time.Now()) {
+			return &domain.RunLockedError{Lock: *current}
+		}
+		if current != nil && !current.IsHeldBy(lock.Holder) {
+			log.Warnf("Taking over the lock of service principal %s from %s, which expired at %s", lock.SPObjectID, current.Holder, current.ExpiresAt.Format(time.RFC3339))
+		}
+		if group.Location != nil {
+			location = *group.Location
+		}
+		if group.Tags != nil {
+			tags = maps.Clone(group.Tags)
+		}
+	}
+
+	tags[HolderTagName] = to.Ptr(lock.Holder)
+	tags[AcquiredAtTagName] = to.Ptr(lock.AcquiredAt.UTC().Format(time.RFC3339))
+	tags[ExpiresAtTagName] = to.Ptr(lock.ExpiresAt.UTC().Format(time.RFC3339))
+	_, err = l.rgAPIClient.CreateOrUpdate(ctx, name, armresources.ResourceGroup{Location: &location, Tags: tags}, nil)
+	if err != nil {
+		return fmt.Errorf("could not write lock resource group %s: %w", name, err)
+	}
+
+	// a concurrent run may have written its lock at the same time
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(l.settleDelay):
+	}
+
+	group, err = l.getResourceGroup(ctx, name)
+	if err != nil {
+		return fmt.Errorf("could not read lock resource group %s: %w", name, err)
+	}
+	if group == nil {
+		return fmt.Errorf("lock resource group %s was deleted while acquiring the lock", name)
+	}
+	current := getRunLockFromTags(lock, group.Tags)
+	if current == nil {
+		return fmt.Errorf("the lock was removed from resource group %s while acquiring it", name)
+	}
+	if !current.IsHeldBy(lock.Holder) {
+		return &domain.RunLockedError{Lock: *current}
+	}
+	return nil
+}
+
+// ReleaseRunLock removes the lock from the tags of the lock resource group, if it is still held by the holder of the lock
+func (l *ResourceGroupRunLocker) ReleaseRunLock(ctx context.Context, lock domain.RunLock) error {
+	name := domain.GetRunLockName(lock.SPObjectID)
+
+	group, err := l.getResourceGroup(ctx, name)
+	if err != nil {
+		return fmt.Errorf("could not read lock resource group %s: %w", name, err)
+	}
+	if group == nil {
+		return nil
+	}
+	current := getRunLockFromTags(lock, group.Tags)
+	if current == nil || !current.IsHeldBy(lock.Holder) {
+		log.Debugf("The lock of service principal %s is not held by %s, it is not released", lock.SPObjectID, lock.Holder)
+		return nil
+	}
+
+	tags := maps.Clone(group.Tags)
+	delete(tags, HolderTagName)
+	delete(tags, AcquiredAtTagName)
+	delete(tags, ExpiresAtTagName)
+	_, err = l.rgAPIClient.CreateOrUpdate(ctx, name, armresources.ResourceGroup{Location: group.Location, Tags: tags}, nil)
+	if err != nil {
+		return fmt.Errorf("could not write lock resource group %s: %w", name, err)
+	}
+	return nil
+}
+
+// getResourceGroup returns the resource group, or nil if it does not exist
+func (l *ResourceGroupRunLocker) getResourceGroup(ctx context.Context, name string) (*armresources.ResourceGroup, error) {
+	resp, err := l.rgAPIClient.Get(ctx, name, nil)
+	var respErr *azcore.ResponseError
+	if errors.As(err, &respErr) && respErr.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &resp.ResourceGroup, nil
+}
+
+// getRunLockFromTags returns the lock of the service principal held according to the tags, or nil if the lock is not held.
+// A lock whose times cannot be parsed is expired.
+func getRunLockFromTags(lock domain.RunLock, tags map[string]*string) *domain.RunLock {
+	holder := tags[HolderTagName]
+	if holder == nil || *holder == "" {
+		return nil
+	}
+
+	current := domain.RunLock{
+		SubscriptionID: lock.SubscriptionID,
+		SPObjectID:     lock.SPObjectID,
+		Holder:         *holder,
+	}
+	if acquiredAt := tags[AcquiredAtTagName]; acquiredAt != nil {
+		current.AcquiredAt, _ = time.Parse(time.RFC3339, *acquiredAt)
+	}
+	if expiresAt := tags[ExpiresAtTagName]; expiresAt != nil {
+		current.ExpiresAt, _ = time.Parse(time.RFC3339, *expiresAt)
+	}
+	return &current
+}
@@ -0,0 +1,140 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package runlock
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/mpf/pkg/domain"
+	"github.com/Azure/mpf/pkg/infrastructure/azureAPI"
+	fakearm "github.com/Azure/mpf/pkg/infrastructure/fakeARM"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSPObjectID = "0A0A0A0A-0000-0000-0000-000000000000"
+
+func newTestResourceGroupRunLocker(t *testing.T, server *fakearm.Server, settleDelay time.Duration) *ResourceGroupRunLocker {
+	azAPIClients, err := azureAPI.NewAzureAPIClientsWithOptions("sub", server.AzureAPIClientsOptions())
+	require.NoError(t, err)
+	return &ResourceGroupRunLocker{
+		rgAPIClient: azAPIClients.ResourceGroupsClient,
+		location:    "eastus2",
+		settleDelay: settleDelay,
+	}
+}
+
+func getTestRunLock(holder string, ttl time.Duration) domain.RunLock {
+	now := time.Now().UTC().Truncate(time.Second)
+	return domain.RunLock{
+		SubscriptionID: "sub",
+		SPObjectID:     testSPObjectID,
+		Holder:         holder,
+		AcquiredAt:     now,
+		ExpiresAt:      now.Add(ttl),
+	}
+}
+
+func TestResourceGroupRunLocker(t *testing.T) {
+	server := fakearm.NewServer(fakearm.Config{})
+	defer server.Close()
+	locker := newTestResourceGroupRunLocker(t, server, 0)
+	lockName := domain.GetRunLockName(testSPObjectID)
+
+	lockA := getTestRunLock("run-a", time.Hour)
+	require.NoError(t, locker.AcquireRunLock(t.Context(), lockA))
+	assert.Equal(t, "run-a", server.ResourceGroupTags(lockName)[HolderTagName])
+	assert.Equal(t, lockA.ExpiresAt.Format(time.RFC3339), server.ResourceGroupTags(lockName)[ExpiresAtTagName])
+
+	var runLockedError *domain.RunLockedError
+	require.ErrorAs(t, locker.AcquireRunLock(t.Context(), getTestRunLock("run-b", time.Hour)), &runLockedError)
+	assert.Equal(t, "run-a", runLockedError.Lock.Holder)
+	assert.Equal(t, lockA.ExpiresAt, runLockedError.Lock.ExpiresAt)
+
+	// releasing a lock held by another run leaves it in place
+	require.NoError(t, locker.ReleaseRunLock(t.Context(), getTestRunLock("run-b", time.Hour)))
+	assert.Equal(t, "run-a", server.ResourceGroupTags(lockName)[HolderTagName])
+
+	// the holder renews its lock
+	renewed := lockA
+	renewed.ExpiresAt = lockA.ExpiresAt.Add(time.Hour)
+	require.NoError(t, locker.AcquireRunLock(t.Context(), renewed))
+	assert.Equal(t, renewed.ExpiresAt.Format(time.RFC3339), server.ResourceGroupTags(lockName)[ExpiresAtTagName])
+
+	require.NoError(t, locker.ReleaseRunLock(t.Context(), lockA))
+	assert.True(t, server.ResourceGroupExists(lockName))
+	assert.NotContains(t, server.ResourceGroupTags(lockName), HolderTagName)
+
+	require.NoError(t, locker.AcquireRunLock(t.Context(), getTestRunLock("run-b", time.Hour)))
+	assert.Equal(t, "run-b", server.ResourceGroupTags(lockName)[HolderTagName])
+}
+
+func TestResourceGroupRunLockerTakesOverExpiredLock(t *testing.T) {
+	server := fakearm.NewServer(fakearm.Config{})
+	defer server.Close()
+	locker := newTestResourceGroupRunLocker(t, server, 0)
+
+	require.NoError(t, locker.AcquireRunLock(t.Context(), getTestRunLock("run-a", -time.Minute)))
+	require.NoError(t, locker.AcquireRunLock(t.Context(), getTestRunLock("run-b", time.Hour)))
+
+	assert.Equal(t, "run-b", server.ResourceGroupTags(domain.GetRunLockName(testSPObjectID))[HolderTagName])
+}
+
+func TestResourceGroupRunLockerLetsOneConcurrentRunAcquire(t *testing.T) {
+	server := fakearm.NewServer(fakearm.Config{})
+	defer server.Close()
+
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i, holder := range []string{"run-a", "run-b"} {
+		wg.Go(func() {
+			errs[i] = newTestResourceGroupRunLocker(t, server, 100*time.Millisecond).AcquireRunLock(t.Context(), getTestRunLock(holder, time.Hour))
+		})
+	}
+	wg.Wait()
+
+	holder := server.ResourceGroupTags(domain.GetRunLockName(testSPObjectID))[HolderTagName]
+	for i, runHolder := range []string{"run-a", "run-b"} {
+		if runHolder == holder {
+			assert.NoError(t, errs[i])
+			continue
+		}
+		var runLockedError *domain.RunLockedError
+		assert.ErrorAs(t, errs[i], &runLockedError)
+	}
+}
+
+func TestGetRunLockFromTags(t *testing.T) {
+	lock := getTestRunLock("run-a", time.Hour)
+
+	assert.Nil(t, getRunLockFromTags(lock, nil))
+	assert.Nil(t, getRunLockFromTags(lock, map[string]*string{"other": new("tag")}))
+
+	// a lock whose expiry cannot be parsed is expired
+	current := getRunLockFromTags(lock, map[string]*string{HolderTagName: new("run-b"), ExpiresAtTagName: new("tomorrow")})
+	require.NotNil(t, current)
+	assert.Equal(t, "run-b", current.Holder)
+	assert.True(t, current.IsExpired(time.Now()))
+}
@@ -23,15 +23,17 @@
 package sproleassignmentmanager
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"slices"
 	"strings"
+	"time"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/authorization/armauthorization/v3"
 	"github.com/Azure/mpf/pkg/domain"
 	"github.com/Azure/mpf/pkg/infrastructure/azureAPI"
@@ -40,14 +42,31 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+const (
+	// maxRetries is the number of retries of role definition and role assignment requests failing with a conflict
+	maxRetries = 5
+	// defaultRetryDelay is the delay before the first retry, doubled after each retry
+	defaultRetryDelay = 5 * time.Second
+)
+
 type SPRoleAssignmentManager struct {
 	azAPIClient *azureAPI.AzureAPIClients
+	retryDelay  time.Duration
+}
+
+func NewSPRoleAssignmentManager(subscriptionID string) (*SPRoleAssignmentManager, error) {
+	azAPIClient, err := azureAPI.NewAzureAPIClients(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	return NewSPRoleAssignmentManagerWithClients(azAPIClient), nil
 }
 
-func NewSPRoleAssignmentManager(subscriptionID string) *SPRoleAssignmentManager {
-	azAPIClient := azureAPI.NewAzureAPIClients(subscriptionID)
+// NewSPRoleAssignmentManagerWithClients returns the role assignment manager using the given Azure API clients
+func NewSPRoleAssignmentManagerWithClients(azAPIClient *azureAPI.AzureAPIClients) *SPRoleAssignmentManager {
 	return &SPRoleAssignmentManager{
 		azAPIClient: azAPIClient,
+		retryDelay:  defaultRetryDelay,
 	}
 }
 
@@ -55,16 +74,17 @@ func NewSPRoleAssignmentManager(subscriptionID string) *SPRoleAssignmentManager
 // It retries up to 5 times if it encounters an InvalidActionOrNotAction error
 // It returns an error if it fails to create or update the role
 // It returns a list of invalid actions that were removed from the role
-func (r *SPRoleAssignmentManager) CreateUpdateCustomRole(subscription string, role domain.Role, permissions []string) (error, []string) { //nolint:staticcheck
+// The permissions are added to the actions of the role and the data actions to the dataActions of the role
+func (r *SPRoleAssignmentManager) CreateUpdateCustomRole(ctx context.Context, subscription string, role domain.Role, permissions []string, dataActions []string) (error, []string) { //nolint:staticcheck
 	retryCount := 5
 	permissionsToAdd := permissions
+	dataActionsToAdd := dataActions
 	var invalidActions []string
 
 	for i := range retryCount {
 		log.Debugf("Creating/Updating Role Definition: %s, Retry: %d", role.RoleDefinitionName, i+1)
-		err := r.createUpdateCustomRole(subscription, role, permissionsToAdd)
-		if err != nil && strings.Contains(err.Error(), "InvalidActionOrNotAction") {
-			errMsg := err.Error()
+		err := r.createUpdateCustomRole(ctx, subscription, role, permissionsToAdd, dataActionsToAdd)
+		if errMsg, ok := getInvalidActionOrNotActionError(err); ok {
 			log.Warnf("InvalidActionOrNotAction error occurred. Attempting to remove invalid action...")
 			actionsToRemove, err := domain.GetInvalidActionFromInvalidActionOrNotActionError(errMsg)
 			if err != nil {
@@ -74,6 +94,7 @@ func (r *SPRoleAssignmentManager) CreateUpdateCustomRole(subscription string, ro
 			log.Debug("Filtering Invalid Actions: ", actionsToRemove)
 			invalidActions = append(invalidActions, actionsToRemove...)
 			permissionsToAdd = filterInvalidActions(permissionsToAdd, actionsToRemove)
+			dataActionsToAdd = filterInvalidActions(dataActionsToAdd, actionsToRemove)
 			continue // retry
 		}
 		if err != nil { // not retrying for other errors
@@ -86,211 +107,151 @@ func (r *SPRoleAssignmentManager) CreateUpdateCustomRole(subscription string, ro
 	return nil, invalidActions
 }
 
-func (r *SPRoleAssignmentManager) createUpdateCustomRole(subscription string, role domain.Role, permissions []string) error {
-
-	// rgScope := fmt.Sprintf("/subscriptions/%s/resourceGroups/%s", subscription, resourceGroupName)
+func (r *SPRoleAssignmentManager) createUpdateCustomRole(ctx context.Context, subscription string, role domain.Role, permissions []string, dataActions []string) error {
 	subScope := fmt.Sprintf("/subscriptions/%s", subscription)
 
-	data := map[string]any{
-		"assignableScopes": []string{
-			// rgScope,
-			subScope,
-		},
-		"description": role.RoleDefinitionName,
-		"id":          role.RoleDefinitionResourceID,
-		"name":        role.RoleDefinitionID,
-		"permissions": []map[string]any{
-			{
-				"actions":        permissions,
-				"dataActions":    []string{},
-				"notActions":     []string{},
-				"notDataActions": []string{},
+	description := role.RoleDefinitionDescription
+	if description == "" {
+		description = role.RoleDefinitionName
+	}
+
+	roleDefinition := armauthorization.RoleDefinition{
+		Properties: &armauthorization.RoleDefinitionProperties{
+			AssignableScopes: []*string{new(subScope)},
+			Description:      new(description),
+			Permissions: []*armauthorization.Permission{
+				{
+					Actions:        to.SliceOfPtrs(permissions...),
+					DataActions:    to.SliceOfPtrs(dataActions...),
+					NotActions:     []*string{},
+					NotDataActions: []*string{},
+				},
 			},
+			RoleName: new(role.RoleDefinitionName),
+			RoleType: new("CustomRole"),
 		},
-		"roleName": role.RoleDefinitionName,
-		"roleType": "CustomRole",
-		// "type":     "Microsoft.Authorization/roleDefinitions",
 	}
+	log.Debugf("Role definition permissions: actions %v, data actions %v", permissions, dataActions)
 
-	properties := map[string]any{
-		"properties": data,
-	}
-	// marshal data as json
-	jsonData, err := json.Marshal(properties)
-	if err != nil {
+	return r.retryOnConflict(ctx, "Updating the role definition", func() error {
+		_, err := r.azAPIClient.RoleDefinitionsClient.CreateOrUpdate(ctx, subScope, role.RoleDefinitionID, roleDefinition, nil)
 		return err
-	}
-
-	//convert to json string
-	jsonString := string(jsonData)
-
-	// log.Printf("jsonString: %s", jsonString)
-	log.Debugf("jsonString: %s", jsonString)
-
-	url := fmt.Sprintf("https://management.azure.com/subscriptions/%s/providers/Microsoft.Authorization/roleDefinitions/%s?api-version=2018-01-01-preview", subscription, role.RoleDefinitionID)
-
-	client := &http.Client{}
+	})
+}
 
-	req, err := http.NewRequest("PUT", url, bytes.NewBufferString(jsonString))
-	if err != nil {
-		return err
+// getInvalidActionOrNotActionError returns the response body of an InvalidActionOrNotAction error,
+// which names the invalid action
+func getInvalidActionOrNotActionError(err error) (string, bool) {
+	var respErr *azcore.ResponseError
+	if !errors.As(err, &respErr) || respErr.ErrorCode != "InvalidActionOrNotAction" {
+		return "", false
 	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("User-Agent", "Go HTTP Client")
-
-	defaultApiBearerToken, err := r.azAPIClient.GetDefaultAPIBearerToken()
-	if err != nil {
-		return err
+	body, payloadErr := runtime.Payload(respErr.RawResponse)
+	if payloadErr != nil {
+		return err.Error(), true
 	}
+	return string(body), true
+}
 
-	// add bearer token to header
-	req.Header.Add("Authorization", "Bearer "+defaultApiBearerToken)
+func (r *SPRoleAssignmentManager) AssignRoleToSP(ctx context.Context, subscription string, SPOBjectID string, role domain.Role) error {
+	scope := fmt.Sprintf("/subscriptions/%s", subscription)
 
-	// make request
-	resp, err := client.Do(req)
-	if err != nil {
-		return err
+	parameters := armauthorization.RoleAssignmentCreateParameters{
+		Properties: &armauthorization.RoleAssignmentProperties{
+			PrincipalID:      new(SPOBjectID),
+			PrincipalType:    to.Ptr(armauthorization.PrincipalTypeServicePrincipal),
+			RoleDefinitionID: new(role.RoleDefinitionResourceID),
+		},
 	}
 
-	// read response body
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
+	err := r.retryOnConflict(ctx, "Assigning the role", func() error {
+		// the role assignments are created with the default credential, like they are deleted
+		_, err := r.azAPIClient.RoleAssignmentsDeletionClient.Create(ctx, scope, uuid.New().String(), parameters, nil)
 		return err
+	})
+	var respErr *azcore.ResponseError
+	if errors.As(err, &respErr) && respErr.ErrorCode == "RoleAssignmentExists" {
+		log.Infoln("Role assignment already exists. Skipping...")
+		return nil
 	}
-
-	log.Debugln(string(body))
-	if strings.Contains(string(body), "InvalidActionOrNotAction") {
-		return fmt.Errorf("InvalidActionOrNotAction: %s", string(body))
+	if err != nil {
+		return fmt.Errorf("failed to assign role to SP: %w", err)
 	}
-
 	return nil
 }
 
-func (r *SPRoleAssignmentManager) AssignRoleToSP(subscription string, SPOBjectID string, role domain.Role) error {
-
-	// scope := fmt.Sprintf("/subscriptions/%s/resourceGroups/%s", subscription, resourceGroupName)
-	scope := fmt.Sprintf("/subscriptions/%s", subscription)
-	url := fmt.Sprintf("https://management.azure.com/%s/providers/Microsoft.Authorization/roleAssignments/%s?api-version=2022-04-01", scope, uuid.New().String())
-
-	data := map[string]any{
-		"principalId":      SPOBjectID,
-		"principalType":    "ServicePrincipal",
-		"roleDefinitionId": role.RoleDefinitionResourceID,
+// ListRoleAssignments returns the role assignments of the SP in the subscription, including those inherited from management groups.
+// All role assignments of the subscription are returned if SPOBjectID is empty.
+func (r *SPRoleAssignmentManager) ListRoleAssignments(ctx context.Context, subscription string, SPOBjectID string) ([]domain.RoleAssignment, error) {
+	options := &armauthorization.RoleAssignmentsClientListForSubscriptionOptions{}
+	if SPOBjectID != "" {
+		options.Filter = new(fmt.Sprintf("assignedTo('%s')", SPOBjectID))
 	}
+	pager := r.azAPIClient.RoleAssignmentsClient.NewListForSubscriptionPager(options)
 
-	properties := map[string]any{
-		"properties": data,
-	}
+	var assignments []domain.RoleAssignment
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
 
-	// marshal data as json
-	jsonData, err := json.Marshal(properties)
-	if err != nil {
-		return err
+		for _, roleAssignment := range page.Value {
+			if roleAssignment.Name == nil || roleAssignment.Properties == nil {
+				continue
+			}
+			properties := roleAssignment.Properties
+			assignment := domain.RoleAssignment{
+				Name:             *roleAssignment.Name,
+				Scope:            getString(properties.Scope),
+				RoleDefinitionID: getString(properties.RoleDefinitionID),
+				PrincipalID:      getString(properties.PrincipalID),
+				Description:      getString(properties.Description),
+				Condition:        getString(properties.Condition),
+				ConditionVersion: getString(properties.ConditionVersion),
+			}
+			if properties.PrincipalType != nil {
+				assignment.PrincipalType = string(*properties.PrincipalType)
+			}
+			assignments = append(assignments, assignment)
+		}
 	}
 
-	//convert to json string
-	jsonString := string(jsonData)
-
-	log.Debugf("jsonString: %s", jsonString)
-
-	client := &http.Client{}
+	return assignments, nil
+}
 
-	req, err := http.NewRequest("PUT", url, bytes.NewBufferString(jsonString))
-	if err != nil {
-		return err
+// RestoreRoleAssignment recreates the role assignment with its name, scope and condition.
+// Restoring a role assignment which still exists succeeds.
+func (r *SPRoleAssignmentManager) RestoreRoleAssignment(ctx context.Context, assignment domain.RoleAssignment) error {
+	properties := &armauthorization.RoleAssignmentProperties{
+		PrincipalID:      new(assignment.PrincipalID),
+		RoleDefinitionID: new(assignment.RoleDefinitionID),
 	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("User-Agent", "Go HTTP Client")
-
-	defaultApiBearerToken, err := r.azAPIClient.GetDefaultAPIBearerToken()
-	if err != nil {
-		return err
+	if assignment.PrincipalType != "" {
+		properties.PrincipalType = to.Ptr(armauthorization.PrincipalType(assignment.PrincipalType))
 	}
-
-	// add bearer token to header
-	req.Header.Add("Authorization", "Bearer "+defaultApiBearerToken)
-
-	// make request
-	resp, err := client.Do(req)
-	if err != nil {
-		return err
+	if assignment.Description != "" {
+		properties.Description = new(assignment.Description)
 	}
-
-	// read response body
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return err
+	if assignment.Condition != "" {
+		properties.Condition = new(assignment.Condition)
 	}
-
-	if resp.StatusCode != 200 && resp.StatusCode != 201 {
-		return fmt.Errorf("failed to assign role to SP. Status code: %s", string(body))
+	if assignment.ConditionVersion != "" {
+		properties.ConditionVersion = new(assignment.ConditionVersion)
 	}
 
-	// print response body
-	log.Debugln(string(body))
-	return nil
+	err := r.retryOnConflict(ctx, "Restoring the role assignment", func() error {
+		_, err := r.azAPIClient.RoleAssignmentsDeletionClient.Create(ctx, assignment.Scope, assignment.Name, armauthorization.RoleAssignmentCreateParameters{Properties: properties}, nil)
+		return err
+	})
+	var respErr *azcore.ResponseError
+	if errors.As(err, &respErr) && respErr.ErrorCode == "RoleAssignmentExists" {
+		log.Debugf("Role assignment %s still exists", assignment.Name)
+		return nil
+	}
+	return err
 }
 
-// func (r *SPRoleAssignmentManager) AssignRoleToSP(scope string) error {
-
-// 	// armauthorization.NewClassicAdministratorsClient()
-// 	clientFactory, err := armauthorization.NewClientFactory(m.SubscriptionID, m.DefaultCred, nil)
-// 	if err != nil {
-// 		log.Fatalf("failed to create client: %v", err)
-// 	}
-
-// 	res, err := clientFactory.NewRoleAssignmentsClient().Create(m.Ctx, m.SubscriptionID, uuid.New().String(), armauthorization.RoleAssignmentCreateParameters{
-// 		Properties: &armauthorization.RoleAssignmentProperties{
-// 			PrincipalID:      &m.SPObjectID,
-// 			PrincipalType:    to.StringPtr(armauthorization.PrincipalTypeServicePrincipal),
-// 			RoleDefinitionID: &m.RoleDefinitionResourceID,
-// 		},
-// 	}, nil)
-
-// 	// rac, err := armauthorization.NewRoleAssignmentsClient(m.SubscriptionID, m.DefaultCred, nil)
-// 	// if err != nil {
-// 	// 	return err
-// 	// }
-
-// 	// rao := armauthorization.RoleAssignmentsClientCreateOptions{
-
-// 	// }
-// 	// roleAssignmentParams := authorization.RoleAssignmentCreateParameters{
-// 	// 	Properties: &authorization.RoleAssignmentProperties{
-// 	// 		PrincipalID:      &m.SPObjectID,
-// 	// 		RoleDefinitionID: &m.RoleDefinitionResourceID,
-// 	// 	},
-// 	// }
-
-// 	// roleAssignmentParams := armauthorization.RoleAssignmentCreateParameters{
-// 	// 	Properties: &armauthorization.RoleAssignmentProperties{
-// 	// 		PrincipalID:      &m.SPObjectID,
-// 	// 		RoleDefinitionID: &m.RoleDefinitionResourceID,
-// 	// 	},
-// 	// }
-
-// 	_, err = rac.Create(m.Ctx, scope, uuid.New().String(), roleAssignmentParams, nil)
-// 	// _, err = m.RoleAssignmentsClient.Create(m.Ctx, scope, uuid.New().String(), roleAssignmentParams)
-
-// 	if err != nil {
-// 		if strings.Contains(err.Error(), "RoleAssignmentExists") {
-// 			log.Infoln("Role assignment already exists. Skipping...")
-// 			return nil
-// 		}
-// 		return err
-// 	}
-
-// 	if err != nil {
-// 		return err
-// 	}
-
-// 	return nil
-// }z
-
 // DetachRolesFromSP detaches the specified role from the SP
 func (r *SPRoleAssignmentManager) DetachRolesFromSP(ctx context.Context, subscription string, SPOBjectID string, role domain.Role) error {
 	pager := r.azAPIClient.RoleAssignmentsClient.NewListForSubscriptionPager(&armauthorization.RoleAssignmentsClientListForSubscriptionOptions{
@@ -336,49 +297,174 @@ func (r *SPRoleAssignmentManager) DetachRolesFromSP(ctx context.Context, subscri
 	return nil
 }
 
-func (r *SPRoleAssignmentManager) DeleteCustomRole(subscription string, role domain.Role) error {
-	url := fmt.Sprintf("https://management.azure.com/subscriptions/%s/providers/Microsoft.Authorization/roleDefinitions/%s?api-version=2018-01-01-preview", subscription, role.RoleDefinitionID)
-
-	client := &http.Client{}
+func (r *SPRoleAssignmentManager) DeleteCustomRole(ctx context.Context, subscription string, role domain.Role) error {
+	subScope := fmt.Sprintf("/subscriptions/%s", subscription)
 
-	req, err := http.NewRequest("DELETE", url, nil)
-	if err != nil {
+	err := r.retryOnConflict(ctx, "Deleting the role definition", func() error {
+		_, err := r.azAPIClient.RoleDefinitionsClient.Delete(ctx, subScope, role.RoleDefinitionID, nil)
 		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete role definition: %w", err)
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("User-Agent", "Go HTTP Client")
+	log.Infoln("Role definition deleted successfully")
+	return nil
+}
 
-	defaultApiBearerToken, err := r.azAPIClient.GetDefaultAPIBearerToken()
-	if err != nil {
-		return err
+// ListCustomRoles returns the custom role definitions of the subscription
+func (r *SPRoleAssignmentManager) ListCustomRoles(ctx context.Context, subscription string) ([]domain.CustomRole, error) {
+	subScope := fmt.Sprintf("/subscriptions/%s", subscription)
+	pager := r.azAPIClient.RoleDefinitionsClient.NewListPager(subScope, &armauthorization.RoleDefinitionsClientListOptions{
+		Filter: new("type eq 'CustomRole'"),
+	})
+
+	var roles []domain.CustomRole
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list role definitions: %w", err)
+		}
+
+		for _, roleDefinition := range page.Value {
+			if roleDefinition.Name == nil || roleDefinition.Properties == nil {
+				continue
+			}
+			role := domain.CustomRole{
+				Role: domain.Role{
+					RoleDefinitionID:          *roleDefinition.Name,
+					RoleDefinitionName:        getString(roleDefinition.Properties.RoleName),
+					RoleDefinitionDescription: getString(roleDefinition.Properties.Description),
+					RoleDefinitionResourceID:  getString(roleDefinition.ID),
+				},
+			}
+			if roleDefinition.Properties.CreatedOn != nil {
+				role.CreatedOn = *roleDefinition.Properties.CreatedOn
+			}
+			roles = append(roles, role)
+		}
 	}
 
-	// add bearer token to header
-	req.Header.Add("Authorization", "Bearer "+defaultApiBearerToken)
+	return roles, nil
+}
 
-	// make request
-	resp, err := client.Do(req)
-	if err != nil {
+// DeleteRoleAssignment deletes the role assignment. Deleting a role assignment which does not exist succeeds.
+func (r *SPRoleAssignmentManager) DeleteRoleAssignment(ctx context.Context, assignment domain.RoleAssignment) error {
+	err := r.retryOnConflict(ctx, "Deleting the role assignment", func() error {
+		_, err := r.azAPIClient.RoleAssignmentsDeletionClient.Delete(ctx, assignment.Scope, assignment.Name, nil)
 		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete role assignment: %w", err)
 	}
-	defer resp.Body.Close() //nolint:errcheck
+	return nil
+}
 
-	// read response body
-	body, err := io.ReadAll(resp.Body)
+// GetCustomRolePermissions returns the actions and data actions of the custom role.
+// No permissions are returned if the role definition does not exist (yet).
+func (r *SPRoleAssignmentManager) GetCustomRolePermissions(ctx context.Context, subscription string, role domain.Role) ([]string, []string, error) {
+	subScope := fmt.Sprintf("/subscriptions/%s", subscription)
+
+	resp, err := r.azAPIClient.RoleDefinitionsClient.Get(ctx, subScope, role.RoleDefinitionID, nil)
+	var respErr *azcore.ResponseError
+	if errors.As(err, &respErr) && respErr.StatusCode == http.StatusNotFound {
+		return nil, nil, nil
+	}
 	if err != nil {
-		log.Warnf("Could not delete role definition: %s\n", err)
+		return nil, nil, fmt.Errorf("failed to get role definition: %w", err)
 	}
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("failed to delete role definition. Status: %s, Body: %s", resp.Status, string(body))
+	var actions, dataActions []string
+	if resp.Properties == nil {
+		return actions, dataActions, nil
+	}
+	for _, permission := range resp.Properties.Permissions {
+		if permission == nil {
+			continue
+		}
+		actions = append(actions, getStrings(permission.Actions)...)
+		dataActions = append(dataActions, getStrings(permission.DataActions)...)
 	}
+	return actions, dataActions, nil
+}
 
-	log.Debugln(string(body))
-	log.Infoln("Role definition deleted successfully")
+// retryOnConflict calls operation until it succeeds or fails with an error other than a conflict,
+// for at most maxRetries retries. The delay between retries doubles after each retry.
+// Throttling is not retried here, as the Azure SDK clients already retry it with its Retry-After delay.
+func (r *SPRoleAssignmentManager) retryOnConflict(ctx context.Context, description string, operation func() error) error {
+	delay := r.retryDelay
+	for i := 0; ; i++ {
+		err := operation()
+		if err == nil || i == maxRetries || !isRetryableError(err) {
+			return err
+		}
 
-	return nil
+		log.Warnf("%s failed, retrying in %s (%d/%d): %v", description, delay, i+1, maxRetries, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// isRetryableError returns true for conflicts with a concurrent change, e.g. of the role definition
+func isRetryableError(err error) bool {
+	var respErr *azcore.ResponseError
+	if !errors.As(err, &respErr) || respErr.StatusCode != http.StatusConflict {
+		return false
+	}
+
+	// conflicts with existing role assignments and role definitions do not resolve on retries
+	return respErr.ErrorCode != "RoleAssignmentExists" && respErr.ErrorCode != "RoleDefinitionWithSameNameExists"
+}
+
+func getString(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+func getStrings(values []*string) []string {
+	var result []string
+	for _, value := range values {
+		if value != nil {
+			result = append(result, *value)
+		}
+	}
+	return result
+}
+
+// CountRoleAssignments returns the number of assignments of the role to the SP,
+// or the number of all role assignments of the SP if the role has no resource ID
+func (r *SPRoleAssignmentManager) CountRoleAssignments(ctx context.Context, subscription string, SPOBjectID string, role domain.Role) (int, error) {
+	pager := r.azAPIClient.RoleAssignmentsClient.NewListForSubscriptionPager(&armauthorization.RoleAssignmentsClientListForSubscriptionOptions{
+		Filter: new(fmt.Sprintf("assignedTo('%s')", SPOBjectID)),
+	})
+
+	count := 0
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return 0, err
+		}
+
+		for _, roleAssignment := range page.Value {
+			if role.RoleDefinitionResourceID != "" {
+				if roleAssignment.Properties == nil || roleAssignment.Properties.RoleDefinitionID == nil {
+					continue
+				}
+				if !strings.EqualFold(*roleAssignment.Properties.RoleDefinitionID, role.RoleDefinitionResourceID) {
+					continue
+				}
+			}
+			count++
+		}
+	}
+
+	return count, nil
 }
 
 func stringExistsInSlice(s string, sl []string) bool {
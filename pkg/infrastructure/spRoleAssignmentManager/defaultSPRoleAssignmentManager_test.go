@@ -0,0 +1,354 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package sproleassignmentmanager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/mpf/pkg/domain"
+	"github.com/Azure/mpf/pkg/infrastructure/azureAPI"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type staticTokenCredential string
+
+func (c staticTokenCredential) GetToken(ctx context.Context, opts policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	return azcore.AccessToken{Token: string(c), ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+// newTestSPRoleAssignmentManager returns a manager sending its requests to handler, without delays between retries
+func newTestSPRoleAssignmentManager(t *testing.T, handler http.HandlerFunc) *SPRoleAssignmentManager {
+	// the Azure SDK only sends bearer tokens over TLS
+	server := httptest.NewTLSServer(handler)
+	t.Cleanup(server.Close)
+	azAPIClient, err := azureAPI.NewAzureAPIClientsWithOptions("sub", azureAPI.AzureAPIClientsOptions{
+		Endpoint:   server.URL,
+		Credential: staticTokenCredential("token"),
+		Transport:  server.Client(),
+	})
+	require.NoError(t, err)
+	return &SPRoleAssignmentManager{
+		azAPIClient: azAPIClient,
+	}
+}
+
+func writeTestError(w http.ResponseWriter, statusCode int, code string, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	fmt.Fprintf(w, `{"error":{"code":"%s","message":"%s"}}`, code, message)
+}
+
+var testRole = domain.Role{
+	RoleDefinitionID:         "11111111-1111-1111-1111-111111111111",
+	RoleDefinitionName:       "tmp-rol-test",
+	RoleDefinitionResourceID: "/subscriptions/sub/providers/Microsoft.Authorization/roleDefinitions/11111111-1111-1111-1111-111111111111",
+}
+
+func TestCreateUpdateCustomRoleRetriesConflicts(t *testing.T) {
+	requests := 0
+	var actions []string
+	manager := newTestSPRoleAssignmentManager(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			writeTestError(w, http.StatusConflict, "Conflict", "The role definition is being updated.")
+			return
+		}
+
+		var body struct {
+			Properties struct {
+				Permissions []struct {
+					Actions     []string `json:"actions"`
+					DataActions []string `json:"dataActions"`
+				} `json:"permissions"`
+			} `json:"properties"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		actions = body.Properties.Permissions[0].Actions
+		assert.Equal(t, []string{}, body.Properties.Permissions[0].DataActions)
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{}`)
+	})
+
+	err, invalidActions := manager.CreateUpdateCustomRole(t.Context(), "sub", testRole, []string{"Microsoft.Storage/storageAccounts/write"}, nil)
+	require.NoError(t, err)
+	assert.Empty(t, invalidActions)
+	assert.Equal(t, 2, requests)
+	assert.Equal(t, []string{"Microsoft.Storage/storageAccounts/write"}, actions)
+}
+
+func TestCreateUpdateCustomRoleDescription(t *testing.T) {
+	var descriptions []string
+	manager := newTestSPRoleAssignmentManager(t, func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Properties struct {
+				Description string `json:"description"`
+			} `json:"properties"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		descriptions = append(descriptions, body.Properties.Description)
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{}`)
+	})
+
+	// the role name is the description of roles without one
+	err, _ := manager.CreateUpdateCustomRole(t.Context(), "sub", testRole, []string{"Microsoft.Storage/storageAccounts/write"}, nil)
+	require.NoError(t, err)
+
+	describedRole := testRole
+	describedRole.RoleDefinitionDescription = "Temporary role of azmpf run 8f14e45f-ceea-467a-9af0-fd5bd2b2d1a4"
+	err, _ = manager.CreateUpdateCustomRole(t.Context(), "sub", describedRole, []string{"Microsoft.Storage/storageAccounts/write"}, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"tmp-rol-test", "Temporary role of azmpf run 8f14e45f-ceea-467a-9af0-fd5bd2b2d1a4"}, descriptions)
+}
+
+func TestCreateUpdateCustomRoleRemovesInvalidActions(t *testing.T) {
+	var actions []string
+	manager := newTestSPRoleAssignmentManager(t, func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Properties struct {
+				Permissions []struct {
+					Actions []string `json:"actions"`
+				} `json:"permissions"`
+			} `json:"properties"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		actions = body.Properties.Permissions[0].Actions
+		for _, action := range actions {
+			if action == "Microsoft.KeyVault/vaults/accessPolicies/read" {
+				writeTestError(w, http.StatusBadRequest, "InvalidActionOrNotAction", fmt.Sprintf("'%s' does not match any of the actions supported by the providers.", action))
+				return
+			}
+		}
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{}`)
+	})
+
+	err, invalidActions := manager.CreateUpdateCustomRole(t.Context(), "sub", testRole, []string{"Microsoft.KeyVault/vaults/accessPolicies/read", "Microsoft.KeyVault/vaults/accessPolicies/write"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Microsoft.KeyVault/vaults/accessPolicies/read"}, invalidActions)
+	assert.Equal(t, []string{"Microsoft.KeyVault/vaults/accessPolicies/write"}, actions)
+}
+
+func TestCreateUpdateCustomRoleReturnsOtherErrors(t *testing.T) {
+	requests := 0
+	manager := newTestSPRoleAssignmentManager(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		writeTestError(w, http.StatusForbidden, "AuthorizationFailed", "The client does not have authorization.")
+	})
+
+	err, _ := manager.CreateUpdateCustomRole(t.Context(), "sub", testRole, []string{"Microsoft.Storage/storageAccounts/write"}, nil)
+	var respErr *azcore.ResponseError
+	require.True(t, errors.As(err, &respErr))
+	assert.Equal(t, "AuthorizationFailed", respErr.ErrorCode)
+	assert.Equal(t, 1, requests)
+}
+
+func TestAssignRoleToSPIgnoresExistingAssignment(t *testing.T) {
+	requests := 0
+	manager := newTestSPRoleAssignmentManager(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		writeTestError(w, http.StatusConflict, "RoleAssignmentExists", "The role assignment already exists.")
+	})
+
+	err := manager.AssignRoleToSP(t.Context(), "sub", "OOOOOOOO-OOOO-OOOO-OOOO-OOOOOOOOOOOO", testRole)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, requests)
+}
+
+func TestGetCustomRolePermissions(t *testing.T) {
+	manager := newTestSPRoleAssignmentManager(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"properties":{"permissions":[{"actions":["Microsoft.Storage/storageAccounts/write"],"dataActions":["Microsoft.KeyVault/vaults/secrets/getSecret/action"]}]}}`)
+	})
+
+	actions, dataActions, err := manager.GetCustomRolePermissions(t.Context(), "sub", testRole)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Microsoft.Storage/storageAccounts/write"}, actions)
+	assert.Equal(t, []string{"Microsoft.KeyVault/vaults/secrets/getSecret/action"}, dataActions)
+}
+
+func TestGetCustomRolePermissionsOfMissingRole(t *testing.T) {
+	manager := newTestSPRoleAssignmentManager(t, func(w http.ResponseWriter, r *http.Request) {
+		writeTestError(w, http.StatusNotFound, "RoleDefinitionDoesNotExist", "The specified role definition does not exist.")
+	})
+
+	actions, dataActions, err := manager.GetCustomRolePermissions(t.Context(), "sub", testRole)
+	require.NoError(t, err)
+	assert.Nil(t, actions)
+	assert.Nil(t, dataActions)
+}
+
+func TestIsRetryableError(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		retryable bool
+	}{
+		// throttling is retried by the Azure SDK clients
+		{"throttling", &azcore.ResponseError{StatusCode: http.StatusTooManyRequests}, false},
+		{"conflict", &azcore.ResponseError{StatusCode: http.StatusConflict, ErrorCode: "Conflict"}, true},
+		{"wrapped conflict", fmt.Errorf("wrapped: %w", &azcore.ResponseError{StatusCode: http.StatusConflict}), true},
+		{"existing role assignment", &azcore.ResponseError{StatusCode: http.StatusConflict, ErrorCode: "RoleAssignmentExists"}, false},
+		{"existing role name", &azcore.ResponseError{StatusCode: http.StatusConflict, ErrorCode: "RoleDefinitionWithSameNameExists"}, false},
+		{"bad request", &azcore.ResponseError{StatusCode: http.StatusBadRequest}, false},
+		{"other error", errors.New("connection reset"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.retryable, isRetryableError(tt.err))
+		})
+	}
+}
+
+func TestRetryOnConflictStopsAfterMaxRetries(t *testing.T) {
+	manager := &SPRoleAssignmentManager{}
+	calls := 0
+	err := manager.retryOnConflict(t.Context(), "Testing", func() error {
+		calls++
+		return &azcore.ResponseError{StatusCode: http.StatusConflict}
+	})
+	assert.Error(t, err)
+	assert.Equal(t, maxRetries+1, calls)
+}
+
+func TestListRoleAssignments(t *testing.T) {
+	var filter string
+	manager := newTestSPRoleAssignmentManager(t, func(w http.ResponseWriter, r *http.Request) {
+		filter = r.URL.Query().Get("$filter")
+		fmt.Fprint(w, `{"value":[{"id":"/subscriptions/sub/providers/Microsoft.Authorization/roleAssignments/AAAAAAAA-AAAA-AAAA-AAAA-AAAAAAAAAAAA","name":"AAAAAAAA-AAAA-AAAA-AAAA-AAAAAAAAAAAA",
+"properties":{"scope":"/subscriptions/sub/resourceGroups/shared","roleDefinitionId":"/subscriptions/sub/providers/Microsoft.Authorization/roleDefinitions/reader","principalId":"sp","principalType":"ServicePrincipal",
+"description":"State access","condition":"@Resource[Microsoft.Storage/storageAccounts/blobServices/containers:name] StringEquals 'state'","conditionVersion":"2.0"}}]}`)
+	})
+
+	assignments, err := manager.ListRoleAssignments(t.Context(), "sub", "sp")
+	require.NoError(t, err)
+	assert.Equal(t, "assignedTo('sp')", filter)
+	assert.Equal(t, []domain.RoleAssignment{{
+		Name:             "AAAAAAAA-AAAA-AAAA-AAAA-AAAAAAAAAAAA",
+		Scope:            "/subscriptions/sub/resourceGroups/shared",
+		RoleDefinitionID: "/subscriptions/sub/providers/Microsoft.Authorization/roleDefinitions/reader",
+		PrincipalID:      "sp",
+		PrincipalType:    "ServicePrincipal",
+		Description:      "State access",
+		Condition:        "@Resource[Microsoft.Storage/storageAccounts/blobServices/containers:name] StringEquals 'state'",
+		ConditionVersion: "2.0",
+	}}, assignments)
+}
+
+func TestRestoreRoleAssignment(t *testing.T) {
+	var path string
+	var body struct {
+		Properties map[string]string `json:"properties"`
+	}
+	manager := newTestSPRoleAssignmentManager(t, func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{}`)
+	})
+
+	err := manager.RestoreRoleAssignment(t.Context(), domain.RoleAssignment{
+		Name:             "AAAAAAAA-AAAA-AAAA-AAAA-AAAAAAAAAAAA",
+		Scope:            "/subscriptions/sub/resourceGroups/shared",
+		RoleDefinitionID: "/subscriptions/sub/providers/Microsoft.Authorization/roleDefinitions/reader",
+		PrincipalID:      "sp",
+		PrincipalType:    "ServicePrincipal",
+		Condition:        "@Resource[Microsoft.Storage/storageAccounts/blobServices/containers:name] StringEquals 'state'",
+		ConditionVersion: "2.0",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "/subscriptions/sub/resourceGroups/shared/providers/Microsoft.Authorization/roleAssignments/AAAAAAAA-AAAA-AAAA-AAAA-AAAAAAAAAAAA", path)
+	assert.Equal(t, map[string]string{
+		"roleDefinitionId": "/subscriptions/sub/providers/Microsoft.Authorization/roleDefinitions/reader",
+		"principalId":      "sp",
+		"principalType":    "ServicePrincipal",
+		"condition":        "@Resource[Microsoft.Storage/storageAccounts/blobServices/containers:name] StringEquals 'state'",
+		"conditionVersion": "2.0",
+	}, body.Properties)
+}
+
+func TestRestoreExistingRoleAssignment(t *testing.T) {
+	manager := newTestSPRoleAssignmentManager(t, func(w http.ResponseWriter, r *http.Request) {
+		writeTestError(w, http.StatusConflict, "RoleAssignmentExists", "The role assignment already exists.")
+	})
+
+	err := manager.RestoreRoleAssignment(t.Context(), domain.RoleAssignment{Name: "a", Scope: "/subscriptions/sub", RoleDefinitionID: "reader", PrincipalID: "sp"})
+	assert.NoError(t, err)
+}
+
+func TestListAllRoleAssignments(t *testing.T) {
+	filter := "unset"
+	manager := newTestSPRoleAssignmentManager(t, func(w http.ResponseWriter, r *http.Request) {
+		filter = r.URL.Query().Get("$filter")
+		fmt.Fprint(w, `{"value":[]}`)
+	})
+
+	_, err := manager.ListRoleAssignments(t.Context(), "sub", "")
+	require.NoError(t, err)
+	assert.Empty(t, filter)
+}
+
+func TestListCustomRoles(t *testing.T) {
+	var filter string
+	manager := newTestSPRoleAssignmentManager(t, func(w http.ResponseWriter, r *http.Request) {
+		filter = r.URL.Query().Get("$filter")
+		fmt.Fprint(w, `{"value":[{"id":"/subscriptions/sub/providers/Microsoft.Authorization/roleDefinitions/11111111-1111-1111-1111-111111111111","name":"11111111-1111-1111-1111-111111111111",
+"properties":{"roleName":"tmp-rol-aB3dE5g","description":"tmp-rol-aB3dE5g","roleType":"CustomRole","createdOn":"2024-01-01T12:00:00Z"}}]}`)
+	})
+
+	roles, err := manager.ListCustomRoles(t.Context(), "sub")
+	require.NoError(t, err)
+	assert.Equal(t, "type eq 'CustomRole'", filter)
+	assert.Equal(t, []domain.CustomRole{{
+		Role: domain.Role{
+			RoleDefinitionID:          "11111111-1111-1111-1111-111111111111",
+			RoleDefinitionName:        "tmp-rol-aB3dE5g",
+			RoleDefinitionDescription: "tmp-rol-aB3dE5g",
+			RoleDefinitionResourceID:  "/subscriptions/sub/providers/Microsoft.Authorization/roleDefinitions/11111111-1111-1111-1111-111111111111",
+		},
+		CreatedOn: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+	}}, roles)
+}
+
+func TestDeleteRoleAssignment(t *testing.T) {
+	var method, path string
+	manager := newTestSPRoleAssignmentManager(t, func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.Path
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	err := manager.DeleteRoleAssignment(t.Context(), domain.RoleAssignment{Name: "AAAAAAAA-AAAA-AAAA-AAAA-AAAAAAAAAAAA", Scope: "/subscriptions/sub/resourceGroups/shared"})
+	require.NoError(t, err)
+	assert.Equal(t, http.MethodDelete, method)
+	assert.Equal(t, "/subscriptions/sub/resourceGroups/shared/providers/Microsoft.Authorization/roleAssignments/AAAAAAAA-AAAA-AAAA-AAAA-AAAAAAAAAAAA", path)
+}
@@ -0,0 +1,81 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package mpf
+
+import (
+	"fmt"
+
+	"github.com/Azure/mpf/pkg/domain"
+	"github.com/Azure/mpf/pkg/infrastructure/ARMTemplateShared"
+)
+
+// ErrInvalidTemplate is wrapped by the errors of runs whose ARM template or parameters file is invalid
+var ErrInvalidTemplate = ARMTemplateShared.ErrInvalidTemplate
+
+// RunLockedError is wrapped by the error of a run which found the service principal locked by another run.
+// The run changed nothing.
+type RunLockedError = domain.RunLockedError
+
+// OptionsError reports missing or invalid options. The run is not started.
+type OptionsError struct {
+	// Option is the name of the invalid option or field
+	Option string
+	// Reason describes why the option is invalid
+	Reason string
+}
+
+func (e *OptionsError) Error() string {
+	return fmt.Sprintf("invalid option %s: %s", e.Option, e.Reason)
+}
+
+// SetupError reports a failure to create a default component of the run, such as the Azure API clients.
+// The run is not started.
+type SetupError struct {
+	// Component is the component which could not be created
+	Component string
+	Err       error
+}
+
+func (e *SetupError) Error() string {
+	return fmt.Sprintf("unable to create %s: %v", e.Component, e.Err)
+}
+
+func (e *SetupError) Unwrap() error {
+	return e.Err
+}
+
+// RunError reports a run which failed after it started. The resources created by the run have been cleaned up,
+// and the result returned with the error holds the permissions found before the failure.
+type RunError struct {
+	// RunID identifies the failed run, as tagged on the resources it created
+	RunID string
+	Err   error
+}
+
+func (e *RunError) Error() string {
+	return fmt.Sprintf("run %s failed: %v", e.RunID, e.Err)
+}
+
+func (e *RunError) Unwrap() error {
+	return e.Err
+}
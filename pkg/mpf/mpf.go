@@ -0,0 +1,306 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+// Package mpf finds the minimum permissions required to deploy an ARM template or Terraform module,
+// for programs embedding azmpf. A run creates a custom role and assigns it to the service principal,
+// deploys as the service principal, and adds the permissions reported missing by the authorization errors
+// to the role until the deployment succeeds. The role, its assignment and the resource group created
+// for the run are cleaned up when the run ends.
+//
+// The Azure API clients use the Azure CLI or default Azure credentials, and the endpoints of Options.Cloud.
+package mpf
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/Azure/mpf/pkg/domain"
+	"github.com/Azure/mpf/pkg/infrastructure/ARMTemplateShared"
+	"github.com/Azure/mpf/pkg/infrastructure/authorizationCheckers/ARMTemplateDeployment"
+	"github.com/Azure/mpf/pkg/infrastructure/authorizationCheckers/terraform"
+	"github.com/Azure/mpf/pkg/infrastructure/azureAPI"
+	"github.com/Azure/mpf/pkg/infrastructure/mpfSharedUtils"
+	resourceGroupManager "github.com/Azure/mpf/pkg/infrastructure/resourceGroupManager"
+	sproleassignmentmanager "github.com/Azure/mpf/pkg/infrastructure/spRoleAssignmentManager"
+	"github.com/Azure/mpf/pkg/usecase"
+	"github.com/google/uuid"
+)
+
+// Result holds the permissions found by a run
+type Result struct {
+	domain.MPFResult
+	// Metadata describes the run, such as its run ID, start and end time
+	Metadata domain.MPFRunMetadata
+}
+
+// Run finds the minimum permissions required by the deployment configured by WithARMTemplate, WithTerraform or WithChecker.
+// Invalid options are reported with an *OptionsError, and a failure to create the default components of the run
+// with a *SetupError, before anything is changed in Azure. A run which fails after it started is reported with a *RunError,
+// and the permissions found before the failure are returned with it.
+func Run(ctx context.Context, options Options, opts ...Option) (Result, error) {
+	s := &settings{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(s)
+		}
+	}
+	if err := validate(options, s); err != nil {
+		return Result{}, err
+	}
+
+	cloud, err := domain.GetCloud(options.Cloud.Name, options.Cloud)
+	if err != nil {
+		return Result{}, &OptionsError{Option: "Cloud", Reason: err.Error()}
+	}
+
+	metadata := newRunMetadata(s)
+	modeSettings := getModeSettings(s)
+	mpfConfig := newMPFConfig(options, s, metadata, modeSettings.AutoCreateResourceGroup)
+	mpfConfig.Cloud = cloud
+
+	rgManager, roleAssignmentManager, checker, err := getComponents(mpfConfig, s, metadata)
+	if err != nil {
+		return Result{}, err
+	}
+
+	mpfService := usecase.NewMPFServiceForMode(ctx, rgManager, roleAssignmentManager, checker, mpfConfig, modeSettings)
+	if s.propagationWaiter != nil {
+		mpfService.SetPropagationWaiter(s.propagationWaiter)
+	}
+	if s.operationsCatalog != nil {
+		mpfService.SetOperationsCatalog(s.operationsCatalog)
+	}
+	if s.journal != nil {
+		mpfService.EnableJournal(s.journal)
+	}
+	if s.runLocker != nil {
+		holder := s.runLockHolder
+		if holder == "" {
+			holder = fmt.Sprintf("azmpf run %s", metadata.RunID)
+		}
+		mpfService.EnableRunLock(s.runLocker, holder, s.runLockTTL)
+	}
+	if s.keepExistingRoleAssignments {
+		mpfService.KeepExistingRoleAssignments()
+	} else if s.snapshotter != nil {
+		mpfService.EnableRoleAssignmentBackup(s.snapshotter, s.snapshotStore)
+	}
+
+	mpfResult, err := mpfService.GetMinimumPermissionsRequired()
+	metadata.EndTime = time.Now().UTC()
+	result := Result{MPFResult: mpfResult, Metadata: metadata}
+	if err != nil {
+		return result, &RunError{RunID: metadata.RunID, Err: err}
+	}
+	return result, nil
+}
+
+// validate checks that the options and settings are complete, before anything is created
+func validate(options Options, s *settings) error {
+	required := map[string]string{
+		"SubscriptionID": options.SubscriptionID,
+		"TenantID":       options.TenantID,
+		"SPClientID":     options.SPClientID,
+		"SPObjectID":     options.SPObjectID,
+	}
+	// custom checkers may deploy without the secret of the service principal
+	if s.kind != customDeployment {
+		required["SPClientSecret"] = options.SPClientSecret
+	}
+	for _, name := range slices.Sorted(maps.Keys(required)) {
+		if required[name] == "" {
+			return &OptionsError{Option: name, Reason: "is required"}
+		}
+	}
+
+	switch s.kind {
+	case "":
+		return &OptionsError{Option: "WithARMTemplate, WithTerraform or WithChecker", Reason: "no deployment to analyse"}
+	case armDeployment:
+		if s.templateFilePath == "" || s.parametersFilePath == "" {
+			return &OptionsError{Option: "WithARMTemplate", Reason: "the template and parameters file paths are required"}
+		}
+		if s.createResourceGroup != nil && !*s.createResourceGroup {
+			return &OptionsError{Option: "WithResourceGroup", Reason: "ARM template deployments need the resource group created for the run"}
+		}
+	case terraformDeployment:
+		if s.terraform.WorkingDir == "" || s.terraform.ExecPath == "" {
+			return &OptionsError{Option: "WithTerraform", Reason: "the working directory and Terraform executable path are required"}
+		}
+	case customDeployment:
+		if s.checker == nil {
+			return &OptionsError{Option: "WithChecker", Reason: "the checker is nil"}
+		}
+	}
+
+	if s.runLocker != nil && s.runLockTTL <= 0 {
+		return &OptionsError{Option: "WithRunLock", Reason: fmt.Sprintf("invalid TTL %s, expected a positive duration", s.runLockTTL)}
+	}
+	if s.snapshotter != nil && s.snapshotStore == nil {
+		return &OptionsError{Option: "WithRoleAssignmentBackup", Reason: "the snapshot store is nil"}
+	}
+	return nil
+}
+
+func newRunMetadata(s *settings) domain.MPFRunMetadata {
+	metadata := domain.MPFRunMetadata{
+		RunID:          uuid.NewString(),
+		User:           s.user,
+		Version:        s.version,
+		DeploymentType: string(s.kind),
+		StartTime:      time.Now().UTC(),
+	}
+	switch s.kind {
+	case armDeployment:
+		metadata.TemplatePath = s.templateFilePath
+		metadata.ParametersPath = s.parametersFilePath
+	case terraformDeployment:
+		metadata.TemplatePath = s.terraform.WorkingDir
+		metadata.ParametersPath = s.terraform.VarFilePath
+		metadata.TargetModule = s.terraform.TargetModule
+	}
+	return metadata
+}
+
+// newMPFConfig returns the configuration of the run with a new custom role, and the resource group of the run if it creates one
+func newMPFConfig(options Options, s *settings, metadata domain.MPFRunMetadata, createResourceGroup bool) domain.MPFConfig {
+	roleDefinitionID := uuid.NewString()
+	mpfConfig := domain.MPFConfig{
+		SubscriptionID: options.SubscriptionID,
+		TenantID:       options.TenantID,
+		SP: domain.ServicePrincipal{
+			SPClientID:     options.SPClientID,
+			SPObjectID:     options.SPObjectID,
+			SPClientSecret: options.SPClientSecret,
+		},
+		Role: domain.Role{
+			RoleDefinitionID:          roleDefinitionID,
+			RoleDefinitionName:        fmt.Sprintf("%s-%s", domain.MPFRoleNamePrefix, mpfSharedUtils.GenerateRandomString(7)),
+			RoleDefinitionResourceID:  fmt.Sprintf("/subscriptions/%s/providers/Microsoft.Authorization/roleDefinitions/%s", options.SubscriptionID, roleDefinitionID),
+			RoleDefinitionDescription: metadata.RoleDescription(),
+		},
+	}
+
+	if createResourceGroup {
+		prefix := s.resourceGroupPrefix
+		if prefix == "" {
+			prefix = defaultResourceGroupNamePrefix
+		}
+		location := s.location
+		if location == "" {
+			location = defaultLocation
+		}
+		name := fmt.Sprintf("%s-%s", prefix, mpfSharedUtils.GenerateRandomString(7))
+		mpfConfig.ResourceGroup = domain.ResourceGroup{
+			ResourceGroupName:       name,
+			ResourceGroupResourceID: fmt.Sprintf("/subscriptions/%s/resourceGroups/%s", options.SubscriptionID, name),
+			Location:                location,
+		}
+	}
+	return mpfConfig
+}
+
+// getComponents returns the components set with options, or creates the default ones with the Azure API clients of the cloud of the run
+func getComponents(mpfConfig domain.MPFConfig, s *settings, metadata domain.MPFRunMetadata) (usecase.ResourceGroupManager, usecase.ServicePrincipalRolemAssignmentManager, usecase.DeploymentAuthorizationCheckerCleaner, error) {
+	var azAPIClients *azureAPI.AzureAPIClients
+	if s.rgManager == nil || s.roleAssignmentManager == nil || s.kind == armDeployment {
+		var err error
+		azAPIClients, err = azureAPI.NewAzureAPIClientsWithOptions(mpfConfig.SubscriptionID, getAzureAPIClientsOptions(mpfConfig.Cloud, s))
+		if err != nil {
+			return nil, nil, nil, &SetupError{Component: "Azure API clients", Err: err}
+		}
+	}
+
+	rgManager := s.rgManager
+	if rgManager == nil {
+		defaultRGManager := resourceGroupManager.NewResourceGroupManagerWithClients(azAPIClients)
+		defaultRGManager.SetTags(metadata.Tags())
+		rgManager = defaultRGManager
+	}
+
+	roleAssignmentManager := s.roleAssignmentManager
+	if roleAssignmentManager == nil {
+		roleAssignmentManager = sproleassignmentmanager.NewSPRoleAssignmentManagerWithClients(azAPIClients)
+	}
+
+	var checker usecase.DeploymentAuthorizationCheckerCleaner
+	switch s.kind {
+	case armDeployment:
+		prefix := s.deploymentNamePrefix
+		if prefix == "" {
+			prefix = defaultDeploymentNamePrefix
+		}
+		checker = ARMTemplateDeployment.NewARMTemplateDeploymentAuthorizationCheckerWithClients(azAPIClients, ARMTemplateShared.ArmTemplateAdditionalConfig{
+			TemplateFilePath:   s.templateFilePath,
+			ParametersFilePath: s.parametersFilePath,
+			DeploymentName:     fmt.Sprintf("%s-%s", prefix, mpfSharedUtils.GenerateRandomString(7)),
+			Tags:               metadata.Tags(),
+		})
+	case terraformDeployment:
+		checker = terraform.NewTerraformAuthorizationChecker(s.terraform.WorkingDir, s.terraform.ExecPath, s.terraform.VarFilePath, s.terraform.ImportExistingResources, s.terraform.TargetModule)
+	default:
+		checker = s.checker
+	}
+	return rgManager, roleAssignmentManager, checker, nil
+}
+
+// getAzureAPIClientsOptions returns the options of the Azure API clients set with WithAzureAPIClientsOptions,
+// with the endpoints of the cloud where they are not set
+func getAzureAPIClientsOptions(cloud domain.Cloud, s *settings) azureAPI.AzureAPIClientsOptions {
+	cloudOptions := azureAPI.GetCloudAzureAPIClientsOptions(cloud)
+	if s.azureAPIClientsOptions == nil {
+		return cloudOptions
+	}
+
+	options := *s.azureAPIClientsOptions
+	if options.Endpoint == "" {
+		options.Endpoint = cloudOptions.Endpoint
+		if options.Audience == "" {
+			options.Audience = cloudOptions.Audience
+		}
+	}
+	if options.ActiveDirectoryAuthorityHost == "" {
+		options.ActiveDirectoryAuthorityHost = cloudOptions.ActiveDirectoryAuthorityHost
+	}
+	return options
+}
+
+// getModeSettings returns the settings the arm and terraform commands use for the deployment,
+// with the initial permissions and the settings changed by options
+func getModeSettings(s *settings) usecase.ModeSettings {
+	var modeSettings usecase.ModeSettings
+	switch s.kind {
+	case armDeployment:
+		modeSettings = usecase.ARMModeSettings()
+	case terraformDeployment:
+		modeSettings = usecase.TerraformModeSettings()
+	}
+	modeSettings.InitialPermissions = append(modeSettings.InitialPermissions, s.initialPermissions...)
+	modeSettings.PermissionsToAddToResult = append(modeSettings.PermissionsToAddToResult, s.initialPermissions...)
+	modeSettings.AutoAddReadPermissionForEachWrite = getBoolOrDefault(s.autoReadForWrite, modeSettings.AutoAddReadPermissionForEachWrite)
+	modeSettings.AutoAddDeletePermissionForEachWrite = getBoolOrDefault(s.autoDeleteForWrite, modeSettings.AutoAddDeletePermissionForEachWrite)
+	modeSettings.AutoCreateResourceGroup = getBoolOrDefault(s.createResourceGroup, modeSettings.AutoCreateResourceGroup)
+	return modeSettings
+}
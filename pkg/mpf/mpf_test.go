@@ -0,0 +1,263 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package mpf
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Azure/mpf/pkg/domain"
+	"github.com/Azure/mpf/pkg/infrastructure/azureAPI"
+	fakearm "github.com/Azure/mpf/pkg/infrastructure/fakeARM"
+	propagationwaiter "github.com/Azure/mpf/pkg/infrastructure/propagationWaiter"
+	runlock "github.com/Azure/mpf/pkg/infrastructure/runLock"
+	"github.com/Azure/mpf/pkg/usecase"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testTemplate = `{
+	"$schema": "https://schema.management.azure.com/schemas/2019-04-01/deploymentTemplate.json#",
+	"contentVersion": "1.0.0.0",
+	"resources": [
+		{
+			"type": "Microsoft.Storage/storageAccounts",
+			"apiVersion": "2023-01-01",
+			"name": "mpfsa",
+			"location": "eastus2"
+		}
+	]
+}`
+
+const testParameters = `{
+	"$schema": "https://schema.management.azure.com/schemas/2019-04-01/deploymentParameters.json#",
+	"contentVersion": "1.0.0.0",
+	"parameters": {}
+}`
+
+var testOptions = Options{
+	SubscriptionID: "SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS",
+	TenantID:       "TTTTTTTT-TTTT-TTTT-TTTT-TTTTTTTTTTTT",
+	SPClientID:     "CCCCCCCC-CCCC-CCCC-CCCC-CCCCCCCCCCCC",
+	SPObjectID:     "OOOOOOOO-OOOO-OOOO-OOOO-OOOOOOOOOOOO",
+	SPClientSecret: "fake",
+}
+
+// newTestServer starts a fake Azure Resource Manager, and returns the option making the Azure API clients of a run use it
+func newTestServer(t *testing.T) (*fakearm.Server, Option) {
+	server := fakearm.NewServer(fakearm.Config{
+		SPClientID: testOptions.SPClientID,
+		SPObjectID: testOptions.SPObjectID,
+		RequiredActions: map[string][]string{
+			"Microsoft.Storage/storageAccounts": {"Microsoft.Storage/storageAccounts/write"},
+		},
+	})
+	t.Cleanup(server.Close)
+	return server, WithAzureAPIClientsOptions(server.AzureAPIClientsOptions())
+}
+
+// writeTestTemplate writes the test ARM template and parameters files, and returns their paths
+func writeTestTemplate(t *testing.T) (string, string) {
+	dir := t.TempDir()
+	templateFilePath := filepath.Join(dir, "storage.json")
+	parametersFilePath := filepath.Join(dir, "storage.parameters.json")
+	require.NoError(t, os.WriteFile(templateFilePath, []byte(testTemplate), 0600))
+	require.NoError(t, os.WriteFile(parametersFilePath, []byte(testParameters), 0600))
+	return templateFilePath, parametersFilePath
+}
+
+var noPropagationWait = WithPropagationWaiter(propagationwaiter.NewFixedPropagationWaiter(0, 0))
+
+type failingChecker struct {
+	err error
+}
+
+func (c failingChecker) GetDeploymentAuthorizationErrors(ctx context.Context, mpfConfig domain.MPFConfig) (string, error) {
+	return "", c.err
+}
+
+func (c failingChecker) CleanDeployment(ctx context.Context, mpfConfig domain.MPFConfig) error {
+	return nil
+}
+
+func TestRunValidatesOptions(t *testing.T) {
+	missingTenant := testOptions
+	missingTenant.TenantID = ""
+	missingSecret := testOptions
+	missingSecret.SPClientSecret = ""
+	customCloud := testOptions
+	customCloud.Cloud = domain.Cloud{Name: domain.CustomCloudName}
+
+	tests := []struct {
+		name       string
+		options    Options
+		opts       []Option
+		wantOption string
+	}{
+		{"missing field", missingTenant, []Option{WithARMTemplate("template.json", "parameters.json")}, "TenantID"},
+		{"missing secret", missingSecret, []Option{WithARMTemplate("template.json", "parameters.json")}, "SPClientSecret"},
+		{"no deployment", testOptions, nil, "WithARMTemplate, WithTerraform or WithChecker"},
+		{"missing parameters file", testOptions, []Option{WithARMTemplate("template.json", "")}, "WithARMTemplate"},
+		{"ARM template without resource group", testOptions, []Option{WithARMTemplate("template.json", "parameters.json"), WithResourceGroup("rg", "eastus2", false)}, "WithResourceGroup"},
+		{"missing Terraform executable", testOptions, []Option{WithTerraform(TerraformOptions{WorkingDir: "."})}, "WithTerraform"},
+		{"nil checker", testOptions, []Option{WithChecker(nil)}, "WithChecker"},
+		{"custom cloud without endpoints", customCloud, []Option{WithChecker(failingChecker{})}, "Cloud"},
+		{"run lock without TTL", testOptions, []Option{WithChecker(failingChecker{}), WithRunLock(runlock.NewFileRunLocker(t.TempDir()), "", 0)}, "WithRunLock"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Run(t.Context(), tt.options, tt.opts...)
+			var optionsError *OptionsError
+			require.ErrorAs(t, err, &optionsError)
+			assert.Equal(t, tt.wantOption, optionsError.Option)
+		})
+	}
+}
+
+func TestRunARMTemplate(t *testing.T) {
+	server, withServer := newTestServer(t)
+	templateFilePath, parametersFilePath := writeTestTemplate(t)
+
+	result, err := Run(t.Context(), testOptions, withServer, WithARMTemplate(templateFilePath, parametersFilePath), WithRunMetadata("v1.2.3", "pipeline"), noPropagationWait)
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{
+		"Microsoft.Resources/deployments/read",
+		"Microsoft.Resources/deployments/write",
+		"Microsoft.Storage/storageAccounts/read",
+		"Microsoft.Storage/storageAccounts/write",
+	}, result.RequiredPermissions[testOptions.SubscriptionID])
+	assert.NotEmpty(t, result.Metadata.RunID)
+	assert.Equal(t, "arm", result.Metadata.DeploymentType)
+	assert.Equal(t, "v1.2.3", result.Metadata.Version)
+	assert.False(t, result.Metadata.EndTime.Before(result.Metadata.StartTime))
+
+	// the role and its assignment are cleaned up
+	assert.Equal(t, 0, server.RoleDefinitionCount())
+	assert.Equal(t, 0, server.RoleAssignmentCount())
+}
+
+func TestRunAgainstCloud(t *testing.T) {
+	server, _ := newTestServer(t)
+	templateFilePath, parametersFilePath := writeTestTemplate(t)
+
+	// the Azure API clients use the Resource Manager endpoint of the cloud, as no endpoint is set in their options
+	serverOptions := server.AzureAPIClientsOptions()
+	options := testOptions
+	options.Cloud = domain.Cloud{
+		Name:                         domain.CustomCloudName,
+		ResourceManagerEndpoint:      serverOptions.Endpoint,
+		ActiveDirectoryAuthorityHost: "https://login.example.com/",
+	}
+	serverOptions.Endpoint = ""
+
+	result, err := Run(t.Context(), options, WithAzureAPIClientsOptions(serverOptions), WithARMTemplate(templateFilePath, parametersFilePath), noPropagationWait)
+	require.NoError(t, err)
+	assert.Contains(t, result.RequiredPermissions[testOptions.SubscriptionID], "Microsoft.Storage/storageAccounts/write")
+}
+
+func TestGetAzureAPIClientsOptions(t *testing.T) {
+	// without options, the clients use the endpoints of the cloud
+	options := getAzureAPIClientsOptions(domain.AzureUSGovernmentCloud, &settings{})
+	assert.Equal(t, domain.AzureUSGovernmentCloud.ResourceManagerEndpoint, options.Endpoint)
+	assert.Equal(t, domain.AzureUSGovernmentCloud.ResourceManagerAudience, options.Audience)
+	assert.Equal(t, domain.AzureUSGovernmentCloud.ActiveDirectoryAuthorityHost, options.ActiveDirectoryAuthorityHost)
+
+	// endpoints set in the options take precedence over the cloud
+	s := &settings{}
+	WithAzureAPIClientsOptions(azureAPI.AzureAPIClientsOptions{Endpoint: "https://localhost:8443"})(s)
+	options = getAzureAPIClientsOptions(domain.AzureUSGovernmentCloud, s)
+	assert.Equal(t, "https://localhost:8443", options.Endpoint)
+	assert.Empty(t, options.Audience)
+	assert.Equal(t, domain.AzureUSGovernmentCloud.ActiveDirectoryAuthorityHost, options.ActiveDirectoryAuthorityHost)
+
+	assert.Equal(t, azureAPI.AzureAPIClientsOptions{}, getAzureAPIClientsOptions(domain.Cloud{}, &settings{}))
+}
+
+func TestRunWithoutAutoReadForWrite(t *testing.T) {
+	_, withServer := newTestServer(t)
+	templateFilePath, parametersFilePath := writeTestTemplate(t)
+
+	result, err := Run(t.Context(), testOptions, withServer, WithAutoReadForWrite(false), WithARMTemplate(templateFilePath, parametersFilePath), WithInitialPermissions("Microsoft.Storage/storageAccounts/write"), noPropagationWait)
+	require.NoError(t, err)
+
+	// the initial permissions are part of the result, and no deployment was needed to find them
+	assert.ElementsMatch(t, []string{
+		"Microsoft.Resources/deployments/read",
+		"Microsoft.Resources/deployments/write",
+		"Microsoft.Storage/storageAccounts/write",
+	}, result.RequiredPermissions[testOptions.SubscriptionID])
+	assert.Equal(t, 0, result.IterationCount)
+}
+
+func TestGetModeSettings(t *testing.T) {
+	// the library uses the settings of the commands, changed by the options
+	modeSettings := getModeSettings(&settings{kind: terraformDeployment, initialPermissions: []string{"Microsoft.Storage/storageAccounts/write"}})
+	terraformSettings := usecase.TerraformModeSettings()
+	assert.Equal(t, append(terraformSettings.InitialPermissions, "Microsoft.Storage/storageAccounts/write"), modeSettings.InitialPermissions)
+	assert.Equal(t, terraformSettings.AutoAddDeletePermissionForEachWrite, modeSettings.AutoAddDeletePermissionForEachWrite)
+	assert.False(t, modeSettings.AutoCreateResourceGroup)
+
+	modeSettings = getModeSettings(&settings{kind: armDeployment, autoReadForWrite: new(false)})
+	assert.Equal(t, usecase.ARMModeSettings().InitialPermissions, modeSettings.InitialPermissions)
+	assert.False(t, modeSettings.AutoAddReadPermissionForEachWrite)
+	assert.True(t, modeSettings.AutoCreateResourceGroup)
+}
+
+func TestRunReportsCheckerErrors(t *testing.T) {
+	server, withServer := newTestServer(t)
+	checkerErr := errors.New("deployment failed")
+
+	result, err := Run(t.Context(), testOptions, withServer, WithChecker(failingChecker{err: checkerErr}), noPropagationWait)
+
+	var runError *RunError
+	require.ErrorAs(t, err, &runError)
+	assert.ErrorIs(t, err, checkerErr)
+	assert.Equal(t, result.Metadata.RunID, runError.RunID)
+	assert.Equal(t, 0, server.RoleDefinitionCount())
+}
+
+func TestRunReportsLockedServicePrincipal(t *testing.T) {
+	server, withServer := newTestServer(t)
+	locker := runlock.NewFileRunLocker(t.TempDir())
+	now := time.Now().UTC()
+	require.NoError(t, locker.AcquireRunLock(t.Context(), domain.RunLock{
+		SubscriptionID: testOptions.SubscriptionID,
+		SPObjectID:     testOptions.SPObjectID,
+		Holder:         "other run",
+		AcquiredAt:     now,
+		ExpiresAt:      now.Add(time.Hour),
+	}))
+
+	_, err := Run(t.Context(), testOptions, withServer, WithChecker(failingChecker{}), WithRunLock(locker, "", time.Hour), noPropagationWait)
+
+	var runLockedError *RunLockedError
+	require.ErrorAs(t, err, &runLockedError)
+	assert.Equal(t, "other run", runLockedError.Lock.Holder)
+	// the locked run changed nothing
+	assert.Equal(t, 0, server.RoleDefinitionCount())
+}
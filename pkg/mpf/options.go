@@ -0,0 +1,268 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package mpf
+
+import (
+	"time"
+
+	"github.com/Azure/mpf/pkg/domain"
+	"github.com/Azure/mpf/pkg/infrastructure/azureAPI"
+	"github.com/Azure/mpf/pkg/usecase"
+)
+
+// Options are the settings every run needs
+type Options struct {
+	// SubscriptionID is the subscription in which the custom role and resource group of the run are created
+	SubscriptionID string
+	// TenantID is the tenant of the service principal
+	TenantID string
+	// SPClientID, SPObjectID and SPClientSecret identify the service principal the deployments run as.
+	// Its role assignments are removed during the run, and restored afterwards if role assignment backup is enabled.
+	SPClientID     string
+	SPObjectID     string
+	SPClientSecret string
+	// Cloud is the Azure cloud the run is against, e.g. domain.AzureUSGovernmentCloud. Default is the Azure public cloud.
+	// The endpoints of a custom cloud are set with domain.GetCloud.
+	Cloud domain.Cloud
+}
+
+// Option configures a run
+type Option func(*settings)
+
+// TerraformOptions configure the Terraform deployments of a run
+type TerraformOptions struct {
+	// WorkingDir is the directory of the Terraform module
+	WorkingDir string
+	// ExecPath is the path of the Terraform executable
+	ExecPath string
+	// VarFilePath is the path of the Terraform variables file, if any
+	VarFilePath string
+	// ImportExistingResources imports resources which already exist to the state, instead of failing
+	ImportExistingResources bool
+	// TargetModule limits the deployment to a module, e.g. module.storage
+	TargetModule string
+}
+
+// deploymentKind is the kind of deployment analysed by the run
+type deploymentKind string
+
+const (
+	armDeployment       deploymentKind = "arm"
+	terraformDeployment deploymentKind = "terraform"
+	customDeployment    deploymentKind = "custom"
+)
+
+const (
+	defaultResourceGroupNamePrefix = "testdeployrg"
+	defaultDeploymentNamePrefix    = "testDeploy"
+	defaultLocation                = "eastus2"
+)
+
+type settings struct {
+	kind                 deploymentKind
+	templateFilePath     string
+	parametersFilePath   string
+	terraform            TerraformOptions
+	checker              usecase.DeploymentAuthorizationCheckerCleaner
+	createResourceGroup  *bool
+	resourceGroupPrefix  string
+	deploymentNamePrefix string
+	location             string
+
+	initialPermissions []string
+	// autoReadForWrite and autoDeleteForWrite are nil unless set, in which case the default of the deployment kind applies
+	autoReadForWrite   *bool
+	autoDeleteForWrite *bool
+
+	azureAPIClientsOptions      *azureAPI.AzureAPIClientsOptions
+	rgManager                   usecase.ResourceGroupManager
+	roleAssignmentManager       usecase.ServicePrincipalRolemAssignmentManager
+	propagationWaiter           usecase.PropagationWaiter
+	operationsCatalog           *domain.OperationsCatalog
+	journal                     usecase.IterationJournal
+	runLocker                   usecase.RunLocker
+	runLockHolder               string
+	runLockTTL                  time.Duration
+	snapshotter                 usecase.RoleAssignmentSnapshotter
+	snapshotStore               usecase.RoleAssignmentSnapshotStore
+	keepExistingRoleAssignments bool
+	version                     string
+	user                        string
+}
+
+// WithARMTemplate analyses the deployment of an ARM template to a resource group created for the run.
+// By default the read permission of every write permission found is added.
+func WithARMTemplate(templateFilePath string, parametersFilePath string) Option {
+	return func(s *settings) {
+		s.kind = armDeployment
+		s.templateFilePath = templateFilePath
+		s.parametersFilePath = parametersFilePath
+	}
+}
+
+// WithTerraform analyses the apply and destroy of a Terraform module.
+// By default the delete permission of every write permission found is added.
+func WithTerraform(terraform TerraformOptions) Option {
+	return func(s *settings) {
+		s.kind = terraformDeployment
+		s.terraform = terraform
+	}
+}
+
+// WithChecker analyses the deployments of checker, which returns the authorization errors of the deployment
+// as the service principal. No read or delete permissions are added by default.
+func WithChecker(checker usecase.DeploymentAuthorizationCheckerCleaner) Option {
+	return func(s *settings) {
+		s.kind = customDeployment
+		s.checker = checker
+	}
+}
+
+// WithResourceGroup sets the name prefix and location of the resource group created for the run.
+// Default is a resource group in eastus2 prefixed with testdeployrg, which is only created for ARM template runs
+// unless createResourceGroup is set.
+func WithResourceGroup(namePrefix string, location string, createResourceGroup bool) Option {
+	return func(s *settings) {
+		s.resourceGroupPrefix = namePrefix
+		s.location = location
+		s.createResourceGroup = &createResourceGroup
+	}
+}
+
+// WithDeploymentNamePrefix sets the prefix of the name of the ARM template deployment. Default is testDeploy.
+func WithDeploymentNamePrefix(prefix string) Option {
+	return func(s *settings) {
+		s.deploymentNamePrefix = prefix
+	}
+}
+
+// WithAutoReadForWrite adds the read permission of every write permission found, such as
+// Microsoft.Storage/storageAccounts/read for Microsoft.Storage/storageAccounts/write
+func WithAutoReadForWrite(enabled bool) Option {
+	return func(s *settings) {
+		s.autoReadForWrite = &enabled
+	}
+}
+
+// WithAutoDeleteForWrite adds the delete permission of every write permission found
+func WithAutoDeleteForWrite(enabled bool) Option {
+	return func(s *settings) {
+		s.autoDeleteForWrite = &enabled
+	}
+}
+
+// WithInitialPermissions adds permissions to the custom role before the first deployment.
+// They are part of the result. Data actions are added to the data actions of the role.
+func WithInitialPermissions(permissions ...string) Option {
+	return func(s *settings) {
+		s.initialPermissions = append(s.initialPermissions, permissions...)
+	}
+}
+
+// WithPropagationWaiter sets the strategy used to wait for RBAC changes to propagate.
+// Default is a fixed wait after every change.
+func WithPropagationWaiter(propagationWaiter usecase.PropagationWaiter) Option {
+	return func(s *settings) {
+		s.propagationWaiter = propagationWaiter
+	}
+}
+
+// WithOperationsCatalog validates and normalizes the permissions found with the catalog
+func WithOperationsCatalog(operationsCatalog *domain.OperationsCatalog) Option {
+	return func(s *settings) {
+		s.operationsCatalog = operationsCatalog
+	}
+}
+
+// WithJournal records an entry in the journal for every iteration
+func WithJournal(journal usecase.IterationJournal) Option {
+	return func(s *settings) {
+		s.journal = journal
+	}
+}
+
+// WithRunLock locks the service principal against concurrent runs for the duration of the run.
+// The lock expires after ttl, and is renewed while the run is in progress.
+func WithRunLock(runLocker usecase.RunLocker, holder string, ttl time.Duration) Option {
+	return func(s *settings) {
+		s.runLocker = runLocker
+		s.runLockHolder = holder
+		s.runLockTTL = ttl
+	}
+}
+
+// WithRoleAssignmentBackup saves the role assignments of the service principal to store before they are removed,
+// and restores them when the run ends
+func WithRoleAssignmentBackup(snapshotter usecase.RoleAssignmentSnapshotter, store usecase.RoleAssignmentSnapshotStore) Option {
+	return func(s *settings) {
+		s.snapshotter = snapshotter
+		s.snapshotStore = store
+	}
+}
+
+// WithKeepExistingRoleAssignments keeps the role assignments of the service principal during the run.
+// The permissions they grant are missing from the result.
+func WithKeepExistingRoleAssignments() Option {
+	return func(s *settings) {
+		s.keepExistingRoleAssignments = true
+	}
+}
+
+// WithAzureAPIClientsOptions sets the options of the Azure API clients, e.g. to use another credential or HTTP transport.
+// The endpoints which are not set are those of the cloud of the run.
+func WithAzureAPIClientsOptions(options azureAPI.AzureAPIClientsOptions) Option {
+	return func(s *settings) {
+		s.azureAPIClientsOptions = &options
+	}
+}
+
+// WithResourceGroupManager replaces the manager of the resource group created for the run
+func WithResourceGroupManager(rgManager usecase.ResourceGroupManager) Option {
+	return func(s *settings) {
+		s.rgManager = rgManager
+	}
+}
+
+// WithRoleAssignmentManager replaces the manager of the custom role and its assignment to the service principal
+func WithRoleAssignmentManager(roleAssignmentManager usecase.ServicePrincipalRolemAssignmentManager) Option {
+	return func(s *settings) {
+		s.roleAssignmentManager = roleAssignmentManager
+	}
+}
+
+// WithRunMetadata sets the version of the caller and the user on whose behalf the run is made,
+// which are tagged on the resources created by the run
+func WithRunMetadata(version string, user string) Option {
+	return func(s *settings) {
+		s.version = version
+		s.user = user
+	}
+}
+
+// getBoolOrDefault returns the value of an option which is set, or the default
+func getBoolOrDefault(value *bool, defaultValue bool) bool {
+	if value == nil {
+		return defaultValue
+	}
+	return *value
+}
@@ -0,0 +1,146 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package presentation
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Azure/mpf/pkg/domain"
+)
+
+type jsonBuiltInRoleComparison struct {
+	SingleRoles          []jsonBuiltInRoleMatch `json:"singleRoles"`
+	Cover                []jsonBuiltInRoleMatch `json:"cover"`
+	UncoveredActions     []string               `json:"uncoveredActions"`
+	UncoveredDataActions []string               `json:"uncoveredDataActions"`
+}
+
+type jsonBuiltInRoleMatch struct {
+	RoleName           string   `json:"roleName"`
+	RoleDefinitionID   string   `json:"roleDefinitionId"`
+	CoveredActions     []string `json:"coveredActions"`
+	CoveredDataActions []string `json:"coveredDataActions"`
+	ExcessActions      []string `json:"excessActions"`
+	ExcessDataActions  []string `json:"excessDataActions"`
+	ExcessScore        int      `json:"excessScore"`
+}
+
+// DisplayBuiltInRoleComparison writes the comparison of the required permissions with the built-in roles.
+// The output is text or json, and at most maxSingleRoles roles granting all permissions are listed.
+func DisplayBuiltInRoleComparison(w io.Writer, comparison domain.BuiltInRoleComparison, output string, maxSingleRoles int) error {
+	if maxSingleRoles > 0 && len(comparison.SingleRoles) > maxSingleRoles {
+		comparison.SingleRoles = comparison.SingleRoles[:maxSingleRoles]
+	}
+
+	switch output {
+	case "", TextOutput:
+		displayBuiltInRoleComparisonText(w, comparison)
+		return nil
+	case JSONOutput:
+		return displayBuiltInRoleComparisonJSON(w, comparison)
+	default:
+		return fmt.Errorf("invalid output format for the built-in role comparison: %s, valid values are %s and %s", output, TextOutput, JSONOutput)
+	}
+}
+
+func displayBuiltInRoleComparisonText(w io.Writer, comparison domain.BuiltInRoleComparison) {
+	fmt.Fprintln(w, "------------------------------------------------------------------------------------------------------------------------------------------")
+	fmt.Fprintln(w, "Built-in roles granting all required permissions:")
+	fmt.Fprintln(w, "------------------------------------------------------------------------------------------------------------------------------------------")
+	if len(comparison.SingleRoles) == 0 {
+		fmt.Fprintln(w, "None")
+	}
+	for _, match := range comparison.SingleRoles {
+		writeBuiltInRoleMatchText(w, match, false)
+	}
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, "------------------------------------------------------------------------------------------------------------------------------------------")
+	fmt.Fprintln(w, "Smallest combination of built-in roles:")
+	fmt.Fprintln(w, "------------------------------------------------------------------------------------------------------------------------------------------")
+	if len(comparison.Cover) == 0 {
+		fmt.Fprintln(w, "None")
+	}
+	for _, match := range comparison.Cover {
+		writeBuiltInRoleMatchText(w, match, true)
+	}
+	fmt.Fprintln(w)
+
+	if len(comparison.UncoveredActions) > 0 || len(comparison.UncoveredDataActions) > 0 {
+		fmt.Fprintln(w, "------------------------------------------------------------------------------------------------------------------------------------------")
+		fmt.Fprintln(w, "Permissions granted by no built-in role:")
+		fmt.Fprintln(w, "------------------------------------------------------------------------------------------------------------------------------------------")
+		for _, action := range comparison.UncoveredActions {
+			fmt.Fprintln(w, action)
+		}
+		for _, dataAction := range comparison.UncoveredDataActions {
+			fmt.Fprintf(w, "%s (data action)\n", dataAction)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+func writeBuiltInRoleMatchText(w io.Writer, match domain.BuiltInRoleMatch, showCovered bool) {
+	fmt.Fprintf(w, "%s (%s), excess score: %d\n", match.Role.Name, match.Role.ID, match.ExcessScore)
+	if showCovered {
+		fmt.Fprintf(w, "  Grants: %s\n", strings.Join(append(append([]string{}, match.CoveredActions...), match.CoveredDataActions...), ", "))
+	}
+	if len(match.ExcessActions) > 0 || len(match.ExcessDataActions) > 0 {
+		fmt.Fprintf(w, "  Also grants: %s\n", strings.Join(append(append([]string{}, match.ExcessActions...), match.ExcessDataActions...), ", "))
+	}
+}
+
+func displayBuiltInRoleComparisonJSON(w io.Writer, comparison domain.BuiltInRoleComparison) error {
+	output := jsonBuiltInRoleComparison{
+		SingleRoles:          getJSONBuiltInRoleMatches(comparison.SingleRoles),
+		Cover:                getJSONBuiltInRoleMatches(comparison.Cover),
+		UncoveredActions:     getSortedCopy(comparison.UncoveredActions),
+		UncoveredDataActions: getSortedCopy(comparison.UncoveredDataActions),
+	}
+
+	jsonBytes, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error converting output to JSON: %w", err)
+	}
+	_, err = w.Write(append(jsonBytes, '\n'))
+	return err
+}
+
+func getJSONBuiltInRoleMatches(matches []domain.BuiltInRoleMatch) []jsonBuiltInRoleMatch {
+	jsonMatches := make([]jsonBuiltInRoleMatch, 0, len(matches))
+	for _, match := range matches {
+		jsonMatches = append(jsonMatches, jsonBuiltInRoleMatch{
+			RoleName:           match.Role.Name,
+			RoleDefinitionID:   match.Role.ID,
+			CoveredActions:     getSortedCopy(match.CoveredActions),
+			CoveredDataActions: getSortedCopy(match.CoveredDataActions),
+			ExcessActions:      getSortedCopy(match.ExcessActions),
+			ExcessDataActions:  getSortedCopy(match.ExcessDataActions),
+			ExcessScore:        match.ExcessScore,
+		})
+	}
+	return jsonMatches
+}
@@ -0,0 +1,76 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package presentation
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+)
+
+// Permission types of the CSV output
+const (
+	csvActionType     = "Action"
+	csvDataActionType = "DataAction"
+)
+
+// displayCSV writes one row per permission with the scope it is required at.
+// The aggregated permissions are written at the subscription scope,
+// and with detailed output the permissions of every resource scope are written instead.
+func (d *displayConfig) displayCSV(w io.Writer) error {
+	csvWriter := csv.NewWriter(w)
+	if err := csvWriter.Write([]string{"Scope", "Type", "Permission"}); err != nil {
+		return err
+	}
+
+	writeRows := func(permissionType string, scopePermissions map[string][]string) error {
+		if !d.displayOptions.ShowDetailedOutput {
+			subscriptionScope := fmt.Sprintf("/subscriptions/%s", d.displayOptions.SubscriptionID)
+			for _, perm := range getSortedCopy(scopePermissions[d.displayOptions.SubscriptionID]) {
+				if err := csvWriter.Write([]string{subscriptionScope, permissionType, perm}); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		for _, scope := range getSortedScopes(scopePermissions, d.displayOptions.SubscriptionID) {
+			for _, perm := range getSortedCopy(scopePermissions[scope]) {
+				if err := csvWriter.Write([]string{scope, permissionType, perm}); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	if err := writeRows(csvActionType, d.result.RequiredPermissions); err != nil {
+		return err
+	}
+	if err := writeRows(csvDataActionType, d.result.RequiredDataActions); err != nil {
+		return err
+	}
+
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
@@ -33,8 +33,9 @@ import (
 func (d *displayConfig) displayText(w io.Writer) error {
 
 	sm := d.result.RequiredPermissions
-	if len(sm) == 0 {
-		fmt.Println("No permissions required")
+	dm := d.result.RequiredDataActions
+	if len(sm) == 0 && len(dm) == 0 {
+		fmt.Fprintln(w, "No permissions required")
 		return nil
 	}
 
@@ -47,22 +48,37 @@ func (d *displayConfig) displayText(w io.Writer) error {
 	sort.Strings(defaultPerms)
 
 	// print permissions for default scope
-	fmt.Println("------------------------------------------------------------------------------------------------------------------------------------------")
-	fmt.Println("Permissions Required:")
-	fmt.Println("------------------------------------------------------------------------------------------------------------------------------------------")
+	fmt.Fprintln(w, "------------------------------------------------------------------------------------------------------------------------------------------")
+	fmt.Fprintln(w, "Permissions Required:")
+	fmt.Fprintln(w, "------------------------------------------------------------------------------------------------------------------------------------------")
 	for _, perm := range defaultPerms {
-		fmt.Println(perm)
+		fmt.Fprintln(w, perm)
+	}
+	fmt.Fprintln(w, "------------------------------------------------------------------------------------------------------------------------------------------")
+	fmt.Fprintln(w)
+
+	defaultDataActions := dm[d.displayOptions.SubscriptionID]
+	sort.Strings(defaultDataActions)
+
+	// print data actions for default scope
+	if len(defaultDataActions) > 0 {
+		fmt.Fprintln(w, "------------------------------------------------------------------------------------------------------------------------------------------")
+		fmt.Fprintln(w, "Data Actions Required:")
+		fmt.Fprintln(w, "------------------------------------------------------------------------------------------------------------------------------------------")
+		for _, dataAction := range defaultDataActions {
+			fmt.Fprintln(w, dataAction)
+		}
+		fmt.Fprintln(w, "------------------------------------------------------------------------------------------------------------------------------------------")
+		fmt.Fprintln(w)
 	}
-	fmt.Println("------------------------------------------------------------------------------------------------------------------------------------------")
-	fmt.Println()
 
 	if !d.displayOptions.ShowDetailedOutput {
 		return nil
 	}
 
-	fmt.Println()
-	fmt.Println("Break down of permissions by different resource types:")
-	fmt.Println()
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Break down of permissions by different resource types:")
+	fmt.Fprintln(w)
 
 	// print permissions for other scopes
 	for scope, perms := range sm {
@@ -73,13 +89,30 @@ func (d *displayConfig) displayText(w io.Writer) error {
 		// perms = getUniqueSlice(perms)
 		sort.Strings(perms)
 
-		fmt.Printf("Permissions required for %s: \n", scope)
+		fmt.Fprintf(w, "Permissions required for %s: \n", scope)
 		for _, perm := range perms {
-			fmt.Printf("%s\n", perm)
+			fmt.Fprintf(w, "%s\n", perm)
+		}
+		fmt.Fprintln(w, "--------------")
+		fmt.Fprintln(w)
+		fmt.Fprintln(w)
+	}
+
+	// print data actions for other scopes
+	for scope, dataActions := range dm {
+		if scope == d.displayOptions.SubscriptionID {
+			continue
+		}
+
+		sort.Strings(dataActions)
+
+		fmt.Fprintf(w, "Data actions required for %s: \n", scope)
+		for _, dataAction := range dataActions {
+			fmt.Fprintf(w, "%s\n", dataAction)
 		}
-		fmt.Println("--------------")
-		fmt.Println()
-		fmt.Println()
+		fmt.Fprintln(w, "--------------")
+		fmt.Fprintln(w)
+		fmt.Fprintln(w)
 	}
 	return nil
 }
@@ -0,0 +1,161 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package presentation
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Azure/mpf/pkg/domain"
+)
+
+// Names of the built-in formatters
+const (
+	TextOutput                    = "text"
+	JSONOutput                    = "json"
+	JSONV1Output                  = "jsonV1"
+	YAMLOutput                    = "yaml"
+	CSVOutput                     = "csv"
+	MarkdownOutput                = "markdown"
+	MarkdownReportOutput          = "reportMarkdown"
+	HTMLReportOutput              = "reportHtml"
+	RoleDefinitionJSONOutput      = "roleDefinitionJson"
+	RoleDefinitionBicepOutput     = "roleDefinitionBicep"
+	RoleDefinitionARMOutput       = "roleDefinitionArm"
+	RoleDefinitionTerraformOutput = "roleDefinitionTerraform"
+)
+
+// Formatter renders the MPF result in an output format
+type Formatter interface {
+	Format(w io.Writer, result domain.MPFResult, options DisplayOptions) error
+}
+
+// FormatterFunc adapts a function to the Formatter interface
+type FormatterFunc func(w io.Writer, result domain.MPFResult, options DisplayOptions) error
+
+func (f FormatterFunc) Format(w io.Writer, result domain.MPFResult, options DisplayOptions) error {
+	return f(w, result, options)
+}
+
+var (
+	formattersMu sync.RWMutex
+	formatters   = map[string]Formatter{}
+)
+
+// RegisterFormatter registers the formatter of an output format, replacing any formatter registered with the same name
+func RegisterFormatter(name string, formatter Formatter) {
+	formattersMu.Lock()
+	defer formattersMu.Unlock()
+	formatters[name] = formatter
+}
+
+// GetFormatter returns the formatter registered with the name
+func GetFormatter(name string) (Formatter, error) {
+	formattersMu.RLock()
+	defer formattersMu.RUnlock()
+	formatter, ok := formatters[name]
+	if !ok {
+		return nil, fmt.Errorf("invalid output format %q, must be one of: %s", name, strings.Join(getFormatterNames(), ", "))
+	}
+	return formatter, nil
+}
+
+// FormatterNames returns the sorted names of the registered formatters
+func FormatterNames() []string {
+	formattersMu.RLock()
+	defer formattersMu.RUnlock()
+	return getFormatterNames()
+}
+
+func getFormatterNames() []string {
+	names := make([]string, 0, len(formatters))
+	for name := range formatters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// displayConfigFormatter returns a formatter calling a display method of displayConfig
+func displayConfigFormatter(display func(d *displayConfig, w io.Writer) error) Formatter {
+	return FormatterFunc(func(w io.Writer, result domain.MPFResult, options DisplayOptions) error {
+		return display(NewMPFResultDisplayer(result, options), w)
+	})
+}
+
+// roleDefinitionFormatter returns a formatter rendering the result as a role definition in the format
+func roleDefinitionFormatter(format string) Formatter {
+	return displayConfigFormatter(func(d *displayConfig, w io.Writer) error {
+		return d.displayRoleDefinition(w, format)
+	})
+}
+
+func init() {
+	RegisterFormatter(TextOutput, displayConfigFormatter((*displayConfig).displayText))
+	RegisterFormatter(JSONOutput, displayConfigFormatter((*displayConfig).displayJSON))
+	RegisterFormatter(JSONV1Output, displayConfigFormatter((*displayConfig).displayJSONV1))
+	RegisterFormatter(YAMLOutput, displayConfigFormatter((*displayConfig).displayYAML))
+	RegisterFormatter(CSVOutput, displayConfigFormatter((*displayConfig).displayCSV))
+	RegisterFormatter(MarkdownOutput, displayConfigFormatter((*displayConfig).displayMarkdown))
+	RegisterFormatter(MarkdownReportOutput, displayConfigFormatter((*displayConfig).displayMarkdownReport))
+	RegisterFormatter(HTMLReportOutput, displayConfigFormatter((*displayConfig).displayHTMLReport))
+	RegisterFormatter(RoleDefinitionJSONOutput, roleDefinitionFormatter(RoleDefinitionFormatJSON))
+	RegisterFormatter(RoleDefinitionBicepOutput, roleDefinitionFormatter(RoleDefinitionFormatBicep))
+	RegisterFormatter(RoleDefinitionARMOutput, roleDefinitionFormatter(RoleDefinitionFormatARM))
+	RegisterFormatter(RoleDefinitionTerraformOutput, roleDefinitionFormatter(RoleDefinitionFormatTerraform))
+}
+
+// getSortedScopes returns the sorted scopes of the per scope permissions, without the aggregated subscription key
+func getSortedScopes(scopePermissions map[string][]string, subscriptionID string) []string {
+	scopes := make([]string, 0, len(scopePermissions))
+	for scope := range scopePermissions {
+		if scope == subscriptionID {
+			continue
+		}
+		scopes = append(scopes, scope)
+	}
+	sort.Strings(scopes)
+	return scopes
+}
+
+// getSortedScopePermissions returns a copy of the per scope permissions with sorted values
+func getSortedScopePermissions(scopePermissions map[string][]string) map[string][]string {
+	if len(scopePermissions) == 0 {
+		return nil
+	}
+	sorted := make(map[string][]string, len(scopePermissions))
+	for scope, perms := range scopePermissions {
+		sorted[scope] = getSortedCopy(perms)
+	}
+	return sorted
+}
+
+func getSortedCopy(values []string) []string {
+	sorted := make([]string, len(values))
+	copy(sorted, values)
+	sort.Strings(sorted)
+	return sorted
+}
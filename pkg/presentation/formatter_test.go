@@ -0,0 +1,133 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package presentation
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/Azure/mpf/pkg/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v3"
+)
+
+func TestRegisterFormatter(t *testing.T) {
+	RegisterFormatter("count", FormatterFunc(func(w io.Writer, result domain.MPFResult, options DisplayOptions) error {
+		_, err := io.WriteString(w, "2")
+		return err
+	}))
+	defer func() {
+		formattersMu.Lock()
+		delete(formatters, "count")
+		formattersMu.Unlock()
+	}()
+
+	assert.Contains(t, FormatterNames(), "count")
+	assert.Equal(t, "2", displayTestMPFResult(t, "count", RoleDefinitionOptions{}))
+
+	_, err := GetFormatter("xml")
+	assert.ErrorContains(t, err, "invalid output format")
+}
+
+func TestDisplayResultDefaultsToText(t *testing.T) {
+	output := displayTestMPFResult(t, "", RoleDefinitionOptions{})
+	assert.Contains(t, output, "Permissions Required:\n")
+	assert.Contains(t, output, "Data Actions Required:\n")
+	assert.NotContains(t, output, testStorageAccountScope)
+}
+
+func TestDisplayYAML(t *testing.T) {
+	output := displayTestMPFResult(t, YAMLOutput, RoleDefinitionOptions{})
+
+	var result yamlOutput
+	require.NoError(t, yaml.Unmarshal([]byte(output), &result))
+	assert.Equal(t, []string{"Microsoft.Resources/deployments/write", "Microsoft.Storage/storageAccounts/write"}, result.RequiredPermissions[testSubscriptionID])
+	assert.Equal(t, []string{"Microsoft.KeyVault/vaults/secrets/getSecret/action"}, result.RequiredDataActions[testSubscriptionID])
+}
+
+func TestDisplayJSONKeepsLegacyShape(t *testing.T) {
+	mpfResult := getTestMPFResult()
+	mpfResult.RequiredDataActions = nil
+	var buf bytes.Buffer
+	require.NoError(t, NewMPFResultDisplayer(mpfResult, DisplayOptions{Output: JSONOutput, SubscriptionID: testSubscriptionID}).DisplayResult(&buf))
+
+	var result map[string][]string
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &result))
+	assert.ElementsMatch(t, []string{"Microsoft.Resources/deployments/write", "Microsoft.Storage/storageAccounts/write"}, result[testSubscriptionID])
+}
+
+func TestDisplayJSONKeepsDataActions(t *testing.T) {
+	output := displayTestMPFResult(t, JSONOutput, RoleDefinitionOptions{})
+
+	var result jsonPermissionsFile
+	require.NoError(t, json.Unmarshal([]byte(output), &result))
+	assert.ElementsMatch(t, []string{"Microsoft.Resources/deployments/write", "Microsoft.Storage/storageAccounts/write"}, result.RequiredPermissions[testSubscriptionID])
+	assert.Equal(t, []string{"Microsoft.KeyVault/vaults/secrets/getSecret/action"}, result.RequiredDataActions[testSubscriptionID])
+
+	parsed, aggregateKey, err := ParseJSONResult([]byte(output))
+	require.NoError(t, err)
+	assert.Equal(t, testSubscriptionID, aggregateKey)
+	assert.Equal(t, result.RequiredDataActions, parsed.RequiredDataActions)
+}
+
+func TestDisplayCSV(t *testing.T) {
+	var buf bytes.Buffer
+	displayer := NewMPFResultDisplayer(getTestMPFResult(), DisplayOptions{Output: CSVOutput, SubscriptionID: testSubscriptionID})
+	require.NoError(t, displayer.DisplayResult(&buf))
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"Scope", "Type", "Permission"},
+		{"/subscriptions/" + testSubscriptionID, "Action", "Microsoft.Resources/deployments/write"},
+		{"/subscriptions/" + testSubscriptionID, "Action", "Microsoft.Storage/storageAccounts/write"},
+		{"/subscriptions/" + testSubscriptionID, "DataAction", "Microsoft.KeyVault/vaults/secrets/getSecret/action"},
+	}, records)
+
+	buf.Reset()
+	displayer = NewMPFResultDisplayer(getTestMPFResult(), DisplayOptions{Output: CSVOutput, SubscriptionID: testSubscriptionID, ShowDetailedOutput: true})
+	require.NoError(t, displayer.DisplayResult(&buf))
+
+	records, err = csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"Scope", "Type", "Permission"},
+		{testStorageAccountScope, "Action", "Microsoft.Storage/storageAccounts/write"},
+	}, records)
+}
+
+func TestDisplayMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	displayer := NewMPFResultDisplayer(getTestMPFResult(), DisplayOptions{Output: MarkdownOutput, SubscriptionID: testSubscriptionID, ShowDetailedOutput: true})
+	require.NoError(t, displayer.DisplayResult(&buf))
+
+	output := buf.String()
+	assert.Contains(t, output, "## Permissions Required\n\n- `Microsoft.Resources/deployments/write`\n- `Microsoft.Storage/storageAccounts/write`\n\n")
+	assert.Contains(t, output, "## Data Actions Required\n\n- `Microsoft.KeyVault/vaults/secrets/getSecret/action`\n\n")
+	assert.Contains(t, output, "### `"+testStorageAccountScope+"`\n\n- `Microsoft.Storage/storageAccounts/write`\n")
+	assert.NotContains(t, output, "Data Actions Required by Scope")
+}
@@ -0,0 +1,104 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package presentation
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+)
+
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>azmpf Permission Report</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #1f2328; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { border: 1px solid #d0d7de; padding: 6px 12px; text-align: left; vertical-align: top; }
+th { background: #f6f8fa; }
+code { font-family: ui-monospace, Consolas, monospace; font-size: 90%; }
+.high-risk { background: #fff8c5; }
+.warning { border-left: 4px solid #bf8700; padding: 0.5em 1em; background: #fff8c5; }
+</style>
+</head>
+<body>
+<h1>azmpf Permission Report</h1>
+<table>
+{{- range .MetadataRows}}
+<tr><th>{{index . 0}}</th><td>{{index . 1}}</td></tr>
+{{- end}}
+</table>
+<p><strong>{{.Report.ActionCount}} actions</strong> and <strong>{{.Report.DataActionCount}} data actions</strong> across <strong>{{len .Report.Providers}} resource providers</strong>.</p>
+{{- if .Report.HighRisk}}
+<h2>High-Risk Permissions</h2>
+<p class="warning">These permissions allow privilege escalation or access to secrets. Review them before granting the role.</p>
+<table>
+<tr><th>Permission</th><th>Reason</th></tr>
+{{- range .Report.HighRisk}}
+<tr class="high-risk"><td><code>{{.Permission}}</code></td><td>{{.HighRiskReason}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- range .Report.Providers}}
+<h2>{{.Name}}</h2>
+<table>
+<tr><th>Resource type</th><th>Permission</th><th>Type</th><th>Scopes</th></tr>
+{{- range .ResourceTypes}}
+{{- $resourceType := .Name}}
+{{- range .Permissions}}
+<tr{{if .HighRiskReason}} class="high-risk" title="{{.HighRiskReason}}"{{end}}><td>{{$resourceType}}</td><td><code>{{.Permission}}</code></td><td>{{if .IsDataAction}}Data action{{else}}Action{{end}}</td><td>{{range $i, $scope := .Scopes}}{{if $i}}<br>{{end}}<code>{{$scope}}</code>{{else}}-{{end}}</td></tr>
+{{- end}}
+{{- end}}
+</table>
+{{- end}}
+{{- if .Report.InvalidActions}}
+<h2>Invalid Actions Removed</h2>
+<p>Azure rejected these actions as invalid, so they were removed from the custom role:</p>
+<ul>
+{{- range .Report.InvalidActions}}
+<li><code>{{.}}</code></li>
+{{- end}}
+</ul>
+{{- end}}
+</body>
+</html>
+`))
+
+type htmlReportData struct {
+	Report       permissionReport
+	MetadataRows [][2]string
+}
+
+func (d *displayConfig) displayHTMLReport(w io.Writer) error {
+	report := d.getPermissionReport()
+	err := htmlReportTemplate.Execute(w, htmlReportData{
+		Report:       report,
+		MetadataRows: report.getReportMetadataRows(),
+	})
+	if err != nil {
+		return fmt.Errorf("error rendering HTML report: %w", err)
+	}
+	return nil
+}
@@ -24,14 +24,26 @@ package presentation
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
-	"log"
+
+	"github.com/Azure/mpf/pkg/domain"
 )
 
+// displayJSON writes the legacy JSON output, a map of scope to required permissions.
+// If data actions are required, they are not dropped: the output is then the permissions file format accepted by
+// --initialPermissions, holding the map of scope to required permissions and the map of scope to required data actions.
 func (d *displayConfig) displayJSON(w io.Writer) error {
-	jsonBytes, err := json.Marshal(d.result.RequiredPermissions)
+	var output any = d.result.RequiredPermissions
+	if hasDataActions(d.result) {
+		output = jsonPermissionsFile{
+			RequiredPermissions: d.result.RequiredPermissions,
+			RequiredDataActions: d.result.RequiredDataActions,
+		}
+	}
+	jsonBytes, err := json.Marshal(output)
 	if err != nil {
-		log.Fatalf("Error converting output to JSON :%v \n", err)
+		return fmt.Errorf("error converting output to JSON: %w", err)
 	}
 	// fmt.Println(string(jsonBytes))
 	_, err = w.Write(jsonBytes)
@@ -40,3 +52,13 @@ func (d *displayConfig) displayJSON(w io.Writer) error {
 	}
 	return nil
 }
+
+// hasDataActions returns true if the result requires a data action on any scope
+func hasDataActions(result domain.MPFResult) bool {
+	for _, dataActions := range result.RequiredDataActions {
+		if len(dataActions) > 0 {
+			return true
+		}
+	}
+	return false
+}
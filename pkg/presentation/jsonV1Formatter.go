@@ -0,0 +1,128 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package presentation
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// ResultSchemaVersion is the version of the JSON result schema of the jsonV1 output.
+// Fields may be added within a major version, but are never renamed or removed.
+const ResultSchemaVersion = "1.0"
+
+// jsonResultV1 is the versioned JSON result, described by docs/schemas/mpf-result-v1.schema.json
+type jsonResultV1 struct {
+	SchemaVersion string                 `json:"schemaVersion"`
+	Metadata      jsonResultMetadataV1   `json:"metadata"`
+	Permissions   jsonPermissionsV1      `json:"permissions"`
+	Scopes        []jsonScopePermissions `json:"scopes"`
+	// IterationCount is 0 when all permissions were provided upfront
+	IterationCount        int      `json:"iterationCount"`
+	InvalidActionsRemoved []string `json:"invalidActionsRemoved"`
+}
+
+type jsonResultMetadataV1 struct {
+	RunID           string     `json:"runId,omitempty"`
+	AzmpfVersion    string     `json:"azmpfVersion"`
+	DeploymentType  string     `json:"deploymentType"`
+	TemplatePath    string     `json:"templatePath,omitempty"`
+	ParametersPath  string     `json:"parametersPath,omitempty"`
+	TargetModule    string     `json:"targetModule,omitempty"`
+	SubscriptionID  string     `json:"subscriptionId"`
+	StartTime       *time.Time `json:"startTime,omitempty"`
+	EndTime         *time.Time `json:"endTime,omitempty"`
+	DurationSeconds float64    `json:"durationSeconds"`
+}
+
+type jsonPermissionsV1 struct {
+	Actions     []string `json:"actions"`
+	DataActions []string `json:"dataActions"`
+}
+
+type jsonScopePermissions struct {
+	Scope       string   `json:"scope"`
+	Actions     []string `json:"actions"`
+	DataActions []string `json:"dataActions"`
+}
+
+func (d *displayConfig) getJSONResultV1() jsonResultV1 {
+	metadata := d.displayOptions.Metadata
+	result := jsonResultV1{
+		SchemaVersion: ResultSchemaVersion,
+		Metadata: jsonResultMetadataV1{
+			RunID:           metadata.RunID,
+			AzmpfVersion:    metadata.Version,
+			DeploymentType:  metadata.DeploymentType,
+			TemplatePath:    metadata.TemplatePath,
+			ParametersPath:  metadata.ParametersPath,
+			TargetModule:    metadata.TargetModule,
+			SubscriptionID:  d.displayOptions.SubscriptionID,
+			DurationSeconds: metadata.Duration().Seconds(),
+		},
+		Permissions: jsonPermissionsV1{
+			Actions:     getSortedCopy(d.result.RequiredPermissions[d.displayOptions.SubscriptionID]),
+			DataActions: getSortedCopy(d.result.RequiredDataActions[d.displayOptions.SubscriptionID]),
+		},
+		Scopes:                []jsonScopePermissions{},
+		IterationCount:        d.result.IterationCount,
+		InvalidActionsRemoved: getSortedCopy(d.result.InvalidActions),
+	}
+	if !metadata.StartTime.IsZero() {
+		startTime := metadata.StartTime.UTC()
+		result.Metadata.StartTime = &startTime
+	}
+	if !metadata.EndTime.IsZero() {
+		endTime := metadata.EndTime.UTC()
+		result.Metadata.EndTime = &endTime
+	}
+
+	// the scopes of actions and data actions are merged into a single sorted list
+	scopes := getSortedScopes(d.result.RequiredPermissions, d.displayOptions.SubscriptionID)
+	for _, scope := range getSortedScopes(d.result.RequiredDataActions, d.displayOptions.SubscriptionID) {
+		if _, ok := d.result.RequiredPermissions[scope]; !ok {
+			scopes = append(scopes, scope)
+		}
+	}
+	sort.Strings(scopes)
+	for _, scope := range scopes {
+		result.Scopes = append(result.Scopes, jsonScopePermissions{
+			Scope:       scope,
+			Actions:     getSortedCopy(d.result.RequiredPermissions[scope]),
+			DataActions: getSortedCopy(d.result.RequiredDataActions[scope]),
+		})
+	}
+	return result
+}
+
+func (d *displayConfig) displayJSONV1(w io.Writer) error {
+	jsonBytes, err := json.MarshalIndent(d.getJSONResultV1(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("error converting output to JSON: %w", err)
+	}
+	_, err = w.Write(append(jsonBytes, '\n'))
+	return err
+}
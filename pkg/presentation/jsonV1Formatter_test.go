@@ -0,0 +1,89 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package presentation
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Azure/mpf/pkg/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDisplayJSONV1(t *testing.T) {
+	mpfResult := getTestMPFResult()
+	mpfResult.IterationCount = 3
+	mpfResult.InvalidActions = []string{"Microsoft.Foo/bars/read"}
+	startTime := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	var buf bytes.Buffer
+	displayer := NewMPFResultDisplayer(mpfResult, DisplayOptions{
+		Output:         JSONV1Output,
+		SubscriptionID: testSubscriptionID,
+		Metadata: domain.MPFRunMetadata{
+			RunID:          "8f14e45f-ceea-467a-9af0-fd5bd2b2d1a4",
+			Version:        "1.2.3",
+			DeploymentType: "arm",
+			TemplatePath:   "./template.json",
+			ParametersPath: "./parameters.json",
+			StartTime:      startTime,
+			EndTime:        startTime.Add(90 * time.Second),
+		},
+	})
+	require.NoError(t, displayer.DisplayResult(&buf))
+
+	var result jsonResultV1
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &result))
+	assert.Equal(t, ResultSchemaVersion, result.SchemaVersion)
+	assert.Equal(t, "8f14e45f-ceea-467a-9af0-fd5bd2b2d1a4", result.Metadata.RunID)
+	assert.Equal(t, "1.2.3", result.Metadata.AzmpfVersion)
+	assert.Equal(t, "arm", result.Metadata.DeploymentType)
+	assert.Equal(t, "./template.json", result.Metadata.TemplatePath)
+	assert.Equal(t, testSubscriptionID, result.Metadata.SubscriptionID)
+	assert.Equal(t, startTime, *result.Metadata.StartTime)
+	assert.Equal(t, 90.0, result.Metadata.DurationSeconds)
+	assert.Equal(t, []string{"Microsoft.Resources/deployments/write", "Microsoft.Storage/storageAccounts/write"}, result.Permissions.Actions)
+	assert.Equal(t, []string{"Microsoft.KeyVault/vaults/secrets/getSecret/action"}, result.Permissions.DataActions)
+	assert.Equal(t, []jsonScopePermissions{
+		{Scope: testStorageAccountScope, Actions: []string{"Microsoft.Storage/storageAccounts/write"}, DataActions: []string{}},
+	}, result.Scopes)
+	assert.Equal(t, 3, result.IterationCount)
+	assert.Equal(t, []string{"Microsoft.Foo/bars/read"}, result.InvalidActionsRemoved)
+}
+
+func TestDisplayJSONV1EmptyResult(t *testing.T) {
+	var buf bytes.Buffer
+	displayer := NewMPFResultDisplayer(domain.MPFResult{}, DisplayOptions{Output: JSONV1Output, Metadata: domain.MPFRunMetadata{DeploymentType: "parse"}})
+	require.NoError(t, displayer.DisplayResult(&buf))
+
+	// lists are always present, so consumers don't need to handle null
+	var result map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &result))
+	assert.Equal(t, map[string]any{"actions": []any{}, "dataActions": []any{}}, result["permissions"])
+	assert.Equal(t, []any{}, result["scopes"])
+	assert.Equal(t, []any{}, result["invalidActionsRemoved"])
+	assert.NotContains(t, result["metadata"], "startTime")
+}
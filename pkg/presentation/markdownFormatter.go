@@ -0,0 +1,78 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package presentation
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+func (d *displayConfig) displayMarkdown(w io.Writer) error {
+	var sb strings.Builder
+
+	actions := getSortedCopy(d.result.RequiredPermissions[d.displayOptions.SubscriptionID])
+	dataActions := getSortedCopy(d.result.RequiredDataActions[d.displayOptions.SubscriptionID])
+
+	sb.WriteString("## Permissions Required\n\n")
+	if len(actions) == 0 && len(dataActions) == 0 {
+		sb.WriteString("No permissions required\n")
+	}
+	writeMarkdownList(&sb, actions)
+
+	if len(dataActions) > 0 {
+		sb.WriteString("## Data Actions Required\n\n")
+		writeMarkdownList(&sb, dataActions)
+	}
+
+	if d.displayOptions.ShowDetailedOutput {
+		writeMarkdownScopes(&sb, "Permissions Required by Scope", d.result.RequiredPermissions, d.displayOptions.SubscriptionID)
+		writeMarkdownScopes(&sb, "Data Actions Required by Scope", d.result.RequiredDataActions, d.displayOptions.SubscriptionID)
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func writeMarkdownScopes(sb *strings.Builder, title string, scopePermissions map[string][]string, subscriptionID string) {
+	scopes := getSortedScopes(scopePermissions, subscriptionID)
+	if len(scopes) == 0 {
+		return
+	}
+
+	fmt.Fprintf(sb, "## %s\n\n", title)
+	for _, scope := range scopes {
+		fmt.Fprintf(sb, "### `%s`\n\n", scope)
+		writeMarkdownList(sb, getSortedCopy(scopePermissions[scope]))
+	}
+}
+
+func writeMarkdownList(sb *strings.Builder, values []string) {
+	if len(values) == 0 {
+		return
+	}
+	for _, v := range values {
+		fmt.Fprintf(sb, "- `%s`\n", v)
+	}
+	sb.WriteString("\n")
+}
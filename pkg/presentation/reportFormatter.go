@@ -0,0 +1,244 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package presentation
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Azure/mpf/pkg/domain"
+)
+
+// reportPermission is a permission of the report with the scopes it was required at
+type reportPermission struct {
+	Permission   string
+	IsDataAction bool
+	Scopes       []string
+	// HighRiskReason is set for actions allowing privilege escalation or access to secrets
+	HighRiskReason string
+}
+
+type reportResourceType struct {
+	Name        string
+	Permissions []reportPermission
+}
+
+type reportProvider struct {
+	Name          string
+	ResourceTypes []reportResourceType
+}
+
+// permissionReport groups the permissions of the result by resource provider and resource type
+type permissionReport struct {
+	Metadata        domain.MPFRunMetadata
+	SubscriptionID  string
+	IterationCount  int
+	Duration        string
+	ActionCount     int
+	DataActionCount int
+	Providers       []reportProvider
+	HighRisk        []reportPermission
+	InvalidActions  []string
+}
+
+func (d *displayConfig) getPermissionReport() permissionReport {
+	report := permissionReport{
+		Metadata:       d.displayOptions.Metadata,
+		SubscriptionID: d.displayOptions.SubscriptionID,
+		IterationCount: d.result.IterationCount,
+		InvalidActions: getSortedCopy(d.result.InvalidActions),
+	}
+	if duration := d.displayOptions.Metadata.Duration(); duration > 0 {
+		report.Duration = duration.Round(time.Second).String()
+	}
+
+	actions := getSortedCopy(d.result.RequiredPermissions[d.displayOptions.SubscriptionID])
+	dataActions := getSortedCopy(d.result.RequiredDataActions[d.displayOptions.SubscriptionID])
+	report.ActionCount = len(actions)
+	report.DataActionCount = len(dataActions)
+
+	actionScopes := getPermissionScopes(d.result.RequiredPermissions, d.displayOptions.SubscriptionID)
+	dataActionScopes := getPermissionScopes(d.result.RequiredDataActions, d.displayOptions.SubscriptionID)
+
+	providers := map[string]map[string][]reportPermission{}
+	addPermission := func(permission string, isDataAction bool, scopes []string) {
+		p := reportPermission{
+			Permission:     permission,
+			IsDataAction:   isDataAction,
+			Scopes:         scopes,
+			HighRiskReason: domain.GetHighRiskActionReason(permission),
+		}
+		if p.HighRiskReason != "" {
+			report.HighRisk = append(report.HighRisk, p)
+		}
+
+		provider, resourceType := getReportProviderAndResourceType(permission)
+		if providers[provider] == nil {
+			providers[provider] = map[string][]reportPermission{}
+		}
+		providers[provider][resourceType] = append(providers[provider][resourceType], p)
+	}
+	for _, action := range actions {
+		addPermission(action, false, actionScopes[action])
+	}
+	for _, dataAction := range dataActions {
+		addPermission(dataAction, true, dataActionScopes[dataAction])
+	}
+
+	for _, provider := range getSortedKeys(providers) {
+		reportProvider := reportProvider{Name: provider}
+		for _, resourceType := range getSortedKeys(providers[provider]) {
+			reportProvider.ResourceTypes = append(reportProvider.ResourceTypes, reportResourceType{
+				Name:        resourceType,
+				Permissions: providers[provider][resourceType],
+			})
+		}
+		report.Providers = append(report.Providers, reportProvider)
+	}
+	return report
+}
+
+// getReportProviderAndResourceType returns the resource provider of the permission
+// and its resource type relative to the provider, e.g. Microsoft.Network and virtualNetworks/subnets
+func getReportProviderAndResourceType(permission string) (string, string) {
+	provider, _, _ := strings.Cut(permission, "/")
+	resourceType := domain.GetResourceTypeFromAction(permission)
+	resourceType = strings.TrimPrefix(strings.TrimPrefix(resourceType, provider), "/")
+	if resourceType == "" {
+		resourceType = "-"
+	}
+	return provider, resourceType
+}
+
+// getPermissionScopes returns the sorted scopes every permission was required at, without the aggregated subscription key
+func getPermissionScopes(scopePermissions map[string][]string, subscriptionID string) map[string][]string {
+	permissionScopes := map[string][]string{}
+	for _, scope := range getSortedScopes(scopePermissions, subscriptionID) {
+		for _, permission := range scopePermissions[scope] {
+			permissionScopes[permission] = append(permissionScopes[permission], scope)
+		}
+	}
+	return permissionScopes
+}
+
+func getSortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// getPermissionType returns the type of the permission as shown in the reports
+func (p reportPermission) getPermissionType() string {
+	if p.IsDataAction {
+		return "Data action"
+	}
+	return "Action"
+}
+
+// getReportMetadataRows returns the run metadata rows shown in the reports, omitting unknown values
+func (r permissionReport) getReportMetadataRows() [][2]string {
+	rows := [][2]string{}
+	addRow := func(name, value string) {
+		if value != "" {
+			rows = append(rows, [2]string{name, value})
+		}
+	}
+	addRow("Deployment type", r.Metadata.DeploymentType)
+	addRow("Template", r.Metadata.TemplatePath)
+	addRow("Parameters", r.Metadata.ParametersPath)
+	addRow("Target module", r.Metadata.TargetModule)
+	addRow("Subscription", r.SubscriptionID)
+	addRow("Iterations", fmt.Sprintf("%d", r.IterationCount))
+	addRow("Duration", r.Duration)
+	if !r.Metadata.StartTime.IsZero() {
+		addRow("Started", r.Metadata.StartTime.UTC().Format(time.RFC3339))
+	}
+	addRow("azmpf version", r.Metadata.Version)
+	return rows
+}
+
+func (d *displayConfig) displayMarkdownReport(w io.Writer) error {
+	report := d.getPermissionReport()
+	var sb strings.Builder
+
+	sb.WriteString("## azmpf Permission Report\n\n")
+	sb.WriteString("| Run | |\n|---|---|\n")
+	for _, row := range report.getReportMetadataRows() {
+		fmt.Fprintf(&sb, "| %s | %s |\n", row[0], escapeMarkdownTableCell(row[1]))
+	}
+	fmt.Fprintf(&sb, "\n**%d actions** and **%d data actions** across **%d resource providers**.\n\n", report.ActionCount, report.DataActionCount, len(report.Providers))
+
+	if len(report.HighRisk) > 0 {
+		sb.WriteString("### High-Risk Permissions\n\n")
+		sb.WriteString("> [!WARNING]\n> These permissions allow privilege escalation or access to secrets. Review them before granting the role.\n\n")
+		sb.WriteString("| Permission | Reason |\n|---|---|\n")
+		for _, p := range report.HighRisk {
+			fmt.Fprintf(&sb, "| `%s` | %s |\n", p.Permission, escapeMarkdownTableCell(p.HighRiskReason))
+		}
+		sb.WriteString("\n")
+	}
+
+	for _, provider := range report.Providers {
+		fmt.Fprintf(&sb, "### %s\n\n", provider.Name)
+		sb.WriteString("| Resource type | Permission | Type | Scopes |\n|---|---|---|---|\n")
+		for _, resourceType := range provider.ResourceTypes {
+			for _, p := range resourceType.Permissions {
+				permission := fmt.Sprintf("`%s`", p.Permission)
+				if p.HighRiskReason != "" {
+					permission += " **(high risk)**"
+				}
+				scopes := make([]string, 0, len(p.Scopes))
+				for _, scope := range p.Scopes {
+					scopes = append(scopes, fmt.Sprintf("`%s`", scope))
+				}
+				if len(scopes) == 0 {
+					scopes = append(scopes, "-")
+				}
+				fmt.Fprintf(&sb, "| %s | %s | %s | %s |\n", escapeMarkdownTableCell(resourceType.Name), permission, p.getPermissionType(), strings.Join(scopes, "<br>"))
+			}
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(report.InvalidActions) > 0 {
+		sb.WriteString("### Invalid Actions Removed\n\n")
+		sb.WriteString("Azure rejected these actions as invalid, so they were removed from the custom role:\n\n")
+		for _, action := range report.InvalidActions {
+			fmt.Fprintf(&sb, "- `%s`\n", action)
+		}
+		sb.WriteString("\n")
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func escapeMarkdownTableCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
@@ -0,0 +1,116 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package presentation
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/Azure/mpf/pkg/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getTestReportMPFResult() domain.MPFResult {
+	mpfResult := getTestMPFResult()
+	mpfResult.RequiredPermissions[testSubscriptionID] = append(mpfResult.RequiredPermissions[testSubscriptionID], "Microsoft.Storage/storageAccounts/listKeys/action")
+	mpfResult.RequiredPermissions[testStorageAccountScope] = append(mpfResult.RequiredPermissions[testStorageAccountScope], "Microsoft.Storage/storageAccounts/listKeys/action")
+	mpfResult.IterationCount = 2
+	return mpfResult
+}
+
+func getTestReportDisplayOptions(output string) DisplayOptions {
+	startTime := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	return DisplayOptions{
+		Output:         output,
+		SubscriptionID: testSubscriptionID,
+		Metadata: domain.MPFRunMetadata{
+			Version:        "1.2.3",
+			DeploymentType: "bicep",
+			TemplatePath:   "./main.bicep",
+			StartTime:      startTime,
+			EndTime:        startTime.Add(150 * time.Second),
+		},
+	}
+}
+
+func TestGetPermissionReport(t *testing.T) {
+	displayer := NewMPFResultDisplayer(getTestReportMPFResult(), getTestReportDisplayOptions(MarkdownReportOutput))
+	report := displayer.getPermissionReport()
+
+	assert.Equal(t, 3, report.ActionCount)
+	assert.Equal(t, 1, report.DataActionCount)
+	assert.Equal(t, "2m30s", report.Duration)
+
+	var providers []string
+	for _, provider := range report.Providers {
+		providers = append(providers, provider.Name)
+	}
+	assert.Equal(t, []string{"Microsoft.KeyVault", "Microsoft.Resources", "Microsoft.Storage"}, providers)
+
+	storage := report.Providers[2]
+	require.Len(t, storage.ResourceTypes, 1)
+	assert.Equal(t, "storageAccounts", storage.ResourceTypes[0].Name)
+	assert.Equal(t, []reportPermission{
+		{Permission: "Microsoft.Storage/storageAccounts/listKeys/action", Scopes: []string{testStorageAccountScope}, HighRiskReason: domain.GetHighRiskActionReason("Microsoft.Storage/storageAccounts/listKeys/action")},
+		{Permission: "Microsoft.Storage/storageAccounts/write", Scopes: []string{testStorageAccountScope}},
+	}, storage.ResourceTypes[0].Permissions)
+
+	var highRisk []string
+	for _, p := range report.HighRisk {
+		highRisk = append(highRisk, p.Permission)
+	}
+	assert.Equal(t, []string{"Microsoft.Storage/storageAccounts/listKeys/action", "Microsoft.KeyVault/vaults/secrets/getSecret/action"}, highRisk)
+}
+
+func TestDisplayMarkdownReport(t *testing.T) {
+	var buf bytes.Buffer
+	displayer := NewMPFResultDisplayer(getTestReportMPFResult(), getTestReportDisplayOptions(MarkdownReportOutput))
+	require.NoError(t, displayer.DisplayResult(&buf))
+
+	output := buf.String()
+	assert.Contains(t, output, "| Deployment type | bicep |\n")
+	assert.Contains(t, output, "| Duration | 2m30s |\n")
+	assert.Contains(t, output, "### High-Risk Permissions\n")
+	assert.Contains(t, output, "| storageAccounts | `Microsoft.Storage/storageAccounts/listKeys/action` **(high risk)** | Action | `"+testStorageAccountScope+"` |\n")
+	assert.Contains(t, output, "| deployments | `Microsoft.Resources/deployments/write` | Action | - |\n")
+	assert.Contains(t, output, "| vaults/secrets | `Microsoft.KeyVault/vaults/secrets/getSecret/action` **(high risk)** | Data action | - |\n")
+	assert.NotContains(t, output, "Invalid Actions Removed")
+}
+
+func TestDisplayHTMLReport(t *testing.T) {
+	mpfResult := getTestReportMPFResult()
+	mpfResult.InvalidActions = []string{"Microsoft.Foo/<bars>/read"}
+
+	var buf bytes.Buffer
+	displayer := NewMPFResultDisplayer(mpfResult, getTestReportDisplayOptions(HTMLReportOutput))
+	require.NoError(t, displayer.DisplayResult(&buf))
+
+	output := buf.String()
+	assert.Contains(t, output, "<!DOCTYPE html>")
+	assert.Contains(t, output, "<tr><th>Template</th><td>./main.bicep</td></tr>")
+	assert.Contains(t, output, "<h2>High-Risk Permissions</h2>")
+	assert.Contains(t, output, "<h2>Microsoft.Storage</h2>")
+	assert.Contains(t, output, "<li><code>Microsoft.Foo/&lt;bars&gt;/read</code></li>")
+}
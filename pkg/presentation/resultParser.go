@@ -0,0 +1,127 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package presentation
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Azure/mpf/pkg/domain"
+)
+
+// jsonPermissionsFile is the format of the permissions file accepted by --initialPermissions.
+type jsonPermissionsFile struct {
+	RequiredPermissions map[string][]string
+	RequiredDataActions map[string][]string
+}
+
+// ParseJSONResult parses a result written with the json or jsonV1 output, or a permissions file.
+// It returns the result and the key of the result maps holding the permissions of all scopes,
+// which is the subscription ID of the run.
+func ParseJSONResult(data []byte) (domain.MPFResult, string, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return domain.MPFResult{}, "", fmt.Errorf("error parsing result: %w", err)
+	}
+
+	if _, ok := fields["schemaVersion"]; ok {
+		return parseJSONResultV1(data)
+	}
+
+	var output jsonPermissionsFile
+	if _, ok := fields["RequiredPermissions"]; ok {
+		if err := json.Unmarshal(data, &output); err != nil {
+			return domain.MPFResult{}, "", fmt.Errorf("error parsing result: %w", err)
+		}
+	} else if err := json.Unmarshal(data, &output.RequiredPermissions); err != nil {
+		return domain.MPFResult{}, "", fmt.Errorf("error parsing result: %w", err)
+	}
+
+	result := domain.MPFResult{
+		RequiredPermissions: output.RequiredPermissions,
+		RequiredDataActions: output.RequiredDataActions,
+	}
+	if result.RequiredPermissions == nil {
+		result.RequiredPermissions = map[string][]string{}
+	}
+	return result, getAggregateKey(result), nil
+}
+
+func parseJSONResultV1(data []byte) (domain.MPFResult, string, error) {
+	var output jsonResultV1
+	if err := json.Unmarshal(data, &output); err != nil {
+		return domain.MPFResult{}, "", fmt.Errorf("error parsing result: %w", err)
+	}
+	if !strings.HasPrefix(output.SchemaVersion, "1.") {
+		return domain.MPFResult{}, "", fmt.Errorf("unsupported result schema version: %s", output.SchemaVersion)
+	}
+
+	subscriptionID := output.Metadata.SubscriptionID
+	result := domain.MPFResult{
+		RequiredPermissions: map[string][]string{subscriptionID: output.Permissions.Actions},
+		IterationCount:      output.IterationCount,
+		InvalidActions:      output.InvalidActionsRemoved,
+	}
+	if len(output.Permissions.DataActions) > 0 {
+		result.RequiredDataActions = map[string][]string{subscriptionID: output.Permissions.DataActions}
+	}
+	for _, scope := range output.Scopes {
+		if len(scope.Actions) > 0 {
+			result.RequiredPermissions[scope.Scope] = scope.Actions
+		}
+		if len(scope.DataActions) > 0 {
+			if result.RequiredDataActions == nil {
+				result.RequiredDataActions = map[string][]string{}
+			}
+			result.RequiredDataActions[scope.Scope] = scope.DataActions
+		}
+	}
+	return result, subscriptionID, nil
+}
+
+// getAggregateKey returns the key of the permissions of all scopes, which is the only key not being a scope.
+// Results without such a key get one holding the permissions of all scopes.
+func getAggregateKey(result domain.MPFResult) string {
+	for _, scopePermissions := range []map[string][]string{result.RequiredPermissions, result.RequiredDataActions} {
+		for key := range scopePermissions {
+			if !strings.HasPrefix(key, "/") {
+				return key
+			}
+		}
+	}
+
+	aggregate := func(scopePermissions map[string][]string) {
+		var all []string
+		for _, permissions := range scopePermissions {
+			all = append(all, permissions...)
+		}
+		scopePermissions[""] = slices.Compact(getSortedCopy(all))
+	}
+	aggregate(result.RequiredPermissions)
+	if result.RequiredDataActions != nil {
+		aggregate(result.RequiredDataActions)
+	}
+	return ""
+}
@@ -0,0 +1,70 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package presentation
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseJSONResult(t *testing.T) {
+	result, aggregateKey, err := ParseJSONResult([]byte(`{"sub":["Microsoft.Storage/storageAccounts/write"],"/subscriptions/sub/resourceGroups/rg":["Microsoft.Storage/storageAccounts/write"]}`))
+	require.NoError(t, err)
+	assert.Equal(t, "sub", aggregateKey)
+	assert.Equal(t, []string{"Microsoft.Storage/storageAccounts/write"}, result.RequiredPermissions[aggregateKey])
+}
+
+func TestParseJSONResultWithDataActions(t *testing.T) {
+	result, aggregateKey, err := ParseJSONResult([]byte(`{"RequiredPermissions":{"sub":["Microsoft.KeyVault/vaults/read"]},"RequiredDataActions":{"sub":["Microsoft.KeyVault/vaults/secrets/getSecret/action"]}}`))
+	require.NoError(t, err)
+	assert.Equal(t, "sub", aggregateKey)
+	assert.Equal(t, []string{"Microsoft.KeyVault/vaults/read"}, result.RequiredPermissions[aggregateKey])
+	assert.Equal(t, []string{"Microsoft.KeyVault/vaults/secrets/getSecret/action"}, result.RequiredDataActions[aggregateKey])
+}
+
+func TestParseJSONResultAggregatesScopes(t *testing.T) {
+	result, aggregateKey, err := ParseJSONResult([]byte(`{"/subscriptions/sub/resourceGroups/a":["Microsoft.Storage/storageAccounts/write"],"/subscriptions/sub/resourceGroups/b":["Microsoft.Network/virtualNetworks/write","Microsoft.Storage/storageAccounts/write"]}`))
+	require.NoError(t, err)
+	assert.Equal(t, "", aggregateKey)
+	assert.Equal(t, []string{"Microsoft.Network/virtualNetworks/write", "Microsoft.Storage/storageAccounts/write"}, result.RequiredPermissions[aggregateKey])
+}
+
+func TestParseJSONResultV1(t *testing.T) {
+	result := getTestMPFResult()
+	var output bytes.Buffer
+	err := NewMPFResultDisplayer(result, DisplayOptions{Output: JSONV1Output, SubscriptionID: testSubscriptionID}).DisplayResult(&output)
+	require.NoError(t, err)
+
+	parsed, aggregateKey, err := ParseJSONResult(output.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, testSubscriptionID, aggregateKey)
+	assert.ElementsMatch(t, result.RequiredPermissions[testSubscriptionID], parsed.RequiredPermissions[aggregateKey])
+}
+
+func TestParseJSONResultRejectsUnsupportedSchemaVersion(t *testing.T) {
+	_, _, err := ParseJSONResult([]byte(`{"schemaVersion":"2.0"}`))
+	assert.Error(t, err)
+}
@@ -30,8 +30,13 @@ import (
 
 type DisplayOptions struct {
 	ShowDetailedOutput bool
-	JSONOutput         bool
-	SubscriptionID     string
+	// Output is the name of the registered formatter rendering the result. Default is text
+	Output         string
+	SubscriptionID string
+	// RoleDefinition configures the role definition formatters
+	RoleDefinition RoleDefinitionOptions
+	// Metadata describes the run the result was produced by
+	Metadata domain.MPFRunMetadata
 }
 
 // type ResultDisplayer interface {
@@ -51,8 +56,13 @@ func NewMPFResultDisplayer(result domain.MPFResult, options DisplayOptions) *dis
 }
 
 func (d *displayConfig) DisplayResult(w io.Writer) error {
-	if d.displayOptions.JSONOutput {
-		return d.displayJSON(w)
+	output := d.displayOptions.Output
+	if output == "" {
+		output = TextOutput
 	}
-	return d.displayText(w)
+	formatter, err := GetFormatter(output)
+	if err != nil {
+		return err
+	}
+	return formatter.Format(w, d.result, d.displayOptions)
 }
@@ -0,0 +1,318 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package presentation
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Formats of the custom role definition rendered from the MPF result
+const (
+	// RoleDefinitionFormatJSON is the role definition accepted by az role definition create --role-definition
+	RoleDefinitionFormatJSON      = "json"
+	RoleDefinitionFormatBicep     = "bicep"
+	RoleDefinitionFormatARM       = "arm"
+	RoleDefinitionFormatTerraform = "terraform"
+)
+
+// RoleDefinitionFormats lists the supported role definition formats
+var RoleDefinitionFormats = []string{RoleDefinitionFormatJSON, RoleDefinitionFormatBicep, RoleDefinitionFormatARM, RoleDefinitionFormatTerraform}
+
+const (
+	DefaultRoleDefinitionName        = "azmpf-custom-role"
+	DefaultRoleDefinitionDescription = "Minimum permissions discovered by azmpf"
+
+	roleDefinitionsAPIVersion = "2022-04-01"
+)
+
+// RoleDefinitionOptions configures the rendered custom role definition
+type RoleDefinitionOptions struct {
+	Name        string
+	Description string
+	// AssignableScopes defaults to the subscription of the MPF result
+	AssignableScopes []string
+}
+
+// azureCLIRoleDefinition is the role definition format of az role definition create
+type azureCLIRoleDefinition struct {
+	Name             string
+	IsCustom         bool
+	Description      string
+	Actions          []string
+	NotActions       []string
+	DataActions      []string
+	NotDataActions   []string
+	AssignableScopes []string
+}
+
+type roleDefinitionPermissions struct {
+	Actions        []string `json:"actions"`
+	NotActions     []string `json:"notActions"`
+	DataActions    []string `json:"dataActions"`
+	NotDataActions []string `json:"notDataActions"`
+}
+
+type roleDefinitionProperties struct {
+	RoleName         string                      `json:"roleName"`
+	Description      string                      `json:"description"`
+	Type             string                      `json:"type"`
+	Permissions      []roleDefinitionPermissions `json:"permissions"`
+	AssignableScopes []string                    `json:"assignableScopes"`
+}
+
+type armTemplateParameter struct {
+	Type         string            `json:"type"`
+	DefaultValue string            `json:"defaultValue"`
+	Metadata     map[string]string `json:"metadata"`
+}
+
+type armTemplateResource struct {
+	Type       string                   `json:"type"`
+	APIVersion string                   `json:"apiVersion"`
+	Name       string                   `json:"name"`
+	Properties roleDefinitionProperties `json:"properties"`
+}
+
+type armTemplateOutput struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type armTemplate struct {
+	Schema         string                          `json:"$schema"`
+	ContentVersion string                          `json:"contentVersion"`
+	Parameters     map[string]armTemplateParameter `json:"parameters"`
+	Resources      []armTemplateResource           `json:"resources"`
+	Outputs        map[string]armTemplateOutput    `json:"outputs"`
+}
+
+// roleDefinition holds the sorted permissions and the options of the rendered role definition
+type roleDefinition struct {
+	name             string
+	description      string
+	actions          []string
+	dataActions      []string
+	assignableScopes []string
+}
+
+func (d *displayConfig) getRoleDefinition() roleDefinition {
+	options := d.displayOptions.RoleDefinition
+	rd := roleDefinition{
+		name:             options.Name,
+		description:      options.Description,
+		actions:          getSortedCopy(d.result.RequiredPermissions[d.displayOptions.SubscriptionID]),
+		dataActions:      getSortedCopy(d.result.RequiredDataActions[d.displayOptions.SubscriptionID]),
+		assignableScopes: options.AssignableScopes,
+	}
+	if rd.name == "" {
+		rd.name = DefaultRoleDefinitionName
+	}
+	if rd.description == "" {
+		rd.description = DefaultRoleDefinitionDescription
+	}
+	if len(rd.assignableScopes) == 0 {
+		rd.assignableScopes = []string{fmt.Sprintf("/subscriptions/%s", d.displayOptions.SubscriptionID)}
+	}
+	return rd
+}
+
+func (d *displayConfig) displayRoleDefinition(w io.Writer, format string) error {
+	rd := d.getRoleDefinition()
+
+	var output string
+	var err error
+	switch format {
+	case RoleDefinitionFormatJSON:
+		output, err = rd.azureCLIJSON()
+	case RoleDefinitionFormatBicep:
+		output = rd.bicep()
+	case RoleDefinitionFormatARM:
+		output, err = rd.armTemplate()
+	case RoleDefinitionFormatTerraform:
+		output = rd.terraform()
+	default:
+		return fmt.Errorf("invalid role definition format %q, must be one of: %s", format, strings.Join(RoleDefinitionFormats, ", "))
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, output)
+	return err
+}
+
+func (rd roleDefinition) azureCLIJSON() (string, error) {
+	jsonBytes, err := json.MarshalIndent(azureCLIRoleDefinition{
+		Name:             rd.name,
+		IsCustom:         true,
+		Description:      rd.description,
+		Actions:          rd.actions,
+		NotActions:       []string{},
+		DataActions:      rd.dataActions,
+		NotDataActions:   []string{},
+		AssignableScopes: rd.assignableScopes,
+	}, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("error converting role definition to JSON: %w", err)
+	}
+	return string(jsonBytes) + "\n", nil
+}
+
+func (rd roleDefinition) armTemplate() (string, error) {
+	template := armTemplate{
+		Schema:         "https://schema.management.azure.com/schemas/2018-05-01/subscriptionDeploymentTemplate.json#",
+		ContentVersion: "1.0.0.0",
+		Parameters: map[string]armTemplateParameter{
+			"roleName": {
+				Type:         "string",
+				DefaultValue: escapeARMString(rd.name),
+				Metadata:     map[string]string{"description": "Name of the custom role"},
+			},
+			"roleDescription": {
+				Type:         "string",
+				DefaultValue: escapeARMString(rd.description),
+				Metadata:     map[string]string{"description": "Description of the custom role"},
+			},
+		},
+		Resources: []armTemplateResource{
+			{
+				Type:       "Microsoft.Authorization/roleDefinitions",
+				APIVersion: roleDefinitionsAPIVersion,
+				Name:       "[guid(subscription().id, parameters('roleName'))]",
+				Properties: roleDefinitionProperties{
+					RoleName:    "[parameters('roleName')]",
+					Description: "[parameters('roleDescription')]",
+					Type:        "CustomRole",
+					Permissions: []roleDefinitionPermissions{
+						{
+							Actions:        rd.actions,
+							NotActions:     []string{},
+							DataActions:    rd.dataActions,
+							NotDataActions: []string{},
+						},
+					},
+					AssignableScopes: rd.assignableScopes,
+				},
+			},
+		},
+		Outputs: map[string]armTemplateOutput{
+			"roleDefinitionId": {
+				Type:  "string",
+				Value: "[subscriptionResourceId('Microsoft.Authorization/roleDefinitions', guid(subscription().id, parameters('roleName')))]",
+			},
+		},
+	}
+
+	jsonBytes, err := json.MarshalIndent(template, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("error converting role definition to ARM template: %w", err)
+	}
+	return string(jsonBytes) + "\n", nil
+}
+
+func (rd roleDefinition) bicep() string {
+	var sb strings.Builder
+	sb.WriteString("targetScope = 'subscription'\n\n")
+	sb.WriteString("@description('Name of the custom role')\n")
+	fmt.Fprintf(&sb, "param roleName string = %s\n\n", quoteBicepString(rd.name))
+	sb.WriteString("@description('Description of the custom role')\n")
+	fmt.Fprintf(&sb, "param roleDescription string = %s\n\n", quoteBicepString(rd.description))
+	fmt.Fprintf(&sb, "resource roleDefinition 'Microsoft.Authorization/roleDefinitions@%s' = {\n", roleDefinitionsAPIVersion)
+	sb.WriteString("  name: guid(subscription().id, roleName)\n")
+	sb.WriteString("  properties: {\n")
+	sb.WriteString("    roleName: roleName\n")
+	sb.WriteString("    description: roleDescription\n")
+	sb.WriteString("    type: 'CustomRole'\n")
+	sb.WriteString("    permissions: [\n")
+	sb.WriteString("      {\n")
+	writeList(&sb, "        actions: ", rd.actions, "          ", quoteBicepString, "")
+	sb.WriteString("        notActions: []\n")
+	writeList(&sb, "        dataActions: ", rd.dataActions, "          ", quoteBicepString, "")
+	sb.WriteString("        notDataActions: []\n")
+	sb.WriteString("      }\n")
+	sb.WriteString("    ]\n")
+	writeList(&sb, "    assignableScopes: ", rd.assignableScopes, "      ", quoteBicepString, "")
+	sb.WriteString("  }\n")
+	sb.WriteString("}\n\n")
+	sb.WriteString("output roleDefinitionId string = roleDefinition.id\n")
+	return sb.String()
+}
+
+func (rd roleDefinition) terraform() string {
+	var sb strings.Builder
+	sb.WriteString("resource \"azurerm_role_definition\" \"mpf\" {\n")
+	fmt.Fprintf(&sb, "  name        = %s\n", quoteHCLString(rd.name))
+	fmt.Fprintf(&sb, "  scope       = %s\n", quoteHCLString(rd.assignableScopes[0]))
+	fmt.Fprintf(&sb, "  description = %s\n\n", quoteHCLString(rd.description))
+	sb.WriteString("  permissions {\n")
+	writeList(&sb, "    actions = ", rd.actions, "      ", quoteHCLString, ",")
+	sb.WriteString("    not_actions = []\n")
+	writeList(&sb, "    data_actions = ", rd.dataActions, "      ", quoteHCLString, ",")
+	sb.WriteString("    not_data_actions = []\n")
+	sb.WriteString("  }\n\n")
+	writeList(&sb, "  assignable_scopes = ", rd.assignableScopes, "    ", quoteHCLString, ",")
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// writeList writes a multi-line list of quoted values, closed at the indentation of the prefix
+func writeList(sb *strings.Builder, prefix string, values []string, indent string, quote func(string) string, separator string) {
+	if len(values) == 0 {
+		sb.WriteString(prefix + "[]\n")
+		return
+	}
+	sb.WriteString(prefix + "[\n")
+	for _, v := range values {
+		sb.WriteString(indent + quote(v) + separator + "\n")
+	}
+	closingIndent := prefix[:len(prefix)-len(strings.TrimLeft(prefix, " "))]
+	sb.WriteString(closingIndent + "]\n")
+}
+
+func quoteBicepString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `'`, `\'`)
+	s = strings.ReplaceAll(s, "${", `\${`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return "'" + s + "'"
+}
+
+func quoteHCLString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "${", "$${")
+	s = strings.ReplaceAll(s, "%{", "%%{")
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
+
+// escapeARMString escapes a leading [, which ARM would otherwise evaluate as an expression
+func escapeARMString(s string) string {
+	if strings.HasPrefix(s, "[") {
+		return "[" + s
+	}
+	return s
+}
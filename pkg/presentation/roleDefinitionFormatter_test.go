@@ -0,0 +1,111 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package presentation
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/Azure/mpf/pkg/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testSubscriptionID      = "SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS"
+	testStorageAccountScope = "/subscriptions/SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS/resourceGroups/rg/providers/Microsoft.Storage/storageAccounts/sa"
+)
+
+func getTestMPFResult() domain.MPFResult {
+	return domain.MPFResult{
+		RequiredPermissions: map[string][]string{
+			testSubscriptionID: {
+				"Microsoft.Storage/storageAccounts/write",
+				"Microsoft.Resources/deployments/write",
+			},
+			testStorageAccountScope: {"Microsoft.Storage/storageAccounts/write"},
+		},
+		RequiredDataActions: map[string][]string{
+			testSubscriptionID: {"Microsoft.KeyVault/vaults/secrets/getSecret/action"},
+		},
+	}
+}
+
+func displayTestMPFResult(t *testing.T, output string, options RoleDefinitionOptions) string {
+	var buf bytes.Buffer
+	displayer := NewMPFResultDisplayer(getTestMPFResult(), DisplayOptions{Output: output, SubscriptionID: testSubscriptionID, RoleDefinition: options})
+	require.NoError(t, displayer.DisplayResult(&buf))
+	return buf.String()
+}
+
+func TestDisplayRoleDefinitionJSON(t *testing.T) {
+	output := displayTestMPFResult(t, RoleDefinitionJSONOutput, RoleDefinitionOptions{Name: "deployer"})
+
+	var rd azureCLIRoleDefinition
+	require.NoError(t, json.Unmarshal([]byte(output), &rd))
+	assert.Equal(t, "deployer", rd.Name)
+	assert.True(t, rd.IsCustom)
+	assert.Equal(t, DefaultRoleDefinitionDescription, rd.Description)
+	assert.Equal(t, []string{"Microsoft.Resources/deployments/write", "Microsoft.Storage/storageAccounts/write"}, rd.Actions)
+	assert.Equal(t, []string{"Microsoft.KeyVault/vaults/secrets/getSecret/action"}, rd.DataActions)
+	assert.Equal(t, []string{"/subscriptions/" + testSubscriptionID}, rd.AssignableScopes)
+}
+
+func TestDisplayRoleDefinitionARMTemplate(t *testing.T) {
+	output := displayTestMPFResult(t, RoleDefinitionARMOutput, RoleDefinitionOptions{Name: "[deployer]", AssignableScopes: []string{"/subscriptions/a", "/subscriptions/b"}})
+
+	var template armTemplate
+	require.NoError(t, json.Unmarshal([]byte(output), &template))
+	assert.Equal(t, "[[deployer]", template.Parameters["roleName"].DefaultValue)
+	require.Len(t, template.Resources, 1)
+	assert.Equal(t, "Microsoft.Authorization/roleDefinitions", template.Resources[0].Type)
+	assert.Equal(t, []string{"/subscriptions/a", "/subscriptions/b"}, template.Resources[0].Properties.AssignableScopes)
+	assert.Equal(t, []string{"Microsoft.Resources/deployments/write", "Microsoft.Storage/storageAccounts/write"}, template.Resources[0].Properties.Permissions[0].Actions)
+}
+
+func TestDisplayRoleDefinitionBicep(t *testing.T) {
+	output := displayTestMPFResult(t, RoleDefinitionBicepOutput, RoleDefinitionOptions{Name: "deployer's role"})
+
+	assert.Contains(t, output, "targetScope = 'subscription'")
+	assert.Contains(t, output, `param roleName string = 'deployer\'s role'`)
+	assert.Contains(t, output, "resource roleDefinition 'Microsoft.Authorization/roleDefinitions@2022-04-01' = {")
+	assert.Contains(t, output, "        actions: [\n          'Microsoft.Resources/deployments/write'\n          'Microsoft.Storage/storageAccounts/write'\n        ]\n")
+	assert.Contains(t, output, "    assignableScopes: [\n      '/subscriptions/"+testSubscriptionID+"'\n    ]\n")
+}
+
+func TestDisplayRoleDefinitionTerraform(t *testing.T) {
+	output := displayTestMPFResult(t, RoleDefinitionTerraformOutput, RoleDefinitionOptions{Description: `role for "${var.env}"`})
+
+	assert.Contains(t, output, `resource "azurerm_role_definition" "mpf" {`)
+	assert.Contains(t, output, `  name        = "azmpf-custom-role"`)
+	assert.Contains(t, output, `  scope       = "/subscriptions/`+testSubscriptionID+`"`)
+	assert.Contains(t, output, `  description = "role for \"$${var.env}\""`)
+	assert.Contains(t, output, "    data_actions = [\n      \"Microsoft.KeyVault/vaults/secrets/getSecret/action\",\n    ]\n")
+}
+
+func TestDisplayRoleDefinitionInvalidFormat(t *testing.T) {
+	var buf bytes.Buffer
+	displayer := NewMPFResultDisplayer(getTestMPFResult(), DisplayOptions{})
+	assert.ErrorContains(t, displayer.displayRoleDefinition(&buf, "yaml"), "invalid role definition format")
+}
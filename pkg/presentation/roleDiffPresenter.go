@@ -0,0 +1,125 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package presentation
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Azure/mpf/pkg/domain"
+)
+
+type jsonRoleDiff struct {
+	RoleName            string                 `json:"roleName"`
+	RoleDefinitionID    string                 `json:"roleDefinitionId,omitempty"`
+	MissingActions      []string               `json:"missingActions"`
+	MissingDataActions  []string               `json:"missingDataActions"`
+	ExcessActions       []string               `json:"excessActions"`
+	ExcessDataActions   []string               `json:"excessDataActions"`
+	WildcardActions     []jsonWildcardCoverage `json:"wildcardActions"`
+	WildcardDataActions []jsonWildcardCoverage `json:"wildcardDataActions"`
+}
+
+type jsonWildcardCoverage struct {
+	Pattern            string   `json:"pattern"`
+	CoveredPermissions []string `json:"coveredPermissions"`
+}
+
+// DisplayRoleDiff writes the comparison of the role definition with the required permissions as text or json
+func DisplayRoleDiff(w io.Writer, role domain.RoleDefinition, diff domain.RoleDiff, output string) error {
+	switch output {
+	case "", TextOutput:
+		displayRoleDiffText(w, role, diff)
+		return nil
+	case JSONOutput:
+		return displayRoleDiffJSON(w, role, diff)
+	default:
+		return fmt.Errorf("invalid output format for the role diff: %s, valid values are %s and %s", output, TextOutput, JSONOutput)
+	}
+}
+
+func displayRoleDiffText(w io.Writer, role domain.RoleDefinition, diff domain.RoleDiff) {
+	fmt.Fprintf(w, "Role: %s\n", role.Name)
+	fmt.Fprintln(w)
+
+	writeRoleDiffSection(w, "Missing Actions (required, not granted by the role):", diff.MissingActions)
+	writeRoleDiffSection(w, "Missing Data Actions (required, not granted by the role):", diff.MissingDataActions)
+	writeRoleDiffSection(w, "Excess Actions (granted by the role, not required):", diff.ExcessActions)
+	writeRoleDiffSection(w, "Excess Data Actions (granted by the role, not required):", diff.ExcessDataActions)
+
+	var wildcards []string
+	for _, wildcard := range append(append([]domain.WildcardCoverage{}, diff.WildcardActions...), diff.WildcardDataActions...) {
+		wildcards = append(wildcards, fmt.Sprintf("%s grants %s", wildcard.Pattern, strings.Join(wildcard.CoveredPermissions, ", ")))
+	}
+	writeRoleDiffSection(w, "Wildcards (grant required permissions and more):", wildcards)
+
+	if !diff.HasMissingPermissions() {
+		fmt.Fprintln(w, "The role grants all required permissions")
+	}
+}
+
+func writeRoleDiffSection(w io.Writer, title string, lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "------------------------------------------------------------------------------------------------------------------------------------------")
+	fmt.Fprintln(w, title)
+	fmt.Fprintln(w, "------------------------------------------------------------------------------------------------------------------------------------------")
+	for _, line := range lines {
+		fmt.Fprintln(w, line)
+	}
+	fmt.Fprintln(w)
+}
+
+func displayRoleDiffJSON(w io.Writer, role domain.RoleDefinition, diff domain.RoleDiff) error {
+	output := jsonRoleDiff{
+		RoleName:            role.Name,
+		RoleDefinitionID:    role.ID,
+		MissingActions:      getSortedCopy(diff.MissingActions),
+		MissingDataActions:  getSortedCopy(diff.MissingDataActions),
+		ExcessActions:       getSortedCopy(diff.ExcessActions),
+		ExcessDataActions:   getSortedCopy(diff.ExcessDataActions),
+		WildcardActions:     getJSONWildcardCoverage(diff.WildcardActions),
+		WildcardDataActions: getJSONWildcardCoverage(diff.WildcardDataActions),
+	}
+
+	jsonBytes, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error converting output to JSON: %w", err)
+	}
+	_, err = w.Write(append(jsonBytes, '\n'))
+	return err
+}
+
+func getJSONWildcardCoverage(wildcards []domain.WildcardCoverage) []jsonWildcardCoverage {
+	jsonWildcards := make([]jsonWildcardCoverage, 0, len(wildcards))
+	for _, wildcard := range wildcards {
+		jsonWildcards = append(jsonWildcards, jsonWildcardCoverage{
+			Pattern:            wildcard.Pattern,
+			CoveredPermissions: getSortedCopy(wildcard.CoveredPermissions),
+		})
+	}
+	return jsonWildcards
+}
@@ -0,0 +1,49 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package presentation
+
+import (
+	"fmt"
+	"io"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// yamlOutput has the keys of the JSON output with data actions
+type yamlOutput struct {
+	RequiredPermissions map[string][]string `yaml:"RequiredPermissions"`
+	RequiredDataActions map[string][]string `yaml:"RequiredDataActions,omitempty"`
+}
+
+func (d *displayConfig) displayYAML(w io.Writer) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	err := encoder.Encode(yamlOutput{
+		RequiredPermissions: getSortedScopePermissions(d.result.RequiredPermissions),
+		RequiredDataActions: getSortedScopePermissions(d.result.RequiredDataActions),
+	})
+	if err != nil {
+		return fmt.Errorf("error converting output to YAML: %w", err)
+	}
+	return encoder.Close()
+}
@@ -22,7 +22,11 @@
 
 package usecase
 
-import "github.com/Azure/mpf/pkg/domain"
+import (
+	"context"
+
+	"github.com/Azure/mpf/pkg/domain"
+)
 
 type DeploymentAuthorizationChecker interface {
 
@@ -30,11 +34,11 @@ type DeploymentAuthorizationChecker interface {
 	// If Authorization Error is received the authorization error message string is returned, and error is nil
 	// If string is empty and error is not nill, then non authorization error is received
 	// If string is empty and error is nil, then authorization is successful
-	GetDeploymentAuthorizationErrors(mpfCoreConfig domain.MPFConfig) (string, error)
+	GetDeploymentAuthorizationErrors(ctx context.Context, mpfCoreConfig domain.MPFConfig) (string, error)
 }
 
 type DeploymentCleaner interface {
-	CleanDeployment(mpfCoreConfig domain.MPFConfig) error
+	CleanDeployment(ctx context.Context, mpfCoreConfig domain.MPFConfig) error
 }
 
 type DeploymentAuthorizationCheckerCleaner interface {
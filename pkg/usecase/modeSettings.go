@@ -0,0 +1,80 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package usecase
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Deployment modes, named after the commands running them
+const (
+	ARMMode       = "arm"
+	BicepMode     = "bicep"
+	TerraformMode = "terraform"
+)
+
+// ModeSettings are the permissions, auto added permissions and resource group of the MPFService for a deployment mode.
+// The arm, bicep and terraform commands, the replay of their journals and the library share them.
+type ModeSettings struct {
+	// InitialPermissions are added to the custom role before the first deployment
+	InitialPermissions []string
+	// PermissionsToAddToResult are added to the result, as the deployments need them
+	PermissionsToAddToResult            []string
+	AutoAddReadPermissionForEachWrite   bool
+	AutoAddDeletePermissionForEachWrite bool
+	// AutoCreateResourceGroup creates the resource group of the run, which the deployments target
+	AutoCreateResourceGroup bool
+}
+
+// ARMModeSettings returns the settings of ARM template and Bicep deployments
+func ARMModeSettings() ModeSettings {
+	return ModeSettings{
+		InitialPermissions:                []string{"Microsoft.Resources/deployments/*", "Microsoft.Resources/subscriptions/operationresults/read"},
+		PermissionsToAddToResult:          []string{"Microsoft.Resources/deployments/read", "Microsoft.Resources/deployments/write"},
+		AutoAddReadPermissionForEachWrite: true,
+		// only resource group scoped deployments are supported
+		AutoCreateResourceGroup: true,
+	}
+}
+
+// TerraformModeSettings returns the settings of Terraform deployments
+func TerraformModeSettings() ModeSettings {
+	return ModeSettings{
+		InitialPermissions:                  []string{"Microsoft.Resources/deployments/read", "Microsoft.Resources/deployments/write"},
+		PermissionsToAddToResult:            []string{"Microsoft.Resources/deployments/read", "Microsoft.Resources/deployments/write"},
+		AutoAddDeletePermissionForEachWrite: true,
+	}
+}
+
+// GetModeSettings returns the settings of the arm, bicep or terraform mode
+func GetModeSettings(mode string) (ModeSettings, error) {
+	switch strings.ToLower(mode) {
+	case ARMMode, BicepMode:
+		return ARMModeSettings(), nil
+	case TerraformMode:
+		return TerraformModeSettings(), nil
+	default:
+		return ModeSettings{}, fmt.Errorf("invalid mode %q, must be one of %s, %s or %s", mode, ARMMode, BicepMode, TerraformMode)
+	}
+}
@@ -0,0 +1,56 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package usecase
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetModeSettings(t *testing.T) {
+	armSettings, err := GetModeSettings(ARMMode)
+	require.NoError(t, err)
+	assert.True(t, armSettings.AutoAddReadPermissionForEachWrite)
+	assert.False(t, armSettings.AutoAddDeletePermissionForEachWrite)
+	assert.True(t, armSettings.AutoCreateResourceGroup)
+
+	bicepSettings, err := GetModeSettings("Bicep")
+	require.NoError(t, err)
+	assert.Equal(t, armSettings, bicepSettings)
+
+	terraformSettings, err := GetModeSettings(TerraformMode)
+	require.NoError(t, err)
+	assert.False(t, terraformSettings.AutoAddReadPermissionForEachWrite)
+	assert.True(t, terraformSettings.AutoAddDeletePermissionForEachWrite)
+	assert.False(t, terraformSettings.AutoCreateResourceGroup)
+	assert.Equal(t, []string{"Microsoft.Resources/deployments/read", "Microsoft.Resources/deployments/write"}, terraformSettings.PermissionsToAddToResult)
+
+	// callers append their own permissions, which must not change the settings of the mode
+	terraformSettings.InitialPermissions[0] = "Microsoft.Storage/storageAccounts/read"
+	assert.Equal(t, "Microsoft.Resources/deployments/read", terraformSettings.PermissionsToAddToResult[0])
+
+	_, err = GetModeSettings("pulumi")
+	assert.ErrorContains(t, err, `invalid mode "pulumi"`)
+}
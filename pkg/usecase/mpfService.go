@@ -24,6 +24,7 @@ package usecase
 
 import (
 	"context"
+	"slices"
 	"strings"
 	"time"
 
@@ -34,6 +35,10 @@ import (
 // RetryDeploymentResponseErrorMessage is the error message returned by a deployment authorization checker when it wants the deployment to be retried
 const RetryDeploymentResponseErrorMessage = "RetryGetDeploymentAuthorizationErrors"
 
+// CleanUpTimeout bounds the clean up of the resources created by MPF.
+// Clean up runs with its own context, so that it also runs after the run is cancelled.
+const CleanUpTimeout = 30 * time.Minute
+
 type MPFService struct {
 	ctx                                 context.Context
 	rgManager                           ResourceGroupManager
@@ -47,8 +52,34 @@ type MPFService struct {
 	autoAddDeletePermissionForEachWrite bool
 	autoCreateResourceGroup             bool
 	iterationCount                      int
+	propagationWaiter                   PropagationWaiter
+	// roleUpdatesWithoutProgress counts the consecutive role updates after which no new permissions were found
+	roleUpdatesWithoutProgress int
+	checkpointStore            CheckpointStore
+	checkpointBase             domain.MPFCheckpoint
+	journal                    IterationJournal
+	// invalidActions are the actions removed from the custom role during the run
+	invalidActions []string
+	// operationsCatalog validates and normalizes the permissions before role updates. Permissions are not validated if nil
+	operationsCatalog *domain.OperationsCatalog
+	// roleAssignmentSnapshotter and roleAssignmentSnapshotStore back up the role assignments of the service principal
+	// before they are removed. roleAssignmentSnapshot holds the role assignments to restore when cleaning up.
+	roleAssignmentSnapshotter   RoleAssignmentSnapshotter
+	roleAssignmentSnapshotStore RoleAssignmentSnapshotStore
+	roleAssignmentSnapshot      *domain.RoleAssignmentSnapshot
+	keepExistingRoleAssignments bool
+	// runLocker locks the service principal for the run. runLock is the lock held by the run.
+	// runLockLost is set once the lock could not be renewed, and another run may hold it.
+	runLocker     RunLocker
+	runLockHolder string
+	runLockTTL    time.Duration
+	runLock       *domain.RunLock
+	runLockLost   bool
 }
 
+// maxIterations is the maximum number of deployments a run attempts to find the required permissions
+const maxIterations = 50
+
 func NewMPFService(ctx context.Context, rgMgr ResourceGroupManager, spRoleAssgnMgr ServicePrincipalRolemAssignmentManager, deploymentAuthChkCln DeploymentAuthorizationCheckerCleaner, mpfConfig domain.MPFConfig, initialPermissionsToAdd []string, permissionsToAddToResult []string, autoAddReadPermissionForEachWrite bool, autoAddDeletePermissionForEachWrite bool, autoCreateResourceGroup bool) *MPFService {
 	return &MPFService{
 		ctx:                                 ctx,
@@ -62,24 +93,150 @@ func NewMPFService(ctx context.Context, rgMgr ResourceGroupManager, spRoleAssgnM
 		autoAddReadPermissionForEachWrite:   autoAddReadPermissionForEachWrite,
 		autoAddDeletePermissionForEachWrite: autoAddDeletePermissionForEachWrite,
 		autoCreateResourceGroup:             autoCreateResourceGroup,
+		propagationWaiter:                   defaultPropagationWaiter{},
+	}
+}
+
+// NewMPFServiceForMode returns the service with the permissions, auto added permissions and resource group of a deployment mode
+func NewMPFServiceForMode(ctx context.Context, rgMgr ResourceGroupManager, spRoleAssgnMgr ServicePrincipalRolemAssignmentManager, deploymentAuthChkCln DeploymentAuthorizationCheckerCleaner, mpfConfig domain.MPFConfig, modeSettings ModeSettings) *MPFService {
+	return NewMPFService(ctx, rgMgr, spRoleAssgnMgr, deploymentAuthChkCln, mpfConfig, modeSettings.InitialPermissions, modeSettings.PermissionsToAddToResult, modeSettings.AutoAddReadPermissionForEachWrite, modeSettings.AutoAddDeletePermissionForEachWrite, modeSettings.AutoCreateResourceGroup)
+}
+
+// SetPropagationWaiter sets the strategy used to wait for RBAC changes to propagate.
+// By default MPF waits 45 seconds after removing role assignments and 5 seconds after every other change.
+func (s *MPFService) SetPropagationWaiter(propagationWaiter PropagationWaiter) {
+	if propagationWaiter == nil {
+		propagationWaiter = defaultPropagationWaiter{}
+	}
+	s.propagationWaiter = propagationWaiter
+}
+
+// EnableCheckpoints saves a checkpoint to the store after every iteration.
+// The base checkpoint holds the details of the run not known to the service, such as the mode and deployment name.
+func (s *MPFService) EnableCheckpoints(checkpointStore CheckpointStore, base domain.MPFCheckpoint) {
+	s.checkpointStore = checkpointStore
+	s.checkpointBase = base
+}
+
+// ResumeFromCheckpoint seeds the service with the permissions and iteration count of an interrupted run.
+// The permissions are added to the custom role before the first deployment. The iteration count of the result
+// continues from the checkpoint, while the resumed run may run up to maxIterations iterations of its own.
+func (s *MPFService) ResumeFromCheckpoint(checkpoint domain.MPFCheckpoint) {
+	for scope, permissions := range checkpoint.RequiredPermissions {
+		s.requiredPermissions[scope] = slices.Clone(permissions)
+	}
+	s.iterationCount = checkpoint.IterationCount
+	log.Infof("Resuming from checkpoint at iteration %d with %d permissions", s.iterationCount, len(s.requiredPermissions[s.mpfConfig.SubscriptionID]))
+}
+
+// SetOperationsCatalog makes the service normalize the casing of the permissions found with the catalog,
+// and skip the read and delete permissions added for each write permission which the resource provider does not have
+func (s *MPFService) SetOperationsCatalog(operationsCatalog *domain.OperationsCatalog) {
+	s.operationsCatalog = operationsCatalog
+}
+
+// EnableJournal records an entry in the journal for every iteration
+func (s *MPFService) EnableJournal(journal IterationJournal) {
+	s.journal = journal
+}
+
+func (s *MPFService) writeJournalEntry(entry domain.MPFJournalEntry) {
+	if s.journal == nil {
+		return
+	}
+
+	entry.Timestamp = time.Now().UTC()
+
+	// a failed journal write does not fail the run
+	err := s.journal.WriteJournalEntry(entry)
+	if err != nil {
+		log.Warnf("Could not write journal entry: %v", err)
+	}
+}
+
+func (s *MPFService) writeFailedJournalEntry(entry domain.MPFJournalEntry, err error) {
+	entry.Outcome = domain.JournalFailed
+	entry.Error = err.Error()
+	s.writeJournalEntry(entry)
+}
+
+func (s *MPFService) saveCheckpoint() {
+	if s.checkpointStore == nil {
+		return
+	}
+
+	checkpoint := s.checkpointBase
+	checkpoint.Version = domain.MPFCheckpointVersion
+	checkpoint.SubscriptionID = s.mpfConfig.SubscriptionID
+	checkpoint.RoleDefinitionName = s.mpfConfig.Role.RoleDefinitionName
+	checkpoint.RoleDefinitionID = s.mpfConfig.Role.RoleDefinitionID
+	checkpoint.ResourceGroupName = s.mpfConfig.ResourceGroup.ResourceGroupName
+	checkpoint.Location = s.mpfConfig.ResourceGroup.Location
+	checkpoint.IterationCount = s.iterationCount
+	checkpoint.RequiredPermissions = s.requiredPermissions
+	checkpoint.UpdatedAt = time.Now().UTC()
+
+	// a failed checkpoint does not fail the run
+	err := s.checkpointStore.SaveCheckpoint(checkpoint)
+	if err != nil {
+		log.Warnf("Could not save checkpoint: %v", err)
+	}
+}
+
+func (s *MPFService) waitForPropagation(kind domain.RBACChangeKind, actions []string, dataActions []string) error {
+	return s.propagationWaiter.WaitForPropagation(s.ctx, domain.RBACChange{
+		Kind:           kind,
+		SubscriptionID: s.mpfConfig.SubscriptionID,
+		SPObjectID:     s.mpfConfig.SP.SPObjectID,
+		Role:           s.mpfConfig.Role,
+		Actions:        actions,
+		DataActions:    dataActions,
+		Attempt:        s.roleUpdatesWithoutProgress,
+	})
+}
+
+func (s *MPFService) addInvalidActions(invalidActions []string) {
+	for _, action := range invalidActions {
+		if !slices.Contains(s.invalidActions, action) {
+			s.invalidActions = append(s.invalidActions, action)
+		}
 	}
 }
 
 func (s *MPFService) returnMPFResult(err error) (domain.MPFResult, error) {
 	mpfResult := domain.GetMPFResultWithIterationCount(s.requiredPermissions, s.iterationCount)
+	mpfResult.InvalidActions = slices.Clone(s.invalidActions)
 
-	if err != nil && len(mpfResult.RequiredPermissions) == 0 {
+	if err != nil && len(mpfResult.RequiredPermissions) == 0 && len(mpfResult.RequiredDataActions) == 0 {
 		return domain.MPFResult{}, err
 	}
 
-	if err != nil && len(mpfResult.RequiredPermissions) > 0 {
+	if err != nil {
 		return mpfResult, err
 	}
 
 	return mpfResult, nil
 }
 
-func (s *MPFService) GetMinimumPermissionsRequired() (domain.MPFResult, error) {
+func (s *MPFService) GetMinimumPermissionsRequired() (mpfResult domain.MPFResult, err error) {
+
+	// Lock the service principal before anything is changed, so that a run which finds it locked leaves it untouched
+	if err := s.acquireRunLock(); err != nil {
+		log.Warnf("Unable to lock service principal: %v\n", err)
+		return domain.MPFResult{}, err
+	}
+
+	// Clean up also when creating the resource group fails, as the run may have been cancelled after it was created
+	defer s.CleanUpResources()
+
+	// Keep the lock while the run lasts, the renewal is stopped before cleaning up.
+	// A run which lost its lock is cancelled and returns the error which lost it.
+	stopRunLockRenewal := s.startRunLockRenewal()
+	defer func() {
+		if lockErr := stopRunLockRenewal(); lockErr != nil {
+			err = lockErr
+		}
+	}()
 
 	if s.autoCreateResourceGroup {
 		// Create Resource Group
@@ -95,40 +252,59 @@ func (s *MPFService) GetMinimumPermissionsRequired() (domain.MPFResult, error) {
 		// defer s.deploymentAuthCheckerCleaner.CleanDeployment(s.mpfConfig)
 	}
 
-	defer s.CleanUpResources()
+	waitStart := time.Now()
+	if s.keepExistingRoleAssignments {
+		log.Warnln("Keeping the existing role assignments of the service principal, the permissions they grant are missing from the result")
+	} else {
+		// Save the existing role assignments, so that they are restored when cleaning up
+		err := s.backupRoleAssignments()
+		if err != nil {
+			log.Warnf("Unable to back up Role Assignments: %v\n", err)
+			return s.returnMPFResult(err)
+		}
 
-	// Delete all existing role assignments for the service principal
-	// Pass empty role to delete ALL role assignments (not just the specific custom role)
-	err := s.spRoleAssignmentManager.DetachRolesFromSP(s.ctx, s.mpfConfig.SubscriptionID, s.mpfConfig.SP.SPObjectID, domain.Role{})
-	if err != nil {
-		log.Warnf("Unable to delete Role Assignments: %v\n", err)
-		return s.returnMPFResult(err)
-	}
-	log.Info("Deleted all existing role assignments for service principal \n")
+		// Delete all existing role assignments for the service principal
+		// Pass empty role to delete ALL role assignments (not just the specific custom role)
+		err = s.spRoleAssignmentManager.DetachRolesFromSP(s.ctx, s.mpfConfig.SubscriptionID, s.mpfConfig.SP.SPObjectID, domain.Role{})
+		if err != nil {
+			log.Warnf("Unable to delete Role Assignments: %v\n", err)
+			return s.returnMPFResult(err)
+		}
+		log.Info("Deleted all existing role assignments for service principal \n")
 
-	// Wait for Azure RBAC propagation after deleting role assignments
-	// This ensures that any previous permissions are fully revoked before starting the new test
-	log.Infoln("Waiting for Azure RBAC propagation after deleting role assignments...")
-	time.Sleep(45 * time.Second)
+		// Wait for Azure RBAC propagation after deleting role assignments
+		// This ensures that any previous permissions are fully revoked before starting the new test
+		log.Infoln("Waiting for Azure RBAC propagation after deleting role assignments...")
+		waitStart = time.Now()
+		err = s.waitForPropagation(domain.RoleAssignmentsRemoved, nil, nil)
+		if err != nil {
+			return s.returnMPFResult(err)
+		}
+	}
 
 	// Initialize new custom role
 	log.Infoln("Initializing Custom Role")
 	// err = mpf.CreateUpdateCustomRole([]string{})
 
-	err, invalidActions := s.spRoleAssignmentManager.CreateUpdateCustomRole(s.mpfConfig.SubscriptionID, s.mpfConfig.Role, s.initialPermissionsToAdd)
+	// when resuming, the permissions found by the interrupted run are added to the role upfront
+	initialActions, initialDataActions := domain.SplitActionsAndDataActions(append(slices.Clone(s.initialPermissionsToAdd), s.requiredPermissions[s.mpfConfig.SubscriptionID]...))
+	err, invalidActions := s.spRoleAssignmentManager.CreateUpdateCustomRole(s.ctx, s.mpfConfig.SubscriptionID, s.mpfConfig.Role, initialActions, initialDataActions)
 	if err != nil {
 		log.Warn(err)
 		return s.returnMPFResult(err)
 	}
 	if len(invalidActions) > 0 {
 		log.Warnf("The following invalid actions were removed from the role: %v", invalidActions)
+		s.addInvalidActions(invalidActions)
+		initialActions = removeActions(initialActions, invalidActions)
+		initialDataActions = removeActions(initialDataActions, invalidActions)
 	}
 	log.Infoln("Custom role initialized successfully")
 
 	// Assign new custom role to service principal
 	log.Infoln("Assigning new custom role to service principal")
 	// err = mpf.AssignRoleToSP()
-	err = s.spRoleAssignmentManager.AssignRoleToSP(s.mpfConfig.SubscriptionID, s.mpfConfig.SP.SPObjectID, s.mpfConfig.Role)
+	err = s.spRoleAssignmentManager.AssignRoleToSP(s.ctx, s.mpfConfig.SubscriptionID, s.mpfConfig.SP.SPObjectID, s.mpfConfig.Role)
 	if err != nil {
 		log.Warn(err)
 		return s.returnMPFResult(err)
@@ -138,20 +314,51 @@ func (s *MPFService) GetMinimumPermissionsRequired() (domain.MPFResult, error) {
 	// Wait for Azure RBAC propagation after initial role assignment
 	// Azure role assignments can take a few seconds to propagate across all authorization endpoints
 	log.Infoln("Waiting for Azure RBAC propagation after initial role assignment...")
-	time.Sleep(5 * time.Second)
+	err = s.waitForPropagation(domain.RoleAssigned, initialActions, initialDataActions)
+	if err != nil {
+		return s.returnMPFResult(err)
+	}
+
+	initialEntry := domain.MPFJournalEntry{
+		Iteration:      s.iterationCount,
+		Outcome:        domain.JournalRoleInitialized,
+		InvalidActions: invalidActions,
+		WaitSeconds:    time.Since(waitStart).Seconds(),
+	}
+	if initialPermissions := append(slices.Clone(initialActions), initialDataActions...); len(initialPermissions) > 0 {
+		initialEntry.PermissionsAdded = map[string][]string{s.mpfConfig.SubscriptionID: initialPermissions}
+	}
+	s.writeJournalEntry(initialEntry)
 
-	// Add initial permissions to requiredPermissions map
+	// Add initial permissions to requiredPermissions map, unless a resumed checkpoint already holds them
 	log.Infoln("Adding initial permissions to requiredPermissions map")
-	s.requiredPermissions[s.mpfConfig.SubscriptionID] = append(s.requiredPermissions[s.mpfConfig.SubscriptionID], s.permissionsToAddToResult...)
+	for _, permission := range s.permissionsToAddToResult {
+		if !slices.Contains(s.requiredPermissions[s.mpfConfig.SubscriptionID], permission) {
+			s.requiredPermissions[s.mpfConfig.SubscriptionID] = append(s.requiredPermissions[s.mpfConfig.SubscriptionID], permission)
+		}
+	}
 
-	maxIterations := 50
+	// the iterations are capped per run, so that a resumed run is not stopped by the iterations of the interrupted run
+	iterations := 0
 	for {
-		authErrMesg, err := s.deploymentAuthCheckerCleaner.GetDeploymentAuthorizationErrors(s.mpfConfig)
+		if err := s.ctx.Err(); err != nil {
+			log.Warnf("MPF run cancelled: %v", err)
+			return s.returnMPFResult(err)
+		}
+
+		authErrMesg, err := s.deploymentAuthCheckerCleaner.GetDeploymentAuthorizationErrors(s.ctx, s.mpfConfig)
 
 		log.Infof("Iteration Number: %d \n", s.iterationCount)
 
+		entry := domain.MPFJournalEntry{
+			Iteration:          s.iterationCount,
+			AuthorizationError: authErrMesg,
+		}
+
 		if authErrMesg == "" && err == nil {
 			log.Infoln("Authorization Successful")
+			entry.Outcome = domain.JournalSucceeded
+			s.writeJournalEntry(entry)
 			break
 		}
 
@@ -159,21 +366,27 @@ func (s *MPFService) GetMinimumPermissionsRequired() (domain.MPFResult, error) {
 
 		if err == nil && strings.Contains(authErrMesg, RetryDeploymentResponseErrorMessage) {
 			log.Warnf("received retry request from authorization checker, retrying deployment.... \n")
+			entry.Outcome = domain.JournalRetry
+			s.writeJournalEntry(entry)
 			continue
 		}
 
 		if err != nil {
 			log.Warnf("Non Authorization error received: %v \n", err)
+			s.writeFailedJournalEntry(entry, err)
 			return s.returnMPFResult(err)
 		}
 
 		log.Debugln("Deployment Authorization Error:", authErrMesg)
 
-		scpMp, err := domain.GetScopePermissionsFromAuthError(authErrMesg)
+		findings, err := domain.GetAuthorizationFindingsFromAuthError(authErrMesg)
 		if err != nil {
 			log.Warnf("Could Not Parse Deployment Authorization Error: %v \n", err)
+			s.writeFailedJournalEntry(entry, err)
 			return s.returnMPFResult(err)
 		}
+		entry.Findings = findings
+		scpMp := domain.GetScopePermissionsFromFindings(findings)
 
 		log.Infoln("Successfully Parsed Deployment Authorization Error")
 		log.Debugln("scope permissions found from deployment error:", scpMp)
@@ -183,14 +396,25 @@ func (s *MPFService) GetMinimumPermissionsRequired() (domain.MPFResult, error) {
 			for _, permission := range permissions {
 				if s.autoAddReadPermissionForEachWrite && strings.HasSuffix(permission, "/write") {
 					readPermission := strings.Replace(permission, "/write", "/read", 1)
-					scpMp[scope] = append(scpMp[scope], readPermission)
+					scpMp[scope] = s.appendAutoAddedPermission(scpMp[scope], readPermission)
 				}
 				if s.autoAddDeletePermissionForEachWrite && strings.HasSuffix(permission, "/write") {
 					deletePermission := strings.Replace(permission, "/write", "/delete", 1)
-					scpMp[scope] = append(scpMp[scope], deletePermission)
+					scpMp[scope] = s.appendAutoAddedPermission(scpMp[scope], deletePermission)
 				}
 			}
 		}
+		s.normalizePermissions(scpMp)
+
+		// if the deployment only reports permissions which were already added to the role,
+		// the previous role update has not propagated yet
+		entry.PermissionsAdded = s.getNewPermissions(scpMp)
+		if len(entry.PermissionsAdded) > 0 {
+			s.roleUpdatesWithoutProgress = 0
+		} else {
+			s.roleUpdatesWithoutProgress++
+			log.Warnf("No new permissions found, role update has potentially not propagated yet (attempt %d)", s.roleUpdatesWithoutProgress)
+		}
 
 		log.Infoln("Adding mising scopes/permissions to final result map...")
 		for k, v := range scpMp {
@@ -203,27 +427,49 @@ func (s *MPFService) GetMinimumPermissionsRequired() (domain.MPFResult, error) {
 		log.Debugln("Number of Permissions added to role:", len(s.requiredPermissions[s.mpfConfig.SubscriptionID]))
 
 		permissionsIncludingInitialPermissions := append(s.initialPermissionsToAdd, s.requiredPermissions[s.mpfConfig.SubscriptionID]...)
-		err, invalidActions := s.spRoleAssignmentManager.CreateUpdateCustomRole(s.mpfConfig.SubscriptionID, s.mpfConfig.Role, permissionsIncludingInitialPermissions)
+		// data plane permissions have to be added to the dataActions of the role
+		actions, dataActions := domain.SplitActionsAndDataActions(permissionsIncludingInitialPermissions)
+		if len(dataActions) > 0 {
+			log.Debugln("Data actions added to role:", dataActions)
+		}
+		err, invalidActions := s.spRoleAssignmentManager.CreateUpdateCustomRole(s.ctx, s.mpfConfig.SubscriptionID, s.mpfConfig.Role, actions, dataActions)
 
 		// err = s.spRoleAssignmentManager.CreateUpdateCustomRole(s.mpfConfig.SubscriptionID, s.mpfConfig.ResourceGroup.ResourceGroupName, s.mpfConfig.Role, s.requiredPermissions[s.mpfConfig.ResourceGroup.ResourceGroupResourceID])
 
 		if err != nil {
 			log.Infoln("Error when adding permission/scope to role: \n", err)
 			log.Warn(err)
+			s.writeFailedJournalEntry(entry, err)
 			return s.returnMPFResult(err)
 		}
 		if len(invalidActions) > 0 {
 			log.Warnf("The following invalid actions were removed from the role during iteration: %v", invalidActions)
+			s.addInvalidActions(invalidActions)
+			// the role does not contain the invalid actions, so they are not waited for
+			actions = removeActions(actions, invalidActions)
+			dataActions = removeActions(dataActions, invalidActions)
 		}
+		entry.InvalidActions = invalidActions
 		log.Infoln("Permission/scope added to role successfully")
 
 		// Wait for Azure RBAC propagation before retrying deployment
 		// Azure role definition updates can take a few seconds to propagate across all authorization endpoints
 		log.Infoln("Waiting for Azure RBAC propagation...")
-		time.Sleep(5 * time.Second)
+		waitStart := time.Now()
+		err = s.waitForPropagation(domain.RoleUpdated, actions, dataActions)
+		entry.WaitSeconds = time.Since(waitStart).Seconds()
+		if err != nil {
+			s.writeFailedJournalEntry(entry, err)
+			return s.returnMPFResult(err)
+		}
+
+		entry.Outcome = domain.JournalAuthorizationErrors
+		s.writeJournalEntry(entry)
 
 		s.iterationCount++
-		if s.iterationCount == maxIterations {
+		iterations++
+		s.saveCheckpoint()
+		if iterations >= maxIterations {
 			log.Warnln("max iterations for fetching authorization errors reached, exiting...")
 			return s.returnMPFResult(err)
 		}
@@ -233,37 +479,96 @@ func (s *MPFService) GetMinimumPermissionsRequired() (domain.MPFResult, error) {
 
 }
 
+// appendAutoAddedPermission appends a permission added for a write permission,
+// unless the operations catalog knows that the resource provider has no such operation
+func (s *MPFService) appendAutoAddedPermission(permissions []string, permission string) []string {
+	if s.operationsCatalog != nil && s.operationsCatalog.IsInvalidAction(permission) {
+		log.Infof("Not adding %s, the resource provider has no such operation", permission)
+		return permissions
+	}
+	return append(permissions, permission)
+}
+
+// normalizePermissions changes the permissions found to the casing of the operations catalog,
+// as authorization errors may report them in lower case
+func (s *MPFService) normalizePermissions(scpMp map[string][]string) {
+	if s.operationsCatalog == nil {
+		return
+	}
+	for scope, permissions := range scpMp {
+		for i, permission := range permissions {
+			scpMp[scope][i] = s.operationsCatalog.NormalizeAction(permission)
+		}
+	}
+}
+
+// getNewPermissions returns the permissions per scope in scpMp which were not added to the custom role yet
+func (s *MPFService) getNewPermissions(scpMp map[string][]string) map[string][]string {
+	newPermissions := make(map[string][]string)
+	for scope, permissions := range scpMp {
+		for _, permission := range permissions {
+			if slices.Contains(s.requiredPermissions[s.mpfConfig.SubscriptionID], permission) || slices.Contains(s.initialPermissionsToAdd, permission) {
+				continue
+			}
+			if !slices.Contains(newPermissions[scope], permission) {
+				newPermissions[scope] = append(newPermissions[scope], permission)
+			}
+		}
+	}
+	return newPermissions
+}
+
+// removeActions returns the actions without the actions to remove
+func removeActions(actions []string, actionsToRemove []string) []string {
+	return slices.DeleteFunc(slices.Clone(actions), func(action string) bool {
+		return slices.Contains(actionsToRemove, action)
+	})
+}
+
 func (s *MPFService) CleanUpResources() {
 	log.Infoln("Cleaning up resources...")
 	log.Infoln("*************************")
 
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(s.ctx), CleanUpTimeout)
+	defer cancel()
+
 	// Cancel deployment. Even if cancelling deployment fails attempt to delete other resources
 	// _ = m.CancelDeployment(deploymentName)
 
-	err := s.deploymentAuthCheckerCleaner.CleanDeployment(s.mpfConfig)
+	err := s.deploymentAuthCheckerCleaner.CleanDeployment(ctx, s.mpfConfig)
 	if err != nil {
 		log.Warnln("Cleaning up deployment returned an error, attempting to clean rest of the resources")
 	}
 
-	// Detach Roles from SP
-	err = s.spRoleAssignmentManager.DetachRolesFromSP(s.ctx, s.mpfConfig.SubscriptionID, s.mpfConfig.SP.SPObjectID, s.mpfConfig.Role)
-	if err != nil {
-		log.Warnf("Could not detach roles from SP: %s\n", err)
-	}
+	if s.runLockLost {
+		// the role assignments of the service principal belong to the run which took over the lock
+		s.logRoleAssignmentsLeftAfterLostRunLock()
+	} else {
+		// Detach Roles from SP
+		err = s.spRoleAssignmentManager.DetachRolesFromSP(ctx, s.mpfConfig.SubscriptionID, s.mpfConfig.SP.SPObjectID, s.mpfConfig.Role)
+		if err != nil {
+			log.Warnf("Could not detach roles from SP: %s\n", err)
+		}
 
-	// Delete Custom Role
-	err = s.spRoleAssignmentManager.DeleteCustomRole(s.mpfConfig.SubscriptionID, s.mpfConfig.Role)
-	if err != nil {
-		log.Warnf("Could not delete custom role: %s\n", err)
+		// Delete Custom Role
+		err = s.spRoleAssignmentManager.DeleteCustomRole(ctx, s.mpfConfig.SubscriptionID, s.mpfConfig.Role)
+		if err != nil {
+			log.Warnf("Could not delete custom role: %s\n", err)
+		}
+
+		// Restore the role assignments the service principal had before the run
+		s.restoreRoleAssignments(ctx)
 	}
 
 	// Delete Resource Group
 	if s.autoCreateResourceGroup {
-		err = s.rgManager.DeleteResourceGroup(s.ctx, s.mpfConfig.ResourceGroup.ResourceGroupName)
+		err = s.rgManager.DeleteResourceGroup(ctx, s.mpfConfig.ResourceGroup.ResourceGroupName)
 		if err != nil {
 			log.Warnf("Error when deleting resource group: %s \n", err)
 		}
 		log.Infoln("Resource group deletion initiated successfully...")
 	}
 
+	// Release the lock last, once the role assignments of the service principal are restored
+	s.releaseRunLock(ctx)
 }
@@ -0,0 +1,383 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/Azure/mpf/pkg/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeRGManager struct {
+	deleted []string
+}
+
+func (f *fakeRGManager) CreateResourceGroup(ctx context.Context, rgName, location string) error {
+	return nil
+}
+
+func (f *fakeRGManager) DeleteResourceGroup(ctx context.Context, rgName string) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	f.deleted = append(f.deleted, rgName)
+	return nil
+}
+
+type fakeSPRoleAssignmentManager struct {
+	roleDeleted bool
+	// events records the removal of all role assignments of the SP
+	events *[]string
+	// roleUpdates holds the actions of every role update
+	roleUpdates [][]string
+	// invalidActions are rejected by role updates
+	invalidActions []string
+}
+
+func (f *fakeSPRoleAssignmentManager) DetachRolesFromSP(ctx context.Context, subscription string, SPOBjectID string, role domain.Role) error {
+	if f.events != nil && role.RoleDefinitionResourceID == "" {
+		*f.events = append(*f.events, "detach all")
+	}
+	return nil
+}
+
+func (f *fakeSPRoleAssignmentManager) AssignRoleToSP(ctx context.Context, subscription string, SPOBjectID string, role domain.Role) error {
+	return nil
+}
+
+func (f *fakeSPRoleAssignmentManager) CreateUpdateCustomRole(ctx context.Context, subscription string, role domain.Role, permissions []string, dataActions []string) (error, []string) { //nolint:staticcheck
+	f.roleUpdates = append(f.roleUpdates, permissions)
+	var invalidActions []string
+	for _, permission := range permissions {
+		if slices.Contains(f.invalidActions, permission) {
+			invalidActions = append(invalidActions, permission)
+		}
+	}
+	return nil, invalidActions
+}
+
+func (f *fakeSPRoleAssignmentManager) DeleteCustomRole(ctx context.Context, subscription string, role domain.Role) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	f.roleDeleted = true
+	return nil
+}
+
+// cancellingChecker cancels the run when the deployment is first attempted
+type cancellingChecker struct {
+	cancel      context.CancelFunc
+	deployments int
+	cleaned     bool
+}
+
+func (c *cancellingChecker) GetDeploymentAuthorizationErrors(ctx context.Context, mpfConfig domain.MPFConfig) (string, error) {
+	c.deployments++
+	c.cancel()
+	return "", ctx.Err()
+}
+
+func (c *cancellingChecker) CleanDeployment(ctx context.Context, mpfConfig domain.MPFConfig) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	c.cleaned = true
+	return nil
+}
+
+type noWaitPropagationWaiter struct{}
+
+func (noWaitPropagationWaiter) WaitForPropagation(ctx context.Context, change domain.RBACChange) error {
+	return ctx.Err()
+}
+
+func TestGetMinimumPermissionsRequiredCleansUpWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	rgManager := &fakeRGManager{}
+	spRoleAssignmentManager := &fakeSPRoleAssignmentManager{}
+	checker := &cancellingChecker{cancel: cancel}
+	mpfConfig := domain.MPFConfig{
+		SubscriptionID: "SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS",
+		ResourceGroup:  domain.ResourceGroup{ResourceGroupName: "testdeployrg-abc"},
+	}
+
+	mpfService := NewMPFService(ctx, rgManager, spRoleAssignmentManager, checker, mpfConfig, nil, nil, true, false, true)
+	mpfService.SetPropagationWaiter(noWaitPropagationWaiter{})
+
+	_, err := mpfService.GetMinimumPermissionsRequired()
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, checker.deployments)
+
+	// clean up runs with its own context after the run was cancelled
+	assert.True(t, checker.cleaned)
+	assert.True(t, spRoleAssignmentManager.roleDeleted)
+	assert.Equal(t, []string{"testdeployrg-abc"}, rgManager.deleted)
+}
+
+func TestGetMinimumPermissionsRequiredNotStartedWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	checker := &cancellingChecker{cancel: cancel}
+	mpfService := NewMPFService(ctx, &fakeRGManager{}, &fakeSPRoleAssignmentManager{}, checker, domain.MPFConfig{}, nil, nil, false, false, false)
+
+	_, err := mpfService.GetMinimumPermissionsRequired()
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 0, checker.deployments)
+	assert.True(t, checker.cleaned)
+}
+
+// sequenceChecker returns the authorization errors in order, then succeeds
+type sequenceChecker struct {
+	authErrors []string
+}
+
+func (c *sequenceChecker) GetDeploymentAuthorizationErrors(ctx context.Context, mpfConfig domain.MPFConfig) (string, error) {
+	if len(c.authErrors) == 0 {
+		return "", nil
+	}
+	authError := c.authErrors[0]
+	c.authErrors = c.authErrors[1:]
+	return authError, nil
+}
+
+func (c *sequenceChecker) CleanDeployment(ctx context.Context, mpfConfig domain.MPFConfig) error {
+	return nil
+}
+
+type memoryCheckpointStore struct {
+	checkpoints []domain.MPFCheckpoint
+}
+
+func (m *memoryCheckpointStore) SaveCheckpoint(checkpoint domain.MPFCheckpoint) error {
+	checkpoint.RequiredPermissions = maps.Clone(checkpoint.RequiredPermissions)
+	m.checkpoints = append(m.checkpoints, checkpoint)
+	return nil
+}
+
+func getTestAuthorizationFailedError(action string) string {
+	return fmt.Sprintf("{\"error\":{\"code\":\"AuthorizationFailed\",\"message\":\"The client 'XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX' with object id 'XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX' does not have authorization to perform action '%s' over scope '/subscriptions/SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS/resourcegroups/testdeployrg/providers/Microsoft.Storage/storageAccounts/sa' or the scope is invalid. If access was recently granted, please refresh your credentials.\"}}", action)
+}
+
+func TestGetMinimumPermissionsRequiredSavesCheckpoints(t *testing.T) {
+	checker := &sequenceChecker{authErrors: []string{
+		getTestAuthorizationFailedError("Microsoft.Storage/storageAccounts/write"),
+		getTestAuthorizationFailedError("Microsoft.Storage/storageAccounts/listKeys/action"),
+	}}
+	store := &memoryCheckpointStore{}
+	mpfConfig := domain.MPFConfig{SubscriptionID: "SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS"}
+
+	mpfService := NewMPFService(t.Context(), &fakeRGManager{}, &fakeSPRoleAssignmentManager{}, checker, mpfConfig, nil, nil, false, false, false)
+	mpfService.SetPropagationWaiter(noWaitPropagationWaiter{})
+	mpfService.EnableCheckpoints(store, domain.MPFCheckpoint{Mode: "arm", DeploymentName: "testDeploy-abc"})
+
+	_, err := mpfService.GetMinimumPermissionsRequired()
+	assert.NoError(t, err)
+	assert.Len(t, store.checkpoints, 2)
+
+	last := store.checkpoints[1]
+	assert.Equal(t, domain.MPFCheckpointVersion, last.Version)
+	assert.Equal(t, "arm", last.Mode)
+	assert.Equal(t, "testDeploy-abc", last.DeploymentName)
+	assert.Equal(t, 2, last.IterationCount)
+	assert.ElementsMatch(t, []string{"Microsoft.Storage/storageAccounts/write", "Microsoft.Storage/storageAccounts/listKeys/action"}, last.RequiredPermissions[mpfConfig.SubscriptionID])
+}
+
+func TestGetMinimumPermissionsRequiredResumesFromCheckpoint(t *testing.T) {
+	checker := &sequenceChecker{authErrors: []string{
+		getTestAuthorizationFailedError("Microsoft.Storage/storageAccounts/listKeys/action"),
+	}}
+	spRoleAssignmentManager := &fakeSPRoleAssignmentManager{}
+	mpfConfig := domain.MPFConfig{SubscriptionID: "SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS"}
+
+	mpfService := NewMPFService(t.Context(), &fakeRGManager{}, spRoleAssignmentManager, checker, mpfConfig, []string{"Microsoft.Resources/deployments/*"}, nil, false, false, false)
+	mpfService.SetPropagationWaiter(noWaitPropagationWaiter{})
+	mpfService.ResumeFromCheckpoint(domain.MPFCheckpoint{
+		IterationCount: 5,
+		RequiredPermissions: map[string][]string{
+			mpfConfig.SubscriptionID: {"Microsoft.Storage/storageAccounts/write"},
+		},
+	})
+
+	mpfResult, err := mpfService.GetMinimumPermissionsRequired()
+	assert.NoError(t, err)
+	assert.Equal(t, 6, mpfResult.IterationCount)
+	assert.Equal(t, []string{"Microsoft.Storage/storageAccounts/listKeys/action", "Microsoft.Storage/storageAccounts/write"}, mpfResult.RequiredPermissions[mpfConfig.SubscriptionID])
+
+	// the permissions of the interrupted run are added to the role before the first deployment
+	assert.Equal(t, []string{"Microsoft.Resources/deployments/*", "Microsoft.Storage/storageAccounts/write"}, spRoleAssignmentManager.roleUpdates[0])
+}
+
+func TestGetMinimumPermissionsRequiredResumesWithItsOwnIterations(t *testing.T) {
+	checker := &sequenceChecker{authErrors: []string{
+		getTestAuthorizationFailedError("Microsoft.Storage/storageAccounts/listKeys/action"),
+		getTestAuthorizationFailedError("Microsoft.Storage/storageAccounts/read"),
+	}}
+	store := &memoryCheckpointStore{}
+	mpfConfig := domain.MPFConfig{SubscriptionID: "SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS"}
+
+	mpfService := NewMPFService(t.Context(), &fakeRGManager{}, &fakeSPRoleAssignmentManager{}, checker, mpfConfig, nil, []string{"Microsoft.Resources/deployments/read"}, false, false, false)
+	mpfService.SetPropagationWaiter(noWaitPropagationWaiter{})
+	mpfService.EnableCheckpoints(store, domain.MPFCheckpoint{})
+	// the interrupted run was stopped one iteration short of the maximum
+	mpfService.ResumeFromCheckpoint(domain.MPFCheckpoint{
+		IterationCount: maxIterations - 1,
+		RequiredPermissions: map[string][]string{
+			mpfConfig.SubscriptionID: {"Microsoft.Resources/deployments/read", "Microsoft.Storage/storageAccounts/write"},
+		},
+	})
+
+	mpfResult, err := mpfService.GetMinimumPermissionsRequired()
+	assert.NoError(t, err)
+	assert.Equal(t, maxIterations+1, mpfResult.IterationCount)
+	assert.ElementsMatch(t, []string{
+		"Microsoft.Resources/deployments/read",
+		"Microsoft.Storage/storageAccounts/listKeys/action",
+		"Microsoft.Storage/storageAccounts/read",
+		"Microsoft.Storage/storageAccounts/write",
+	}, mpfResult.RequiredPermissions[mpfConfig.SubscriptionID])
+
+	// the permissions of the checkpoint are seeded once
+	last := store.checkpoints[len(store.checkpoints)-1]
+	assert.Equal(t, []string{
+		"Microsoft.Resources/deployments/read",
+		"Microsoft.Storage/storageAccounts/write",
+		"Microsoft.Storage/storageAccounts/listKeys/action",
+		"Microsoft.Storage/storageAccounts/read",
+	}, last.RequiredPermissions[mpfConfig.SubscriptionID])
+}
+
+type memoryJournal struct {
+	entries []domain.MPFJournalEntry
+}
+
+func (m *memoryJournal) WriteJournalEntry(entry domain.MPFJournalEntry) error {
+	m.entries = append(m.entries, entry)
+	return nil
+}
+
+func TestGetMinimumPermissionsRequiredWritesJournal(t *testing.T) {
+	writeError := getTestAuthorizationFailedError("Microsoft.Storage/storageAccounts/write")
+	checker := &sequenceChecker{authErrors: []string{writeError, writeError}}
+	journal := &memoryJournal{}
+	mpfConfig := domain.MPFConfig{SubscriptionID: "SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS"}
+	storageAccountScope := "/subscriptions/SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS/resourcegroups/testdeployrg/providers/Microsoft.Storage/storageAccounts/sa"
+
+	mpfService := NewMPFService(t.Context(), &fakeRGManager{}, &fakeSPRoleAssignmentManager{}, checker, mpfConfig, []string{"Microsoft.Resources/deployments/*"}, nil, true, false, false)
+	mpfService.SetPropagationWaiter(noWaitPropagationWaiter{})
+	mpfService.EnableJournal(journal)
+
+	_, err := mpfService.GetMinimumPermissionsRequired()
+	assert.NoError(t, err)
+	assert.Len(t, journal.entries, 4)
+
+	initialEntry := journal.entries[0]
+	assert.Equal(t, domain.JournalRoleInitialized, initialEntry.Outcome)
+	assert.Equal(t, map[string][]string{mpfConfig.SubscriptionID: {"Microsoft.Resources/deployments/*"}}, initialEntry.PermissionsAdded)
+
+	firstIteration := journal.entries[1]
+	assert.Equal(t, domain.JournalAuthorizationErrors, firstIteration.Outcome)
+	assert.Equal(t, 0, firstIteration.Iteration)
+	assert.Equal(t, writeError, firstIteration.AuthorizationError)
+	assert.Len(t, firstIteration.Findings, 1)
+	assert.Equal(t, "Microsoft.Storage/storageAccounts/write", firstIteration.Findings[0].Action)
+	assert.Equal(t, map[string][]string{storageAccountScope: {"Microsoft.Storage/storageAccounts/write", "Microsoft.Storage/storageAccounts/read"}}, firstIteration.PermissionsAdded)
+	assert.False(t, firstIteration.Timestamp.IsZero())
+
+	// the repeated error did not add any permissions
+	secondIteration := journal.entries[2]
+	assert.Equal(t, domain.JournalAuthorizationErrors, secondIteration.Outcome)
+	assert.Equal(t, 1, secondIteration.Iteration)
+	assert.Empty(t, secondIteration.PermissionsAdded)
+
+	assert.Equal(t, domain.JournalSucceeded, journal.entries[3].Outcome)
+	assert.Equal(t, 2, journal.entries[3].Iteration)
+}
+
+func TestGetMinimumPermissionsRequiredJournalsFailedIteration(t *testing.T) {
+	checker := &sequenceChecker{authErrors: []string{"InvalidTemplate: the template is not valid"}}
+	journal := &memoryJournal{}
+	mpfConfig := domain.MPFConfig{SubscriptionID: "SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS"}
+
+	mpfService := NewMPFService(t.Context(), &fakeRGManager{}, &fakeSPRoleAssignmentManager{}, checker, mpfConfig, nil, nil, false, false, false)
+	mpfService.SetPropagationWaiter(noWaitPropagationWaiter{})
+	mpfService.EnableJournal(journal)
+
+	_, err := mpfService.GetMinimumPermissionsRequired()
+	assert.Error(t, err)
+
+	lastEntry := journal.entries[len(journal.entries)-1]
+	assert.Equal(t, domain.JournalFailed, lastEntry.Outcome)
+	assert.Equal(t, "InvalidTemplate: the template is not valid", lastEntry.AuthorizationError)
+	assert.Equal(t, err.Error(), lastEntry.Error)
+}
+
+func TestGetMinimumPermissionsRequiredReportsInvalidActions(t *testing.T) {
+	checker := &sequenceChecker{authErrors: []string{
+		getTestAuthorizationFailedError("Microsoft.Storage/storageAccounts/write"),
+	}}
+	spRoleAssignmentManager := &fakeSPRoleAssignmentManager{invalidActions: []string{"Microsoft.Storage/storageAccounts/read", "Microsoft.Foo/bars/read"}}
+	mpfConfig := domain.MPFConfig{SubscriptionID: "SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS"}
+
+	mpfService := NewMPFService(t.Context(), &fakeRGManager{}, spRoleAssignmentManager, checker, mpfConfig, []string{"Microsoft.Foo/bars/read"}, nil, true, false, false)
+	mpfService.SetPropagationWaiter(noWaitPropagationWaiter{})
+
+	mpfResult, err := mpfService.GetMinimumPermissionsRequired()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Microsoft.Foo/bars/read", "Microsoft.Storage/storageAccounts/read"}, mpfResult.InvalidActions)
+}
+
+func TestGetMinimumPermissionsRequiredValidatesWithOperationsCatalog(t *testing.T) {
+	checker := &sequenceChecker{authErrors: []string{
+		getTestAuthorizationFailedError("microsoft.storage/storageaccounts/write"),
+		getTestAuthorizationFailedError("Microsoft.KeyVault/vaults/accessPolicies/write"),
+	}}
+	mpfConfig := domain.MPFConfig{SubscriptionID: "SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS"}
+	catalog := domain.NewOperationsCatalog([]string{
+		"Microsoft.Storage/storageAccounts/read",
+		"Microsoft.Storage/storageAccounts/write",
+		"Microsoft.Storage/storageAccounts/delete",
+		"Microsoft.KeyVault/vaults/accessPolicies/write",
+	})
+
+	mpfService := NewMPFService(t.Context(), &fakeRGManager{}, &fakeSPRoleAssignmentManager{}, checker, mpfConfig, nil, nil, true, true, false)
+	mpfService.SetPropagationWaiter(noWaitPropagationWaiter{})
+	mpfService.SetOperationsCatalog(catalog)
+
+	mpfResult, err := mpfService.GetMinimumPermissionsRequired()
+	assert.NoError(t, err)
+	// the casing of the catalog is used, and the read and delete operations missing for access policies are not added
+	assert.Equal(t, []string{
+		"Microsoft.KeyVault/vaults/accessPolicies/write",
+		"Microsoft.Storage/storageAccounts/delete",
+		"Microsoft.Storage/storageAccounts/read",
+		"Microsoft.Storage/storageAccounts/write",
+	}, mpfResult.RequiredPermissions[mpfConfig.SubscriptionID])
+}
@@ -0,0 +1,145 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Azure/mpf/pkg/domain"
+	log "github.com/sirupsen/logrus"
+)
+
+// OrphanedRoleManager finds and deletes the custom roles left behind by MPF runs, and their role assignments
+type OrphanedRoleManager interface {
+	ListCustomRoles(ctx context.Context, subscription string) ([]domain.CustomRole, error)
+	// ListRoleAssignments returns all role assignments of the subscription if SPObjectID is empty
+	ListRoleAssignments(ctx context.Context, subscription string, SPObjectID string) ([]domain.RoleAssignment, error)
+	DeleteRoleAssignment(ctx context.Context, assignment domain.RoleAssignment) error
+	DeleteCustomRole(ctx context.Context, subscription string, role domain.Role) error
+}
+
+// OrphanedResourceGroupManager finds and deletes the resource groups left behind by MPF runs
+type OrphanedResourceGroupManager interface {
+	ListResourceGroups(ctx context.Context) ([]domain.TaggedResourceGroup, error)
+	DeleteResourceGroup(ctx context.Context, rgName string) error
+}
+
+// OrphanedResourceCleaner cleans up the custom roles, role assignments and resource groups left behind by MPF runs
+// which crashed or were killed before cleaning up
+type OrphanedResourceCleaner struct {
+	roleManager    OrphanedRoleManager
+	rgManager      OrphanedResourceGroupManager
+	subscriptionID string
+}
+
+func NewOrphanedResourceCleaner(roleManager OrphanedRoleManager, rgManager OrphanedResourceGroupManager, subscriptionID string) *OrphanedResourceCleaner {
+	return &OrphanedResourceCleaner{
+		roleManager:    roleManager,
+		rgManager:      rgManager,
+		subscriptionID: subscriptionID,
+	}
+}
+
+// FindOrphanedResources returns the custom roles, with their role assignments, and the resource groups matching the filter
+func (c *OrphanedResourceCleaner) FindOrphanedResources(ctx context.Context, filter domain.OrphanedResourceFilter) (domain.OrphanedResources, error) {
+	var orphans domain.OrphanedResources
+	now := time.Now()
+
+	roles, err := c.roleManager.ListCustomRoles(ctx, c.subscriptionID)
+	if err != nil {
+		return orphans, fmt.Errorf("could not list custom roles: %w", err)
+	}
+	for _, role := range roles {
+		if filter.MatchesRole(role, now) {
+			orphans.Roles = append(orphans.Roles, domain.OrphanedRole{Role: role})
+		}
+	}
+
+	if len(orphans.Roles) > 0 {
+		assignments, err := c.roleManager.ListRoleAssignments(ctx, c.subscriptionID, "")
+		if err != nil {
+			return orphans, fmt.Errorf("could not list role assignments: %w", err)
+		}
+		for i, orphanedRole := range orphans.Roles {
+			for _, assignment := range assignments {
+				if strings.EqualFold(assignment.RoleDefinitionID, orphanedRole.Role.RoleDefinitionResourceID) {
+					orphans.Roles[i].RoleAssignments = append(orphans.Roles[i].RoleAssignments, assignment)
+				}
+			}
+		}
+	}
+
+	groups, err := c.rgManager.ListResourceGroups(ctx)
+	if err != nil {
+		return orphans, fmt.Errorf("could not list resource groups: %w", err)
+	}
+	for _, group := range groups {
+		if filter.MatchesResourceGroup(group, now) {
+			orphans.ResourceGroups = append(orphans.ResourceGroups, group)
+		}
+	}
+
+	return orphans, nil
+}
+
+// CleanUpOrphanedResources deletes the role assignments before their custom roles, and the resource groups.
+// All resources are attempted, and the returned error lists those which could not be deleted.
+func (c *OrphanedResourceCleaner) CleanUpOrphanedResources(ctx context.Context, orphans domain.OrphanedResources) error {
+	var errs []error
+
+	for _, orphanedRole := range orphans.Roles {
+		roleAssignmentsDeleted := true
+		for _, assignment := range orphanedRole.RoleAssignments {
+			if err := c.roleManager.DeleteRoleAssignment(ctx, assignment); err != nil {
+				errs = append(errs, fmt.Errorf("could not delete role assignment %s of role %s: %w", assignment.Name, orphanedRole.Role.RoleDefinitionName, err))
+				roleAssignmentsDeleted = false
+				continue
+			}
+			log.Infof("Deleted role assignment %s of role %s at scope %s", assignment.Name, orphanedRole.Role.RoleDefinitionName, assignment.Scope)
+		}
+		// a role cannot be deleted while it is assigned
+		if !roleAssignmentsDeleted {
+			continue
+		}
+
+		if err := c.roleManager.DeleteCustomRole(ctx, c.subscriptionID, orphanedRole.Role.Role); err != nil {
+			errs = append(errs, fmt.Errorf("could not delete role %s: %w", orphanedRole.Role.RoleDefinitionName, err))
+			continue
+		}
+		log.Infof("Deleted role %s", orphanedRole.Role.RoleDefinitionName)
+	}
+
+	for _, group := range orphans.ResourceGroups {
+		if err := c.rgManager.DeleteResourceGroup(ctx, group.ResourceGroupName); err != nil {
+			errs = append(errs, fmt.Errorf("could not delete resource group %s: %w", group.ResourceGroupName, err))
+			continue
+		}
+		log.Infof("Deletion of resource group %s initiated", group.ResourceGroupName)
+	}
+
+	return errors.Join(errs...)
+}
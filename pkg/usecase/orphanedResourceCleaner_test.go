@@ -0,0 +1,161 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Azure/mpf/pkg/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeOrphanedRoleManager struct {
+	events              *[]string
+	roles               []domain.CustomRole
+	assignments         []domain.RoleAssignment
+	deleteAssignmentErr error
+}
+
+func (f *fakeOrphanedRoleManager) ListCustomRoles(ctx context.Context, subscription string) ([]domain.CustomRole, error) {
+	return f.roles, nil
+}
+
+func (f *fakeOrphanedRoleManager) ListRoleAssignments(ctx context.Context, subscription string, SPObjectID string) ([]domain.RoleAssignment, error) {
+	return f.assignments, nil
+}
+
+func (f *fakeOrphanedRoleManager) DeleteRoleAssignment(ctx context.Context, assignment domain.RoleAssignment) error {
+	if f.deleteAssignmentErr != nil {
+		return f.deleteAssignmentErr
+	}
+	*f.events = append(*f.events, "delete assignment "+assignment.Name)
+	return nil
+}
+
+func (f *fakeOrphanedRoleManager) DeleteCustomRole(ctx context.Context, subscription string, role domain.Role) error {
+	*f.events = append(*f.events, "delete role "+role.RoleDefinitionName)
+	return nil
+}
+
+type fakeOrphanedResourceGroupManager struct {
+	events *[]string
+	groups []domain.TaggedResourceGroup
+}
+
+func (f *fakeOrphanedResourceGroupManager) ListResourceGroups(ctx context.Context) ([]domain.TaggedResourceGroup, error) {
+	return f.groups, nil
+}
+
+func (f *fakeOrphanedResourceGroupManager) DeleteResourceGroup(ctx context.Context, rgName string) error {
+	*f.events = append(*f.events, "delete resource group "+rgName)
+	return nil
+}
+
+func getTestCustomRole(name string, createdOn time.Time) domain.CustomRole {
+	return domain.CustomRole{
+		Role: domain.Role{
+			RoleDefinitionID:         name,
+			RoleDefinitionName:       name,
+			RoleDefinitionResourceID: "/subscriptions/" + testSubscriptionID + "/providers/Microsoft.Authorization/roleDefinitions/" + name,
+		},
+		CreatedOn: createdOn,
+	}
+}
+
+func newTestOrphanedResourceCleaner(events *[]string) (*OrphanedResourceCleaner, *fakeOrphanedRoleManager) {
+	now := time.Now()
+	roleManager := &fakeOrphanedRoleManager{
+		events: events,
+		roles: []domain.CustomRole{
+			getTestCustomRole("tmp-rol-aaaaaaa", now.Add(-48*time.Hour)),
+			// the role of a run in progress
+			getTestCustomRole("tmp-rol-bbbbbbb", now.Add(-time.Minute)),
+			getTestCustomRole("Custom Deployer", now.Add(-48*time.Hour)),
+		},
+		assignments: []domain.RoleAssignment{
+			getTestRoleAssignment("orphaned", "/subscriptions/"+testSubscriptionID+"/providers/Microsoft.Authorization/roleDefinitions/TMP-ROL-AAAAAAA"),
+			getTestRoleAssignment("in-progress", "/subscriptions/"+testSubscriptionID+"/providers/Microsoft.Authorization/roleDefinitions/tmp-rol-bbbbbbb"),
+			getTestRoleAssignment("reader", "/providers/Microsoft.Authorization/roleDefinitions/reader"),
+		},
+	}
+	rgManager := &fakeOrphanedResourceGroupManager{
+		events: events,
+		groups: []domain.TaggedResourceGroup{
+			{ResourceGroup: domain.ResourceGroup{ResourceGroupName: "testdeployrg-aaaaaaa"}, Tags: domain.GetMPFResourceGroupTags(now.Add(-48 * time.Hour))},
+			{ResourceGroup: domain.ResourceGroup{ResourceGroupName: "testdeployrg-bbbbbbb"}, Tags: domain.GetMPFResourceGroupTags(now)},
+			{ResourceGroup: domain.ResourceGroup{ResourceGroupName: "production"}},
+		},
+	}
+	return NewOrphanedResourceCleaner(roleManager, rgManager, testSubscriptionID), roleManager
+}
+
+var testOrphanedResourceFilter = domain.OrphanedResourceFilter{
+	RoleNamePrefix:            domain.MPFRoleNamePrefix,
+	ResourceGroupNamePrefixes: []string{"testdeployrg"},
+	OlderThan:                 time.Hour,
+}
+
+func TestFindOrphanedResources(t *testing.T) {
+	var events []string
+	cleaner, _ := newTestOrphanedResourceCleaner(&events)
+
+	orphans, err := cleaner.FindOrphanedResources(t.Context(), testOrphanedResourceFilter)
+	require.NoError(t, err)
+
+	require.Len(t, orphans.Roles, 1)
+	assert.Equal(t, "tmp-rol-aaaaaaa", orphans.Roles[0].Role.RoleDefinitionName)
+	require.Len(t, orphans.Roles[0].RoleAssignments, 1)
+	assert.Equal(t, "orphaned", orphans.Roles[0].RoleAssignments[0].Name)
+	require.Len(t, orphans.ResourceGroups, 1)
+	assert.Equal(t, "testdeployrg-aaaaaaa", orphans.ResourceGroups[0].ResourceGroupName)
+	assert.Empty(t, events)
+}
+
+func TestCleanUpOrphanedResources(t *testing.T) {
+	var events []string
+	cleaner, _ := newTestOrphanedResourceCleaner(&events)
+	orphans, err := cleaner.FindOrphanedResources(t.Context(), testOrphanedResourceFilter)
+	require.NoError(t, err)
+
+	require.NoError(t, cleaner.CleanUpOrphanedResources(t.Context(), orphans))
+
+	assert.Equal(t, []string{"delete assignment orphaned", "delete role tmp-rol-aaaaaaa", "delete resource group testdeployrg-aaaaaaa"}, events)
+}
+
+func TestCleanUpOrphanedResourcesKeepsRoleWhoseAssignmentsRemain(t *testing.T) {
+	var events []string
+	cleaner, roleManager := newTestOrphanedResourceCleaner(&events)
+	orphans, err := cleaner.FindOrphanedResources(t.Context(), testOrphanedResourceFilter)
+	require.NoError(t, err)
+	roleManager.deleteAssignmentErr = errors.New("forbidden")
+
+	err = cleaner.CleanUpOrphanedResources(t.Context(), orphans)
+
+	assert.ErrorContains(t, err, "could not delete role assignment orphaned of role tmp-rol-aaaaaaa: forbidden")
+	// the resource groups are still deleted
+	assert.Equal(t, []string{"delete resource group testdeployrg-aaaaaaa"}, events)
+}
@@ -0,0 +1,60 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/Azure/mpf/pkg/domain"
+	log "github.com/sirupsen/logrus"
+)
+
+// PropagationWaiter waits for an RBAC change to propagate across the Azure authorization endpoints
+type PropagationWaiter interface {
+	WaitForPropagation(ctx context.Context, change domain.RBACChange) error
+}
+
+// Waits used when no PropagationWaiter is set
+const (
+	DefaultRoleAssignmentsRemovedWait = 45 * time.Second
+	DefaultRoleChangeWait             = 5 * time.Second
+)
+
+// defaultPropagationWaiter sleeps for the default fixed durations
+type defaultPropagationWaiter struct{}
+
+func (defaultPropagationWaiter) WaitForPropagation(ctx context.Context, change domain.RBACChange) error {
+	wait := DefaultRoleChangeWait
+	if change.Kind == domain.RoleAssignmentsRemoved {
+		wait = DefaultRoleAssignmentsRemovedWait
+	}
+	log.Debugf("Waiting %s for %s to propagate", wait, change.Kind)
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(wait):
+		return nil
+	}
+}
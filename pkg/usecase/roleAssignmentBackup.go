@@ -0,0 +1,142 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Azure/mpf/pkg/domain"
+	log "github.com/sirupsen/logrus"
+)
+
+// RoleAssignmentSnapshotter lists the role assignments of a service principal, and recreates them
+type RoleAssignmentSnapshotter interface {
+	ListRoleAssignments(ctx context.Context, subscription string, SPObjectID string) ([]domain.RoleAssignment, error)
+	// RestoreRoleAssignment recreates the role assignment. Restoring an existing role assignment succeeds.
+	RestoreRoleAssignment(ctx context.Context, assignment domain.RoleAssignment) error
+}
+
+// RoleAssignmentSnapshotStore saves the snapshot of the role assignments removed from the service principal
+type RoleAssignmentSnapshotStore interface {
+	// LoadRoleAssignmentSnapshot returns the saved snapshot, or nil if there is none
+	LoadRoleAssignmentSnapshot() (*domain.RoleAssignmentSnapshot, error)
+	SaveRoleAssignmentSnapshot(snapshot domain.RoleAssignmentSnapshot) error
+	DeleteRoleAssignmentSnapshot() error
+}
+
+// RestoreRoleAssignments recreates the role assignments of the snapshot.
+// All role assignments are attempted, and the returned error lists those which could not be restored.
+func RestoreRoleAssignments(ctx context.Context, snapshotter RoleAssignmentSnapshotter, snapshot domain.RoleAssignmentSnapshot) error {
+	var errs []error
+	for _, assignment := range snapshot.RoleAssignments {
+		if err := snapshotter.RestoreRoleAssignment(ctx, assignment); err != nil {
+			errs = append(errs, fmt.Errorf("could not restore role assignment %s of role %s at scope %s: %w", assignment.Name, assignment.RoleDefinitionID, assignment.Scope, err))
+			continue
+		}
+		log.Debugf("Restored role assignment %s of role %s at scope %s", assignment.Name, assignment.RoleDefinitionID, assignment.Scope)
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	log.Infof("Restored %d role assignments of the service principal", len(snapshot.RoleAssignments))
+	return nil
+}
+
+// EnableRoleAssignmentBackup makes the service save the role assignments of the service principal to the store
+// before removing them, and restore them when cleaning up
+func (s *MPFService) EnableRoleAssignmentBackup(snapshotter RoleAssignmentSnapshotter, store RoleAssignmentSnapshotStore) {
+	s.roleAssignmentSnapshotter = snapshotter
+	s.roleAssignmentSnapshotStore = store
+}
+
+// KeepExistingRoleAssignments makes the service leave the role assignments of the service principal in place.
+// The permissions they grant are then missing from the result.
+func (s *MPFService) KeepExistingRoleAssignments() {
+	s.keepExistingRoleAssignments = true
+}
+
+// backupRoleAssignments saves the role assignments of the service principal, except those of the custom role.
+// The snapshot of an interrupted run, whose role assignments were already removed, is kept and extended.
+func (s *MPFService) backupRoleAssignments() error {
+	if s.roleAssignmentSnapshotter == nil || s.roleAssignmentSnapshotStore == nil {
+		return nil
+	}
+
+	snapshot, err := s.roleAssignmentSnapshotStore.LoadRoleAssignmentSnapshot()
+	if err != nil {
+		return err
+	}
+	if snapshot != nil {
+		if !snapshot.IsSnapshotOf(s.mpfConfig.SubscriptionID, s.mpfConfig.SP.SPObjectID) {
+			return fmt.Errorf("the role assignment snapshot holds the role assignments of service principal %s in subscription %s, restore or delete it first", snapshot.SPObjectID, snapshot.SubscriptionID)
+		}
+		log.Infof("Keeping the snapshot of %d role assignments saved by an interrupted run", len(snapshot.RoleAssignments))
+	} else {
+		snapshot = &domain.RoleAssignmentSnapshot{
+			Version:        domain.RoleAssignmentSnapshotVersion,
+			SubscriptionID: s.mpfConfig.SubscriptionID,
+			SPObjectID:     s.mpfConfig.SP.SPObjectID,
+			CreatedAt:      time.Now().UTC(),
+		}
+	}
+
+	assignments, err := s.roleAssignmentSnapshotter.ListRoleAssignments(s.ctx, s.mpfConfig.SubscriptionID, s.mpfConfig.SP.SPObjectID)
+	if err != nil {
+		return fmt.Errorf("could not list the role assignments of the service principal: %w", err)
+	}
+	var existingAssignments []domain.RoleAssignment
+	for _, assignment := range assignments {
+		if s.mpfConfig.Role.RoleDefinitionResourceID != "" && strings.EqualFold(assignment.RoleDefinitionID, s.mpfConfig.Role.RoleDefinitionResourceID) {
+			continue
+		}
+		existingAssignments = append(existingAssignments, assignment)
+	}
+	snapshot.AddRoleAssignments(existingAssignments)
+
+	if err := s.roleAssignmentSnapshotStore.SaveRoleAssignmentSnapshot(*snapshot); err != nil {
+		return err
+	}
+	log.Infof("Saved %d role assignments of the service principal", len(snapshot.RoleAssignments))
+	s.roleAssignmentSnapshot = snapshot
+	return nil
+}
+
+// restoreRoleAssignments restores the saved role assignments, and deletes the snapshot if all were restored
+func (s *MPFService) restoreRoleAssignments(ctx context.Context) {
+	if s.roleAssignmentSnapshot == nil {
+		return
+	}
+
+	if err := RestoreRoleAssignments(ctx, s.roleAssignmentSnapshotter, *s.roleAssignmentSnapshot); err != nil {
+		log.Warnf("Could not restore all role assignments of the service principal, the snapshot is kept: %v", err)
+		return
+	}
+	if err := s.roleAssignmentSnapshotStore.DeleteRoleAssignmentSnapshot(); err != nil {
+		log.Warnf("Could not delete role assignment snapshot: %v", err)
+	}
+	s.roleAssignmentSnapshot = nil
+}
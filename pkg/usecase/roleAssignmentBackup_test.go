@@ -0,0 +1,177 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Azure/mpf/pkg/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testSubscriptionID = "SSSSSSSS-SSSS-SSSS-SSSS-SSSSSSSSSSSS"
+	testSPObjectID     = "OOOOOOOO-OOOO-OOOO-OOOO-OOOOOOOOOOOO"
+)
+
+type fakeRoleAssignmentSnapshotter struct {
+	events      *[]string
+	assignments []domain.RoleAssignment
+	restored    []domain.RoleAssignment
+	restoreErr  error
+}
+
+func (f *fakeRoleAssignmentSnapshotter) ListRoleAssignments(ctx context.Context, subscription string, SPObjectID string) ([]domain.RoleAssignment, error) {
+	*f.events = append(*f.events, "list")
+	return f.assignments, nil
+}
+
+func (f *fakeRoleAssignmentSnapshotter) RestoreRoleAssignment(ctx context.Context, assignment domain.RoleAssignment) error {
+	if f.restoreErr != nil {
+		return f.restoreErr
+	}
+	*f.events = append(*f.events, "restore "+assignment.Name)
+	f.restored = append(f.restored, assignment)
+	return nil
+}
+
+type memoryRoleAssignmentSnapshotStore struct {
+	events   *[]string
+	snapshot *domain.RoleAssignmentSnapshot
+}
+
+func (m *memoryRoleAssignmentSnapshotStore) LoadRoleAssignmentSnapshot() (*domain.RoleAssignmentSnapshot, error) {
+	return m.snapshot, nil
+}
+
+func (m *memoryRoleAssignmentSnapshotStore) SaveRoleAssignmentSnapshot(snapshot domain.RoleAssignmentSnapshot) error {
+	*m.events = append(*m.events, "save")
+	m.snapshot = &snapshot
+	return nil
+}
+
+func (m *memoryRoleAssignmentSnapshotStore) DeleteRoleAssignmentSnapshot() error {
+	*m.events = append(*m.events, "delete snapshot")
+	m.snapshot = nil
+	return nil
+}
+
+func getTestRoleAssignment(name string, roleDefinitionID string) domain.RoleAssignment {
+	return domain.RoleAssignment{
+		Name:             name,
+		Scope:            "/subscriptions/" + testSubscriptionID,
+		RoleDefinitionID: roleDefinitionID,
+		PrincipalID:      testSPObjectID,
+		PrincipalType:    "ServicePrincipal",
+	}
+}
+
+func newTestRoleAssignmentBackupService(t *testing.T, events *[]string, snapshotter *fakeRoleAssignmentSnapshotter, store *memoryRoleAssignmentSnapshotStore) *MPFService {
+	mpfConfig := domain.MPFConfig{
+		SubscriptionID: testSubscriptionID,
+		SP:             domain.ServicePrincipal{SPObjectID: testSPObjectID},
+		Role:           domain.Role{RoleDefinitionResourceID: "/subscriptions/" + testSubscriptionID + "/providers/Microsoft.Authorization/roleDefinitions/mpf"},
+	}
+	mpfService := NewMPFService(t.Context(), &fakeRGManager{}, &fakeSPRoleAssignmentManager{events: events}, &sequenceChecker{}, mpfConfig, nil, nil, false, false, false)
+	mpfService.SetPropagationWaiter(noWaitPropagationWaiter{})
+	mpfService.EnableRoleAssignmentBackup(snapshotter, store)
+	return mpfService
+}
+
+func TestGetMinimumPermissionsRequiredRestoresRoleAssignments(t *testing.T) {
+	var events []string
+	snapshotter := &fakeRoleAssignmentSnapshotter{events: &events, assignments: []domain.RoleAssignment{
+		getTestRoleAssignment("reader", "/providers/Microsoft.Authorization/roleDefinitions/reader"),
+		// the assignment of the custom role of the run is not backed up
+		getTestRoleAssignment("mpf", "/subscriptions/"+testSubscriptionID+"/providers/Microsoft.Authorization/roleDefinitions/MPF"),
+	}}
+	store := &memoryRoleAssignmentSnapshotStore{events: &events}
+
+	_, err := newTestRoleAssignmentBackupService(t, &events, snapshotter, store).GetMinimumPermissionsRequired()
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"list", "save", "detach all", "restore reader", "delete snapshot"}, events)
+	assert.Nil(t, store.snapshot)
+}
+
+func TestGetMinimumPermissionsRequiredKeepsSnapshotOfInterruptedRun(t *testing.T) {
+	var events []string
+	snapshotter := &fakeRoleAssignmentSnapshotter{events: &events, assignments: []domain.RoleAssignment{
+		getTestRoleAssignment("contributor", "/providers/Microsoft.Authorization/roleDefinitions/contributor"),
+	}}
+	store := &memoryRoleAssignmentSnapshotStore{events: &events, snapshot: &domain.RoleAssignmentSnapshot{
+		Version:         domain.RoleAssignmentSnapshotVersion,
+		SubscriptionID:  testSubscriptionID,
+		SPObjectID:      testSPObjectID,
+		RoleAssignments: []domain.RoleAssignment{getTestRoleAssignment("reader", "/providers/Microsoft.Authorization/roleDefinitions/reader")},
+	}}
+
+	_, err := newTestRoleAssignmentBackupService(t, &events, snapshotter, store).GetMinimumPermissionsRequired()
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"list", "save", "detach all", "restore reader", "restore contributor", "delete snapshot"}, events)
+}
+
+func TestGetMinimumPermissionsRequiredRejectsSnapshotOfOtherServicePrincipal(t *testing.T) {
+	var events []string
+	snapshotter := &fakeRoleAssignmentSnapshotter{events: &events}
+	store := &memoryRoleAssignmentSnapshotStore{events: &events, snapshot: &domain.RoleAssignmentSnapshot{
+		Version:        domain.RoleAssignmentSnapshotVersion,
+		SubscriptionID: testSubscriptionID,
+		SPObjectID:     "XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX",
+	}}
+
+	_, err := newTestRoleAssignmentBackupService(t, &events, snapshotter, store).GetMinimumPermissionsRequired()
+	assert.ErrorContains(t, err, "restore or delete it first")
+	// nothing is removed without a backup
+	assert.Empty(t, events)
+}
+
+func TestGetMinimumPermissionsRequiredKeepsSnapshotWhenRestoreFails(t *testing.T) {
+	var events []string
+	snapshotter := &fakeRoleAssignmentSnapshotter{events: &events, restoreErr: errors.New("forbidden"), assignments: []domain.RoleAssignment{
+		getTestRoleAssignment("reader", "/providers/Microsoft.Authorization/roleDefinitions/reader"),
+	}}
+	store := &memoryRoleAssignmentSnapshotStore{events: &events}
+
+	_, err := newTestRoleAssignmentBackupService(t, &events, snapshotter, store).GetMinimumPermissionsRequired()
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"list", "save", "detach all"}, events)
+	require.NotNil(t, store.snapshot)
+	assert.Len(t, store.snapshot.RoleAssignments, 1)
+}
+
+func TestGetMinimumPermissionsRequiredKeepsExistingRoleAssignments(t *testing.T) {
+	var events []string
+	snapshotter := &fakeRoleAssignmentSnapshotter{events: &events}
+	mpfService := newTestRoleAssignmentBackupService(t, &events, snapshotter, &memoryRoleAssignmentSnapshotStore{events: &events})
+	mpfService.KeepExistingRoleAssignments()
+
+	_, err := mpfService.GetMinimumPermissionsRequired()
+	require.NoError(t, err)
+	assert.Empty(t, events)
+}
@@ -0,0 +1,174 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Azure/mpf/pkg/domain"
+	log "github.com/sirupsen/logrus"
+)
+
+// RunLocker holds the lock of a service principal, so that concurrent MPF runs do not remove each other's role assignments
+type RunLocker interface {
+	// AcquireRunLock takes the lock, unless it is held by another run and not expired, in which case a *domain.RunLockedError is returned.
+	// Acquiring the lock again with the same holder renews it.
+	AcquireRunLock(ctx context.Context, lock domain.RunLock) error
+	// ReleaseRunLock releases the lock if it is still held by the holder of the lock
+	ReleaseRunLock(ctx context.Context, lock domain.RunLock) error
+}
+
+// EnableRunLock makes the service acquire the lock of the service principal before removing its role assignments,
+// and release it when cleaning up. The lock expires after the ttl unless it is renewed, which the service does in the background while the run lasts.
+func (s *MPFService) EnableRunLock(runLocker RunLocker, holder string, ttl time.Duration) {
+	s.runLocker = runLocker
+	s.runLockHolder = holder
+	s.runLockTTL = ttl
+}
+
+// acquireRunLock acquires the lock of the service principal, if enabled
+func (s *MPFService) acquireRunLock() error {
+	if s.runLocker == nil {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	lock := domain.RunLock{
+		SubscriptionID: s.mpfConfig.SubscriptionID,
+		SPObjectID:     s.mpfConfig.SP.SPObjectID,
+		Holder:         s.runLockHolder,
+		AcquiredAt:     now,
+		ExpiresAt:      now.Add(s.runLockTTL),
+	}
+	if err := s.runLocker.AcquireRunLock(s.ctx, lock); err != nil {
+		return err
+	}
+	log.Infof("Acquired the lock of service principal %s until %s", lock.SPObjectID, lock.ExpiresAt.Format(time.RFC3339))
+	s.runLock = &lock
+	return nil
+}
+
+// startRunLockRenewal renews the lock in the background while the run lasts, if a lock is held,
+// and cancels the run if the lock is lost. The returned function stops the renewal
+// and returns the error which lost the lock, if any.
+func (s *MPFService) startRunLockRenewal() func() error {
+	if s.runLock == nil {
+		return func() error { return nil }
+	}
+
+	runCtx := s.ctx
+	ctx, cancel := context.WithCancelCause(runCtx)
+	s.ctx = ctx
+
+	var lostErr error
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(s.runLockTTL / 4)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := s.renewRunLock(); err != nil {
+					log.Warnf("Lost the lock of the service principal, cancelling the run: %v", err)
+					lostErr = err
+					cancel(err)
+					return
+				}
+			}
+		}
+	}()
+
+	return func() error {
+		close(stop)
+		<-stopped
+		cancel(nil)
+		s.ctx = runCtx
+		return lostErr
+	}
+}
+
+// renewRunLock extends the expiry of the lock once half of its ttl has passed.
+// The run has to stop if another run took over the lock, or if the lock expired as it could not be renewed.
+// Other failures to renew the lock are only logged, and the renewal is retried.
+func (s *MPFService) renewRunLock() error {
+	if s.runLock == nil || time.Until(s.runLock.ExpiresAt) > s.runLockTTL/2 {
+		return nil
+	}
+
+	lock := *s.runLock
+	lock.ExpiresAt = time.Now().UTC().Add(s.runLockTTL)
+	err := s.runLocker.AcquireRunLock(s.ctx, lock)
+	var runLockedError *domain.RunLockedError
+	if errors.As(err, &runLockedError) {
+		s.runLock = nil
+		s.runLockLost = true
+		return err
+	}
+	if err != nil && s.runLock.IsExpired(time.Now()) {
+		s.runLockLost = true
+		return fmt.Errorf("the lock of the service principal expired at %s and could not be renewed: %w", s.runLock.ExpiresAt.Format(time.RFC3339), err)
+	}
+	if err != nil {
+		log.Warnf("Could not renew the lock of the service principal: %v", err)
+		return nil
+	}
+	log.Debugf("Renewed the lock of service principal %s until %s", lock.SPObjectID, lock.ExpiresAt.Format(time.RFC3339))
+	s.runLock = &lock
+	return nil
+}
+
+// logRoleAssignmentsLeftAfterLostRunLock logs the role assignments of the service principal which are left as they are,
+// since the run lost its lock and the run which took it over may have removed and saved them in turn
+func (s *MPFService) logRoleAssignmentsLeftAfterLostRunLock() {
+	log.Warnf("The lock of the service principal was lost, its role assignments are not detached nor restored. The custom role %s is left assigned to service principal %s, delete it once no run uses it",
+		s.mpfConfig.Role.RoleDefinitionResourceID, s.mpfConfig.SP.SPObjectID)
+	if s.roleAssignmentSnapshot != nil {
+		log.Warnf("The %d role assignments saved before the run are not restored, their snapshot is kept", len(s.roleAssignmentSnapshot.RoleAssignments))
+		for _, assignment := range s.roleAssignmentSnapshot.RoleAssignments {
+			log.Warnf("Not restored: role assignment %s of role %s at scope %s", assignment.Name, assignment.RoleDefinitionID, assignment.Scope)
+		}
+	}
+}
+
+// releaseRunLock releases the lock of the service principal, if held. A lock which cannot be released expires.
+func (s *MPFService) releaseRunLock(ctx context.Context) {
+	if s.runLock == nil {
+		return
+	}
+
+	if err := s.runLocker.ReleaseRunLock(ctx, *s.runLock); err != nil {
+		log.Warnf("Could not release the lock of the service principal, it expires at %s: %v", s.runLock.ExpiresAt.Format(time.RFC3339), err)
+		return
+	}
+	log.Infof("Released the lock of service principal %s", s.runLock.SPObjectID)
+	s.runLock = nil
+}
@@ -0,0 +1,277 @@
+//     MIT License
+//
+//     Copyright (c) Microsoft Corporation.
+//
+//     Permission is hereby granted, free of charge, to any person obtaining a copy
+//     of this software and associated documentation files (the "Software"), to deal
+//     in the Software without restriction, including without limitation the rights
+//     to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//     copies of the Software, and to permit persons to whom the Software is
+//     furnished to do so, subject to the following conditions:
+//
+//     The above copyright notice and this permission notice shall be included in all
+//     copies or substantial portions of the Software.
+//
+//     THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//     IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//     FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//     AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//     LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//     OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+//     SOFTWARE
+
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/mpf/pkg/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryRunLocker struct {
+	// mu guards the lock, which is renewed in the background
+	mu         sync.Mutex
+	events     *[]string
+	lock       *domain.RunLock
+	acquireErr error
+}
+
+func (m *memoryRunLocker) AcquireRunLock(ctx context.Context, lock domain.RunLock) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.lock != nil && !m.lock.IsHeldBy(lock.Holder) && !m.lock.IsExpired(time.Now()) {
+		return &domain.RunLockedError{Lock: *m.lock}
+	}
+	if m.acquireErr != nil {
+		return m.acquireErr
+	}
+	*m.events = append(*m.events, "acquire "+lock.Holder)
+	m.lock = &lock
+	return nil
+}
+
+func (m *memoryRunLocker) ReleaseRunLock(ctx context.Context, lock domain.RunLock) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	*m.events = append(*m.events, "release "+lock.Holder)
+	if m.lock != nil && m.lock.IsHeldBy(lock.Holder) {
+		m.lock = nil
+	}
+	return nil
+}
+
+func (m *memoryRunLocker) getLock() *domain.RunLock {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lock
+}
+
+func (m *memoryRunLocker) setLock(lock *domain.RunLock) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lock = lock
+}
+
+// slowChecker runs a deployment which lasts longer than the ttl of the run lock
+type slowChecker struct {
+	duration time.Duration
+	// onDeploy is called when the deployment starts, and when it ends
+	onDeploy func(ctx context.Context)
+}
+
+func (c *slowChecker) GetDeploymentAuthorizationErrors(ctx context.Context, mpfConfig domain.MPFConfig) (string, error) {
+	c.onDeploy(ctx)
+	select {
+	case <-time.After(c.duration):
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+	c.onDeploy(ctx)
+	return "", nil
+}
+
+func (c *slowChecker) CleanDeployment(ctx context.Context, mpfConfig domain.MPFConfig) error {
+	return nil
+}
+
+func newTestRunLockService(t *testing.T, events *[]string, runLocker RunLocker, checker DeploymentAuthorizationCheckerCleaner) *MPFService {
+	mpfConfig := domain.MPFConfig{
+		SubscriptionID: testSubscriptionID,
+		SP:             domain.ServicePrincipal{SPObjectID: testSPObjectID},
+		Role:           domain.Role{RoleDefinitionResourceID: "/subscriptions/" + testSubscriptionID + "/providers/Microsoft.Authorization/roleDefinitions/mpf"},
+	}
+	mpfService := NewMPFService(t.Context(), &fakeRGManager{}, &fakeSPRoleAssignmentManager{events: events}, checker, mpfConfig, nil, nil, false, false, false)
+	mpfService.SetPropagationWaiter(noWaitPropagationWaiter{})
+	mpfService.EnableRunLock(runLocker, "run-a", time.Hour)
+	return mpfService
+}
+
+func TestGetMinimumPermissionsRequiredLocksServicePrincipal(t *testing.T) {
+	var events []string
+	runLocker := &memoryRunLocker{events: &events}
+
+	_, err := newTestRunLockService(t, &events, runLocker, &sequenceChecker{}).GetMinimumPermissionsRequired()
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"acquire run-a", "detach all", "release run-a"}, events)
+	assert.Nil(t, runLocker.lock)
+}
+
+func TestGetMinimumPermissionsRequiredFailsIfServicePrincipalIsLocked(t *testing.T) {
+	var events []string
+	runLocker := &memoryRunLocker{events: &events, lock: &domain.RunLock{
+		SPObjectID: testSPObjectID,
+		Holder:     "run-b",
+		ExpiresAt:  time.Now().Add(time.Hour),
+	}}
+
+	mpfResult, err := newTestRunLockService(t, &events, runLocker, &sequenceChecker{}).GetMinimumPermissionsRequired()
+
+	var runLockedError *domain.RunLockedError
+	require.ErrorAs(t, err, &runLockedError)
+	assert.Equal(t, "run-b", runLockedError.Lock.Holder)
+	assert.Equal(t, domain.MPFResult{}, mpfResult)
+	// the role assignments of the run holding the lock are left untouched
+	assert.Empty(t, events)
+	assert.Equal(t, "run-b", runLocker.lock.Holder)
+}
+
+func TestGetMinimumPermissionsRequiredTakesOverExpiredLock(t *testing.T) {
+	var events []string
+	runLocker := &memoryRunLocker{events: &events, lock: &domain.RunLock{
+		SPObjectID: testSPObjectID,
+		Holder:     "run-b",
+		ExpiresAt:  time.Now().Add(-time.Minute),
+	}}
+
+	_, err := newTestRunLockService(t, &events, runLocker, &sequenceChecker{}).GetMinimumPermissionsRequired()
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"acquire run-a", "detach all", "release run-a"}, events)
+}
+
+func TestGetMinimumPermissionsRequiredFailsIfLockCannotBeAcquired(t *testing.T) {
+	var events []string
+	runLocker := &memoryRunLocker{events: &events, acquireErr: errors.New("forbidden")}
+
+	_, err := newTestRunLockService(t, &events, runLocker, &sequenceChecker{}).GetMinimumPermissionsRequired()
+
+	assert.ErrorContains(t, err, "forbidden")
+	assert.Empty(t, events)
+}
+
+func TestRenewRunLock(t *testing.T) {
+	var events []string
+	runLocker := &memoryRunLocker{events: &events}
+	mpfService := newTestRunLockService(t, &events, runLocker, &sequenceChecker{})
+	require.NoError(t, mpfService.acquireRunLock())
+	events = nil
+
+	// the lock is not renewed before half of its ttl has passed
+	require.NoError(t, mpfService.renewRunLock())
+	assert.Empty(t, events)
+
+	mpfService.runLock.ExpiresAt = time.Now().Add(10 * time.Minute)
+	require.NoError(t, mpfService.renewRunLock())
+	assert.Equal(t, []string{"acquire run-a"}, events)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), mpfService.runLock.ExpiresAt, time.Minute)
+
+	// a failed renewal is retried at the next renewal
+	mpfService.runLock.ExpiresAt = time.Now().Add(10 * time.Minute)
+	runLocker.acquireErr = errors.New("throttled")
+	require.NoError(t, mpfService.renewRunLock())
+	assert.NotNil(t, mpfService.runLock)
+
+	// until the lock expired
+	mpfService.runLock.ExpiresAt = time.Now().Add(-time.Minute)
+	assert.ErrorContains(t, mpfService.renewRunLock(), "throttled")
+}
+
+func TestRenewRunLockFailsIfLockWasTakenOver(t *testing.T) {
+	var events []string
+	runLocker := &memoryRunLocker{events: &events}
+	mpfService := newTestRunLockService(t, &events, runLocker, &sequenceChecker{})
+	require.NoError(t, mpfService.acquireRunLock())
+
+	// the lock expired and was taken over by another run
+	mpfService.runLock.ExpiresAt = time.Now().Add(-time.Minute)
+	runLocker.lock = &domain.RunLock{SPObjectID: testSPObjectID, Holder: "run-b", ExpiresAt: time.Now().Add(time.Hour)}
+
+	var runLockedError *domain.RunLockedError
+	require.ErrorAs(t, mpfService.renewRunLock(), &runLockedError)
+	assert.Nil(t, mpfService.runLock)
+}
+
+func TestGetMinimumPermissionsRequiredRenewsLockDuringSlowDeployment(t *testing.T) {
+	var events []string
+	runLocker := &memoryRunLocker{events: &events}
+	checker := &slowChecker{duration: 500 * time.Millisecond}
+	mpfService := newTestRunLockService(t, &events, runLocker, checker)
+	mpfService.EnableRunLock(runLocker, "run-a", 100*time.Millisecond)
+
+	heldThroughout := true
+	checker.onDeploy = func(ctx context.Context) {
+		lock := runLocker.getLock()
+		if ctx.Err() != nil || lock == nil || !lock.IsHeldBy("run-a") || lock.IsExpired(time.Now()) {
+			heldThroughout = false
+		}
+	}
+
+	_, err := mpfService.GetMinimumPermissionsRequired()
+	require.NoError(t, err)
+
+	assert.True(t, heldThroughout, "the lock expired while the deployment was running")
+	assert.Greater(t, countEvents(events, "acquire run-a"), 1)
+	assert.Equal(t, "release run-a", events[len(events)-1])
+	assert.Nil(t, runLocker.getLock())
+}
+
+func TestGetMinimumPermissionsRequiredIsCancelledWhenLockIsLost(t *testing.T) {
+	var events []string
+	runLocker := &memoryRunLocker{events: &events}
+	checker := &slowChecker{duration: 10 * time.Second}
+	mpfService := newTestRunLockService(t, &events, runLocker, checker)
+	mpfService.EnableRunLock(runLocker, "run-a", 100*time.Millisecond)
+	snapshotter := &fakeRoleAssignmentSnapshotter{events: &events, assignments: []domain.RoleAssignment{
+		getTestRoleAssignment("reader", "/providers/Microsoft.Authorization/roleDefinitions/reader"),
+	}}
+	store := &memoryRoleAssignmentSnapshotStore{events: &events}
+	mpfService.EnableRoleAssignmentBackup(snapshotter, store)
+
+	// another run takes over the lock while the deployment is running
+	checker.onDeploy = func(ctx context.Context) {
+		runLocker.setLock(&domain.RunLock{SPObjectID: testSPObjectID, Holder: "run-b", ExpiresAt: time.Now().Add(time.Hour)})
+	}
+
+	start := time.Now()
+	_, err := mpfService.GetMinimumPermissionsRequired()
+
+	var runLockedError *domain.RunLockedError
+	require.ErrorAs(t, err, &runLockedError)
+	assert.Equal(t, "run-b", runLockedError.Lock.Holder)
+	assert.Less(t, time.Since(start), checker.duration)
+	// the lock of the other run is left untouched
+	assert.NotContains(t, events, "release run-a")
+	assert.Equal(t, "run-b", runLocker.getLock().Holder)
+	// and so are the role assignments of the service principal, which the other run removed and saved in turn
+	assert.NotContains(t, events, "restore reader")
+	assert.NotContains(t, events, "delete snapshot")
+	assert.NotNil(t, store.snapshot)
+	assert.False(t, mpfService.spRoleAssignmentManager.(*fakeSPRoleAssignmentManager).roleDeleted)
+}
+
+func countEvents(events []string, event string) int {
+	count := 0
+	for _, e := range events {
+		if e == event {
+			count++
+		}
+	}
+	return count
+}
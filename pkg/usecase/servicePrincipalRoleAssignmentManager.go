@@ -30,12 +30,12 @@ import (
 
 type ServicePrincipalAssignmentModifier interface {
 	DetachRolesFromSP(ctx context.Context, subscription string, SPOBjectID string, role domain.Role) error
-	AssignRoleToSP(subscription string, SPOBjectID string, role domain.Role) error
+	AssignRoleToSP(ctx context.Context, subscription string, SPOBjectID string, role domain.Role) error
 }
 
 type CustomRoleCreatorModifier interface {
-	CreateUpdateCustomRole(subscription string, role domain.Role, permissions []string) (error, []string)
-	DeleteCustomRole(subscription string, role domain.Role) error
+	CreateUpdateCustomRole(ctx context.Context, subscription string, role domain.Role, permissions []string, dataActions []string) (error, []string)
+	DeleteCustomRole(ctx context.Context, subscription string, role domain.Role) error
 }
 
 type ServicePrincipalRolemAssignmentManager interface {
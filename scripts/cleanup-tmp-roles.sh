@@ -5,6 +5,7 @@ set -euo pipefail
 # Cleans up custom roles and role assignments created by MPF (names prefixed tmp-rol-).
 # Usage: SUBSCRIPTION_ID=<id> ./cleanup-tmp-roles.sh
 # Optional: TMP_ROLE_PREFIX (default: tmp-rol-)
+# Superseded by `azmpf cleanup`, which also deletes the resource groups left behind by MPF runs.
 
 if ! command -v az >/dev/null 2>&1; then
   echo "Azure CLI (az) is required" >&2